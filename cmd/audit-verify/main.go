@@ -0,0 +1,139 @@
+// Command audit-verify checks the hash chains of the audit log and reports
+// the first broken link of each chain. It exits with status 1 if any chain
+// is broken.
+//
+// Checkpoints downloaded from the admin screens can be checked against the
+// database with -checkpoint, pass -public-key to only trust checkpoints
+// signed with a known key.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/joho/godotenv"
+	_ "modernc.org/sqlite"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/sqlc"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	chainID := flag.Int64(
+		"condominium", -1,
+		"only verify the chain of this condominium, 0 for global events",
+	)
+	checkpointPath := flag.String(
+		"checkpoint", "", "checkpoint file to verify against the database",
+	)
+	publicKey := flag.String(
+		"public-key", "", "base64 encoded public key the checkpoint must be signed with",
+	)
+	flag.Parse()
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		fatal("DATABASE_URL environment variable must be set")
+	}
+	db, err := sql.Open("sqlite", databaseURL)
+	if err != nil {
+		fatal("failed to open database: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	ctx := context.Background()
+	store := sqlc.NewStore(db)
+
+	var external []entry.AuditCheckpoint
+	if *checkpointPath != "" {
+		checkpoint, err := readCheckpoint(*checkpointPath)
+		if err != nil {
+			fatal("%v", err)
+		}
+		if err := checkpoint.Verify(*publicKey); err != nil {
+			fatal("%s: %v", *checkpointPath, err)
+		}
+		if *chainID >= 0 && *chainID != checkpoint.ChainID {
+			fatal("%s belongs to chain %d", *checkpointPath, checkpoint.ChainID)
+		}
+		*chainID = checkpoint.ChainID
+		external = append(external, *checkpoint)
+	}
+
+	chainIDs := []int64{*chainID}
+	if *chainID < 0 {
+		chainIDs, err = allChainIDs(ctx, store)
+		if err != nil {
+			fatal("failed to list chains: %v", err)
+		}
+	}
+
+	broken := false
+	for _, id := range chainIDs {
+		result, err := entry.VerifyAuditChain(ctx, store, id, external...)
+		if err != nil {
+			fatal("failed to verify chain %d: %v", id, err)
+		}
+
+		if result.Broken {
+			broken = true
+			fmt.Printf(
+				"chain %d: BROKEN at entry %d: %s\n",
+				id, result.BrokenID, result.Reason,
+			)
+			continue
+		}
+		fmt.Printf(
+			"chain %d: ok, %d entries, %d checkpoints\n",
+			id, result.Checked, result.Checkpoints,
+		)
+	}
+
+	if broken {
+		os.Exit(1)
+	}
+}
+
+// allChainIDs lists the chains with entries or with a head, a head without
+// entries means the whole chain was deleted.
+func allChainIDs(ctx context.Context, store *sqlc.Store) ([]int64, error) {
+	ids, err := store.AuditChainIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := store.AuditChainHeadList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, head := range heads {
+		if !slices.Contains(ids, head.ChainID) {
+			ids = append(ids, head.ChainID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func readCheckpoint(path string) (*entry.AuditCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint entry.AuditCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("%s: invalid checkpoint: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "audit-verify: "+format+"\n", args...)
+	os.Exit(2)
+}
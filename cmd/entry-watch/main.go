@@ -2,16 +2,17 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"crypto/ed25519"
+	"encoding/base64"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/gorilla/sessions"
 	"github.com/joho/godotenv"
-	_ "modernc.org/sqlite"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	apphttp "github.com/Polo123456789/entry-watch/internal/http"
@@ -46,7 +47,7 @@ func main() {
 		logger.Error("DATABASE_URL environment variable must be set")
 		os.Exit(1)
 	}
-	db, err := sql.Open("sqlite", databaseURL)
+	db, err := sqlc.Open(databaseURL)
 	if err != nil {
 		logger.Error("Failed to open database", "error", err)
 		os.Exit(1)
@@ -54,9 +55,52 @@ func main() {
 	defer db.Close() //nolint:errcheck
 
 	store := sqlc.NewStore(db)
-	app := entry.NewApp(logger, store)
+	if err := store.AuditSealLegacy(ctx); err != nil {
+		logger.Error("Failed to chain the audit log", "error", err)
+		os.Exit(1)
+	}
+	audit := entry.NewAuditLogger(store, logger)
+
+	// The inbox is always on, browser, email and SMS only once configured.
+	notifier := entry.NewNotifier(store, store, logger)
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		sender, err := entry.NewSMTPSender(
+			smtpAddr,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("SMTP_FROM"),
+		)
+		if err != nil {
+			logger.Error("Invalid SMTP configuration", "error", err)
+			os.Exit(1)
+		}
+		notifier.Register(entry.ChannelEmail, sender)
+	}
+	if smsURL := os.Getenv("SMS_GATEWAY_URL"); smsURL != "" {
+		notifier.Register(
+			entry.ChannelSMS,
+			entry.NewSMSGateway(smsURL, os.Getenv("SMS_GATEWAY_TOKEN"), nil),
+		)
+	}
+	if subject := os.Getenv("VAPID_SUBJECT"); subject != "" {
+		key, err := entry.LoadVAPIDKey(ctx, store)
+		if err != nil {
+			logger.Error("Failed to load the VAPID key", "error", err)
+			os.Exit(1)
+		}
+		sender, err := entry.NewPushSender(store, key, subject, nil)
+		if err != nil {
+			logger.Error("Invalid Web Push configuration", "error", err)
+			os.Exit(1)
+		}
+		notifier.Register(entry.ChannelPush, sender)
+	}
+
+	hub := entry.NewHub()
+	app := entry.NewApp(logger, store, audit, notifier, hub)
 
 	userStore := sqlc.NewUserStore(db)
+	throttler := auth.NewThrottler(userStore, userStore, audit, logger)
 
 	if err := auth.EnsureSuperAdminExists(ctx, userStore, logger); err != nil {
 		logger.Error("Failed to ensure superadmin exists", "error", err)
@@ -69,23 +113,68 @@ func main() {
 		os.Exit(1)
 	}
 
-	sessionStore := sessions.NewCookieStore([]byte(sessionKey))
-	sessionStore.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   60 * 60 * 12,
-		HttpOnly: true,
-		Secure:   true, // Always secure (required by Chrome)
-		SameSite: http.SameSiteLaxMode,
+	sessionStore := auth.NewSessionStore(
+		sqlc.NewSessionStore(db),
+		logger,
+		&sessions.Options{
+			Path:     "/",
+			MaxAge:   60 * 60 * 12,
+			HttpOnly: true,
+			Secure:   true, // Always secure (required by Chrome)
+			SameSite: http.SameSiteLaxMode,
+		},
+		[]byte(sessionKey),
+	)
+	userCache := auth.NewUserCache(userStore, 10*time.Second)
+
+	// The signing key is a base64 encoded Ed25519 seed, generate one with
+	// `openssl rand -base64 32`.
+	if signingKey := os.Getenv("AUDIT_SIGNING_KEY"); signingKey != "" {
+		seed, err := base64.StdEncoding.DecodeString(signingKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			logger.Error("AUDIT_SIGNING_KEY must be a base64 encoded 32 byte seed")
+			os.Exit(1)
+		}
+
+		interval := 24 * time.Hour
+		if v := os.Getenv("AUDIT_CHECKPOINT_INTERVAL"); v != "" {
+			interval, err = time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				logger.Error("AUDIT_CHECKPOINT_INTERVAL must be a positive duration, like 24h")
+				os.Exit(1)
+			}
+		}
+
+		checkpointer := entry.NewAuditCheckpointer(
+			store, ed25519.NewKeyFromSeed(seed), logger,
+		)
+		go checkpointer.Run(ctx, interval)
+	} else {
+		logger.Warn("AUDIT_SIGNING_KEY is not set, audit checkpoints are disabled")
 	}
 
+	webhooks := entry.NewWebhookDispatcher(store, nil, logger)
+	go webhooks.Run(ctx, 10*time.Second)
+	go notifier.Run(ctx, 10*time.Second)
+	go entry.NewPatrolMonitor(app, logger).Run(ctx, time.Minute)
+	go entry.NewWelfareMonitor(app, logger).Run(ctx, 30*time.Second)
+
 	server := apphttp.NewServer(
 		"0.0.0.0",
 		8080,
 		app,
 		logger,
 		sessionStore,
+		[]byte(sessionKey),
+		userCache,
 		userStore,
+		throttler,
+		sqlc.NewAPITokenStore(db),
+		audit,
 	)
+	// Live update streams never go idle, closing the hub ends them so that
+	// the shutdown doesn't wait for them.
+	server.RegisterOnShutdown(hub.Close)
 
 	apphttp.RunServer(ctx, cancel, server, logger)
 }
@@ -13,7 +13,7 @@ CREATE TABLE condominiums (
     created_at INTEGER NOT NULL, -- Unix timestamp
     updated_at INTEGER NOT NULL,  -- Unix timestamp
     created_by INTEGER,
-    updated_by INTEGER,
+    updated_by INTEGER, max_visitors INTEGER NOT NULL DEFAULT 0, max_visitors_per_unit INTEGER NOT NULL DEFAULT 0,
 
     FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
     FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
@@ -34,7 +34,7 @@ CREATE TABLE users (
     created_at INTEGER NOT NULL, -- Unix timestamp
     updated_at INTEGER NOT NULL, -- Unix timestamp
     created_by INTEGER,
-    updated_by INTEGER,
+    updated_by INTEGER, totp_secret TEXT, totp_enabled BOOLEAN NOT NULL DEFAULT 0, totp_last_step INTEGER NOT NULL DEFAULT 0, tower TEXT NOT NULL DEFAULT '', unit TEXT NOT NULL DEFAULT '',
 
     FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
     FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
@@ -51,17 +51,578 @@ CREATE TABLE visits (
     valid_to INTEGER NOT NULL, -- Unix timestamp
 
     created_at INTEGER NOT NULL, -- Unix timestamp
-    updated_at INTEGER NOT NULL, -- Unix timestamp
+    updated_at INTEGER NOT NULL, revoked_at INTEGER, revoked_by INTEGER, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL, -- hex encoded SHA-256 of the normalized code
+    used_at INTEGER, -- Unix timestamp, NULL while the code is unused
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX recovery_codes_user_id ON recovery_codes(user_id);
+CREATE TABLE two_factor_requirements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER, -- NULL means every condominium
+    role TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
 
     FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX two_factor_requirements_scope
+    ON two_factor_requirements(IFNULL(condominium_id, 0), role);
+CREATE TABLE login_throttles (
+    kind TEXT NOT NULL, -- account, ip
+    subject TEXT NOT NULL, -- normalized email or IP address
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at INTEGER NOT NULL, -- Unix timestamp
+    locked_until INTEGER NOT NULL DEFAULT 0, -- Unix timestamp, 0 means not
+                                             -- locked
+
+    PRIMARY KEY (kind, subject)
+);
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE, -- hex encoded SHA-256 of the cookie token
+    user_id INTEGER, -- NULL until the login completes
+    data BLOB NOT NULL, -- gob encoded session values
+    user_agent TEXT NOT NULL,
+    ip TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    last_seen_at INTEGER NOT NULL, -- Unix timestamp
+    expires_at INTEGER NOT NULL, -- Unix timestamp
+
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
-CREATE TABLE audit_logs (
+CREATE INDEX sessions_user_id ON sessions(user_id);
+CREATE INDEX sessions_expires_at ON sessions(expires_at);
+CREATE INDEX visits_user_id ON visits(user_id);
+CREATE TABLE entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    visit_id TEXT, -- NULL if the presented code matched no visit
+    guard_id INTEGER,
+    visitor_name TEXT NOT NULL,
+    accepted BOOLEAN NOT NULL,
+    reason TEXT NOT NULL, -- why it was denied, empty if accepted
+
+    created_at INTEGER NOT NULL, station_id INTEGER
+    REFERENCES guard_stations(id) ON DELETE SET NULL, shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL, exited_at INTEGER, exited_by INTEGER REFERENCES users(id) ON DELETE SET NULL, over_limit BOOLEAN NOT NULL DEFAULT 0, override_of INTEGER REFERENCES entries(id) ON DELETE SET NULL, override_reason TEXT NOT NULL DEFAULT '', -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE SET NULL,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX entries_condominium_created_at
+    ON entries(condominium_id, created_at);
+CREATE TABLE IF NOT EXISTS "audit_logs" (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER, -- NULL for global events
     user_id INTEGER,
     level INTEGER NOT NULL, -- 1=info, 2=important, 3=critical
+    action TEXT NOT NULL DEFAULT '',
     message TEXT NOT NULL,
     created_at INTEGER NOT NULL, -- Unix timestamp
 
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+    -- Each entry hashes its content and the hash of the previous entry of
+    -- the same condominium, see entry.AuditEntry.ChainHash. Entries created
+    -- before the chain existed are sealed on startup.
+    prev_hash TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL DEFAULT ''
+, impersonator_id INTEGER, entry_id INTEGER, entry_hash TEXT NOT NULL DEFAULT '');
+CREATE INDEX audit_logs_condominium_created_at
+    ON audit_logs(condominium_id, created_at);
+CREATE INDEX audit_logs_created_at ON audit_logs(created_at);
+CREATE TABLE audit_chain_heads (
+    chain_id INTEGER PRIMARY KEY, -- condominium ID, 0 for global events
+    last_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    entries INTEGER NOT NULL,
+
+    updated_at INTEGER NOT NULL -- Unix timestamp
+);
+CREATE TABLE audit_checkpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chain_id INTEGER NOT NULL, -- condominium ID, 0 for global events
+    last_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    entries INTEGER NOT NULL,
+    public_key TEXT NOT NULL, -- base64 Ed25519 public key
+    signature TEXT NOT NULL, -- base64 Ed25519 signature
+
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+CREATE INDEX audit_checkpoints_chain_id ON audit_checkpoints(chain_id, id);
+CREATE TABLE condominium_memberships (
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'guard', 'user')),
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    PRIMARY KEY (user_id, condominium_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX condominium_memberships_condominium_id
+    ON condominium_memberships(condominium_id);
+CREATE TABLE permission_overrides (
+    condominium_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'guard', 'user')),
+    permission TEXT NOT NULL,
+    granted BOOLEAN NOT NULL, -- false revokes the permission
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    PRIMARY KEY (condominium_id, role, permission),
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE TABLE api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER, -- NULL for tokens of superadmins
+    name TEXT NOT NULL,
+    scopes TEXT NOT NULL, -- space separated permissions
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    last_used_at INTEGER, -- Unix timestamp, NULL until the first use
+    expires_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE
+);
+CREATE INDEX api_tokens_user_id ON api_tokens(user_id);
+CREATE TABLE webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL, -- HMAC key of the signatures, kept to sign
+    events TEXT NOT NULL, -- space separated event types
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX webhooks_condominium_id ON webhooks(condominium_id);
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event_id TEXT NOT NULL, -- shared by the redeliveries of an event
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL, -- Unix timestamp
+    last_attempt_at INTEGER, -- Unix timestamp, NULL until the first attempt
+    response_status INTEGER, -- NULL if there was no response
+    last_error TEXT,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
+CREATE TABLE gates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    driver TEXT NOT NULL CHECK (driver IN ('http', 'modbus', 'tcp', 'noop')),
+    target TEXT NOT NULL, -- URL of the relay, or host:port
+    http_method TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL DEFAULT '',
+    unit_id INTEGER NOT NULL DEFAULT 0, -- Modbus unit
+    coil INTEGER NOT NULL DEFAULT 0, -- Modbus coil
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX gates_condominium_id ON gates(condominium_id);
+CREATE TABLE guard_stations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    gate_id INTEGER, -- opened after accepted check-ins, NULL if none
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER, welfare_interval_minutes INTEGER NOT NULL DEFAULT 0, welfare_grace_minutes INTEGER NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (gate_id) REFERENCES gates(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX guard_stations_condominium_id ON guard_stations(condominium_id);
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    read_at INTEGER, -- Unix timestamp, NULL while unread
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE
+);
+CREATE INDEX notifications_user_id ON notifications(user_id, id);
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    channels TEXT NOT NULL, -- space separated channels, empty for none
+
+    PRIMARY KEY (user_id, event),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE notification_quiet_hours (
+    user_id INTEGER PRIMARY KEY,
+    start_minute INTEGER NOT NULL, -- minutes since midnight, server time
+    end_minute INTEGER NOT NULL, -- minutes since midnight, server time
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    channel TEXT NOT NULL,
+    recipient TEXT NOT NULL, -- email address or phone number
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL, -- Unix timestamp
+    last_attempt_at INTEGER, -- Unix timestamp, NULL until the first attempt
+    last_error TEXT,
+
+    created_at INTEGER NOT NULL, url TEXT NOT NULL DEFAULT '', actions TEXT NOT NULL DEFAULT '[]', -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX notification_deliveries_due ON notification_deliveries(status, next_attempt_at);
+CREATE TABLE walk_ins (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    visitor_name TEXT NOT NULL,
+    announced_by INTEGER,
+    decision TEXT CHECK (decision IN ('allowed', 'denied')), -- NULL while pending
+    decided_at INTEGER, -- Unix timestamp, NULL while pending
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (announced_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX walk_ins_condominium_id ON walk_ins(condominium_id, created_at);
+CREATE INDEX walk_ins_resident_id ON walk_ins(resident_id, created_at);
+CREATE TABLE push_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL, -- base64url encoded public key of the browser
+    auth TEXT NOT NULL, -- base64url encoded authentication secret
+    user_agent TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX push_subscriptions_user_id ON push_subscriptions(user_id);
+CREATE TABLE vapid_keys (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    private_key BLOB NOT NULL, -- PKCS #8
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+CREATE TABLE incidents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    category TEXT NOT NULL CHECK (category IN ('damage', 'suspicious', 'noise', 'access', 'other')),
+    severity TEXT NOT NULL CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    location TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    reported_by INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    updated_at INTEGER NOT NULL, shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (reported_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX incidents_condominium_id ON incidents(condominium_id, status, created_at);
+CREATE TABLE incident_residents (
+    incident_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+
+    PRIMARY KEY (incident_id, user_id),
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE incident_visits (
+    incident_id INTEGER NOT NULL,
+    visit_id TEXT NOT NULL,
+
+    PRIMARY KEY (incident_id, visit_id),
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE CASCADE
+);
+CREATE TABLE incident_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    data BLOB NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+);
+CREATE INDEX incident_attachments_incident_id ON incident_attachments(incident_id);
+CREATE TABLE incident_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL,
+    author_id INTEGER,
+    body TEXT NOT NULL,
+    status TEXT, -- NULL for comments that didn't change the status
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX incident_comments_incident_id ON incident_comments(incident_id);
+CREATE TABLE shifts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    guard_id INTEGER,
+    station_id INTEGER,
+    started_at INTEGER NOT NULL, -- Unix timestamp
+    ended_at INTEGER, -- Unix timestamp, NULL while the shift is open
+    handover_notes TEXT NOT NULL DEFAULT '', -- for the next shift
+    logbook TEXT, welfare_checked_at INTEGER, welfare_escalated_at INTEGER, -- JSON, NULL while the shift is open
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (station_id) REFERENCES guard_stations(id) ON DELETE SET NULL
+);
+CREATE INDEX shifts_condominium_id ON shifts(condominium_id, ended_at);
+CREATE UNIQUE INDEX shifts_open_guard_id ON shifts(guard_id) WHERE ended_at IS NULL;
+CREATE INDEX entries_shift_id ON entries(shift_id);
+CREATE INDEX incidents_shift_id ON incidents(shift_id);
+CREATE TABLE patrol_checkpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    code TEXT NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX patrol_checkpoints_condominium_id ON patrol_checkpoints(condominium_id);
+CREATE TABLE patrol_routes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    station_id INTEGER, -- NULL for the guards of every station
+    start_minute INTEGER NOT NULL,
+    end_minute INTEGER NOT NULL,
+    every_minutes INTEGER NOT NULL,
+    window_minutes INTEGER NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (station_id) REFERENCES guard_stations(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX patrol_routes_condominium_id ON patrol_routes(condominium_id);
+CREATE TABLE patrol_route_checkpoints (
+    route_id INTEGER NOT NULL,
+    checkpoint_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+
+    PRIMARY KEY (route_id, checkpoint_id),
+    FOREIGN KEY (route_id) REFERENCES patrol_routes(id) ON DELETE CASCADE,
+    FOREIGN KEY (checkpoint_id) REFERENCES patrol_checkpoints(id) ON DELETE CASCADE
+);
+CREATE INDEX patrol_route_checkpoints_checkpoint_id ON patrol_route_checkpoints(checkpoint_id);
+CREATE TABLE patrol_scans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    checkpoint_id INTEGER NOT NULL,
+    guard_id INTEGER,
+    shift_id INTEGER NOT NULL,
+    scanned_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (checkpoint_id) REFERENCES patrol_checkpoints(id) ON DELETE CASCADE,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+);
+CREATE INDEX patrol_scans_shift_id ON patrol_scans(shift_id, scanned_at);
+CREATE TABLE patrol_rounds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    route_id INTEGER,
+    route_name TEXT NOT NULL,
+    shift_id INTEGER NOT NULL,
+    due_at INTEGER NOT NULL, -- Unix timestamp
+    status TEXT NOT NULL, -- completed, incomplete or missed
+    checkpoints INTEGER NOT NULL, -- how many the route had
+    scanned INTEGER NOT NULL, -- how many were scanned in the window
+    missing TEXT NOT NULL DEFAULT '', -- names of the checkpoints not scanned
+    evaluated_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (route_id) REFERENCES patrol_routes(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX patrol_rounds_route_shift_due ON patrol_rounds(route_id, shift_id, due_at);
+CREATE INDEX patrol_rounds_condominium_id ON patrol_rounds(condominium_id, due_at);
+CREATE TABLE announcements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    audience TEXT NOT NULL CHECK (audience IN ('residents', 'towers', 'units', 'guards')),
+    pinned BOOLEAN NOT NULL DEFAULT 0,
+    expires_at INTEGER, -- Unix timestamp, NULL if it doesn't expire
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX announcements_condominium_id ON announcements(condominium_id, created_at);
+CREATE TABLE announcement_targets (
+    announcement_id INTEGER NOT NULL,
+    tower TEXT NOT NULL,
+    unit TEXT NOT NULL, -- empty when the whole tower is targeted
+
+    PRIMARY KEY (announcement_id, tower, unit),
+    FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE
+);
+CREATE TABLE announcement_reads (
+    announcement_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    read_at INTEGER NOT NULL, -- Unix timestamp
+
+    PRIMARY KEY (announcement_id, user_id),
+    FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE amenities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    capacity INTEGER NOT NULL, -- people at the same time, residents and guests
+    opens_minute INTEGER NOT NULL, -- minutes since midnight
+    closes_minute INTEGER NOT NULL, -- minutes since midnight
+    slot_minutes INTEGER NOT NULL,
+    max_per_month INTEGER NOT NULL DEFAULT 0, -- reservations per resident, 0 for no limit
+    lead_hours INTEGER NOT NULL DEFAULT 0, -- how long before they start reservations are made
+    requires_approval BOOLEAN NOT NULL DEFAULT 0,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX amenities_condominium_id ON amenities(condominium_id);
+CREATE TABLE reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    amenity_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    starts_at INTEGER NOT NULL, -- Unix timestamp
+    ends_at INTEGER NOT NULL, -- Unix timestamp
+    status TEXT NOT NULL CHECK (status IN ('pending', 'confirmed', 'rejected', 'cancelled')),
+    decided_by INTEGER, -- who confirmed, rejected or cancelled it
+    decided_at INTEGER, -- Unix timestamp
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (amenity_id) REFERENCES amenities(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX reservations_amenity_id ON reservations(amenity_id, starts_at);
+CREATE INDEX reservations_condominium_id ON reservations(condominium_id, starts_at);
+CREATE INDEX reservations_resident_id ON reservations(resident_id, starts_at);
+CREATE TABLE reservation_guests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reservation_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    visit_id TEXT, -- NULL until the reservation is confirmed
+
+    FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE SET NULL
+);
+CREATE INDEX reservation_guests_reservation_id ON reservation_guests(reservation_id);
+CREATE INDEX entries_inside ON entries(condominium_id) WHERE accepted AND exited_at IS NULL;
+CREATE UNIQUE INDEX entries_override_of ON entries(override_of) WHERE override_of IS NOT NULL;
+CREATE TABLE IF NOT EXISTS "parcels" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER,
+    tower TEXT NOT NULL DEFAULT '',
+    unit TEXT NOT NULL DEFAULT '',
+    carrier TEXT NOT NULL,
+    tracking_number TEXT NOT NULL,
+    size TEXT NOT NULL CHECK (size IN ('small', 'medium', 'large')),
+    pickup_code TEXT NOT NULL,
+    received_by INTEGER,
+    authorized_at INTEGER, -- Unix timestamp, NULL unless a resident authorized the pickup
+    collected_at INTEGER, -- Unix timestamp, NULL while at the guardhouse
+    collected_by TEXT, -- name of the person that collected the parcel
+    handed_over_by INTEGER,
+    shift_id INTEGER,
+    collected_shift_id INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (received_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (handed_over_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL,
+    FOREIGN KEY (collected_shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+);
+CREATE TABLE IF NOT EXISTS "parcel_photos" (
+    parcel_id INTEGER PRIMARY KEY,
+    content_type TEXT NOT NULL,
+    data BLOB NOT NULL,
+
+    FOREIGN KEY (parcel_id) REFERENCES "parcels"(id) ON DELETE CASCADE
 );
+CREATE INDEX parcels_condominium_id ON parcels(condominium_id, collected_at);
+CREATE INDEX parcels_resident_id ON parcels(resident_id, collected_at);
+CREATE INDEX parcels_unit ON parcels(condominium_id, tower, unit, collected_at);
@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN totp_secret TEXT; -- base32, set while enrolling
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0; -- last
+                                              -- accepted time step, prevents
+                                              -- code reuse
+
+CREATE TABLE recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL, -- hex encoded SHA-256 of the normalized code
+    used_at INTEGER, -- Unix timestamp, NULL while the code is unused
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX recovery_codes_user_id ON recovery_codes(user_id);
+
+CREATE TABLE two_factor_requirements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER, -- NULL means every condominium
+    role TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX two_factor_requirements_scope
+    ON two_factor_requirements(IFNULL(condominium_id, 0), role);
+
+-- +goose Down
+DROP INDEX two_factor_requirements_scope;
+DROP TABLE two_factor_requirements;
+DROP INDEX recovery_codes_user_id;
+DROP TABLE recovery_codes;
+ALTER TABLE users DROP COLUMN totp_last_step;
+ALTER TABLE users DROP COLUMN totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE login_throttles (
+    kind TEXT NOT NULL, -- account, ip
+    subject TEXT NOT NULL, -- normalized email or IP address
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at INTEGER NOT NULL, -- Unix timestamp
+    locked_until INTEGER NOT NULL DEFAULT 0, -- Unix timestamp, 0 means not
+                                             -- locked
+
+    PRIMARY KEY (kind, subject)
+);
+
+-- +goose Down
+DROP TABLE login_throttles;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE, -- hex encoded SHA-256 of the cookie token
+    user_id INTEGER, -- NULL until the login completes
+    data BLOB NOT NULL, -- gob encoded session values
+    user_agent TEXT NOT NULL,
+    ip TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    last_seen_at INTEGER NOT NULL, -- Unix timestamp
+    expires_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX sessions_user_id ON sessions(user_id);
+CREATE INDEX sessions_expires_at ON sessions(expires_at);
+
+-- +goose Down
+DROP INDEX sessions_expires_at;
+DROP INDEX sessions_user_id;
+DROP TABLE sessions;
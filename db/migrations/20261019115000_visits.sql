@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE visits ADD COLUMN revoked_at INTEGER; -- Unix timestamp, NULL
+                                                  -- while the visit is valid
+ALTER TABLE visits ADD COLUMN revoked_by INTEGER;
+
+CREATE INDEX visits_user_id ON visits(user_id);
+
+-- Every check-in attempt at the gate, accepted or denied.
+CREATE TABLE entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    visit_id TEXT, -- NULL if the presented code matched no visit
+    guard_id INTEGER,
+    visitor_name TEXT NOT NULL,
+    accepted BOOLEAN NOT NULL,
+    reason TEXT NOT NULL, -- why it was denied, empty if accepted
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE SET NULL,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX entries_condominium_created_at
+    ON entries(condominium_id, created_at);
+
+-- +goose Down
+DROP INDEX entries_condominium_created_at;
+DROP TABLE entries;
+DROP INDEX visits_user_id;
+ALTER TABLE visits DROP COLUMN revoked_by;
+ALTER TABLE visits DROP COLUMN revoked_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- No foreign key, the log must outlive the condominiums it mentions.
+ALTER TABLE audit_logs ADD COLUMN condominium_id INTEGER; -- NULL for global
+                                                          -- events
+ALTER TABLE audit_logs ADD COLUMN action TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX audit_logs_condominium_created_at
+    ON audit_logs(condominium_id, created_at);
+CREATE INDEX audit_logs_created_at ON audit_logs(created_at);
+
+-- +goose Down
+DROP INDEX audit_logs_created_at;
+DROP INDEX audit_logs_condominium_created_at;
+ALTER TABLE audit_logs DROP COLUMN action;
+ALTER TABLE audit_logs DROP COLUMN condominium_id;
@@ -0,0 +1,92 @@
+-- +goose Up
+-- audit_logs is rebuilt without the foreign key on user_id: deleting a user
+-- would otherwise rewrite its entries and break the hash chain.
+CREATE TABLE audit_logs_chained (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER, -- NULL for global events
+    user_id INTEGER,
+    level INTEGER NOT NULL, -- 1=info, 2=important, 3=critical
+    action TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    -- Each entry hashes its content and the hash of the previous entry of
+    -- the same condominium, see entry.AuditEntry.ChainHash. Entries created
+    -- before the chain existed are sealed on startup.
+    prev_hash TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO audit_logs_chained (
+    id, condominium_id, user_id, level, action, message, created_at
+)
+SELECT id, condominium_id, user_id, level, action, message, created_at
+FROM audit_logs;
+
+DROP INDEX audit_logs_created_at;
+DROP INDEX audit_logs_condominium_created_at;
+DROP TABLE audit_logs;
+ALTER TABLE audit_logs_chained RENAME TO audit_logs;
+
+CREATE INDEX audit_logs_condominium_created_at
+    ON audit_logs(condominium_id, created_at);
+CREATE INDEX audit_logs_created_at ON audit_logs(created_at);
+
+-- The last entry of each chain. Without it, deleting the newest entries
+-- would go unnoticed.
+CREATE TABLE audit_chain_heads (
+    chain_id INTEGER PRIMARY KEY, -- condominium ID, 0 for global events
+    last_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    entries INTEGER NOT NULL,
+
+    updated_at INTEGER NOT NULL -- Unix timestamp
+);
+
+-- Signed snapshots of the chain heads, meant to be downloaded and kept
+-- outside the system.
+CREATE TABLE audit_checkpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chain_id INTEGER NOT NULL, -- condominium ID, 0 for global events
+    last_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    entries INTEGER NOT NULL,
+    public_key TEXT NOT NULL, -- base64 Ed25519 public key
+    signature TEXT NOT NULL, -- base64 Ed25519 signature
+
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+CREATE INDEX audit_checkpoints_chain_id ON audit_checkpoints(chain_id, id);
+
+-- +goose Down
+DROP INDEX audit_checkpoints_chain_id;
+DROP TABLE audit_checkpoints;
+DROP TABLE audit_chain_heads;
+
+CREATE TABLE audit_logs_unchained (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    level INTEGER NOT NULL, -- 1=info, 2=important, 3=critical
+    message TEXT NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    condominium_id INTEGER,
+    action TEXT NOT NULL DEFAULT '',
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO audit_logs_unchained (
+    id, user_id, level, message, created_at, condominium_id, action
+)
+SELECT id, user_id, level, message, created_at, condominium_id, action
+FROM audit_logs;
+
+DROP INDEX audit_logs_created_at;
+DROP INDEX audit_logs_condominium_created_at;
+DROP TABLE audit_logs;
+ALTER TABLE audit_logs_unchained RENAME TO audit_logs;
+
+CREATE INDEX audit_logs_condominium_created_at
+    ON audit_logs(condominium_id, created_at);
+CREATE INDEX audit_logs_created_at ON audit_logs(created_at);
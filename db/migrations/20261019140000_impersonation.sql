@@ -0,0 +1,7 @@
+-- +goose Up
+-- The superadmin acting as user_id, NULL unless impersonating. No foreign
+-- key, the log must outlive the users it mentions.
+ALTER TABLE audit_logs ADD COLUMN impersonator_id INTEGER;
+
+-- +goose Down
+ALTER TABLE audit_logs DROP COLUMN impersonator_id;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Roles a user holds in condominiums other than its own. The condominium
+-- and role of the users table are the user's home membership.
+CREATE TABLE condominium_memberships (
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'guard', 'user')),
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    PRIMARY KEY (user_id, condominium_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX condominium_memberships_condominium_id
+    ON condominium_memberships(condominium_id);
+
+-- +goose Down
+DROP TABLE condominium_memberships;
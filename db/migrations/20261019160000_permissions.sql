@@ -0,0 +1,19 @@
+-- +goose Up
+-- Per condominium changes to the permissions of a role. Roles not listed
+-- keep the permissions they are built with.
+CREATE TABLE permission_overrides (
+    condominium_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'guard', 'user')),
+    permission TEXT NOT NULL,
+    granted BOOLEAN NOT NULL, -- false revokes the permission
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    PRIMARY KEY (condominium_id, role, permission),
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE permission_overrides;
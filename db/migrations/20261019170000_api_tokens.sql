@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER, -- NULL for tokens of superadmins
+    name TEXT NOT NULL,
+    scopes TEXT NOT NULL, -- space separated permissions
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    last_used_at INTEGER, -- Unix timestamp, NULL until the first use
+    expires_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_tokens_user_id ON api_tokens(user_id);
+
+-- +goose Down
+DROP INDEX api_tokens_user_id;
+DROP TABLE api_tokens;
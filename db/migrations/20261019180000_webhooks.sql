@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL, -- HMAC key of the signatures, kept to sign
+    events TEXT NOT NULL, -- space separated event types
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX webhooks_condominium_id ON webhooks(condominium_id);
+
+-- The outbox of the webhooks. Every event is stored here for each webhook
+-- subscribed to it, and sent later with retries.
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event_id TEXT NOT NULL, -- shared by the redeliveries of an event
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL, -- Unix timestamp
+    last_attempt_at INTEGER, -- Unix timestamp, NULL until the first attempt
+    response_status INTEGER, -- NULL if there was no response
+    last_error TEXT,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
+
+-- +goose Down
+DROP INDEX webhook_deliveries_webhook_id;
+DROP INDEX webhook_deliveries_due;
+DROP TABLE webhook_deliveries;
+DROP INDEX webhooks_condominium_id;
+DROP TABLE webhooks;
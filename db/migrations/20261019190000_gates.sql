@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE gates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    driver TEXT NOT NULL CHECK (driver IN ('http', 'modbus', 'tcp', 'noop')),
+    target TEXT NOT NULL, -- URL of the relay, or host:port
+    http_method TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL DEFAULT '',
+    unit_id INTEGER NOT NULL DEFAULT 0, -- Modbus unit
+    coil INTEGER NOT NULL DEFAULT 0, -- Modbus coil
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX gates_condominium_id ON gates(condominium_id);
+
+CREATE TABLE guard_stations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    gate_id INTEGER, -- opened after accepted check-ins, NULL if none
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (gate_id) REFERENCES gates(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX guard_stations_condominium_id ON guard_stations(condominium_id);
+
+ALTER TABLE entries ADD COLUMN station_id INTEGER
+    REFERENCES guard_stations(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE entries DROP COLUMN station_id;
+DROP INDEX guard_stations_condominium_id;
+DROP TABLE guard_stations;
+DROP INDEX gates_condominium_id;
+DROP TABLE gates;
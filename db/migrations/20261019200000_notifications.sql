@@ -0,0 +1,66 @@
+-- +goose Up
+-- The in-app inbox.
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    condominium_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    read_at INTEGER, -- Unix timestamp, NULL while unread
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE
+);
+
+CREATE INDEX notifications_user_id ON notifications(user_id, id);
+
+-- Users without rows get the default preferences.
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    channels TEXT NOT NULL, -- space separated channels, empty for none
+
+    PRIMARY KEY (user_id, event),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Users without a row have no quiet hours.
+CREATE TABLE notification_quiet_hours (
+    user_id INTEGER PRIMARY KEY,
+    start_minute INTEGER NOT NULL, -- minutes since midnight, server time
+    end_minute INTEGER NOT NULL, -- minutes since midnight, server time
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- The outbox of email and SMS notifications.
+CREATE TABLE notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    channel TEXT NOT NULL,
+    recipient TEXT NOT NULL, -- email address or phone number
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL, -- Unix timestamp
+    last_attempt_at INTEGER, -- Unix timestamp, NULL until the first attempt
+    last_error TEXT,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX notification_deliveries_due ON notification_deliveries(status, next_attempt_at);
+
+-- +goose Down
+DROP INDEX notification_deliveries_due;
+DROP TABLE notification_deliveries;
+DROP TABLE notification_quiet_hours;
+DROP TABLE notification_preferences;
+DROP INDEX notifications_user_id;
+DROP TABLE notifications;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Visitors without a visit announced by a guard, and what the resident
+-- decided.
+CREATE TABLE walk_ins (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    visitor_name TEXT NOT NULL,
+    announced_by INTEGER,
+    decision TEXT CHECK (decision IN ('allowed', 'denied')), -- NULL while pending
+    decided_at INTEGER, -- Unix timestamp, NULL while pending
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (announced_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX walk_ins_condominium_id ON walk_ins(condominium_id, created_at);
+CREATE INDEX walk_ins_resident_id ON walk_ins(resident_id, created_at);
+
+-- +goose Down
+DROP INDEX walk_ins_resident_id;
+DROP INDEX walk_ins_condominium_id;
+DROP TABLE walk_ins;
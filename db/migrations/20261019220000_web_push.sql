@@ -0,0 +1,36 @@
+-- +goose Up
+-- Browsers subscribed to the Web Push notifications of a user.
+CREATE TABLE push_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL, -- base64url encoded public key of the browser
+    auth TEXT NOT NULL, -- base64url encoded authentication secret
+    user_agent TEXT NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX push_subscriptions_user_id ON push_subscriptions(user_id);
+
+-- The key that identifies the server to push services. There is only one,
+-- subscriptions stop working if it changes.
+CREATE TABLE vapid_keys (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    private_key BLOB NOT NULL, -- PKCS #8
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+-- The page a notification links to, and the buttons of push
+-- notifications as JSON.
+ALTER TABLE notification_deliveries ADD COLUMN url TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_deliveries ADD COLUMN actions TEXT NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE notification_deliveries DROP COLUMN actions;
+ALTER TABLE notification_deliveries DROP COLUMN url;
+DROP TABLE vapid_keys;
+DROP INDEX push_subscriptions_user_id;
+DROP TABLE push_subscriptions;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Parcels received at the guardhouse for a resident, and who collected
+-- them.
+CREATE TABLE parcels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    carrier TEXT NOT NULL,
+    tracking_number TEXT NOT NULL,
+    size TEXT NOT NULL CHECK (size IN ('small', 'medium', 'large')),
+    pickup_code TEXT NOT NULL,
+    received_by INTEGER,
+    authorized_at INTEGER, -- Unix timestamp, NULL unless the resident authorized the pickup
+    collected_at INTEGER, -- Unix timestamp, NULL while at the guardhouse
+    collected_by TEXT, -- name of the person that collected the parcel
+    handed_over_by INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (received_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (handed_over_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX parcels_condominium_id ON parcels(condominium_id, collected_at);
+CREATE INDEX parcels_resident_id ON parcels(resident_id, collected_at);
+
+-- Kept apart so that listing parcels doesn't load the photos.
+CREATE TABLE parcel_photos (
+    parcel_id INTEGER PRIMARY KEY,
+    content_type TEXT NOT NULL,
+    data BLOB NOT NULL,
+
+    FOREIGN KEY (parcel_id) REFERENCES parcels(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE parcel_photos;
+DROP INDEX parcels_resident_id;
+DROP INDEX parcels_condominium_id;
+DROP TABLE parcels;
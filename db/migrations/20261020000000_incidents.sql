@@ -0,0 +1,82 @@
+-- +goose Up
+-- Incidents guards report, which the admins review.
+CREATE TABLE incidents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    category TEXT NOT NULL CHECK (category IN ('damage', 'suspicious', 'noise', 'access', 'other')),
+    severity TEXT NOT NULL CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    location TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    reported_by INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    updated_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (reported_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX incidents_condominium_id ON incidents(condominium_id, status, created_at);
+
+-- The residents involved in an incident.
+CREATE TABLE incident_residents (
+    incident_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+
+    PRIMARY KEY (incident_id, user_id),
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- The visits involved in an incident.
+CREATE TABLE incident_visits (
+    incident_id INTEGER NOT NULL,
+    visit_id TEXT NOT NULL,
+
+    PRIMARY KEY (incident_id, visit_id),
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE CASCADE
+);
+
+CREATE TABLE incident_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    data BLOB NOT NULL,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX incident_attachments_incident_id ON incident_attachments(incident_id);
+
+-- The comments of the admins on an incident, and the statuses they moved
+-- it to.
+CREATE TABLE incident_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL,
+    author_id INTEGER,
+    body TEXT NOT NULL,
+    status TEXT, -- NULL for comments that didn't change the status
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX incident_comments_incident_id ON incident_comments(incident_id);
+
+-- +goose Down
+DROP INDEX incident_comments_incident_id;
+DROP TABLE incident_comments;
+DROP INDEX incident_attachments_incident_id;
+DROP TABLE incident_attachments;
+DROP TABLE incident_visits;
+DROP TABLE incident_residents;
+DROP INDEX incidents_condominium_id;
+DROP TABLE incidents;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- The shifts of the guards. The logbook of a shift is generated when it is
+-- closed, and archived with it.
+CREATE TABLE shifts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    guard_id INTEGER,
+    station_id INTEGER,
+    started_at INTEGER NOT NULL, -- Unix timestamp
+    ended_at INTEGER, -- Unix timestamp, NULL while the shift is open
+    handover_notes TEXT NOT NULL DEFAULT '', -- for the next shift
+    logbook TEXT, -- JSON, NULL while the shift is open
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (station_id) REFERENCES guard_stations(id) ON DELETE SET NULL
+);
+
+CREATE INDEX shifts_condominium_id ON shifts(condominium_id, ended_at);
+-- A guard has one open shift at most.
+CREATE UNIQUE INDEX shifts_open_guard_id ON shifts(guard_id) WHERE ended_at IS NULL;
+
+ALTER TABLE entries ADD COLUMN shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL;
+ALTER TABLE parcels ADD COLUMN shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL;
+ALTER TABLE parcels ADD COLUMN collected_shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL;
+ALTER TABLE incidents ADD COLUMN shift_id INTEGER REFERENCES shifts(id) ON DELETE SET NULL;
+
+CREATE INDEX entries_shift_id ON entries(shift_id);
+CREATE INDEX parcels_shift_id ON parcels(shift_id);
+CREATE INDEX parcels_collected_shift_id ON parcels(collected_shift_id);
+CREATE INDEX incidents_shift_id ON incidents(shift_id);
+
+-- +goose Down
+DROP INDEX incidents_shift_id;
+DROP INDEX parcels_collected_shift_id;
+DROP INDEX parcels_shift_id;
+DROP INDEX entries_shift_id;
+ALTER TABLE incidents DROP COLUMN shift_id;
+ALTER TABLE parcels DROP COLUMN collected_shift_id;
+ALTER TABLE parcels DROP COLUMN shift_id;
+ALTER TABLE entries DROP COLUMN shift_id;
+DROP INDEX shifts_open_guard_id;
+DROP INDEX shifts_condominium_id;
+DROP TABLE shifts;
@@ -0,0 +1,105 @@
+-- +goose Up
+-- The checkpoints guards scan on their patrol rounds. code is printed on a
+-- QR code placed at the checkpoint.
+CREATE TABLE patrol_checkpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    code TEXT NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX patrol_checkpoints_condominium_id ON patrol_checkpoints(condominium_id);
+
+-- The rounds guards have to make. A round is due every every_minutes from
+-- start_minute to end_minute, minutes from midnight in local time, and has
+-- to be done within window_minutes. end_minute before start_minute spans
+-- midnight, and equal to it means all day.
+CREATE TABLE patrol_routes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    station_id INTEGER, -- NULL for the guards of every station
+    start_minute INTEGER NOT NULL,
+    end_minute INTEGER NOT NULL,
+    every_minutes INTEGER NOT NULL,
+    window_minutes INTEGER NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (station_id) REFERENCES guard_stations(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX patrol_routes_condominium_id ON patrol_routes(condominium_id);
+
+CREATE TABLE patrol_route_checkpoints (
+    route_id INTEGER NOT NULL,
+    checkpoint_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+
+    PRIMARY KEY (route_id, checkpoint_id),
+    FOREIGN KEY (route_id) REFERENCES patrol_routes(id) ON DELETE CASCADE,
+    FOREIGN KEY (checkpoint_id) REFERENCES patrol_checkpoints(id) ON DELETE CASCADE
+);
+
+CREATE INDEX patrol_route_checkpoints_checkpoint_id ON patrol_route_checkpoints(checkpoint_id);
+
+CREATE TABLE patrol_scans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    checkpoint_id INTEGER NOT NULL,
+    guard_id INTEGER,
+    shift_id INTEGER NOT NULL,
+    scanned_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (checkpoint_id) REFERENCES patrol_checkpoints(id) ON DELETE CASCADE,
+    FOREIGN KEY (guard_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX patrol_scans_shift_id ON patrol_scans(shift_id, scanned_at);
+
+-- The rounds that were due in a shift, once their window is over. The route
+-- and checkpoint names are copied, so that the report survives changes to
+-- the routes.
+CREATE TABLE patrol_rounds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    route_id INTEGER,
+    route_name TEXT NOT NULL,
+    shift_id INTEGER NOT NULL,
+    due_at INTEGER NOT NULL, -- Unix timestamp
+    status TEXT NOT NULL, -- completed, incomplete or missed
+    checkpoints INTEGER NOT NULL, -- how many the route had
+    scanned INTEGER NOT NULL, -- how many were scanned in the window
+    missing TEXT NOT NULL DEFAULT '', -- names of the checkpoints not scanned
+    evaluated_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (route_id) REFERENCES patrol_routes(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+);
+
+-- A round is evaluated once per shift.
+CREATE UNIQUE INDEX patrol_rounds_route_shift_due ON patrol_rounds(route_id, shift_id, due_at);
+CREATE INDEX patrol_rounds_condominium_id ON patrol_rounds(condominium_id, due_at);
+
+-- +goose Down
+DROP INDEX patrol_rounds_condominium_id;
+DROP INDEX patrol_rounds_route_shift_due;
+DROP TABLE patrol_rounds;
+DROP INDEX patrol_scans_shift_id;
+DROP TABLE patrol_scans;
+DROP INDEX patrol_route_checkpoints_checkpoint_id;
+DROP TABLE patrol_route_checkpoints;
+DROP INDEX patrol_routes_condominium_id;
+DROP TABLE patrol_routes;
+DROP INDEX patrol_checkpoints_condominium_id;
+DROP TABLE patrol_checkpoints;
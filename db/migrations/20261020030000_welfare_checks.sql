@@ -0,0 +1,15 @@
+-- +goose Up
+-- Guards working at a station with a welfare check interval confirm they are
+-- fine every welfare_interval_minutes. If they don't within
+-- welfare_grace_minutes, the admins are told.
+ALTER TABLE guard_stations ADD COLUMN welfare_interval_minutes INTEGER NOT NULL DEFAULT 0; -- 0 for none
+ALTER TABLE guard_stations ADD COLUMN welfare_grace_minutes INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE shifts ADD COLUMN welfare_checked_at INTEGER; -- Unix timestamp, NULL until the first check
+ALTER TABLE shifts ADD COLUMN welfare_escalated_at INTEGER; -- Unix timestamp, NULL unless the last check was missed
+
+-- +goose Down
+ALTER TABLE shifts DROP COLUMN welfare_escalated_at;
+ALTER TABLE shifts DROP COLUMN welfare_checked_at;
+ALTER TABLE guard_stations DROP COLUMN welfare_grace_minutes;
+ALTER TABLE guard_stations DROP COLUMN welfare_interval_minutes;
@@ -0,0 +1,54 @@
+-- +goose Up
+-- The unit residents live in, in their home condominium. Condominiums of
+-- houses leave the tower empty.
+ALTER TABLE users ADD COLUMN tower TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN unit TEXT NOT NULL DEFAULT '';
+
+-- Announcements admins publish on the dashboards of residents or guards.
+CREATE TABLE announcements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    audience TEXT NOT NULL CHECK (audience IN ('residents', 'towers', 'units', 'guards')),
+    pinned BOOLEAN NOT NULL DEFAULT 0,
+    expires_at INTEGER, -- Unix timestamp, NULL if it doesn't expire
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX announcements_condominium_id ON announcements(condominium_id, created_at);
+
+-- The towers or units an announcement is for, when its audience is one of
+-- them.
+CREATE TABLE announcement_targets (
+    announcement_id INTEGER NOT NULL,
+    tower TEXT NOT NULL,
+    unit TEXT NOT NULL, -- empty when the whole tower is targeted
+
+    PRIMARY KEY (announcement_id, tower, unit),
+    FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE
+);
+
+-- Who read each announcement.
+CREATE TABLE announcement_reads (
+    announcement_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    read_at INTEGER NOT NULL, -- Unix timestamp
+
+    PRIMARY KEY (announcement_id, user_id),
+    FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE announcement_reads;
+DROP TABLE announcement_targets;
+DROP INDEX announcements_condominium_id;
+DROP TABLE announcements;
+ALTER TABLE users DROP COLUMN unit;
+ALTER TABLE users DROP COLUMN tower;
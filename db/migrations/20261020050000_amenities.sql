@@ -0,0 +1,71 @@
+-- +goose Up
+-- Common areas residents reserve, like the pool or the party room. A day
+-- is split in reservations of slot_minutes from opens_minute to
+-- closes_minute.
+CREATE TABLE amenities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    capacity INTEGER NOT NULL, -- people at the same time, residents and guests
+    opens_minute INTEGER NOT NULL, -- minutes since midnight
+    closes_minute INTEGER NOT NULL, -- minutes since midnight
+    slot_minutes INTEGER NOT NULL,
+    max_per_month INTEGER NOT NULL DEFAULT 0, -- reservations per resident, 0 for no limit
+    lead_hours INTEGER NOT NULL DEFAULT 0, -- how long before they start reservations are made
+    requires_approval BOOLEAN NOT NULL DEFAULT 0,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    created_by INTEGER,
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX amenities_condominium_id ON amenities(condominium_id);
+
+CREATE TABLE reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    amenity_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    starts_at INTEGER NOT NULL, -- Unix timestamp
+    ends_at INTEGER NOT NULL, -- Unix timestamp
+    status TEXT NOT NULL CHECK (status IN ('pending', 'confirmed', 'rejected', 'cancelled')),
+    decided_by INTEGER, -- who confirmed, rejected or cancelled it
+    decided_at INTEGER, -- Unix timestamp
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (amenity_id) REFERENCES amenities(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX reservations_amenity_id ON reservations(amenity_id, starts_at);
+CREATE INDEX reservations_condominium_id ON reservations(condominium_id, starts_at);
+CREATE INDEX reservations_resident_id ON reservations(resident_id, starts_at);
+
+-- The guests of a reservation, and the visit that lets them in once it is
+-- confirmed.
+CREATE TABLE reservation_guests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reservation_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    visit_id TEXT, -- NULL until the reservation is confirmed
+
+    FOREIGN KEY (reservation_id) REFERENCES reservations(id) ON DELETE CASCADE,
+    FOREIGN KEY (visit_id) REFERENCES visits(id) ON DELETE SET NULL
+);
+
+CREATE INDEX reservation_guests_reservation_id ON reservation_guests(reservation_id);
+
+-- +goose Down
+DROP INDEX reservation_guests_reservation_id;
+DROP TABLE reservation_guests;
+DROP INDEX reservations_resident_id;
+DROP INDEX reservations_condominium_id;
+DROP INDEX reservations_amenity_id;
+DROP TABLE reservations;
+DROP INDEX amenities_condominium_id;
+DROP TABLE amenities;
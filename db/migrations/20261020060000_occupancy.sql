@@ -0,0 +1,27 @@
+-- +goose Up
+-- Visitors are inside from an accepted check-in until a guard records their
+-- exit, they check in again with the same visit, or their visit ends.
+-- Check-ins are denied once a condominium, or the unit the visit is for, has
+-- its maximum of visitors inside, unless an admin lets the visitor in anyway.
+ALTER TABLE condominiums ADD COLUMN max_visitors INTEGER NOT NULL DEFAULT 0; -- 0 for no limit
+ALTER TABLE condominiums ADD COLUMN max_visitors_per_unit INTEGER NOT NULL DEFAULT 0; -- 0 for no limit
+
+ALTER TABLE entries ADD COLUMN exited_at INTEGER; -- Unix timestamp, NULL while the visitor is inside
+ALTER TABLE entries ADD COLUMN exited_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE entries ADD COLUMN over_limit BOOLEAN NOT NULL DEFAULT 0; -- denied because of an occupancy limit
+ALTER TABLE entries ADD COLUMN override_of INTEGER REFERENCES entries(id) ON DELETE SET NULL; -- the over_limit entry an admin let in
+ALTER TABLE entries ADD COLUMN override_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX entries_inside ON entries(condominium_id) WHERE accepted AND exited_at IS NULL;
+CREATE INDEX entries_override_of ON entries(override_of) WHERE override_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX entries_override_of;
+DROP INDEX entries_inside;
+ALTER TABLE entries DROP COLUMN override_reason;
+ALTER TABLE entries DROP COLUMN override_of;
+ALTER TABLE entries DROP COLUMN over_limit;
+ALTER TABLE entries DROP COLUMN exited_by;
+ALTER TABLE entries DROP COLUMN exited_at;
+ALTER TABLE condominiums DROP COLUMN max_visitors_per_unit;
+ALTER TABLE condominiums DROP COLUMN max_visitors;
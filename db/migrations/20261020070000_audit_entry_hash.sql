@@ -0,0 +1,11 @@
+-- +goose Up
+-- The check-in the entry records and the hash of its row at the time, see
+-- entry.Entry.Hash. Verifying the chain checks the rows against them, so
+-- that entries can't be edited without breaking the chain either. No
+-- foreign key, a deleted check-in must be reported, not forgotten.
+ALTER TABLE audit_logs ADD COLUMN entry_id INTEGER;
+ALTER TABLE audit_logs ADD COLUMN entry_hash TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE audit_logs DROP COLUMN entry_hash;
+ALTER TABLE audit_logs DROP COLUMN entry_id;
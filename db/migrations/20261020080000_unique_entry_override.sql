@@ -0,0 +1,9 @@
+-- +goose Up
+-- A denied check-in is overridden once, even if the admin submits the
+-- override twice.
+DROP INDEX entries_override_of;
+CREATE UNIQUE INDEX entries_override_of ON entries(override_of) WHERE override_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX entries_override_of;
+CREATE INDEX entries_override_of ON entries(override_of) WHERE override_of IS NOT NULL;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Guards and residents used to reach every feature of their area with
+-- entries:record and visits:create. Each feature has its own permission
+-- now, so carry the overrides of those two over to the new ones.
+INSERT INTO permission_overrides
+    (condominium_id, role, permission, granted, created_at, created_by)
+SELECT o.condominium_id, o.role, p.permission, o.granted, o.created_at, o.created_by
+FROM permission_overrides o
+JOIN (
+    SELECT 'parcels:receive' AS permission
+    UNION ALL SELECT 'incidents:report'
+    UNION ALL SELECT 'shifts:work'
+    UNION ALL SELECT 'welfare:confirm'
+    UNION ALL SELECT 'patrols:walk'
+) p
+WHERE o.permission = 'entries:record'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO permission_overrides
+    (condominium_id, role, permission, granted, created_at, created_by)
+SELECT o.condominium_id, o.role, p.permission, o.granted, o.created_at, o.created_by
+FROM permission_overrides o
+JOIN (
+    SELECT 'walk_ins:answer' AS permission
+    UNION ALL SELECT 'notifications:receive'
+    UNION ALL SELECT 'push:subscribe'
+    UNION ALL SELECT 'parcels:collect'
+    UNION ALL SELECT 'announcements:read'
+    UNION ALL SELECT 'amenities:reserve'
+) p
+WHERE o.permission = 'visits:create'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM permission_overrides WHERE permission IN (
+    'walk_ins:answer', 'notifications:receive', 'push:subscribe',
+    'parcels:receive', 'parcels:collect', 'incidents:report', 'shifts:work',
+    'welfare:confirm', 'patrols:walk', 'announcements:read', 'amenities:reserve'
+);
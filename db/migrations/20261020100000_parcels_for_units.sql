@@ -0,0 +1,136 @@
+-- +goose Up
+-- Parcels can be addressed to a unit instead of a resident, for every
+-- resident of the unit to collect. resident_id is NULL for those, and
+-- tower and unit are empty for parcels of a resident without a unit. The
+-- tables are rebuilt to let resident_id be NULL; the photos are moved
+-- before the parcels are dropped, or the cascade would delete them.
+CREATE TABLE parcels_for_units (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER,
+    tower TEXT NOT NULL DEFAULT '',
+    unit TEXT NOT NULL DEFAULT '',
+    carrier TEXT NOT NULL,
+    tracking_number TEXT NOT NULL,
+    size TEXT NOT NULL CHECK (size IN ('small', 'medium', 'large')),
+    pickup_code TEXT NOT NULL,
+    received_by INTEGER,
+    authorized_at INTEGER, -- Unix timestamp, NULL unless a resident authorized the pickup
+    collected_at INTEGER, -- Unix timestamp, NULL while at the guardhouse
+    collected_by TEXT, -- name of the person that collected the parcel
+    handed_over_by INTEGER,
+    shift_id INTEGER,
+    collected_shift_id INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (received_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (handed_over_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL,
+    FOREIGN KEY (collected_shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+);
+
+INSERT INTO parcels_for_units (
+    id, condominium_id, resident_id, tower, unit, carrier, tracking_number,
+    size, pickup_code, received_by, authorized_at, collected_at, collected_by,
+    handed_over_by, shift_id, collected_shift_id, created_at
+)
+SELECT
+    parcels.id, parcels.condominium_id, parcels.resident_id,
+    CASE WHEN users.condominium_id = parcels.condominium_id THEN users.tower ELSE '' END,
+    CASE WHEN users.condominium_id = parcels.condominium_id THEN users.unit ELSE '' END,
+    parcels.carrier, parcels.tracking_number, parcels.size, parcels.pickup_code,
+    parcels.received_by, parcels.authorized_at, parcels.collected_at,
+    parcels.collected_by, parcels.handed_over_by, parcels.shift_id,
+    parcels.collected_shift_id, parcels.created_at
+FROM parcels
+JOIN users ON users.id = parcels.resident_id;
+
+CREATE TABLE parcel_photos_for_units (
+    parcel_id INTEGER PRIMARY KEY,
+    content_type TEXT NOT NULL,
+    data BLOB NOT NULL,
+
+    FOREIGN KEY (parcel_id) REFERENCES parcels_for_units(id) ON DELETE CASCADE
+);
+
+INSERT INTO parcel_photos_for_units (parcel_id, content_type, data)
+SELECT parcel_id, content_type, data FROM parcel_photos;
+
+DROP TABLE parcel_photos;
+DROP INDEX parcels_resident_id;
+DROP INDEX parcels_condominium_id;
+DROP TABLE parcels;
+ALTER TABLE parcels_for_units RENAME TO parcels;
+ALTER TABLE parcel_photos_for_units RENAME TO parcel_photos;
+
+CREATE INDEX parcels_condominium_id ON parcels(condominium_id, collected_at);
+CREATE INDEX parcels_resident_id ON parcels(resident_id, collected_at);
+CREATE INDEX parcels_unit ON parcels(condominium_id, tower, unit, collected_at);
+
+-- +goose Down
+-- Parcels addressed only to a unit have no resident to keep them for, so
+-- they are dropped.
+CREATE TABLE parcels_of_residents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    condominium_id INTEGER NOT NULL,
+    resident_id INTEGER NOT NULL,
+    carrier TEXT NOT NULL,
+    tracking_number TEXT NOT NULL,
+    size TEXT NOT NULL CHECK (size IN ('small', 'medium', 'large')),
+    pickup_code TEXT NOT NULL,
+    received_by INTEGER,
+    authorized_at INTEGER, -- Unix timestamp, NULL unless the resident authorized the pickup
+    collected_at INTEGER, -- Unix timestamp, NULL while at the guardhouse
+    collected_by TEXT, -- name of the person that collected the parcel
+    handed_over_by INTEGER,
+    shift_id INTEGER,
+    collected_shift_id INTEGER,
+
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (condominium_id) REFERENCES condominiums(id) ON DELETE CASCADE,
+    FOREIGN KEY (resident_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (received_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (handed_over_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL,
+    FOREIGN KEY (collected_shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+);
+
+INSERT INTO parcels_of_residents (
+    id, condominium_id, resident_id, carrier, tracking_number, size,
+    pickup_code, received_by, authorized_at, collected_at, collected_by,
+    handed_over_by, shift_id, collected_shift_id, created_at
+)
+SELECT
+    id, condominium_id, resident_id, carrier, tracking_number, size,
+    pickup_code, received_by, authorized_at, collected_at, collected_by,
+    handed_over_by, shift_id, collected_shift_id, created_at
+FROM parcels
+WHERE resident_id IS NOT NULL;
+
+CREATE TABLE parcel_photos_of_residents (
+    parcel_id INTEGER PRIMARY KEY,
+    content_type TEXT NOT NULL,
+    data BLOB NOT NULL,
+
+    FOREIGN KEY (parcel_id) REFERENCES parcels_of_residents(id) ON DELETE CASCADE
+);
+
+INSERT INTO parcel_photos_of_residents (parcel_id, content_type, data)
+SELECT parcel_id, content_type, data
+FROM parcel_photos
+WHERE parcel_id IN (SELECT id FROM parcels_of_residents);
+
+DROP TABLE parcel_photos;
+DROP INDEX parcels_unit;
+DROP INDEX parcels_resident_id;
+DROP INDEX parcels_condominium_id;
+DROP TABLE parcels;
+ALTER TABLE parcels_of_residents RENAME TO parcels;
+ALTER TABLE parcel_photos_of_residents RENAME TO parcel_photos;
+
+CREATE INDEX parcels_condominium_id ON parcels(condominium_id, collected_at);
+CREATE INDEX parcels_resident_id ON parcels(resident_id, collected_at);
@@ -0,0 +1,180 @@
+-- name: ListAmenitiesByCondominium :many
+SELECT *
+FROM amenities
+WHERE condominium_id = ?
+ORDER BY name, id;
+
+-- name: GetAmenityByID :one
+SELECT *
+FROM amenities
+WHERE id = ?;
+
+-- name: CreateAmenity :one
+INSERT INTO amenities (
+    condominium_id,
+    name,
+    capacity,
+    opens_minute,
+    closes_minute,
+    slot_minutes,
+    max_per_month,
+    lead_hours,
+    requires_approval,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: DeleteAmenity :execrows
+DELETE FROM amenities
+WHERE id = ?;
+
+-- name: CountUpcomingReservations :one
+-- Counts the pending and confirmed reservations of the amenity that didn't
+-- end at now.
+SELECT COUNT(*)
+FROM reservations
+WHERE amenity_id = sqlc.arg(amenity_id)
+    AND status IN ('pending', 'confirmed')
+    AND ends_at > sqlc.arg(now);
+
+-- name: CountReservedPeople :one
+-- Counts the residents and guests of the pending and confirmed reservations
+-- of the amenity that overlap from starts_at to ends_at.
+SELECT CAST(COALESCE(SUM(1 + (
+    SELECT COUNT(*)
+    FROM reservation_guests
+    WHERE reservation_guests.reservation_id = reservations.id
+)), 0) AS INTEGER) AS people
+FROM reservations
+WHERE amenity_id = sqlc.arg(amenity_id)
+    AND status IN ('pending', 'confirmed')
+    AND starts_at < sqlc.arg(ends_at)
+    AND ends_at > sqlc.arg(starts_at);
+
+-- name: CountResidentReservations :one
+-- Counts the pending and confirmed reservations of the amenity the resident
+-- has starting from since to until.
+SELECT COUNT(*)
+FROM reservations
+WHERE amenity_id = sqlc.arg(amenity_id)
+    AND resident_id = sqlc.arg(resident_id)
+    AND status IN ('pending', 'confirmed')
+    AND starts_at >= sqlc.arg(since)
+    AND starts_at < sqlc.arg(until);
+
+-- name: CreateReservation :one
+INSERT INTO reservations (
+    condominium_id,
+    amenity_id,
+    resident_id,
+    starts_at,
+    ends_at,
+    status,
+    decided_by,
+    decided_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: AddReservationGuest :exec
+INSERT INTO reservation_guests (reservation_id, name, visit_id)
+VALUES (?, ?, ?);
+
+-- name: GetReservationByID :one
+SELECT
+    reservations.*,
+    amenities.name AS amenity_name,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name,
+    users.tower AS resident_tower,
+    users.unit AS resident_unit
+FROM reservations
+JOIN amenities ON amenities.id = reservations.amenity_id
+JOIN users ON users.id = reservations.resident_id
+WHERE reservations.id = ?;
+
+-- name: ListReservationsByCondominium :many
+-- Lists the reservations of the condominium that end after since and start
+-- before until.
+SELECT
+    reservations.*,
+    amenities.name AS amenity_name,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name,
+    users.tower AS resident_tower,
+    users.unit AS resident_unit
+FROM reservations
+JOIN amenities ON amenities.id = reservations.amenity_id
+JOIN users ON users.id = reservations.resident_id
+WHERE reservations.condominium_id = sqlc.arg(condominium_id)
+    AND reservations.ends_at > sqlc.arg(since)
+    AND reservations.starts_at < sqlc.arg(until)
+ORDER BY reservations.starts_at, amenities.name, reservations.id;
+
+-- name: ListReservationsByResident :many
+-- Lists the reservations of the resident that end after since.
+SELECT
+    reservations.*,
+    amenities.name AS amenity_name,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name,
+    users.tower AS resident_tower,
+    users.unit AS resident_unit
+FROM reservations
+JOIN amenities ON amenities.id = reservations.amenity_id
+JOIN users ON users.id = reservations.resident_id
+WHERE reservations.resident_id = sqlc.arg(resident_id)
+    AND reservations.ends_at > sqlc.arg(since)
+ORDER BY reservations.starts_at, reservations.id;
+
+-- name: ListReservationGuests :many
+SELECT *
+FROM reservation_guests
+WHERE reservation_id = ?
+ORDER BY id;
+
+-- name: ListReservationGuestsByCondominium :many
+SELECT reservation_guests.*
+FROM reservation_guests
+JOIN reservations ON reservations.id = reservation_guests.reservation_id
+WHERE reservations.condominium_id = sqlc.arg(condominium_id)
+    AND reservations.ends_at > sqlc.arg(since)
+    AND reservations.starts_at < sqlc.arg(until)
+ORDER BY reservation_guests.id;
+
+-- name: ListReservationGuestsByResident :many
+SELECT reservation_guests.*
+FROM reservation_guests
+JOIN reservations ON reservations.id = reservation_guests.reservation_id
+WHERE reservations.resident_id = sqlc.arg(resident_id)
+    AND reservations.ends_at > sqlc.arg(since)
+ORDER BY reservation_guests.id;
+
+-- name: SetReservationGuestVisit :exec
+UPDATE reservation_guests
+SET visit_id = ?
+WHERE id = ?;
+
+-- name: ConfirmReservation :execrows
+UPDATE reservations
+SET status = 'confirmed', decided_by = ?, decided_at = ?
+WHERE id = ? AND status = 'pending';
+
+-- name: EndReservation :execrows
+-- Rejects or cancels a pending or confirmed reservation.
+UPDATE reservations
+SET status = ?, decided_by = ?, decided_at = ?
+WHERE id = ? AND status IN ('pending', 'confirmed');
+
+-- name: RevokeReservationPasses :many
+UPDATE visits
+SET revoked_at = sqlc.arg(revoked_at), revoked_by = sqlc.arg(revoked_by), updated_at = sqlc.arg(revoked_at)
+WHERE id IN (
+    SELECT visit_id
+    FROM reservation_guests
+    WHERE reservation_id = sqlc.arg(reservation_id)
+)
+    AND revoked_at IS NULL
+RETURNING *;
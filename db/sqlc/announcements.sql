@@ -0,0 +1,87 @@
+-- name: CreateAnnouncement :one
+INSERT INTO announcements (
+    condominium_id,
+    title,
+    body,
+    audience,
+    pinned,
+    expires_at,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: AddAnnouncementTarget :exec
+INSERT INTO announcement_targets (announcement_id, tower, unit)
+VALUES (?, ?, ?);
+
+-- name: GetAnnouncementByID :one
+SELECT
+    announcements.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS author_name
+FROM announcements
+LEFT JOIN users ON users.id = announcements.created_by
+WHERE announcements.id = ?;
+
+-- name: ListAnnouncementsByCondominium :many
+SELECT
+    announcements.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS author_name,
+    (
+        SELECT COUNT(*)
+        FROM announcement_reads
+        WHERE announcement_reads.announcement_id = announcements.id
+    ) AS read_count
+FROM announcements
+LEFT JOIN users ON users.id = announcements.created_by
+WHERE announcements.condominium_id = ?
+ORDER BY announcements.pinned DESC, announcements.created_at DESC, announcements.id DESC;
+
+-- name: ListActiveAnnouncements :many
+-- Lists the announcements of the condominium that haven't expired, and when
+-- the user read them.
+SELECT
+    announcements.*,
+    announcement_reads.read_at
+FROM announcements
+LEFT JOIN announcement_reads
+    ON announcement_reads.announcement_id = announcements.id
+    AND announcement_reads.user_id = sqlc.arg(user_id)
+WHERE announcements.condominium_id = sqlc.arg(condominium_id)
+    AND (announcements.expires_at IS NULL OR announcements.expires_at > sqlc.arg(now))
+ORDER BY announcements.pinned DESC, announcements.created_at DESC, announcements.id DESC;
+
+-- name: ListAnnouncementTargetsByCondominium :many
+SELECT announcement_targets.*
+FROM announcement_targets
+JOIN announcements ON announcements.id = announcement_targets.announcement_id
+WHERE announcements.condominium_id = ?
+ORDER BY announcement_targets.tower, announcement_targets.unit;
+
+-- name: ListAnnouncementTargets :many
+SELECT *
+FROM announcement_targets
+WHERE announcement_id = ?
+ORDER BY tower, unit;
+
+-- name: ListAnnouncementReads :many
+SELECT *
+FROM announcement_reads
+WHERE announcement_id = ?
+ORDER BY read_at;
+
+-- name: MarkAnnouncementRead :exec
+INSERT INTO announcement_reads (announcement_id, user_id, read_at)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: SetAnnouncementPinned :execrows
+UPDATE announcements
+SET pinned = ?
+WHERE id = ?;
+
+-- name: DeleteAnnouncement :execrows
+DELETE FROM announcements
+WHERE id = ?;
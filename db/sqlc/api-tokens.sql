@@ -0,0 +1,33 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+    token_hash,
+    user_id,
+    condominium_id,
+    name,
+    scopes,
+    created_at,
+    expires_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetAPITokenByHash :one
+SELECT *
+FROM api_tokens
+WHERE token_hash = ? AND expires_at > ?;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = ?
+WHERE id = ?;
+
+-- name: ListAPITokensByUser :many
+SELECT *
+FROM api_tokens
+WHERE user_id = ? AND expires_at > ?
+ORDER BY created_at DESC;
+
+-- name: DeleteUserAPIToken :execrows
+DELETE FROM api_tokens
+WHERE user_id = ? AND id = ?;
@@ -0,0 +1,134 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (
+    condominium_id,
+    user_id,
+    impersonator_id,
+    level,
+    action,
+    message,
+    entry_id,
+    entry_hash,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: SetAuditLogHash :exec
+UPDATE audit_logs
+SET prev_hash = ?, hash = ?
+WHERE id = ?;
+
+-- name: ListAuditLogs :many
+SELECT
+    audit_logs.id,
+    audit_logs.condominium_id,
+    audit_logs.user_id,
+    audit_logs.impersonator_id,
+    audit_logs.level,
+    audit_logs.action,
+    audit_logs.message,
+    audit_logs.created_at,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS user_name,
+    CAST(IFNULL(users.email, '') AS TEXT) AS user_email,
+    CAST(IFNULL(impersonators.first_name || ' ' || impersonators.last_name, '') AS TEXT) AS impersonator_name,
+    CAST(IFNULL(condominiums.name, '') AS TEXT) AS condominium_name
+FROM audit_logs
+LEFT JOIN users ON users.id = audit_logs.user_id
+LEFT JOIN users AS impersonators ON impersonators.id = audit_logs.impersonator_id
+LEFT JOIN condominiums ON condominiums.id = audit_logs.condominium_id
+WHERE (CAST(sqlc.arg(condominium_id) AS INTEGER) = 0 OR audit_logs.condominium_id = sqlc.arg(condominium_id))
+    AND (CAST(sqlc.arg(user_id) AS INTEGER) = 0 OR audit_logs.user_id = sqlc.arg(user_id))
+    AND audit_logs.level >= sqlc.arg(min_level)
+    AND (CAST(sqlc.arg(action) AS TEXT) = '' OR audit_logs.action = sqlc.arg(action))
+    AND (CAST(sqlc.arg(search) AS TEXT) = '' OR audit_logs.message LIKE '%' || sqlc.arg(search) || '%')
+    AND audit_logs.created_at >= sqlc.arg(created_from)
+    AND (CAST(sqlc.arg(created_to) AS INTEGER) = 0 OR audit_logs.created_at < sqlc.arg(created_to))
+ORDER BY audit_logs.created_at DESC, audit_logs.id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountAuditLogs :one
+SELECT COUNT(*) AS count
+FROM audit_logs
+WHERE (CAST(sqlc.arg(condominium_id) AS INTEGER) = 0 OR audit_logs.condominium_id = sqlc.arg(condominium_id))
+    AND (CAST(sqlc.arg(user_id) AS INTEGER) = 0 OR audit_logs.user_id = sqlc.arg(user_id))
+    AND audit_logs.level >= sqlc.arg(min_level)
+    AND (CAST(sqlc.arg(action) AS TEXT) = '' OR audit_logs.action = sqlc.arg(action))
+    AND (CAST(sqlc.arg(search) AS TEXT) = '' OR audit_logs.message LIKE '%' || sqlc.arg(search) || '%')
+    AND audit_logs.created_at >= sqlc.arg(created_from)
+    AND (CAST(sqlc.arg(created_to) AS INTEGER) = 0 OR audit_logs.created_at < sqlc.arg(created_to));
+
+-- name: ListAuditChain :many
+SELECT *
+FROM audit_logs
+WHERE IFNULL(condominium_id, 0) = CAST(sqlc.arg(chain_id) AS INTEGER) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: ListAuditChainIDs :many
+SELECT DISTINCT CAST(IFNULL(condominium_id, 0) AS INTEGER) AS chain_id
+FROM audit_logs
+ORDER BY chain_id;
+
+-- name: GetAuditChainHead :one
+SELECT *
+FROM audit_chain_heads
+WHERE chain_id = ?;
+
+-- name: ListAuditChainHeads :many
+SELECT *
+FROM audit_chain_heads
+ORDER BY chain_id;
+
+-- name: CountAuditChainHeads :one
+SELECT COUNT(*) AS count
+FROM audit_chain_heads;
+
+-- name: UpsertAuditChainHead :exec
+INSERT INTO audit_chain_heads (
+    chain_id,
+    last_id,
+    hash,
+    entries,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+ON CONFLICT (chain_id) DO UPDATE SET
+    last_id = excluded.last_id,
+    hash = excluded.hash,
+    entries = excluded.entries,
+    updated_at = excluded.updated_at;
+
+-- name: CreateAuditCheckpoint :one
+INSERT INTO audit_checkpoints (
+    chain_id,
+    last_id,
+    hash,
+    entries,
+    public_key,
+    signature,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetAuditCheckpoint :one
+SELECT *
+FROM audit_checkpoints
+WHERE id = ?;
+
+-- name: GetLatestAuditCheckpoint :one
+SELECT *
+FROM audit_checkpoints
+WHERE chain_id = ?
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAuditCheckpoints :many
+SELECT *
+FROM audit_checkpoints
+WHERE chain_id = ?
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
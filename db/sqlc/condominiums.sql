@@ -0,0 +1,41 @@
+-- name: GetCondominiumByID :one
+SELECT *
+FROM condominiums
+WHERE id = ?;
+
+-- name: CreateCondominium :one
+INSERT INTO condominiums (
+    name,
+    address,
+    created_at,
+    updated_at,
+    created_by,
+    updated_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: UpdateCondominium :exec
+UPDATE condominiums
+SET name = ?,
+    address = ?,
+    max_visitors = ?,
+    max_visitors_per_unit = ?,
+    updated_at = ?,
+    updated_by = ?
+WHERE id = ?;
+
+-- name: ListCondominiumsWithCounts :many
+SELECT
+    condominiums.id,
+    condominiums.name,
+    condominiums.address,
+    condominiums.created_at,
+    condominiums.updated_at,
+    condominiums.created_by,
+    condominiums.updated_by,
+    (SELECT COUNT(*) FROM users WHERE users.condominium_id = condominiums.id) AS user_count,
+    (SELECT COUNT(*) FROM visits WHERE visits.condominium_id = condominiums.id) AS visit_count
+FROM condominiums
+ORDER BY condominiums.name, condominiums.id;
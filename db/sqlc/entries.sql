@@ -0,0 +1,87 @@
+-- name: CreateEntry :one
+INSERT INTO entries (
+    condominium_id,
+    visit_id,
+    guard_id,
+    visitor_name,
+    accepted,
+    reason,
+    station_id,
+    shift_id,
+    over_limit,
+    override_of,
+    override_reason,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListEntriesByCondominium :many
+SELECT *
+FROM entries
+WHERE condominium_id = ? AND created_at >= sqlc.arg(since)
+ORDER BY created_at DESC, id DESC;
+
+-- name: ListEntriesByShift :many
+SELECT *
+FROM entries
+WHERE shift_id = ?
+ORDER BY created_at, id;
+
+-- name: GetEntryByID :one
+SELECT *
+FROM entries
+WHERE id = ?;
+
+-- name: ListEntriesInside :many
+-- Lists the visitors of the condominium that checked in and didn't leave,
+-- while their visits last, with the resident they came to see and the unit
+-- they live in, if they live in the condominium. Checking in again with the
+-- same visit means they left before, even if no guard recorded it.
+SELECT
+    entries.*,
+    visits.user_id AS resident_id,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name,
+    CAST(CASE WHEN users.condominium_id = entries.condominium_id THEN users.tower ELSE '' END AS TEXT) AS resident_tower,
+    CAST(CASE WHEN users.condominium_id = entries.condominium_id THEN users.unit ELSE '' END AS TEXT) AS resident_unit
+FROM entries
+JOIN visits ON visits.id = entries.visit_id
+JOIN users ON users.id = visits.user_id
+WHERE entries.condominium_id = sqlc.arg(condominium_id)
+    AND entries.accepted
+    AND entries.exited_at IS NULL
+    AND visits.valid_to >= sqlc.arg(now)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM entries later
+        WHERE later.visit_id = entries.visit_id
+            AND later.accepted
+            AND later.id > entries.id
+    )
+ORDER BY entries.created_at, entries.id;
+
+-- name: ExitEntry :execrows
+UPDATE entries
+SET exited_at = ?, exited_by = ?
+WHERE id = ? AND accepted AND exited_at IS NULL;
+
+-- name: ListOverridableEntries :many
+-- Lists the check-ins of the condominium denied since since because of an
+-- occupancy limit that no admin let in yet, newest first.
+SELECT *
+FROM entries
+WHERE condominium_id = sqlc.arg(condominium_id)
+    AND over_limit
+    AND created_at >= sqlc.arg(since)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM entries overrides
+        WHERE overrides.override_of = entries.id
+    )
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountEntryOverrides :one
+SELECT COUNT(*)
+FROM entries
+WHERE override_of = ?;
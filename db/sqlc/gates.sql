@@ -0,0 +1,68 @@
+-- name: ListGatesByCondominium :many
+SELECT *
+FROM gates
+WHERE condominium_id = ?
+ORDER BY name, id;
+
+-- name: GetGateByID :one
+SELECT *
+FROM gates
+WHERE id = ?;
+
+-- name: CreateGate :one
+INSERT INTO gates (
+    condominium_id,
+    name,
+    driver,
+    target,
+    http_method,
+    payload,
+    unit_id,
+    coil,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: DeleteGate :execrows
+DELETE FROM gates
+WHERE id = ?;
+
+-- name: ListGuardStationsByCondominium :many
+SELECT *
+FROM guard_stations
+WHERE condominium_id = ?
+ORDER BY name, id;
+
+-- name: GetGuardStationByID :one
+SELECT *
+FROM guard_stations
+WHERE id = ?;
+
+-- name: CreateGuardStation :one
+INSERT INTO guard_stations (
+    condominium_id,
+    name,
+    gate_id,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: SetGuardStationGate :execrows
+UPDATE guard_stations
+SET gate_id = ?
+WHERE id = ?;
+
+-- name: DeleteGuardStation :execrows
+DELETE FROM guard_stations
+WHERE id = ?;
+
+-- name: SetGuardStationWelfare :execrows
+UPDATE guard_stations
+SET welfare_interval_minutes = ?, welfare_grace_minutes = ?
+WHERE id = ?;
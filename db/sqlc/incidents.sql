@@ -0,0 +1,113 @@
+-- name: CreateIncident :one
+INSERT INTO incidents (
+    condominium_id,
+    category,
+    severity,
+    location,
+    description,
+    status,
+    reported_by,
+    shift_id,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: AddIncidentResident :exec
+INSERT INTO incident_residents (incident_id, user_id)
+VALUES (?, ?);
+
+-- name: AddIncidentVisit :exec
+INSERT INTO incident_visits (incident_id, visit_id)
+VALUES (?, ?);
+
+-- name: CreateIncidentAttachment :exec
+INSERT INTO incident_attachments (
+    incident_id,
+    filename,
+    content_type,
+    size,
+    data,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetIncidentByID :one
+SELECT
+    incidents.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS reporter_name
+FROM incidents
+LEFT JOIN users ON users.id = incidents.reported_by
+WHERE incidents.id = ?;
+
+-- name: ListIncidentsByCondominium :many
+SELECT
+    incidents.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS reporter_name
+FROM incidents
+LEFT JOIN users ON users.id = incidents.reported_by
+WHERE incidents.condominium_id = sqlc.arg(condominium_id)
+    AND (CAST(sqlc.arg(status) AS TEXT) = '' OR incidents.status = sqlc.arg(status))
+ORDER BY incidents.created_at DESC, incidents.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListIncidentsByShift :many
+SELECT
+    incidents.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS reporter_name
+FROM incidents
+LEFT JOIN users ON users.id = incidents.reported_by
+WHERE incidents.shift_id = ?
+ORDER BY incidents.created_at, incidents.id;
+
+-- name: ListIncidentResidents :many
+SELECT
+    users.id,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS name
+FROM incident_residents
+JOIN users ON users.id = incident_residents.user_id
+WHERE incident_residents.incident_id = ?
+ORDER BY users.first_name, users.last_name;
+
+-- name: ListIncidentVisits :many
+SELECT visit_id
+FROM incident_visits
+WHERE incident_id = ?
+ORDER BY visit_id;
+
+-- name: ListIncidentAttachments :many
+SELECT id, incident_id, filename, content_type, size, created_at
+FROM incident_attachments
+WHERE incident_id = ?
+ORDER BY id;
+
+-- name: GetIncidentAttachment :one
+SELECT *
+FROM incident_attachments
+WHERE id = ?;
+
+-- name: ListIncidentComments :many
+SELECT
+    incident_comments.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS author_name
+FROM incident_comments
+LEFT JOIN users ON users.id = incident_comments.author_id
+WHERE incident_comments.incident_id = ?
+ORDER BY incident_comments.created_at, incident_comments.id;
+
+-- name: CreateIncidentComment :exec
+INSERT INTO incident_comments (incident_id, author_id, body, status, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: UpdateIncidentStatus :execrows
+UPDATE incidents
+SET status = sqlc.arg(status), updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status);
+
+-- name: TouchIncident :exec
+UPDATE incidents
+SET updated_at = ?
+WHERE id = ?;
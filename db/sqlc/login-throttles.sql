@@ -0,0 +1,45 @@
+-- name: GetLoginThrottle :one
+SELECT *
+FROM login_throttles
+WHERE kind = ? AND subject = ?;
+
+-- name: UpsertLoginThrottle :exec
+INSERT INTO login_throttles (
+    kind,
+    subject,
+    failures,
+    last_failure_at,
+    locked_until
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+ON CONFLICT (kind, subject) DO UPDATE SET
+    failures = excluded.failures,
+    last_failure_at = excluded.last_failure_at,
+    locked_until = excluded.locked_until;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE kind = ? AND subject = ?;
+
+-- name: ListLockedAccounts :many
+SELECT
+    users.id,
+    users.condominium_id,
+    users.first_name,
+    users.last_name,
+    users.email,
+    login_throttles.failures,
+    login_throttles.locked_until
+FROM login_throttles
+JOIN users ON lower(users.email) = login_throttles.subject
+WHERE login_throttles.kind = 'account'
+    AND login_throttles.locked_until > sqlc.arg(now)
+    AND (CAST(sqlc.arg(condominium_id) AS INTEGER) = 0 OR users.condominium_id = sqlc.arg(condominium_id))
+ORDER BY login_throttles.locked_until DESC;
+
+-- name: DeleteStaleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE kind = ?
+    AND last_failure_at < sqlc.arg(failed_before)
+    AND locked_until <= sqlc.arg(now);
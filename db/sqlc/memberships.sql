@@ -0,0 +1,35 @@
+-- name: ListMembershipsByUser :many
+-- The home membership comes first.
+SELECT
+    condominiums.id AS condominium_id,
+    condominiums.name AS condominium_name,
+    users.role AS role,
+    1 AS home
+FROM users
+JOIN condominiums ON condominiums.id = users.condominium_id
+WHERE users.id = sqlc.arg(user_id)
+UNION ALL
+SELECT
+    condominiums.id AS condominium_id,
+    condominiums.name AS condominium_name,
+    condominium_memberships.role AS role,
+    0 AS home
+FROM condominium_memberships
+JOIN condominiums ON condominiums.id = condominium_memberships.condominium_id
+WHERE condominium_memberships.user_id = sqlc.arg(user_id)
+ORDER BY home DESC, condominium_name;
+
+-- name: CreateMembership :exec
+INSERT INTO condominium_memberships (
+    user_id,
+    condominium_id,
+    role,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?
+);
+
+-- name: DeleteMembership :execrows
+DELETE FROM condominium_memberships
+WHERE user_id = ? AND condominium_id = ?;
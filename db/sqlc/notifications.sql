@@ -0,0 +1,103 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+    user_id,
+    condominium_id,
+    event,
+    title,
+    body,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT *
+FROM notifications
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: MarkNotificationRead :exec
+UPDATE notifications
+SET read_at = ?
+WHERE user_id = ? AND id = ? AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = ?
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: ListNotificationPreferences :many
+SELECT *
+FROM notification_preferences
+WHERE user_id = ?;
+
+-- name: DeleteNotificationPreferences :exec
+DELETE FROM notification_preferences
+WHERE user_id = ?;
+
+-- name: CreateNotificationPreference :exec
+INSERT INTO notification_preferences (
+    user_id,
+    event,
+    channels
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: GetNotificationQuietHours :one
+SELECT *
+FROM notification_quiet_hours
+WHERE user_id = ?;
+
+-- name: DeleteNotificationQuietHours :exec
+DELETE FROM notification_quiet_hours
+WHERE user_id = ?;
+
+-- name: CreateNotificationQuietHours :exec
+INSERT INTO notification_quiet_hours (
+    user_id,
+    start_minute,
+    end_minute
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: CreateNotificationDelivery :one
+INSERT INTO notification_deliveries (
+    user_id,
+    channel,
+    recipient,
+    subject,
+    body,
+    url,
+    actions,
+    status,
+    next_attempt_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListDueNotificationDeliveries :many
+SELECT *
+FROM notification_deliveries
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?;
+
+-- name: UpdateNotificationDelivery :exec
+UPDATE notification_deliveries
+SET status = ?,
+    attempts = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?,
+    last_error = ?
+WHERE id = ?;
@@ -0,0 +1,114 @@
+-- name: CreateParcel :one
+INSERT INTO parcels (
+    condominium_id,
+    resident_id,
+    tower,
+    unit,
+    carrier,
+    tracking_number,
+    size,
+    pickup_code,
+    received_by,
+    shift_id,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: CreateParcelPhoto :exec
+INSERT INTO parcel_photos (parcel_id, content_type, data)
+VALUES (?, ?, ?);
+
+-- name: GetParcelByID :one
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.id = ?;
+
+-- name: ListPendingParcelsByCondominium :many
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.condominium_id = ? AND parcels.collected_at IS NULL
+ORDER BY parcels.created_at, parcels.id;
+
+-- name: ListCollectedParcelsByCondominium :many
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.condominium_id = ? AND parcels.collected_at >= sqlc.arg(since)
+ORDER BY parcels.collected_at DESC, parcels.id DESC;
+
+-- name: ListPendingParcelsForResident :many
+-- Lists the parcels of the resident, and of the unit it lives in when it
+-- has one.
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.condominium_id = sqlc.arg(condominium_id)
+    AND parcels.collected_at IS NULL
+    AND (
+        parcels.resident_id = sqlc.arg(resident_id)
+        OR (parcels.unit != '' AND parcels.tower = sqlc.arg(tower) AND parcels.unit = sqlc.arg(unit))
+    )
+ORDER BY parcels.created_at, parcels.id;
+
+-- name: AuthorizeParcel :execrows
+UPDATE parcels
+SET authorized_at = ?
+WHERE id = ? AND collected_at IS NULL;
+
+-- name: CollectParcel :execrows
+UPDATE parcels
+SET collected_at = ?, collected_by = ?, handed_over_by = ?, collected_shift_id = ?
+WHERE id = ? AND collected_at IS NULL;
+
+-- name: GetParcelPhoto :one
+SELECT content_type, data
+FROM parcel_photos
+WHERE parcel_id = ?;
+
+-- name: ListParcelsReceivedByShift :many
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.shift_id = ?
+ORDER BY parcels.created_at, parcels.id;
+
+-- name: ListParcelsCollectedByShift :many
+SELECT
+    parcels.*,
+    CAST(IFNULL(users.first_name || ' ' || users.last_name, '') AS TEXT) AS resident_name,
+    CAST(EXISTS (
+        SELECT 1 FROM parcel_photos WHERE parcel_photos.parcel_id = parcels.id
+    ) AS BOOLEAN) AS has_photo
+FROM parcels
+LEFT JOIN users ON users.id = parcels.resident_id
+WHERE parcels.collected_shift_id = ?
+ORDER BY parcels.collected_at, parcels.id;
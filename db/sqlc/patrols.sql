@@ -0,0 +1,137 @@
+-- name: CreatePatrolCheckpoint :one
+INSERT INTO patrol_checkpoints (
+    condominium_id,
+    name,
+    code,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetPatrolCheckpointByID :one
+SELECT * FROM patrol_checkpoints WHERE id = ?;
+
+-- name: GetPatrolCheckpointByCode :one
+SELECT * FROM patrol_checkpoints WHERE code = ?;
+
+-- name: ListPatrolCheckpointsByCondominium :many
+SELECT * FROM patrol_checkpoints
+WHERE condominium_id = ?
+ORDER BY name, id;
+
+-- name: DeletePatrolCheckpoint :exec
+DELETE FROM patrol_checkpoints WHERE id = ?;
+
+-- name: CreatePatrolRoute :one
+INSERT INTO patrol_routes (
+    condominium_id,
+    name,
+    station_id,
+    start_minute,
+    end_minute,
+    every_minutes,
+    window_minutes,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: AddPatrolRouteCheckpoint :exec
+INSERT INTO patrol_route_checkpoints (
+    route_id,
+    checkpoint_id,
+    position
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: GetPatrolRouteByID :one
+SELECT
+    patrol_routes.*,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM patrol_routes
+LEFT JOIN guard_stations ON guard_stations.id = patrol_routes.station_id
+WHERE patrol_routes.id = ?;
+
+-- name: ListPatrolRoutesByCondominium :many
+SELECT
+    patrol_routes.*,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM patrol_routes
+LEFT JOIN guard_stations ON guard_stations.id = patrol_routes.station_id
+WHERE patrol_routes.condominium_id = ?
+ORDER BY patrol_routes.name, patrol_routes.id;
+
+-- name: ListPatrolRoutes :many
+SELECT
+    patrol_routes.*,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM patrol_routes
+LEFT JOIN guard_stations ON guard_stations.id = patrol_routes.station_id
+ORDER BY patrol_routes.condominium_id, patrol_routes.id;
+
+-- name: ListPatrolRouteCheckpoints :many
+SELECT patrol_checkpoints.*
+FROM patrol_route_checkpoints
+JOIN patrol_checkpoints ON patrol_checkpoints.id = patrol_route_checkpoints.checkpoint_id
+WHERE patrol_route_checkpoints.route_id = ?
+ORDER BY patrol_route_checkpoints.position;
+
+-- name: DeletePatrolRoute :exec
+DELETE FROM patrol_routes WHERE id = ?;
+
+-- name: CreatePatrolScan :one
+INSERT INTO patrol_scans (
+    condominium_id,
+    checkpoint_id,
+    guard_id,
+    shift_id,
+    scanned_at
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: ListPatrolScansByShift :many
+SELECT
+    patrol_scans.*,
+    patrol_checkpoints.name AS checkpoint_name
+FROM patrol_scans
+JOIN patrol_checkpoints ON patrol_checkpoints.id = patrol_scans.checkpoint_id
+WHERE patrol_scans.shift_id = ?
+ORDER BY patrol_scans.scanned_at, patrol_scans.id;
+
+-- name: CreatePatrolRound :execrows
+INSERT INTO patrol_rounds (
+    condominium_id,
+    route_id,
+    route_name,
+    shift_id,
+    due_at,
+    status,
+    checkpoints,
+    scanned,
+    missing,
+    evaluated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (route_id, shift_id, due_at) DO NOTHING;
+
+-- name: ListPatrolRoundsByCondominium :many
+SELECT
+    patrol_rounds.*,
+    shifts.guard_id,
+    shifts.started_at AS shift_started_at,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name
+FROM patrol_rounds
+JOIN shifts ON shifts.id = patrol_rounds.shift_id
+LEFT JOIN users ON users.id = shifts.guard_id
+WHERE patrol_rounds.condominium_id = sqlc.arg(condominium_id)
+    AND patrol_rounds.due_at >= sqlc.arg(since)
+    AND patrol_rounds.due_at < sqlc.arg(until)
+ORDER BY patrol_rounds.due_at, patrol_rounds.id;
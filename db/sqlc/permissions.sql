@@ -0,0 +1,21 @@
+-- name: ListPermissionOverrides :many
+SELECT *
+FROM permission_overrides
+WHERE condominium_id = ?
+ORDER BY role, permission;
+
+-- name: CreatePermissionOverride :exec
+INSERT INTO permission_overrides (
+    condominium_id,
+    role,
+    permission,
+    granted,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: DeletePermissionOverrides :exec
+DELETE FROM permission_overrides
+WHERE condominium_id = ?;
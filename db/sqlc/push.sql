@@ -0,0 +1,43 @@
+-- name: ListPushSubscriptionsByUser :many
+SELECT *
+FROM push_subscriptions
+WHERE user_id = ?
+ORDER BY created_at, id;
+
+-- name: GetPushSubscriptionByID :one
+SELECT *
+FROM push_subscriptions
+WHERE id = ?;
+
+-- name: SavePushSubscription :one
+INSERT INTO push_subscriptions (
+    user_id,
+    endpoint,
+    p256dh,
+    auth,
+    user_agent,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = excluded.user_id,
+    p256dh = excluded.p256dh,
+    auth = excluded.auth,
+    user_agent = excluded.user_agent,
+    created_at = excluded.created_at
+RETURNING *;
+
+-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE id = ?;
+
+-- name: GetVAPIDKey :one
+SELECT private_key
+FROM vapid_keys
+WHERE id = 1;
+
+-- name: SaveVAPIDKey :exec
+INSERT INTO vapid_keys (id, private_key, created_at)
+VALUES (1, ?, ?)
+ON CONFLICT (id) DO NOTHING;
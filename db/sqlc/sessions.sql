@@ -0,0 +1,57 @@
+-- name: GetSessionByTokenHash :one
+SELECT *
+FROM sessions
+WHERE token_hash = ? AND expires_at > ?;
+
+-- name: CreateSession :one
+INSERT INTO sessions (
+    token_hash,
+    user_id,
+    data,
+    user_agent,
+    ip,
+    created_at,
+    last_seen_at,
+    expires_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: UpdateSession :execrows
+UPDATE sessions
+SET
+    user_id = ?,
+    data = ?,
+    user_agent = ?,
+    ip = ?,
+    last_seen_at = ?,
+    expires_at = ?
+WHERE token_hash = sqlc.arg(token_hash) AND expires_at > sqlc.arg(now);
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = ?, ip = ?
+WHERE token_hash = ?;
+
+-- name: DeleteSessionByTokenHash :exec
+DELETE FROM sessions
+WHERE token_hash = ?;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM sessions
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions
+WHERE user_id = ?;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions
+WHERE expires_at <= ?;
+
+-- name: ListUserSessions :many
+SELECT *
+FROM sessions
+WHERE user_id = ? AND expires_at > ?
+ORDER BY last_seen_at DESC;
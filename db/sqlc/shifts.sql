@@ -0,0 +1,86 @@
+-- name: CreateShift :one
+INSERT INTO shifts (
+    condominium_id,
+    guard_id,
+    station_id,
+    started_at
+) VALUES (
+    ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: GetShiftByID :one
+SELECT
+    shifts.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM shifts
+LEFT JOIN users ON users.id = shifts.guard_id
+LEFT JOIN guard_stations ON guard_stations.id = shifts.station_id
+WHERE shifts.id = ?;
+
+-- name: GetOpenShiftByGuard :one
+SELECT
+    shifts.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM shifts
+LEFT JOIN users ON users.id = shifts.guard_id
+LEFT JOIN guard_stations ON guard_stations.id = shifts.station_id
+WHERE shifts.guard_id = ? AND shifts.ended_at IS NULL;
+
+-- name: ListClosedShiftsByCondominium :many
+SELECT
+    shifts.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM shifts
+LEFT JOIN users ON users.id = shifts.guard_id
+LEFT JOIN guard_stations ON guard_stations.id = shifts.station_id
+WHERE shifts.condominium_id = ? AND shifts.ended_at IS NOT NULL
+ORDER BY shifts.ended_at DESC, shifts.id DESC
+LIMIT ?;
+
+-- name: CloseShift :execrows
+UPDATE shifts
+SET ended_at = ?, handover_notes = ?, logbook = ?
+WHERE id = ? AND ended_at IS NULL;
+
+-- name: ListShiftsOverlapping :many
+SELECT
+    shifts.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name,
+    CAST(COALESCE(guard_stations.name, '') AS TEXT) AS station_name
+FROM shifts
+LEFT JOIN users ON users.id = shifts.guard_id
+LEFT JOIN guard_stations ON guard_stations.id = shifts.station_id
+WHERE shifts.condominium_id = sqlc.arg(condominium_id)
+    AND shifts.started_at < sqlc.arg(until)
+    AND (shifts.ended_at IS NULL OR shifts.ended_at > sqlc.arg(since))
+ORDER BY shifts.started_at, shifts.id;
+
+-- name: ListWelfareOverdueShifts :many
+SELECT
+    shifts.*,
+    CAST(COALESCE(users.first_name || ' ' || users.last_name, '') AS TEXT) AS guard_name,
+    guard_stations.name AS station_name
+FROM shifts
+JOIN guard_stations ON guard_stations.id = shifts.station_id
+LEFT JOIN users ON users.id = shifts.guard_id
+WHERE shifts.ended_at IS NULL
+    AND shifts.welfare_escalated_at IS NULL
+    AND guard_stations.welfare_interval_minutes > 0
+    AND COALESCE(shifts.welfare_checked_at, shifts.started_at)
+        + (guard_stations.welfare_interval_minutes + guard_stations.welfare_grace_minutes) * 60
+        <= sqlc.arg(now)
+ORDER BY shifts.id;
+
+-- name: EscalateShiftWelfare :execrows
+UPDATE shifts
+SET welfare_escalated_at = ?
+WHERE id = ? AND ended_at IS NULL AND welfare_escalated_at IS NULL;
+
+-- name: ConfirmShiftWelfare :execrows
+UPDATE shifts
+SET welfare_checked_at = ?, welfare_escalated_at = NULL
+WHERE id = ? AND ended_at IS NULL;
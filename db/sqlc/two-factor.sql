@@ -0,0 +1,62 @@
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = ?, totp_enabled = 0, totp_last_step = 0, updated_at = ?
+WHERE id = ?;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled = 1, updated_at = ?
+WHERE id = ? AND totp_secret IS NOT NULL;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0, updated_at = ?
+WHERE id = ?;
+
+-- name: AdvanceUserTOTPStep :execrows
+UPDATE users
+SET totp_last_step = ?
+WHERE id = ? AND totp_last_step < ?;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = ?;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+VALUES (?, ?, ?);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = ?
+WHERE user_id = ? AND code_hash = ? AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) AS count
+FROM recovery_codes
+WHERE user_id = ? AND used_at IS NULL;
+
+-- name: CountTwoFactorRequirements :one
+SELECT COUNT(*) AS count
+FROM two_factor_requirements
+WHERE role = ? AND (condominium_id IS NULL OR condominium_id = ?);
+
+-- name: ListTwoFactorRequirements :many
+SELECT *
+FROM two_factor_requirements
+ORDER BY condominium_id, role;
+
+-- name: CreateTwoFactorRequirement :one
+INSERT INTO two_factor_requirements (
+    condominium_id,
+    role,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: DeleteTwoFactorRequirement :exec
+DELETE FROM two_factor_requirements
+WHERE id = ?;
@@ -37,3 +37,32 @@ WHERE role = 'superadmin' AND enabled = 1;
 UPDATE users
 SET password = ?, updated_at = ?, updated_by = ?
 WHERE id = ?;
+
+-- name: ListUsersByCondominium :many
+-- Lists the users of the condominium and the members from other
+-- condominiums, with the role they hold in it.
+SELECT
+    sqlc.embed(users),
+    CAST(IFNULL(condominium_memberships.role, users.role) AS TEXT) AS membership_role
+FROM users
+LEFT JOIN condominium_memberships
+    ON condominium_memberships.user_id = users.id
+    AND condominium_memberships.condominium_id = sqlc.arg(condominium_id)
+WHERE users.condominium_id = sqlc.arg(condominium_id)
+    OR condominium_memberships.condominium_id IS NOT NULL
+ORDER BY users.hidden, users.last_name, users.first_name;
+
+-- name: UpdateUser :exec
+UPDATE users
+SET condominium_id = ?,
+    first_name = ?,
+    last_name = ?,
+    phone = ?,
+    role = ?,
+    enabled = ?,
+    hidden = ?,
+    tower = ?,
+    unit = ?,
+    updated_at = ?,
+    updated_by = ?
+WHERE id = ?;
@@ -0,0 +1,49 @@
+-- name: GetVisitByID :one
+SELECT *
+FROM visits
+WHERE id = ?;
+
+-- name: CreateVisit :one
+INSERT INTO visits (
+    id,
+    condominium_id,
+    user_id,
+    visitor_name,
+    max_uses,
+    uses,
+    valid_from,
+    valid_to,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: UpdateVisit :exec
+UPDATE visits
+SET visitor_name = ?,
+    max_uses = ?,
+    uses = ?,
+    valid_from = ?,
+    valid_to = ?,
+    revoked_at = ?,
+    revoked_by = ?,
+    updated_at = ?
+WHERE id = ?;
+
+-- name: ListVisitsByUser :many
+SELECT *
+FROM visits
+WHERE user_id = ?
+ORDER BY valid_to DESC, created_at DESC;
+
+-- name: ListExpectedVisitsByCondominium :many
+SELECT *
+FROM visits
+WHERE condominium_id = ?
+    AND revoked_at IS NULL
+    AND valid_from < sqlc.arg(until)
+    AND valid_to >= sqlc.arg(since)
+    AND (max_uses = 0 OR uses < max_uses)
+ORDER BY visitor_name, id;
@@ -0,0 +1,44 @@
+-- name: CreateWalkIn :one
+INSERT INTO walk_ins (
+    condominium_id,
+    resident_id,
+    visitor_name,
+    announced_by,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: GetWalkInByID :one
+SELECT
+    walk_ins.*,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name
+FROM walk_ins
+JOIN users ON users.id = walk_ins.resident_id
+WHERE walk_ins.id = ?;
+
+-- name: ListWalkInsByCondominium :many
+SELECT
+    walk_ins.*,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name
+FROM walk_ins
+JOIN users ON users.id = walk_ins.resident_id
+WHERE walk_ins.condominium_id = ? AND walk_ins.created_at >= sqlc.arg(since)
+ORDER BY walk_ins.created_at DESC, walk_ins.id DESC;
+
+-- name: ListPendingWalkInsByResident :many
+SELECT
+    walk_ins.*,
+    CAST(users.first_name || ' ' || users.last_name AS TEXT) AS resident_name
+FROM walk_ins
+JOIN users ON users.id = walk_ins.resident_id
+WHERE walk_ins.resident_id = ?
+    AND walk_ins.decision IS NULL
+    AND walk_ins.created_at >= sqlc.arg(since)
+ORDER BY walk_ins.created_at DESC, walk_ins.id DESC;
+
+-- name: DecideWalkIn :execrows
+UPDATE walk_ins
+SET decision = ?, decided_at = ?
+WHERE id = ? AND decision IS NULL;
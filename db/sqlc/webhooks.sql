@@ -0,0 +1,70 @@
+-- name: ListWebhooksByCondominium :many
+SELECT *
+FROM webhooks
+WHERE condominium_id = ?
+ORDER BY id;
+
+-- name: GetWebhookByID :one
+SELECT *
+FROM webhooks
+WHERE id = ?;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    condominium_id,
+    url,
+    secret,
+    events,
+    created_at,
+    created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    webhook_id,
+    event_id,
+    event,
+    payload,
+    status,
+    next_attempt_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetWebhookDeliveryByID :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?,
+    attempts = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?,
+    response_status = ?,
+    last_error = ?
+WHERE id = ?;
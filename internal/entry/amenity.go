@@ -0,0 +1,759 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Amenity is a common area residents reserve, like the pool or the party
+// room. Its days are split in slots of SlotMinutes from OpensMinute to
+// ClosesMinute, minutes from midnight, and each reservation takes one.
+type Amenity struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	// Capacity is how many people can be in the amenity at the same time,
+	// counting the residents that reserved it and their guests.
+	Capacity     int64
+	OpensMinute  int64
+	ClosesMinute int64
+	SlotMinutes  int64
+	// MaxPerMonth is how many reservations each resident can have in a
+	// month, zero for no limit.
+	MaxPerMonth int64
+	// LeadHours is how long before they start reservations have to be
+	// made.
+	LeadHours int64
+	// RequiresApproval amenities have their reservations pending until an
+	// admin approves them.
+	RequiresApproval bool
+	CreatedAt        time.Time
+	CreatedBy        int64
+}
+
+// ReservationMaxDays is how many days ahead amenities can be reserved.
+const ReservationMaxDays = 90
+
+const (
+	amenityNameLength = 100
+	minSlotMinutes    = 15
+	maxLeadHours      = 30 * 24
+	guestNameLength   = 100
+)
+
+func (a *Amenity) Valid() error {
+	if a.Name == "" {
+		return NewUserSafeError("El nombre es obligatorio")
+	}
+	if utf8.RuneCountInString(a.Name) > amenityNameLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"El nombre no puede tener más de %d caracteres", amenityNameLength,
+		))
+	}
+	if a.Capacity < 1 {
+		return NewUserSafeError("La capacidad debe ser de al menos una persona")
+	}
+	if a.OpensMinute < 0 || a.ClosesMinute > 24*60 || a.ClosesMinute <= a.OpensMinute {
+		return NewUserSafeError("La hora de cierre debe ser posterior a la de apertura")
+	}
+	if a.SlotMinutes < minSlotMinutes {
+		return NewUserSafeError(fmt.Sprintf(
+			"Las reservas deben durar al menos %d minutos", minSlotMinutes,
+		))
+	}
+	if a.SlotMinutes > a.ClosesMinute-a.OpensMinute {
+		return NewUserSafeError("Las reservas no pueden durar más que el horario")
+	}
+	if a.MaxPerMonth < 0 {
+		return NewUserSafeError("El máximo de reservas al mes no puede ser negativo")
+	}
+	if a.LeadHours < 0 || a.LeadHours > maxLeadHours {
+		return NewUserSafeError(fmt.Sprintf(
+			"La anticipación debe ser de 0 a %d horas", maxLeadHours,
+		))
+	}
+	return nil
+}
+
+// Slots returns the minutes from midnight the slots of a day start at, in
+// order. The last one ends at or before closing.
+func (a *Amenity) Slots() []int64 {
+	var slots []int64
+	if a.SlotMinutes <= 0 {
+		return slots
+	}
+	for m := a.OpensMinute; m+a.SlotMinutes <= a.ClosesMinute; m += a.SlotMinutes {
+		slots = append(slots, m)
+	}
+	return slots
+}
+
+// Hours describes when the amenity can be reserved.
+func (a *Amenity) Hours() string {
+	return fmt.Sprintf(
+		"De %s a %s, turnos de %d minutos",
+		Clock(a.OpensMinute), Clock(a.ClosesMinute), a.SlotMinutes,
+	)
+}
+
+// Rules describes the limits of the reservations of the amenity.
+func (a *Amenity) Rules() string {
+	rules := []string{fmt.Sprintf("Hasta %d personas", a.Capacity)}
+	if a.MaxPerMonth > 0 {
+		rules = append(rules, fmt.Sprintf("%d reservas al mes por vecino", a.MaxPerMonth))
+	}
+	if a.LeadHours > 0 {
+		rules = append(rules, fmt.Sprintf("con %d horas de anticipación", a.LeadHours))
+	}
+	if a.RequiresApproval {
+		rules = append(rules, "requiere aprobación")
+	}
+	return strings.Join(rules, ", ")
+}
+
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationRejected  ReservationStatus = "rejected"
+	ReservationCancelled ReservationStatus = "cancelled"
+)
+
+func (s ReservationStatus) String() string {
+	switch s {
+	case ReservationPending:
+		return "Pendiente de aprobación"
+	case ReservationConfirmed:
+		return "Confirmada"
+	case ReservationRejected:
+		return "Rechazada"
+	case ReservationCancelled:
+		return "Cancelada"
+	default:
+		return string(s)
+	}
+}
+
+// Active tells whether the reservation takes its slot: it is pending or
+// confirmed.
+func (s ReservationStatus) Active() bool {
+	return s == ReservationPending || s == ReservationConfirmed
+}
+
+// Reservation is a slot of an amenity reserved by a resident.
+type Reservation struct {
+	ID            int64
+	CondominiumID int64
+	AmenityID     int64
+	AmenityName   string
+	ResidentID    int64
+	ResidentName  string
+	ResidentUnit  Unit
+	StartsAt      time.Time
+	EndsAt        time.Time
+	Status        ReservationStatus
+	Guests        []ReservationGuest
+	// DecidedBy is who confirmed, rejected or cancelled the reservation,
+	// zero while it is pending or if they were deleted.
+	DecidedBy int64
+	DecidedAt time.Time
+	CreatedAt time.Time
+}
+
+// ReservationGuest is someone a resident brings to a reservation.
+type ReservationGuest struct {
+	ID   int64
+	Name string
+	// VisitID is the code of the pass that lets the guest in during the
+	// reservation, empty until it is confirmed.
+	VisitID string
+}
+
+// People is how many people the reservation brings to the amenity, the
+// resident and their guests.
+func (r *Reservation) People() int64 {
+	return int64(1 + len(r.Guests))
+}
+
+// Window describes when the reservation is.
+func (r *Reservation) Window() string {
+	return fmt.Sprintf(
+		"%s de %s a %s",
+		r.StartsAt.Format("02/01/2006"), r.StartsAt.Format("15:04"), r.EndsAt.Format("15:04"),
+	)
+}
+
+type AmenityStore interface {
+	AmenityList(ctx context.Context, condoID int64) ([]Amenity, error)
+	// AmenityGetByID returns a NotFoundError if the amenity doesn't exist.
+	AmenityGetByID(ctx context.Context, id int64) (*Amenity, error)
+	AmenityCreate(ctx context.Context, amenity *Amenity) (*Amenity, error)
+	// AmenityUpcomingReservations counts the pending and confirmed
+	// reservations of the amenity that didn't end at now.
+	AmenityUpcomingReservations(ctx context.Context, id int64, now time.Time) (int64, error)
+	// AmenityDelete returns a NotFoundError if the amenity doesn't exist.
+	AmenityDelete(ctx context.Context, id int64) error
+	// ReservationCreate creates the reservation with its guests, and the
+	// passes of the guests if it is confirmed, passes[i] for Guests[i]. In
+	// the same transaction, it first calls check with how many people the
+	// active reservations of the amenity overlapping it bring, and how many
+	// active reservations of the amenity the resident has starting from
+	// monthStart to monthEnd, and doesn't create it if check fails.
+	ReservationCreate(
+		ctx context.Context,
+		reservation *Reservation,
+		passes []Visit,
+		monthStart time.Time,
+		monthEnd time.Time,
+		check func(people int64, monthly int64) error,
+	) (int64, error)
+	// ReservationGetByID returns a NotFoundError if the reservation doesn't
+	// exist.
+	ReservationGetByID(ctx context.Context, id int64) (*Reservation, error)
+	// ReservationList lists the reservations of the condominium that end
+	// after since and start before until, in order.
+	ReservationList(
+		ctx context.Context, condoID int64, since time.Time, until time.Time,
+	) ([]Reservation, error)
+	// ReservationListByResident lists the reservations of the resident that
+	// end after since, in order.
+	ReservationListByResident(
+		ctx context.Context, residentID int64, since time.Time,
+	) ([]Reservation, error)
+	// ReservationConfirm confirms a pending reservation and creates the
+	// passes of its guests, passes[i] for Guests[i]. It returns a
+	// NotFoundError if the reservation isn't pending.
+	ReservationConfirm(
+		ctx context.Context, reservation *Reservation, passes []Visit, by int64, at time.Time,
+	) error
+	// ReservationEnd rejects or cancels an active reservation and revokes
+	// the passes of its guests, returning them. It returns a NotFoundError
+	// if the reservation isn't active.
+	ReservationEnd(
+		ctx context.Context, id int64, status ReservationStatus, by int64, at time.Time,
+	) ([]Visit, error)
+}
+
+// AdminAmenities lists the amenities of the admin's condominium and their
+// reservations that didn't end.
+func (a *App) AdminAmenities(ctx context.Context) ([]Amenity, []Reservation, error) {
+	admin, err := RequirePermission(ctx, PermAmenitiesManage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amenities, err := a.store.AmenityList(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	reservations, err := a.store.ReservationList(
+		ctx, admin.CondominiumID, now, now.AddDate(0, 0, ReservationMaxDays+1),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return amenities, reservations, nil
+}
+
+// CreateAmenity adds an amenity to the admin's condominium. A ClosesMinute
+// of zero is midnight, at the end of the day.
+func (a *App) CreateAmenity(ctx context.Context, amenity Amenity) (*Amenity, error) {
+	admin, err := RequirePermission(ctx, PermAmenitiesManage)
+	if err != nil {
+		return nil, err
+	}
+
+	amenity.Name = strings.TrimSpace(amenity.Name)
+	if amenity.ClosesMinute == 0 {
+		amenity.ClosesMinute = 24 * 60
+	}
+	if err := amenity.Valid(); err != nil {
+		return nil, err
+	}
+
+	amenity.CondominiumID = admin.CondominiumID
+	amenity.CreatedBy = admin.ID
+	amenity.CreatedAt = time.Now()
+	var created *Amenity
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.AmenityCreate(ctx, &amenity)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionAmenityChanged,
+			Message: fmt.Sprintf(
+				"Área común creada: %s (%s; %s)", created.Name, created.Hours(), created.Rules(),
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeleteAmenity deletes an amenity of the admin's condominium, with its
+// past reservations. Amenities with upcoming reservations can't be
+// deleted, they have to be cancelled first.
+func (a *App) DeleteAmenity(ctx context.Context, id int64) error {
+	admin, err := RequirePermission(ctx, PermAmenitiesManage)
+	if err != nil {
+		return err
+	}
+	amenity, err := a.store.AmenityGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if amenity.CondominiumID != admin.CondominiumID {
+		return NewNotFoundError("Área común no encontrada")
+	}
+
+	upcoming, err := a.store.AmenityUpcomingReservations(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if upcoming > 0 {
+		return NewUserSafeError(fmt.Sprintf(
+			"%s tiene %d reservas pendientes o confirmadas, cancélalas antes de eliminarla",
+			amenity.Name, upcoming,
+		))
+	}
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.AmenityDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionAmenityChanged,
+			Message:       fmt.Sprintf("Área común eliminada: %s", amenity.Name),
+		})
+	})
+}
+
+// Amenities lists the amenities of the condominium of the resident in ctx.
+func (a *App) Amenities(ctx context.Context) ([]Amenity, error) {
+	user, err := RequirePermission(ctx, PermAmenitiesReserve)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.AmenityList(ctx, user.CondominiumID)
+}
+
+// MyReservations lists the reservations of the resident in ctx that didn't
+// end.
+func (a *App) MyReservations(ctx context.Context) ([]Reservation, error) {
+	user, err := RequirePermission(ctx, PermAmenitiesReserve)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.ReservationListByResident(ctx, user.ID, time.Now())
+}
+
+// ReserveAmenity reserves for the resident in ctx the slot of the amenity
+// that starts startMinute minutes after the midnight of day, with the
+// guests. If the amenity doesn't require approval, the reservation is
+// confirmed and the guests get passes valid during it.
+func (a *App) ReserveAmenity(
+	ctx context.Context, amenityID int64, day time.Time, startMinute int64, guests []string,
+) (*Reservation, error) {
+	user, err := RequirePermission(ctx, PermAmenitiesReserve)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Tu usuario no pertenece a un condominio")
+	}
+
+	amenity, err := a.store.AmenityGetByID(ctx, amenityID)
+	if err != nil {
+		return nil, err
+	}
+	if amenity.CondominiumID != user.CondominiumID {
+		return nil, NewNotFoundError("Área común no encontrada")
+	}
+	if !slices.Contains(amenity.Slots(), startMinute) {
+		return nil, NewUserSafeError("Horario inválido")
+	}
+
+	now := time.Now()
+	reservation := Reservation{
+		CondominiumID: amenity.CondominiumID,
+		AmenityID:     amenity.ID,
+		AmenityName:   amenity.Name,
+		ResidentID:    user.ID,
+		StartsAt: time.Date(
+			day.Year(), day.Month(), day.Day(), 0, int(startMinute), 0, 0, day.Location(),
+		),
+		Status:    ReservationPending,
+		CreatedAt: now,
+	}
+	reservation.EndsAt = reservation.StartsAt.Add(time.Duration(amenity.SlotMinutes) * time.Minute)
+	if reservation.StartsAt.Before(now.Add(time.Duration(amenity.LeadHours) * time.Hour)) {
+		if amenity.LeadHours == 0 {
+			return nil, NewUserSafeError("El horario ya pasó")
+		}
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"%s se reserva con al menos %d horas de anticipación", amenity.Name, amenity.LeadHours,
+		))
+	}
+	if reservation.StartsAt.After(now.AddDate(0, 0, ReservationMaxDays)) {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Solo se puede reservar hasta %d días antes", ReservationMaxDays,
+		))
+	}
+
+	for _, name := range guests {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if utf8.RuneCountInString(name) > guestNameLength {
+			return nil, NewUserSafeError(fmt.Sprintf(
+				"El nombre de un invitado no puede tener más de %d caracteres", guestNameLength,
+			))
+		}
+		reservation.Guests = append(reservation.Guests, ReservationGuest{Name: name})
+	}
+	if reservation.People() > amenity.Capacity {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"%s tiene capacidad para %d personas, contándote a ti", amenity.Name, amenity.Capacity,
+		))
+	}
+
+	var passes []Visit
+	if !amenity.RequiresApproval {
+		reservation.Status = ReservationConfirmed
+		reservation.DecidedAt = now
+		passes, err = reservationPasses(&reservation, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	month := time.Date(
+		reservation.StartsAt.Year(), reservation.StartsAt.Month(), 1,
+		0, 0, 0, 0, reservation.StartsAt.Location(),
+	)
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		reservation.ID, err = a.store.ReservationCreate(
+			ctx, &reservation, passes, month, month.AddDate(0, 1, 0),
+			func(people int64, monthly int64) error {
+				if amenity.MaxPerMonth > 0 && monthly >= amenity.MaxPerMonth {
+					return NewUserSafeError(fmt.Sprintf(
+						"Ya tienes %d reservas de %s ese mes, el máximo", monthly, amenity.Name,
+					))
+				}
+				if people+reservation.People() > amenity.Capacity {
+					return NewUserSafeError(fmt.Sprintf(
+						"%s ya tiene %d de %d personas en ese horario", amenity.Name, people, amenity.Capacity,
+					))
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: reservation.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionReservationChanged,
+			Message: fmt.Sprintf(
+				"Reserva de %s el %s con %d invitados (%s)",
+				amenity.Name, reservation.Window(), len(reservation.Guests),
+				strings.ToLower(reservation.Status.String()),
+			),
+		})
+		if err != nil {
+			return err
+		}
+		return a.passesCreated(ctx, passes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.publishPasses(passes)
+	return &reservation, nil
+}
+
+// reservationPasses returns the passes of the guests of the reservation,
+// one use each, valid only during it. It sets the VisitID of the guests.
+func reservationPasses(reservation *Reservation, now time.Time) ([]Visit, error) {
+	passes := make([]Visit, 0, len(reservation.Guests))
+	for i := range reservation.Guests {
+		code, err := generateVisitCode()
+		if err != nil {
+			return nil, err
+		}
+		reservation.Guests[i].VisitID = code
+		passes = append(passes, Visit{
+			ID:            code,
+			CondominiumID: reservation.CondominiumID,
+			UserID:        reservation.ResidentID,
+			VisitorName:   reservation.Guests[i].Name,
+			MaxUses:       1,
+			ValidFrom:     reservation.StartsAt,
+			ValidTo:       reservation.EndsAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	return passes, nil
+}
+
+// passesCreated records the passes of the guests of a reservation like any
+// other visit. It runs in the transaction that creates them.
+func (a *App) passesCreated(ctx context.Context, passes []Visit) error {
+	for i := range passes {
+		if err := a.visitCreated(ctx, &passes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishPasses shows the passes of the guests of a reservation on the
+// dashboards of the guards like any other visit.
+func (a *App) publishPasses(passes []Visit) {
+	for i := range passes {
+		a.publishVisit(&passes[i])
+	}
+}
+
+// adminReservation returns a reservation of the admin's condominium, or a
+// NotFoundError if it belongs to another.
+func (a *App) adminReservation(ctx context.Context, id int64) (*User, *Reservation, error) {
+	admin, err := RequirePermission(ctx, PermAmenitiesManage)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservation, err := a.store.ReservationGetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reservation.CondominiumID != admin.CondominiumID {
+		return nil, nil, NewNotFoundError("Reserva no encontrada")
+	}
+	return admin, reservation, nil
+}
+
+// ApproveReservation confirms a pending reservation of the admin's
+// condominium, and gives its guests their passes.
+func (a *App) ApproveReservation(ctx context.Context, id int64) error {
+	admin, reservation, err := a.adminReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != ReservationPending {
+		return NewUserSafeError("La reserva ya no está pendiente")
+	}
+
+	now := time.Now()
+	if !reservation.EndsAt.After(now) {
+		return NewUserSafeError("La reserva ya terminó")
+	}
+	passes, err := reservationPasses(reservation, now)
+	if err != nil {
+		return err
+	}
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.ReservationConfirm(ctx, reservation, passes, admin.ID, now)
+		if err != nil {
+			return err
+		}
+
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: reservation.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionReservationChanged,
+			Message: fmt.Sprintf(
+				"Reserva aprobada: %s el %s, de %s",
+				reservation.AmenityName, reservation.Window(), reservation.ResidentName,
+			),
+		})
+		if err != nil {
+			return err
+		}
+		return a.passesCreated(ctx, passes)
+	})
+	if err != nil {
+		return err
+	}
+	a.publishPasses(passes)
+
+	body := fmt.Sprintf("Tu reserva de %s el %s fue aprobada.", reservation.AmenityName, reservation.Window())
+	if len(passes) > 0 {
+		body += " Tus invitados ya tienen sus pases."
+	}
+	// The reservation is already confirmed, a failed notification must not
+	// tell the admin otherwise.
+	err = a.notifier.Notify(ctx, Notification{
+		UserID:        reservation.ResidentID,
+		CondominiumID: reservation.CondominiumID,
+		Event:         NotifyReservationDecided,
+		Title:         "Reserva aprobada",
+		Body:          body,
+		URL:           "/neighbor/amenities",
+	})
+	if err != nil {
+		a.logger.Error(
+			"Failed to notify the reservation decision",
+			"reservation_id", reservation.ID,
+			"user_id", reservation.ResidentID,
+			"error", err,
+		)
+	}
+	return nil
+}
+
+// RejectReservation rejects a pending reservation of the admin's
+// condominium.
+func (a *App) RejectReservation(ctx context.Context, id int64) error {
+	admin, reservation, err := a.adminReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != ReservationPending {
+		return NewUserSafeError("La reserva ya no está pendiente")
+	}
+	return a.endReservation(ctx, admin, reservation, ReservationRejected)
+}
+
+// CancelReservation cancels a reservation before it ends. Residents can
+// cancel their own reservations before they start, users with
+// PermAmenitiesManage any reservation of the condominium.
+func (a *App) CancelReservation(ctx context.Context, id int64) error {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return &UnauthorizedError{msg: "user not authenticated"}
+	}
+
+	reservation, err := a.store.ReservationGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if reservation.ResidentID != user.ID {
+		_, err := RequirePermissionIn(ctx, PermAmenitiesManage, reservation.CondominiumID)
+		if err != nil {
+			return NewNotFoundError("Reserva no encontrada")
+		}
+		if !reservation.EndsAt.After(now) {
+			return NewUserSafeError("La reserva ya terminó")
+		}
+	} else {
+		if _, err := RequirePermissionIn(ctx, PermAmenitiesReserve, reservation.CondominiumID); err != nil {
+			return err
+		}
+		if !reservation.StartsAt.After(now) {
+			return NewUserSafeError("La reserva ya empezó")
+		}
+	}
+	if !reservation.Status.Active() {
+		return NewUserSafeError("La reserva ya fue cancelada o rechazada")
+	}
+	return a.endReservation(ctx, user, reservation, ReservationCancelled)
+}
+
+// endReservation rejects or cancels the reservation, revoking the passes
+// of its guests, and lets the resident know if someone else did it.
+func (a *App) endReservation(
+	ctx context.Context, user *User, reservation *Reservation, status ReservationStatus,
+) error {
+	verb := "cancelada"
+	if status == ReservationRejected {
+		verb = "rechazada"
+	}
+
+	var revoked []Visit
+	err := a.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		revoked, err = a.store.ReservationEnd(ctx, reservation.ID, status, user.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: reservation.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionReservationChanged,
+			Message: fmt.Sprintf(
+				"Reserva %s: %s el %s, de %s, %d pases revocados",
+				verb, reservation.AmenityName, reservation.Window(), reservation.ResidentName, len(revoked),
+			),
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range revoked {
+			err := a.publishWebhookEvent(
+				ctx, revoked[i].CondominiumID, EventVisitRevoked, newWebhookVisit(&revoked[i]),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if reservation.ResidentID == user.ID {
+		return nil
+	}
+	err = a.notifier.Notify(ctx, Notification{
+		UserID:        reservation.ResidentID,
+		CondominiumID: reservation.CondominiumID,
+		Event:         NotifyReservationDecided,
+		Title:         fmt.Sprintf("Reserva %s", verb),
+		Body: fmt.Sprintf(
+			"Tu reserva de %s el %s fue %s por la administración.",
+			reservation.AmenityName, reservation.Window(), verb,
+		),
+		URL: "/neighbor/amenities",
+	})
+	if err != nil {
+		a.logger.Error(
+			"Failed to notify the reservation decision",
+			"reservation_id", reservation.ID,
+			"user_id", reservation.ResidentID,
+			"error", err,
+		)
+	}
+	return nil
+}
+
+// TodayReservations lists the confirmed reservations of the guard's
+// condominium that are today, with their guests.
+func (a *App) TodayReservations(ctx context.Context) ([]Reservation, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	today := startOfDay(time.Now())
+	reservations, err := a.store.ReservationList(
+		ctx, guard.CondominiumID, today, today.AddDate(0, 0, 1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(reservations, func(r Reservation) bool {
+		return r.Status != ReservationConfirmed
+	}), nil
+}
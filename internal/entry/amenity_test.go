@@ -0,0 +1,60 @@
+package entry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAmenitySlots(t *testing.T) {
+	tests := []struct {
+		name    string
+		amenity Amenity
+		want    []int64
+	}{
+		{"exact", Amenity{OpensMinute: 8 * 60, ClosesMinute: 12 * 60, SlotMinutes: 120}, []int64{480, 600}},
+		{"last one doesn't fit", Amenity{OpensMinute: 8 * 60, ClosesMinute: 11 * 60, SlotMinutes: 120}, []int64{480}},
+		{"until midnight", Amenity{OpensMinute: 20 * 60, ClosesMinute: 24 * 60, SlotMinutes: 240}, []int64{1200}},
+		{"no slots", Amenity{OpensMinute: 8 * 60, ClosesMinute: 9 * 60, SlotMinutes: 0}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amenity.Slots(); !slices.Equal(got, tt.want) {
+				t.Errorf("Slots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmenityValid(t *testing.T) {
+	valid := Amenity{
+		Name: "Piscina", Capacity: 10, OpensMinute: 8 * 60, ClosesMinute: 20 * 60, SlotMinutes: 120,
+	}
+	if err := valid.Valid(); err != nil {
+		t.Fatalf("Valid() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(a *Amenity)
+	}{
+		{"no name", func(a *Amenity) { a.Name = "" }},
+		{"no capacity", func(a *Amenity) { a.Capacity = 0 }},
+		{"closes before opening", func(a *Amenity) { a.ClosesMinute = 7 * 60 }},
+		{"overnight", func(a *Amenity) { a.ClosesMinute = 25 * 60 }},
+		{"short slots", func(a *Amenity) { a.SlotMinutes = 10 }},
+		{"slots longer than the day", func(a *Amenity) { a.SlotMinutes = 13 * 60 }},
+		{"negative monthly limit", func(a *Amenity) { a.MaxPerMonth = -1 }},
+		{"negative lead", func(a *Amenity) { a.LeadHours = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amenity := valid
+			tt.change(&amenity)
+			if err := amenity.Valid(); err == nil {
+				t.Error("Valid() = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AnnouncementAudience is who an announcement is for.
+type AnnouncementAudience string
+
+const (
+	AudienceResidents AnnouncementAudience = "residents"
+	AudienceTowers    AnnouncementAudience = "towers"
+	AudienceUnits     AnnouncementAudience = "units"
+	AudienceGuards    AnnouncementAudience = "guards"
+)
+
+// AnnouncementAudiences lists every audience, in the order they are shown.
+var AnnouncementAudiences = []AnnouncementAudience{
+	AudienceResidents, AudienceTowers, AudienceUnits, AudienceGuards,
+}
+
+func (a AnnouncementAudience) String() string {
+	switch a {
+	case AudienceResidents:
+		return "Todos los vecinos"
+	case AudienceTowers:
+		return "Torres"
+	case AudienceUnits:
+		return "Unidades"
+	case AudienceGuards:
+		return "Guardias"
+	default:
+		return string(a)
+	}
+}
+
+// Announcement is a notice admins publish on the dashboards of residents
+// or guards, like a water cut or an assembly.
+type Announcement struct {
+	ID            int64
+	CondominiumID int64
+	Title         string
+	Body          string
+	Audience      AnnouncementAudience
+	// Towers are the towers the announcement is for, when the audience is
+	// AudienceTowers, and Units the units, when it is AudienceUnits.
+	Towers []string
+	Units  []Unit
+	// Pinned announcements are shown first.
+	Pinned bool
+	// ExpiresAt is when the announcement stops being shown, zero if it
+	// doesn't expire.
+	ExpiresAt time.Time
+	// CreatedBy is zero if the author was deleted.
+	CreatedBy  int64
+	AuthorName string
+	CreatedAt  time.Time
+	// ReadAt is when the user that lists the announcements read it, zero if
+	// they didn't. Only set for Announcements.
+	ReadAt time.Time
+	// Recipients is how many users the announcement is for, and ReadCount
+	// how many read it. Only set for AdminAnnouncements.
+	Recipients int
+	ReadCount  int64
+}
+
+const (
+	announcementTitleLength = 120
+	announcementBodyLength  = 5000
+)
+
+func (a *Announcement) Valid() error {
+	if a.Title == "" {
+		return NewUserSafeError("El título es obligatorio")
+	}
+	if utf8.RuneCountInString(a.Title) > announcementTitleLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"El título no puede tener más de %d caracteres", announcementTitleLength,
+		))
+	}
+	if a.Body == "" {
+		return NewUserSafeError("El mensaje es obligatorio")
+	}
+	if utf8.RuneCountInString(a.Body) > announcementBodyLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"El mensaje no puede tener más de %d caracteres", announcementBodyLength,
+		))
+	}
+	if !slices.Contains(AnnouncementAudiences, a.Audience) {
+		return NewUserSafeError("Destinatarios inválidos")
+	}
+	if a.Audience == AudienceTowers && len(a.Towers) == 0 {
+		return NewUserSafeError("Elige al menos una torre")
+	}
+	if a.Audience == AudienceUnits && len(a.Units) == 0 {
+		return NewUserSafeError("Elige al menos una unidad")
+	}
+	return nil
+}
+
+// Expired tells whether the announcement stopped being shown at now.
+func (a *Announcement) Expired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt)
+}
+
+// Reaches tells whether the announcement is for a user with the
+// permissions in its condominium, who lives in the unit. unit is zero for
+// users that don't live in the condominium. Guards are those who work
+// shifts, and residents those who read announcements.
+func (a *Announcement) Reaches(perms []Permission, unit Unit) bool {
+	resident := slices.Contains(perms, PermAnnouncementsRead)
+	switch a.Audience {
+	case AudienceGuards:
+		return slices.Contains(perms, PermShiftsWork)
+	case AudienceResidents:
+		return resident
+	case AudienceTowers:
+		return resident && unit.Tower != "" && slices.Contains(a.Towers, unit.Tower)
+	case AudienceUnits:
+		return resident && !unit.IsZero() && slices.Contains(a.Units, unit)
+	default:
+		return false
+	}
+}
+
+// RecipientsDescription describes who the announcement is for.
+func (a *Announcement) RecipientsDescription() string {
+	switch a.Audience {
+	case AudienceTowers:
+		return "Torres: " + strings.Join(a.Towers, ", ")
+	case AudienceUnits:
+		units := make([]string, 0, len(a.Units))
+		for _, u := range a.Units {
+			units = append(units, u.String())
+		}
+		return "Unidades: " + strings.Join(units, ", ")
+	default:
+		return a.Audience.String()
+	}
+}
+
+// AnnouncementReceipt tells whether a recipient read an announcement.
+type AnnouncementReceipt struct {
+	UserID int64
+	Name   string
+	Unit   Unit
+	// ReadAt is zero if the user didn't read the announcement.
+	ReadAt time.Time
+}
+
+// AnnouncementReads is when each user read an announcement.
+type AnnouncementReads map[int64]time.Time
+
+type AnnouncementStore interface {
+	// AnnouncementCreate creates the announcement with its targets.
+	AnnouncementCreate(ctx context.Context, announcement *Announcement) (int64, error)
+	// AnnouncementGetByID returns a NotFoundError if the announcement doesn't
+	// exist.
+	AnnouncementGetByID(ctx context.Context, id int64) (*Announcement, error)
+	// AnnouncementList lists every announcement of the condominium with its
+	// read count, pinned first, then latest first.
+	AnnouncementList(ctx context.Context, condoID int64) ([]Announcement, error)
+	// AnnouncementListActive lists the announcements of the condominium that
+	// didn't expire at now, with when the user read them, pinned first, then
+	// latest first.
+	AnnouncementListActive(
+		ctx context.Context, condoID int64, userID int64, now time.Time,
+	) ([]Announcement, error)
+	AnnouncementReads(ctx context.Context, id int64) (AnnouncementReads, error)
+	AnnouncementMarkRead(ctx context.Context, id int64, userID int64, at time.Time) error
+	// AnnouncementSetPinned returns a NotFoundError if the announcement
+	// doesn't exist.
+	AnnouncementSetPinned(ctx context.Context, id int64, pinned bool) error
+	// AnnouncementDelete returns a NotFoundError if the announcement doesn't
+	// exist.
+	AnnouncementDelete(ctx context.Context, id int64) error
+}
+
+// AnnouncementTargets lists the towers and the units of the admin's
+// condominium that announcements can be for.
+func (a *App) AnnouncementTargets(ctx context.Context) ([]string, []Unit, error) {
+	admin, err := RequirePermission(ctx, PermAnnouncementsManage)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.condoUnits(ctx, admin.CondominiumID, PermAnnouncementsRead)
+}
+
+// AdminAnnouncements lists every announcement of the admin's condominium,
+// with how many of their recipients read them.
+func (a *App) AdminAnnouncements(ctx context.Context) ([]Announcement, error) {
+	admin, err := RequirePermission(ctx, PermAnnouncementsManage)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements, err := a.store.AnnouncementList(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := a.store.UserListByCondo(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := a.rolePermissions(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range announcements {
+		for _, u := range users {
+			unit := condoUnit(&u, admin.CondominiumID)
+			if u.Enabled && announcements[i].Reaches(perms[u.Role], unit) {
+				announcements[i].Recipients++
+			}
+		}
+	}
+	return announcements, nil
+}
+
+// condoUnit returns the unit the user lives in, if it lives in the
+// condominium.
+func condoUnit(user *UserProfile, condoID int64) Unit {
+	if user.CondominiumID != condoID {
+		return Unit{}
+	}
+	return user.Unit
+}
+
+// residentUnit returns the unit the user lives in, in the condominium it
+// acts in, zero if it doesn't live there or has no unit set.
+func (a *App) residentUnit(ctx context.Context, user *User) (Unit, error) {
+	profile, err := a.store.UserGetByID(ctx, user.ID)
+	if err != nil {
+		return Unit{}, err
+	}
+	return condoUnit(profile, user.CondominiumID), nil
+}
+
+// CreateAnnouncement publishes an announcement in the admin's condominium.
+// The towers and units it is for must be ones residents live in.
+func (a *App) CreateAnnouncement(ctx context.Context, announcement Announcement) (int64, error) {
+	admin, err := RequirePermission(ctx, PermAnnouncementsManage)
+	if err != nil {
+		return 0, err
+	}
+
+	announcement.Title = strings.TrimSpace(announcement.Title)
+	announcement.Body = strings.TrimSpace(announcement.Body)
+	if announcement.Audience != AudienceTowers {
+		announcement.Towers = nil
+	}
+	if announcement.Audience != AudienceUnits {
+		announcement.Units = nil
+	}
+	if err := announcement.Valid(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if announcement.Expired(now) {
+		return 0, NewUserSafeError("La fecha de vencimiento ya pasó")
+	}
+
+	towers, units, err := a.condoUnits(ctx, admin.CondominiumID, PermAnnouncementsRead)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range announcement.Towers {
+		if !slices.Contains(towers, t) {
+			return 0, NewUserSafeError(fmt.Sprintf("Ningún vecino vive en la torre %s", t))
+		}
+	}
+	for _, u := range announcement.Units {
+		if !slices.Contains(units, u) {
+			return 0, NewUserSafeError(fmt.Sprintf("Ningún vecino vive en la unidad %s", u))
+		}
+	}
+
+	announcement.CondominiumID = admin.CondominiumID
+	announcement.CreatedBy = admin.ID
+	announcement.CreatedAt = now
+	var id int64
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		id, err = a.store.AnnouncementCreate(ctx, &announcement)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionAnnouncementChanged,
+			Message: fmt.Sprintf(
+				"Comunicado publicado: %s (%s)", announcement.Title, announcement.RecipientsDescription(),
+			),
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// adminAnnouncement returns an announcement of the admin's condominium, or
+// a NotFoundError if it belongs to another.
+func (a *App) adminAnnouncement(ctx context.Context, id int64) (*User, *Announcement, error) {
+	admin, err := RequirePermission(ctx, PermAnnouncementsManage)
+	if err != nil {
+		return nil, nil, err
+	}
+	announcement, err := a.store.AnnouncementGetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if announcement.CondominiumID != admin.CondominiumID {
+		return nil, nil, NewNotFoundError("Comunicado no encontrado")
+	}
+	return admin, announcement, nil
+}
+
+// AnnouncementReceipts returns an announcement of the admin's condominium
+// and who of its recipients read it, readers first.
+func (a *App) AnnouncementReceipts(
+	ctx context.Context, id int64,
+) (*Announcement, []AnnouncementReceipt, error) {
+	admin, announcement, err := a.adminAnnouncement(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reads, err := a.store.AnnouncementReads(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	users, err := a.store.UserListByCondo(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	perms, err := a.rolePermissions(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receipts := make([]AnnouncementReceipt, 0, len(users))
+	for _, u := range users {
+		unit := condoUnit(&u, admin.CondominiumID)
+		readAt, read := reads[u.ID]
+		if !read && (!u.Enabled || !announcement.Reaches(perms[u.Role], unit)) {
+			continue
+		}
+		receipts = append(receipts, AnnouncementReceipt{
+			UserID: u.ID,
+			Name:   u.FullName(),
+			Unit:   unit,
+			ReadAt: readAt,
+		})
+		announcement.Recipients++
+	}
+	announcement.ReadCount = int64(len(reads))
+
+	// Users are listed by name, the stable sort keeps them so among the
+	// readers and the rest.
+	slices.SortStableFunc(receipts, func(a, b AnnouncementReceipt) int {
+		switch {
+		case a.ReadAt.IsZero() == b.ReadAt.IsZero():
+			return 0
+		case a.ReadAt.IsZero():
+			return 1
+		default:
+			return -1
+		}
+	})
+	return announcement, receipts, nil
+}
+
+// SetAnnouncementPinned pins an announcement of the admin's condominium,
+// or unpins it.
+func (a *App) SetAnnouncementPinned(ctx context.Context, id int64, pinned bool) error {
+	admin, announcement, err := a.adminAnnouncement(ctx, id)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Comunicado desfijado: %s", announcement.Title)
+	if pinned {
+		message = fmt.Sprintf("Comunicado fijado: %s", announcement.Title)
+	}
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.AnnouncementSetPinned(ctx, id, pinned); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionAnnouncementChanged,
+			Message:       message,
+		})
+	})
+}
+
+// DeleteAnnouncement deletes an announcement of the admin's condominium,
+// with who read it.
+func (a *App) DeleteAnnouncement(ctx context.Context, id int64) error {
+	admin, announcement, err := a.adminAnnouncement(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.AnnouncementDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionAnnouncementChanged,
+			Message:       fmt.Sprintf("Comunicado eliminado: %s", announcement.Title),
+		})
+	})
+}
+
+// announcementReader returns the user in ctx and the unit it lives in, in
+// the condominium it acts in.
+func (a *App) announcementReader(ctx context.Context) (*User, Unit, error) {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return nil, Unit{}, &UnauthorizedError{msg: "user not authenticated"}
+	}
+	if !user.Enabled {
+		return nil, Unit{}, &ForbiddenError{msg: "user is disabled"}
+	}
+	if !user.Can(PermAnnouncementsRead, user.CondominiumID) {
+		return user, Unit{}, nil
+	}
+
+	unit, err := a.residentUnit(ctx, user)
+	if err != nil {
+		return nil, Unit{}, err
+	}
+	return user, unit, nil
+}
+
+// Announcements lists the announcements for the user in ctx that didn't
+// expire, pinned first, then latest first.
+func (a *App) Announcements(ctx context.Context) ([]Announcement, error) {
+	user, unit, err := a.announcementReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := a.store.AnnouncementListActive(ctx, user.CondominiumID, user.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]Announcement, 0, len(active))
+	for _, announcement := range active {
+		if announcement.Reaches(user.PermissionsIn(user.CondominiumID), unit) {
+			announcements = append(announcements, announcement)
+		}
+	}
+	return announcements, nil
+}
+
+// MarkAnnouncementRead records that the user in ctx read an announcement
+// for them.
+func (a *App) MarkAnnouncementRead(ctx context.Context, id int64) error {
+	user, unit, err := a.announcementReader(ctx)
+	if err != nil {
+		return err
+	}
+
+	announcement, err := a.store.AnnouncementGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if announcement.CondominiumID != user.CondominiumID || !announcement.Reaches(user.PermissionsIn(user.CondominiumID), unit) {
+		return NewNotFoundError("Comunicado no encontrado")
+	}
+
+	return a.store.AnnouncementMarkRead(ctx, id, user.ID, time.Now())
+}
@@ -0,0 +1,51 @@
+package entry
+
+import "testing"
+
+func TestAnnouncementReaches(t *testing.T) {
+	a302 := Unit{Tower: "A", Number: "302"}
+	b302 := Unit{Tower: "B", Number: "302"}
+	house := Unit{Number: "12"}
+
+	tests := []struct {
+		name         string
+		announcement Announcement
+		role         UserRole
+		unit         Unit
+		want         bool
+	}{
+		{"residents", Announcement{Audience: AudienceResidents}, RoleUser, Unit{}, true},
+		{"residents, guard", Announcement{Audience: AudienceResidents}, RoleGuardian, Unit{}, false},
+		{"guards", Announcement{Audience: AudienceGuards}, RoleGuardian, Unit{}, true},
+		{"guards, admin", Announcement{Audience: AudienceGuards}, RoleAdmin, Unit{}, false},
+		{"tower", Announcement{Audience: AudienceTowers, Towers: []string{"A"}}, RoleUser, a302, true},
+		{"other tower", Announcement{Audience: AudienceTowers, Towers: []string{"A"}}, RoleUser, b302, false},
+		{"tower, no unit", Announcement{Audience: AudienceTowers, Towers: []string{"A"}}, RoleUser, Unit{}, false},
+		{"unit", Announcement{Audience: AudienceUnits, Units: []Unit{a302, house}}, RoleUser, house, true},
+		{"same number", Announcement{Audience: AudienceUnits, Units: []Unit{a302}}, RoleUser, b302, false},
+		{"unit, guard", Announcement{Audience: AudienceUnits, Units: []Unit{a302}}, RoleGuardian, a302, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.announcement.Reaches(builtinPermissions[tt.role], tt.unit); got != tt.want {
+				t.Errorf("Reaches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnitKey(t *testing.T) {
+	for _, unit := range []Unit{{Tower: "A", Number: "302"}, {Number: "12"}, {Tower: "Torre 1/2", Number: "4 B"}} {
+		got, err := ParseUnitKey(unit.Key())
+		if err != nil || got != unit {
+			t.Errorf("ParseUnitKey(%q) = %+v, %v, want %+v", unit.Key(), got, err, unit)
+		}
+	}
+
+	for _, key := range []string{"", "A", "A/", "A/%zz"} {
+		if _, err := ParseUnitKey(key); err == nil {
+			t.Errorf("ParseUnitKey(%q) succeeded, want an error", key)
+		}
+	}
+}
@@ -1,24 +1,67 @@
 package entry
 
-import "log/slog"
+import (
+	"context"
+	"log/slog"
+)
 
 type App struct {
-	Config Config
-	store  Store
-	logger *slog.Logger
+	Config   Config
+	store    Store
+	logger   *slog.Logger
+	audit    *AuditLogger
+	notifier *Notifier
+	live     *Hub
 }
 
-func NewApp(logger *slog.Logger, store Store) *App {
+func NewApp(
+	logger *slog.Logger,
+	store Store,
+	audit *AuditLogger,
+	notifier *Notifier,
+	live *Hub,
+) *App {
 	return &App{
-		store:  store,
-		logger: logger,
-		Config: Config{},
+		store:    store,
+		logger:   logger,
+		audit:    audit,
+		notifier: notifier,
+		live:     live,
+		Config:   Config{},
 	}
 }
 
 type Store interface {
+	TxStore
 	CondominiumStore
 	VisitStore
+	EntryStore
+	AuditStore
+	TwoFactorStore
+	UserStore
+	MembershipStore
+	PermissionStore
+	WebhookStore
+	GateStore
+	NotificationStore
+	WalkInStore
+	ParcelStore
+	IncidentStore
+	ShiftStore
+	PatrolStore
+	AnnouncementStore
+	AmenityStore
+	OccupancyStore
+}
+
+// TxStore keeps changes together. A change and its audit entry are made
+// in the same transaction, so that neither is stored without the other and
+// a failed audit entry doesn't report a stored change as failed.
+type TxStore interface {
+	// InTx runs fn in a transaction. The changes made through the store
+	// with the context fn gets are committed if it returns nil, and rolled
+	// back otherwise. Calls inside fn only roll back their own changes.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type Config struct{}
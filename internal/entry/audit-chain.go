@@ -0,0 +1,476 @@
+package entry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// The audit log is a hash chain per condominium, global events form the
+// chain with ID 0. Every entry hashes its content together with the hash of
+// the previous entry of the chain, so editing, deleting or reordering
+// entries breaks every hash that follows. The chain head keeps the last
+// entry, so that truncating the chain is detected too, and signed
+// checkpoints kept outside the system protect against rewriting the whole
+// chain. The entries about check-ins keep the hash of the check-in too, so
+// that the check-ins are protected by the chain as well.
+
+// ChainHash returns the hash of the entry chained to prevHash.
+func (e *AuditEntry) ChainHash(prevHash string) string {
+	h := sha256.New()
+	fmt.Fprintf(
+		h,
+		"%s\n%d\n%d\n%d\n%d\n%d\n%d:%s\n%d:%s",
+		prevHash,
+		e.ID,
+		e.CondominiumID,
+		e.UserID,
+		e.Level,
+		e.CreatedAt.Unix(),
+		len(e.Action), e.Action,
+		len(e.Message), e.Message,
+	)
+	// Added later, only hashed when set so that older entries keep their
+	// hashes.
+	if e.ImpersonatorID != 0 {
+		fmt.Fprintf(h, "\nimpersonator:%d", e.ImpersonatorID)
+	}
+	if e.EntryID != 0 {
+		fmt.Fprintf(h, "\nentry:%d:%s", e.EntryID, e.EntryHash)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash returns the hash of the check-in, recorded in its audit entries.
+// The station and the shift are left out, they are cleared when their
+// station or shift is deleted. The exit is hashed once it is recorded.
+func (e *Entry) Hash() string {
+	h := sha256.New()
+	fmt.Fprintf(
+		h,
+		"%d\n%d\n%d:%s\n%d\n%d:%s\n%t\n%d:%s\n%t\n%d\n%d:%s\n%d",
+		e.ID,
+		e.CondominiumID,
+		len(e.VisitID), e.VisitID,
+		e.GuardID,
+		len(e.VisitorName), e.VisitorName,
+		e.Accepted,
+		len(e.Reason), e.Reason,
+		e.OverLimit,
+		e.OverrideOf,
+		len(e.OverrideReason), e.OverrideReason,
+		e.CreatedAt.Unix(),
+	)
+	if !e.ExitedAt.IsZero() {
+		fmt.Fprintf(h, "\nexit:%d:%d", e.ExitedAt.Unix(), e.ExitedBy)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditChainHead is the last entry of a chain.
+type AuditChainHead struct {
+	ChainID   int64
+	LastID    int64
+	Hash      string
+	Entries   int64
+	UpdatedAt time.Time
+}
+
+// AuditCheckpoint is a signed snapshot of a chain head. It is also the
+// format of the downloaded checkpoint files.
+type AuditCheckpoint struct {
+	ID int64 `json:"id"`
+	// ChainID is the condominium ID, 0 for global events.
+	ChainID   int64     `json:"condominium_id"`
+	LastID    int64     `json:"last_id"`
+	Hash      string    `json:"hash"`
+	Entries   int64     `json:"entries"`
+	CreatedAt time.Time `json:"created_at"`
+	// PublicKey and Signature are base64 encoded Ed25519 values.
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+func (c *AuditCheckpoint) signedMessage() []byte {
+	return fmt.Appendf(
+		nil,
+		"entry-watch audit checkpoint\nchain:%d\nlast_id:%d\nhash:%s\nentries:%d\ncreated_at:%d\n",
+		c.ChainID, c.LastID, c.Hash, c.Entries, c.CreatedAt.Unix(),
+	)
+}
+
+// Verify checks the signature of the checkpoint against its public key. If
+// trustedKey is not empty, the checkpoint must have been signed with it.
+func (c *AuditCheckpoint) Verify(trustedKey string) error {
+	if trustedKey != "" && trustedKey != c.PublicKey {
+		return errors.New("checkpoint signed with an untrusted key")
+	}
+
+	pub, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid checkpoint public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return errors.New("invalid checkpoint signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), c.signedMessage(), sig) {
+		return errors.New("checkpoint signature doesn't match")
+	}
+	return nil
+}
+
+// PublicKeyFingerprint is a short form of the public key, for people to
+// compare.
+func (c *AuditCheckpoint) PublicKeyFingerprint() string {
+	sum := sha256.Sum256([]byte(c.PublicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+type AuditChainStore interface {
+	// EntryGetByID returns a NotFoundError if the check-in doesn't exist.
+	EntryGetByID(ctx context.Context, id int64) (*Entry, error)
+
+	// AuditChainPage lists up to limit entries of the chain after afterID,
+	// in chain order.
+	AuditChainPage(
+		ctx context.Context, chainID int64, afterID int64, limit int64,
+	) ([]AuditEntry, error)
+	// AuditChainHeadGet returns (AuditChainHead{}, false, nil) for chains
+	// without entries.
+	AuditChainHeadGet(ctx context.Context, chainID int64) (AuditChainHead, bool, error)
+	AuditChainHeadList(ctx context.Context) ([]AuditChainHead, error)
+
+	AuditCheckpointCreate(
+		ctx context.Context, checkpoint *AuditCheckpoint,
+	) (*AuditCheckpoint, error)
+	// AuditCheckpointGet returns a NotFoundError if the checkpoint doesn't
+	// exist.
+	AuditCheckpointGet(ctx context.Context, id int64) (*AuditCheckpoint, error)
+	// AuditCheckpointLatest returns (nil, nil) if the chain has no
+	// checkpoints.
+	AuditCheckpointLatest(ctx context.Context, chainID int64) (*AuditCheckpoint, error)
+	// AuditCheckpointList lists the checkpoints of the chain, newest first.
+	// A zero limit lists all of them.
+	AuditCheckpointList(
+		ctx context.Context, chainID int64, limit int64,
+	) ([]AuditCheckpoint, error)
+}
+
+// AuditVerification is the result of checking a chain.
+type AuditVerification struct {
+	ChainID int64
+	// Checked is the number of entries that were checked.
+	Checked int64
+	// Checkpoints is the number of signed checkpoints that matched.
+	Checkpoints int64
+	Broken      bool
+	// BrokenID is the ID of the first entry that doesn't match, or of the
+	// missing entry.
+	BrokenID int64
+	Reason   string
+}
+
+const auditVerifyPageSize = 1000
+
+// VerifyAuditChain checks every entry of the chain, its head and its signed
+// checkpoints, and reports the first broken link. The external checkpoints,
+// like the ones downloaded by an admin, are checked too, they catch a chain
+// rewritten together with the checkpoints stored in the system.
+func VerifyAuditChain(
+	ctx context.Context,
+	store AuditChainStore,
+	chainID int64,
+	external ...AuditCheckpoint,
+) (AuditVerification, error) {
+	result := AuditVerification{ChainID: chainID}
+
+	checkpoints, err := store.AuditCheckpointList(ctx, chainID, 0)
+	if err != nil {
+		return result, err
+	}
+	for _, c := range external {
+		if c.ChainID != chainID {
+			return result, fmt.Errorf(
+				"checkpoint %d belongs to chain %d, not %d", c.ID, c.ChainID, chainID,
+			)
+		}
+		checkpoints = append(checkpoints, c)
+	}
+	checkpointsByID := make(map[int64][]AuditCheckpoint)
+	for _, c := range checkpoints {
+		if err := c.Verify(""); err != nil {
+			result.Broken = true
+			result.BrokenID = c.LastID
+			result.Reason = fmt.Sprintf(
+				"El punto de control del %s tiene una firma inválida",
+				c.CreatedAt.Format(time.DateTime),
+			)
+			return result, nil
+		}
+		checkpointsByID[c.LastID] = append(checkpointsByID[c.LastID], c)
+	}
+
+	broken := func(id int64, reason string) (AuditVerification, error) {
+		result.Broken = true
+		result.BrokenID = id
+		result.Reason = reason
+		return result, nil
+	}
+
+	// The head is read first, entries appended while verifying are left
+	// for the next verification.
+	head, hasHead, err := store.AuditChainHeadGet(ctx, chainID)
+	if err != nil {
+		return result, err
+	}
+
+	var prevHash string
+	var lastID int64
+pages:
+	for {
+		entries, err := store.AuditChainPage(ctx, chainID, lastID, auditVerifyPageSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, e := range entries {
+			if hasHead && e.ID > head.LastID {
+				break pages
+			}
+			if e.PrevHash != prevHash {
+				return broken(
+					e.ID,
+					"La entrada no continúa a la anterior, se eliminaron o reordenaron entradas",
+				)
+			}
+			if e.Hash != e.ChainHash(prevHash) {
+				return broken(e.ID, "El contenido de la entrada fue modificado")
+			}
+			if e.EntryID != 0 {
+				reason, err := verifyAuditedEntry(ctx, store, &e)
+				if err != nil {
+					return result, err
+				}
+				if reason != "" {
+					return broken(e.ID, reason)
+				}
+			}
+
+			for _, c := range checkpointsByID[e.ID] {
+				if c.Hash != e.Hash || c.Entries != result.Checked+1 {
+					return broken(e.ID, fmt.Sprintf(
+						"La entrada no coincide con el punto de control firmado del %s",
+						c.CreatedAt.Format(time.DateTime),
+					))
+				}
+				result.Checkpoints++
+			}
+			delete(checkpointsByID, e.ID)
+
+			prevHash = e.Hash
+			lastID = e.ID
+			result.Checked++
+		}
+
+		if len(entries) < auditVerifyPageSize {
+			break
+		}
+	}
+
+	if len(checkpointsByID) > 0 {
+		missing := slices.Min(slices.Collect(maps.Keys(checkpointsByID)))
+		return broken(missing, "Falta una entrada incluida en un punto de control firmado")
+	}
+
+	if !hasHead {
+		if result.Checked > 0 {
+			return broken(lastID, "Falta la cabeza de la cadena")
+		}
+		return result, nil
+	}
+	if head.LastID != lastID || head.Hash != prevHash || head.Entries != result.Checked {
+		return broken(head.LastID, "Faltan las últimas entradas de la cadena")
+	}
+
+	return result, nil
+}
+
+// verifyAuditedEntry checks the check-in of the audit entry against the
+// hash recorded with it, and returns why it doesn't match, if it doesn't.
+func verifyAuditedEntry(
+	ctx context.Context, store AuditChainStore, e *AuditEntry,
+) (string, error) {
+	checkIn, err := store.EntryGetByID(ctx, e.EntryID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return fmt.Sprintf("Se eliminó el ingreso %d que registra la entrada", e.EntryID), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Only the exit is recorded after it happened, the other entries saw
+	// the check-in before it.
+	if e.Action != ActionCheckOut {
+		checkIn.ExitedAt = time.Time{}
+		checkIn.ExitedBy = 0
+	}
+	if checkIn.Hash() != e.EntryHash {
+		return fmt.Sprintf("El ingreso %d que registra la entrada fue modificado", e.EntryID), nil
+	}
+	return "", nil
+}
+
+// VerifyAuditLog checks the audit chain of a condominium. A zero condoID
+// checks the chain of global events, only superadmins can do that.
+func (a *App) VerifyAuditLog(
+	ctx context.Context, condoID int64,
+) (AuditVerification, error) {
+	if err := requireAuditChainAccess(ctx, condoID); err != nil {
+		return AuditVerification{}, err
+	}
+	return VerifyAuditChain(ctx, a.store, condoID)
+}
+
+// AuditCheckpoints lists the latest signed checkpoints of the chain.
+func (a *App) AuditCheckpoints(
+	ctx context.Context, condoID int64,
+) ([]AuditCheckpoint, error) {
+	if err := requireAuditChainAccess(ctx, condoID); err != nil {
+		return nil, err
+	}
+	return a.store.AuditCheckpointList(ctx, condoID, 30)
+}
+
+// AuditCheckpoint returns a signed checkpoint to download.
+func (a *App) AuditCheckpoint(
+	ctx context.Context, id int64,
+) (*AuditCheckpoint, error) {
+	if _, err := RequirePermission(ctx, PermAuditRead); err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := a.store.AuditCheckpointGet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAuditChainAccess(ctx, checkpoint.ChainID); err != nil {
+		return nil, NewNotFoundError("Punto de control no encontrado")
+	}
+	return checkpoint, nil
+}
+
+func requireAuditChainAccess(ctx context.Context, condoID int64) error {
+	if condoID == 0 {
+		_, err := RequirePermission(ctx, PermSystemManage)
+		return err
+	}
+	_, err := RequirePermissionIn(ctx, PermAuditRead, condoID)
+	return err
+}
+
+// AuditCheckpointer signs checkpoints of the chains that changed since
+// their last checkpoint.
+type AuditCheckpointer struct {
+	store  AuditChainStore
+	key    ed25519.PrivateKey
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewAuditCheckpointer(
+	store AuditChainStore, key ed25519.PrivateKey, logger *slog.Logger,
+) *AuditCheckpointer {
+	return &AuditCheckpointer{
+		store:  store,
+		key:    key,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run creates checkpoints every interval until ctx is done.
+func (c *AuditCheckpointer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.CreateCheckpoints(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Error("Failed to create audit checkpoints", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CreateCheckpoints signs the head of every chain that changed since its
+// last checkpoint. Broken chains are not signed, signing them would vouch
+// for the tampered entries.
+func (c *AuditCheckpointer) CreateCheckpoints(ctx context.Context) error {
+	heads, err := c.store.AuditChainHeadList(ctx)
+	if err != nil {
+		return err
+	}
+
+	publicKey := base64.StdEncoding.EncodeToString(c.key.Public().(ed25519.PublicKey))
+
+	for _, head := range heads {
+		latest, err := c.store.AuditCheckpointLatest(ctx, head.ChainID)
+		if err != nil {
+			return err
+		}
+		if latest != nil && latest.LastID == head.LastID && latest.PublicKey == publicKey {
+			continue
+		}
+
+		result, err := VerifyAuditChain(ctx, c.store, head.ChainID)
+		if err != nil {
+			return err
+		}
+		if result.Broken {
+			c.logger.Error(
+				"Audit chain is broken, not signing a checkpoint",
+				"chain_id", head.ChainID,
+				"entry_id", result.BrokenID,
+				"reason", result.Reason,
+			)
+			continue
+		}
+
+		checkpoint := &AuditCheckpoint{
+			ChainID:   head.ChainID,
+			LastID:    head.LastID,
+			Hash:      head.Hash,
+			Entries:   head.Entries,
+			CreatedAt: c.now().Truncate(time.Second),
+			PublicKey: publicKey,
+		}
+		checkpoint.Signature = base64.StdEncoding.EncodeToString(
+			ed25519.Sign(c.key, checkpoint.signedMessage()),
+		)
+
+		if _, err := c.store.AuditCheckpointCreate(ctx, checkpoint); err != nil {
+			return err
+		}
+		c.logger.Info(
+			"Audit checkpoint created",
+			"chain_id", head.ChainID,
+			"last_id", head.LastID,
+		)
+	}
+
+	return nil
+}
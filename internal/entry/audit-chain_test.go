@@ -0,0 +1,256 @@
+package entry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// memChainStore keeps a single chain of check-ins in memory.
+type memChainStore struct {
+	entries     []AuditEntry
+	checkIns    map[int64]*Entry
+	head        AuditChainHead
+	checkpoints []AuditCheckpoint
+}
+
+func (s *memChainStore) append(message string) {
+	checkIn := &Entry{
+		ID:            int64(len(s.entries) + 1),
+		CondominiumID: 1,
+		GuardID:       2,
+		VisitorName:   message,
+		Accepted:      true,
+		CreatedAt:     time.Unix(1760000000+int64(len(s.entries)), 0),
+	}
+	if s.checkIns == nil {
+		s.checkIns = map[int64]*Entry{}
+	}
+	s.checkIns[checkIn.ID] = checkIn
+
+	e := AuditEntry{
+		ID:            int64(len(s.entries) + 1),
+		CondominiumID: 1,
+		UserID:        2,
+		Level:         AuditInfo,
+		Action:        ActionCheckIn,
+		Message:       message,
+		CreatedAt:     checkIn.CreatedAt,
+		EntryID:       checkIn.ID,
+		EntryHash:     checkIn.Hash(),
+	}
+	s.entries = append(s.entries, e.chained(s.head.Hash))
+	s.head = AuditChainHead{
+		ChainID: 1,
+		LastID:  e.ID,
+		Hash:    s.entries[len(s.entries)-1].Hash,
+		Entries: s.head.Entries + 1,
+	}
+}
+
+// rehash recomputes every hash and the head, as someone rewriting the
+// whole chain would.
+func (s *memChainStore) rehash() {
+	var prev string
+	for i, e := range s.entries {
+		s.entries[i] = e.chained(prev)
+		prev = s.entries[i].Hash
+	}
+	s.head.Hash = prev
+}
+
+func (e AuditEntry) chained(prevHash string) AuditEntry {
+	e.PrevHash = prevHash
+	e.Hash = e.ChainHash(prevHash)
+	return e
+}
+
+func (s *memChainStore) EntryGetByID(_ context.Context, id int64) (*Entry, error) {
+	checkIn, ok := s.checkIns[id]
+	if !ok {
+		return nil, NewNotFoundError("Ingreso no encontrado")
+	}
+	c := *checkIn
+	return &c, nil
+}
+
+func (s *memChainStore) AuditChainPage(
+	_ context.Context, _ int64, afterID int64, limit int64,
+) ([]AuditEntry, error) {
+	var page []AuditEntry
+	for _, e := range s.entries {
+		if e.ID > afterID && int64(len(page)) < limit {
+			page = append(page, e)
+		}
+	}
+	return page, nil
+}
+
+func (s *memChainStore) AuditChainHeadGet(
+	context.Context, int64,
+) (AuditChainHead, bool, error) {
+	return s.head, s.head.LastID != 0, nil
+}
+
+func (s *memChainStore) AuditChainHeadList(context.Context) ([]AuditChainHead, error) {
+	return []AuditChainHead{s.head}, nil
+}
+
+func (s *memChainStore) AuditCheckpointCreate(
+	_ context.Context, c *AuditCheckpoint,
+) (*AuditCheckpoint, error) {
+	c.ID = int64(len(s.checkpoints) + 1)
+	s.checkpoints = append(s.checkpoints, *c)
+	return c, nil
+}
+
+func (s *memChainStore) AuditCheckpointGet(
+	_ context.Context, id int64,
+) (*AuditCheckpoint, error) {
+	for _, c := range s.checkpoints {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, NewNotFoundError("Punto de control no encontrado")
+}
+
+func (s *memChainStore) AuditCheckpointLatest(
+	context.Context, int64,
+) (*AuditCheckpoint, error) {
+	if len(s.checkpoints) == 0 {
+		return nil, nil
+	}
+	return &s.checkpoints[len(s.checkpoints)-1], nil
+}
+
+func (s *memChainStore) AuditCheckpointList(
+	context.Context, int64, int64,
+) ([]AuditCheckpoint, error) {
+	list := slices.Clone(s.checkpoints)
+	slices.Reverse(list)
+	return list, nil
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	ctx := context.Background()
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// newStore returns a chain of 5 entries with a checkpoint at the third.
+	newStore := func(t *testing.T) *memChainStore {
+		s := &memChainStore{}
+		for _, m := range []string{"a", "b", "c"} {
+			s.append(m)
+		}
+		err := NewAuditCheckpointer(s, key, logger).CreateCheckpoints(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.append("d")
+		s.append("e")
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		tamper   func(s *memChainStore)
+		brokenID int64
+	}{
+		{"intact", func(s *memChainStore) {}, 0},
+		{"modified", func(s *memChainStore) { s.entries[3].Message = "x" }, 4},
+		{"deleted", func(s *memChainStore) {
+			s.entries = slices.Delete(s.entries, 3, 4)
+		}, 5},
+		{"truncated", func(s *memChainStore) {
+			s.entries = s.entries[:4]
+		}, 5},
+		{"rewritten", func(s *memChainStore) {
+			s.entries[1].Message = "x"
+			s.rehash()
+		}, 3},
+		{"rewritten before checkpoint", func(s *memChainStore) {
+			s.entries = s.entries[3:]
+			s.head.Entries = 2
+			s.rehash()
+		}, 3},
+		{"forged checkpoint", func(s *memChainStore) {
+			s.checkpoints[0].Entries = 2
+		}, 3},
+		{"check-in modified", func(s *memChainStore) {
+			s.checkIns[2].Accepted = false
+		}, 2},
+		{"check-in deleted", func(s *memChainStore) { delete(s.checkIns, 4) }, 4},
+		{"check-in exited", func(s *memChainStore) {
+			s.checkIns[2].ExitedAt = time.Unix(1760000100, 0)
+			s.checkIns[2].ExitedBy = 2
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			tt.tamper(s)
+
+			result, err := VerifyAuditChain(ctx, s, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Broken != (tt.brokenID != 0) || result.BrokenID != tt.brokenID {
+				t.Errorf(
+					"broken = %t at %d (%s); want broken at %d",
+					result.Broken, result.BrokenID, result.Reason, tt.brokenID,
+				)
+			}
+		})
+	}
+
+	t.Run("external checkpoint", func(t *testing.T) {
+		s := newStore(t)
+		external := s.checkpoints[0]
+		s.checkpoints = nil
+		s.entries[0].Message = "x"
+		s.rehash()
+
+		result, err := VerifyAuditChain(ctx, s, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Broken {
+			t.Fatalf("rewritten chain broken without the checkpoint: %s", result.Reason)
+		}
+
+		result, err = VerifyAuditChain(ctx, s, 1, external)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Broken || result.BrokenID != 3 {
+			t.Errorf("broken = %t at %d; want broken at 3", result.Broken, result.BrokenID)
+		}
+	})
+}
+
+func TestAuditCheckpointVerify(t *testing.T) {
+	s := &memChainStore{}
+	s.append("a")
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := NewAuditCheckpointer(s, key, logger).CreateCheckpoints(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c := s.checkpoints[0]
+	if err := c.Verify(c.PublicKey); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := c.Verify("b3RoZXIga2V5"); err == nil {
+		t.Error("Verify() with another trusted key succeeded")
+	}
+	c.Hash = s.entries[0].ChainHash("x")
+	if err := c.Verify(""); err == nil {
+		t.Error("Verify() of a modified checkpoint succeeded")
+	}
+}
@@ -0,0 +1,323 @@
+package entry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// AuditLevel is the severity of an audit entry, as stored in the audit_logs
+// table.
+type AuditLevel int64
+
+const (
+	AuditInfo      AuditLevel = 1
+	AuditImportant AuditLevel = 2
+	AuditCritical  AuditLevel = 3
+)
+
+func (l AuditLevel) String() string {
+	switch l {
+	case AuditInfo:
+		return "Información"
+	case AuditImportant:
+		return "Importante"
+	case AuditCritical:
+		return "Crítico"
+	default:
+		return "Desconocido"
+	}
+}
+
+// AuditAction identifies the kind of event, so that the log can be filtered
+// without parsing messages.
+type AuditAction string
+
+const (
+	ActionLogin               AuditAction = "login"
+	ActionLoginFailed         AuditAction = "login_failed"
+	ActionLoginLocked         AuditAction = "login_locked"
+	ActionAccountUnlocked     AuditAction = "account_unlocked"
+	ActionTwoFactorChanged    AuditAction = "two_factor_changed"
+	ActionVisitCreated        AuditAction = "visit_created"
+	ActionVisitRevoked        AuditAction = "visit_revoked"
+	ActionCheckIn             AuditAction = "check_in"
+	ActionCheckInDenied       AuditAction = "check_in_denied"
+	ActionUserChanged         AuditAction = "user_changed"
+	ActionCondominiumChanged  AuditAction = "condominium_changed"
+	ActionImpersonation       AuditAction = "impersonation"
+	ActionImpersonatedAction  AuditAction = "impersonated_action"
+	ActionPermissionsChanged  AuditAction = "permissions_changed"
+	ActionAPITokenChanged     AuditAction = "api_token_changed"
+	ActionWebhookChanged      AuditAction = "webhook_changed"
+	ActionGateChanged         AuditAction = "gate_changed"
+	ActionGateFailed          AuditAction = "gate_failed"
+	ActionWalkInDecided       AuditAction = "walk_in_decided"
+	ActionParcelCollected     AuditAction = "parcel_collected"
+	ActionIncidentReported    AuditAction = "incident_reported"
+	ActionIncidentChanged     AuditAction = "incident_changed"
+	ActionShiftStarted        AuditAction = "shift_started"
+	ActionShiftClosed         AuditAction = "shift_closed"
+	ActionPatrolChanged       AuditAction = "patrol_changed"
+	ActionRoundMissed         AuditAction = "round_missed"
+	ActionWelfareConfirmed    AuditAction = "welfare_confirmed"
+	ActionWelfareMissed       AuditAction = "welfare_missed"
+	ActionAnnouncementChanged AuditAction = "announcement_changed"
+	ActionAmenityChanged      AuditAction = "amenity_changed"
+	ActionReservationChanged  AuditAction = "reservation_changed"
+	ActionCheckOut            AuditAction = "check_out"
+	ActionOccupancyChanged    AuditAction = "occupancy_changed"
+	ActionOccupancyOverride   AuditAction = "occupancy_override"
+)
+
+// AuditActions lists every action, in the order they are offered as filters.
+var AuditActions = []AuditAction{
+	ActionLogin,
+	ActionLoginFailed,
+	ActionLoginLocked,
+	ActionAccountUnlocked,
+	ActionTwoFactorChanged,
+	ActionVisitCreated,
+	ActionVisitRevoked,
+	ActionCheckIn,
+	ActionCheckInDenied,
+	ActionUserChanged,
+	ActionCondominiumChanged,
+	ActionImpersonation,
+	ActionImpersonatedAction,
+	ActionPermissionsChanged,
+	ActionAPITokenChanged,
+	ActionWebhookChanged,
+	ActionGateChanged,
+	ActionGateFailed,
+	ActionWalkInDecided,
+	ActionParcelCollected,
+	ActionIncidentReported,
+	ActionIncidentChanged,
+	ActionShiftStarted,
+	ActionShiftClosed,
+	ActionPatrolChanged,
+	ActionRoundMissed,
+	ActionWelfareConfirmed,
+	ActionWelfareMissed,
+	ActionAnnouncementChanged,
+	ActionAmenityChanged,
+	ActionReservationChanged,
+	ActionCheckOut,
+	ActionOccupancyChanged,
+	ActionOccupancyOverride,
+}
+
+func (a AuditAction) String() string {
+	switch a {
+	case ActionLogin:
+		return "Inicio de sesión"
+	case ActionLoginFailed:
+		return "Inicio de sesión fallido"
+	case ActionLoginLocked:
+		return "Bloqueo de acceso"
+	case ActionAccountUnlocked:
+		return "Cuenta desbloqueada"
+	case ActionTwoFactorChanged:
+		return "Verificación en dos pasos"
+	case ActionVisitCreated:
+		return "Visita creada"
+	case ActionVisitRevoked:
+		return "Visita revocada"
+	case ActionCheckIn:
+		return "Ingreso"
+	case ActionCheckInDenied:
+		return "Ingreso denegado"
+	case ActionUserChanged:
+		return "Cambio de usuario"
+	case ActionCondominiumChanged:
+		return "Cambio de condominio"
+	case ActionImpersonation:
+		return "Suplantación"
+	case ActionImpersonatedAction:
+		return "Acción suplantada"
+	case ActionPermissionsChanged:
+		return "Cambio de permisos"
+	case ActionAPITokenChanged:
+		return "Token de API"
+	case ActionWebhookChanged:
+		return "Webhook"
+	case ActionGateChanged:
+		return "Barreras y garitas"
+	case ActionGateFailed:
+		return "Falla de barrera"
+	case ActionWalkInDecided:
+		return "Visita sin pase"
+	case ActionParcelCollected:
+		return "Paquete entregado"
+	case ActionIncidentReported:
+		return "Incidente reportado"
+	case ActionIncidentChanged:
+		return "Revisión de incidente"
+	case ActionShiftStarted:
+		return "Inicio de turno"
+	case ActionShiftClosed:
+		return "Cierre de turno"
+	case ActionPatrolChanged:
+		return "Rondas"
+	case ActionRoundMissed:
+		return "Ronda no cumplida"
+	case ActionWelfareConfirmed:
+		return "Control de bienestar"
+	case ActionWelfareMissed:
+		return "Control de bienestar sin respuesta"
+	case ActionAnnouncementChanged:
+		return "Comunicados"
+	case ActionAmenityChanged:
+		return "Áreas comunes"
+	case ActionReservationChanged:
+		return "Reservas"
+	case ActionCheckOut:
+		return "Salida"
+	case ActionOccupancyChanged:
+		return "Límites de ocupación"
+	case ActionOccupancyOverride:
+		return "Ingreso sobre el límite"
+	default:
+		return string(a)
+	}
+}
+
+// AuditRecord is a new entry of the audit log.
+type AuditRecord struct {
+	// CondominiumID is zero for events that don't belong to a condominium.
+	CondominiumID int64
+	// UserID is the actor. If zero, the user in the context is used.
+	UserID int64
+	// ImpersonatorID is the superadmin acting as UserID. If zero, the
+	// impersonator in the context is used.
+	ImpersonatorID int64
+	Level          AuditLevel
+	Action         AuditAction
+	Message        string
+	// Entry is the check-in the record is about, if any. Its hash is kept
+	// with the record, so that the entry can't be changed afterwards
+	// without breaking the chain.
+	Entry *Entry
+}
+
+// AuditEntry is a stored entry of the audit log, with the names of the
+// actor and the condominium for display.
+type AuditEntry struct {
+	ID              int64
+	CondominiumID   int64
+	CondominiumName string
+	UserID          int64
+	UserName        string
+	UserEmail       string
+	// ImpersonatorID is zero unless a superadmin acted as the user.
+	ImpersonatorID   int64
+	ImpersonatorName string
+	Level            AuditLevel
+	Action           AuditAction
+	Message          string
+	CreatedAt        time.Time
+	// EntryID is the check-in the entry is about, zero if none, and
+	// EntryHash the hash of its row when the entry was recorded.
+	EntryID   int64
+	EntryHash string
+	// PrevHash and Hash link the entry to its chain, see ChainHash.
+	PrevHash string
+	Hash     string
+}
+
+// AuditFilter selects entries of the audit log. Zero values don't filter.
+type AuditFilter struct {
+	CondominiumID int64
+	UserID        int64
+	MinLevel      AuditLevel
+	Action        AuditAction
+	Search        string
+	From          time.Time
+	// To is exclusive.
+	To     time.Time
+	Limit  int64
+	Offset int64
+}
+
+type AuditStore interface {
+	AuditChainStore
+	// AuditCreate appends the record to the chain of its condominium.
+	AuditCreate(ctx context.Context, record AuditRecord, createdAt time.Time) error
+	AuditList(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+	AuditCount(ctx context.Context, filter AuditFilter) (int64, error)
+}
+
+// AuditLogger records security relevant events. It is shared by the domain
+// and the auth layer, so that every event ends up in the same log.
+type AuditLogger struct {
+	store  AuditStore
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewAuditLogger(store AuditStore, logger *slog.Logger) *AuditLogger {
+	return &AuditLogger{
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Record stores the record in the audit log. Called with the context of
+// TxStore.InTx, the record is stored in its transaction, along with the
+// change it records.
+func (l *AuditLogger) Record(ctx context.Context, record AuditRecord) error {
+	if record.UserID == 0 {
+		if user := UserFromCtx(ctx); user != nil {
+			record.UserID = user.ID
+		}
+	}
+	if record.ImpersonatorID == 0 {
+		if impersonator := ImpersonatorFromCtx(ctx); impersonator != nil {
+			record.ImpersonatorID = impersonator.ID
+		}
+	}
+	if record.Level == 0 {
+		record.Level = AuditInfo
+	}
+
+	if err := l.store.AuditCreate(ctx, record, l.now()); err != nil {
+		l.logger.Error(
+			"Failed to write audit log",
+			"action", record.Action,
+			"message", record.Message,
+			"error", err,
+		)
+		return err
+	}
+	return nil
+}
+
+// AuditPageSize is the number of entries shown per page of the audit log.
+const AuditPageSize = 50
+
+// AuditLog lists the entries of the audit log the user in ctx can see, and
+// the total count of entries matching the filter. Only users that manage the
+// system see the entries of every condominium.
+func (a *App) AuditLog(
+	ctx context.Context, filter AuditFilter,
+) ([]AuditEntry, int64, error) {
+	user, err := RequirePermission(ctx, PermAuditRead)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !user.Can(PermSystemManage, 0) {
+		filter.CondominiumID = user.CondominiumID
+	}
+
+	entries, err := a.store.AuditList(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := a.store.AuditCount(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,270 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Entry is a check-in attempt at the gate.
+type Entry struct {
+	ID            int64
+	CondominiumID int64
+	// VisitID is empty if the code matched no visit.
+	VisitID     string
+	GuardID     int64
+	VisitorName string
+	Accepted    bool
+	// Reason explains a denial, it is empty for accepted entries.
+	Reason string
+	// StationID is the guard station of the check-in, zero if the guard
+	// didn't pick one.
+	StationID int64
+	// ShiftID is the shift of the guard, zero if it had none open.
+	ShiftID int64
+	// OverLimit entries were denied because an occupancy limit was
+	// reached, admins can let them in anyway.
+	OverLimit bool
+	// OverrideOf is the OverLimit entry an admin let in with this one, and
+	// OverrideReason their justification. GuardID is the admin.
+	OverrideOf     int64
+	OverrideReason string
+	// ExitedAt is when the visitor left, zero while they are inside or if
+	// the entry was denied. ExitedBy is the guard that recorded it.
+	ExitedAt  time.Time
+	ExitedBy  int64
+	CreatedAt time.Time
+
+	// GateOpened and GateError report what happened with the gate of the
+	// station after an accepted check-in. They are not stored.
+	GateOpened bool
+	GateError  string
+}
+
+type EntryStore interface {
+	// EntryCreate fails with a UserSafeError if the entry overrides one
+	// that was already overridden.
+	EntryCreate(ctx context.Context, entry *Entry) (*Entry, error)
+	EntryListByCondo(
+		ctx context.Context, condoID int64, since time.Time,
+	) ([]Entry, error)
+	// EntryListByShift lists the check-in attempts of a shift, oldest
+	// first.
+	EntryListByShift(ctx context.Context, shiftID int64) ([]Entry, error)
+}
+
+// CheckIn validates the visit code presented at the gate and records the
+// attempt. Denials are not errors, the returned entry holds the reason to
+// show to the guard. Accepted check-ins open the gate of the guard station
+// stationID, if it has one; zero checks in without a station.
+func (a *App) CheckIn(ctx context.Context, code string, stationID int64) (*Entry, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	code = NormalizeVisitCode(code)
+	if code == "" {
+		return nil, NewUserSafeError("Ingresa el código de la visita")
+	}
+
+	station, err := a.stationForCheckIn(ctx, guard, stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	shiftID, err := a.openShiftID(ctx, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &Entry{
+		CondominiumID: guard.CondominiumID,
+		GuardID:       guard.ID,
+		StationID:     stationID,
+		ShiftID:       shiftID,
+		CreatedAt:     now,
+	}
+
+	// usedUp tells whether the check-in used the last use of the visit.
+	usedUp := false
+	visit, err := a.store.VisitGetByID(ctx, code)
+	var notFound *NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		visit = nil
+	case err != nil:
+		return nil, err
+	case !guard.Can(PermEntriesRecord, visit.CondominiumID),
+		station != nil && station.CondominiumID != visit.CondominiumID:
+		// Codes of other condominiums are treated as unknown.
+		visit = nil
+	}
+
+	if visit == nil {
+		entry.Reason = "Código no encontrado"
+	} else {
+		entry.CondominiumID = visit.CondominiumID
+		entry.VisitID = visit.ID
+		entry.VisitorName = visit.VisitorName
+	}
+
+	// A superadmin checking an unknown code has no condominium to record
+	// the entry in.
+	if entry.CondominiumID == 0 {
+		return entry, nil
+	}
+
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		if visit != nil {
+			// The occupancy is counted in the transaction of the check-in,
+			// so that two check-ins can't both take the last place.
+			limitReason, err := a.occupancyDenial(ctx, visit, now)
+			if err != nil {
+				return err
+			}
+
+			err = a.store.VisitUpdate(ctx, visit.ID, func(v *Visit) (*Visit, error) {
+				if reason := v.DenialReason(now); reason != "" {
+					entry.Reason = reason
+					return nil, errVisitDenied
+				}
+				if limitReason != "" {
+					entry.Reason = limitReason
+					entry.OverLimit = true
+					return nil, errVisitDenied
+				}
+				v.Uses++
+				v.UpdatedAt = now
+				usedUp = v.MaxUses > 0 && v.Uses >= v.MaxUses
+				return v, nil
+			})
+			if err != nil && !errors.Is(err, errVisitDenied) {
+				return err
+			}
+			entry.Accepted = err == nil
+		}
+
+		created, err := a.store.EntryCreate(ctx, entry)
+		if err != nil {
+			return err
+		}
+		entry = created
+
+		record := AuditRecord{
+			CondominiumID: entry.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionCheckIn,
+			Message: fmt.Sprintf(
+				"Ingreso de %s con el código %s", entry.VisitorName, code,
+			),
+			Entry: entry,
+		}
+		if !entry.Accepted {
+			record.Level = AuditImportant
+			record.Action = ActionCheckInDenied
+			record.Message = fmt.Sprintf(
+				"Ingreso denegado con el código %s: %s", code, entry.Reason,
+			)
+		}
+		if err := a.audit.Record(ctx, record); err != nil {
+			return err
+		}
+
+		event := EventEntryAccepted
+		if !entry.Accepted {
+			event = EventEntryDenied
+		}
+		return a.publishWebhookEvent(ctx, entry.CondominiumID, event, newWebhookEntry(entry))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Accepted {
+		a.admitted(ctx, station, visit, entry, usedUp)
+	}
+	if entry.OverLimit {
+		err := a.notifyAdmins(ctx, PermOccupancyManage, Notification{
+			CondominiumID: entry.CondominiumID,
+			Event:         NotifyOccupancyDenied,
+			Title:         "Ingreso denegado por ocupación",
+			Body: fmt.Sprintf(
+				"%s no pudo ingresar. %s.",
+				entry.VisitorName, entry.Reason,
+			),
+			URL:    "/admin/occupancy",
+			Urgent: true,
+		})
+		if err != nil {
+			a.logger.Error(
+				"Failed to notify the admins of a check-in over the limit",
+				"entry_id", entry.ID,
+				"error", err,
+			)
+		}
+	}
+	a.live.Publish(LiveEvent{
+		Kind:          LiveEntry,
+		CondominiumID: entry.CondominiumID,
+		Entry:         entry,
+	})
+
+	return entry, nil
+}
+
+// admitted opens the gate of the station, if any, for an accepted entry of
+// the visit and lets its resident know the visitor arrived. The entry is
+// already stored, so failures are only logged: the guard must still see
+// that the visitor may come in.
+func (a *App) admitted(
+	ctx context.Context, station *GuardStation, visit *Visit, entry *Entry, usedUp bool,
+) {
+	if err := a.openStationGate(ctx, station, entry); err != nil {
+		a.logger.Error(
+			"Failed to open the gate of the station",
+			"entry_id", entry.ID,
+			"station_id", station.ID,
+			"error", err,
+		)
+	}
+	a.arrived(ctx, visit, entry, usedUp)
+}
+
+// arrived lets the resident of the visit know the visitor of the accepted
+// entry arrived, failures are only logged.
+func (a *App) arrived(ctx context.Context, visit *Visit, entry *Entry, usedUp bool) {
+	if err := a.notifyCheckIn(ctx, visit, entry, usedUp); err != nil {
+		a.logger.Error(
+			"Failed to notify the check-in",
+			"entry_id", entry.ID,
+			"user_id", visit.UserID,
+			"error", err,
+		)
+	}
+	a.live.Publish(LiveEvent{
+		Kind:          LiveArrival,
+		CondominiumID: entry.CondominiumID,
+		UserID:        visit.UserID,
+		Visit:         visit,
+		Entry:         entry,
+	})
+}
+
+// TodayEntries lists the check-in attempts of the guard's condominium since
+// midnight.
+func (a *App) TodayEntries(ctx context.Context) ([]Entry, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.store.EntryListByCondo(ctx, guard.CondominiumID, startOfDay(time.Now()))
+}
+
+// startOfDay returns the midnight that starts the day of t.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
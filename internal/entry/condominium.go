@@ -2,25 +2,192 @@ package entry
 
 import (
 	"context"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 )
 
 type Condominium struct {
-	ID        int64
-	Name      string
-	Address   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	CreatedBy int64
-	UpdatedBy int64
+	ID      int64
+	Name    string
+	Address string
+	// MaxVisitors is how many visitors can be inside at the same time, and
+	// MaxVisitorsPerUnit how many of them for the same unit. Zero for no
+	// limit.
+	MaxVisitors        int64
+	MaxVisitorsPerUnit int64
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	CreatedBy          int64
+	UpdatedBy          int64
+}
+
+func (c *Condominium) Valid() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return NewUserSafeError("El nombre del condominio es obligatorio")
+	}
+	if strings.TrimSpace(c.Address) == "" {
+		return NewUserSafeError("La dirección del condominio es obligatoria")
+	}
+	return nil
+}
+
+// CondominiumSummary is a condominium with the counts shown in its listing.
+type CondominiumSummary struct {
+	Condominium
+	Users  int64
+	Visits int64
+}
+
+// NewCondominiumAdmin is the first admin of a new condominium. The password
+// is hashed by the caller, the domain never sees it.
+type NewCondominiumAdmin struct {
+	FirstName    string
+	LastName     string
+	Email        string
+	Phone        string
+	PasswordHash string
+}
+
+func (a *NewCondominiumAdmin) Valid() error {
+	if strings.TrimSpace(a.FirstName) == "" || strings.TrimSpace(a.LastName) == "" {
+		return NewUserSafeError("El nombre del administrador es obligatorio")
+	}
+	if _, err := mail.ParseAddress(a.Email); err != nil {
+		return NewUserSafeError("El correo del administrador es inválido")
+	}
+	if a.PasswordHash == "" {
+		return NewUserSafeError("La contraseña del administrador es obligatoria")
+	}
+	return nil
 }
 
 type CondominiumStore interface {
+	// CondoGetByID returns a NotFoundError if the condominium doesn't exist.
 	CondoGetByID(ctx context.Context, id int64) (*Condominium, error)
+	CondoList(ctx context.Context) ([]CondominiumSummary, error)
 	CondoCreate(ctx context.Context, condo *Condominium) (*Condominium, error)
+	// CondoCreateWithAdmin creates the condominium and its first admin in a
+	// single transaction. It returns a UserSafeError if the email is taken.
+	CondoCreateWithAdmin(
+		ctx context.Context, condo *Condominium, admin *NewCondominiumAdmin,
+	) (*Condominium, *UserProfile, error)
 	CondoUpdate(
 		ctx context.Context,
 		id int64,
 		updateFn func(condo *Condominium) (*Condominium, error),
 	) error
 }
+
+// ListCondominiums lists every condominium with its user and visit counts.
+func (a *App) ListCondominiums(ctx context.Context) ([]CondominiumSummary, error) {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return nil, err
+	}
+	return a.store.CondoList(ctx)
+}
+
+// Condominium returns a condominium the user in ctx can manage.
+func (a *App) Condominium(ctx context.Context, id int64) (*Condominium, error) {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return nil, err
+	}
+	return a.store.CondoGetByID(ctx, id)
+}
+
+// CreateCondominium creates a condominium together with its first admin, so
+// that no condominium is left without someone to manage it.
+func (a *App) CreateCondominium(
+	ctx context.Context, condo Condominium, admin NewCondominiumAdmin,
+) (*Condominium, *UserProfile, error) {
+	actor, err := RequirePermission(ctx, PermSystemManage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	condo.Name = strings.TrimSpace(condo.Name)
+	condo.Address = strings.TrimSpace(condo.Address)
+	if err := condo.Valid(); err != nil {
+		return nil, nil, err
+	}
+	admin.FirstName = strings.TrimSpace(admin.FirstName)
+	admin.LastName = strings.TrimSpace(admin.LastName)
+	admin.Email = strings.ToLower(strings.TrimSpace(admin.Email))
+	admin.Phone = strings.TrimSpace(admin.Phone)
+	if err := admin.Valid(); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	condo.CreatedAt = now
+	condo.UpdatedAt = now
+	condo.CreatedBy = actor.ID
+	condo.UpdatedBy = actor.ID
+
+	var created *Condominium
+	var user *UserProfile
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, user, err = a.store.CondoCreateWithAdmin(ctx, &condo, &admin)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.ID,
+			Level:         AuditImportant,
+			Action:        ActionCondominiumChanged,
+			Message: fmt.Sprintf(
+				"Condominio %q creado con el administrador %s",
+				created.Name, user.Email,
+			),
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, user, nil
+}
+
+// UpdateCondominium changes the name and address of a condominium.
+func (a *App) UpdateCondominium(
+	ctx context.Context, id int64, name string, address string,
+) (*Condominium, error) {
+	actor, err := RequirePermission(ctx, PermSystemManage)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, updated Condominium
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.CondoUpdate(ctx, id, func(c *Condominium) (*Condominium, error) {
+			before = *c
+			c.Name = strings.TrimSpace(name)
+			c.Address = strings.TrimSpace(address)
+			if err := c.Valid(); err != nil {
+				return nil, err
+			}
+			c.UpdatedAt = time.Now()
+			c.UpdatedBy = actor.ID
+			updated = *c
+			return c, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: id,
+			Level:         AuditImportant,
+			Action:        ActionCondominiumChanged,
+			Message: fmt.Sprintf(
+				"Condominio actualizado: %q, %q → %q, %q",
+				before.Name, before.Address, updated.Name, updated.Address,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
@@ -0,0 +1,198 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GateController opens a gate. Open returns once the controller acknowledged
+// the command, or with an error if it didn't before ctx is done.
+type GateController interface {
+	Open(ctx context.Context) error
+}
+
+// NewGateController returns the controller for the driver of the gate.
+func NewGateController(g *Gate) (GateController, error) {
+	switch g.Driver {
+	case GateHTTP:
+		return &httpRelay{
+			method: g.HTTPMethod,
+			url:    g.Target,
+			body:   g.Payload,
+			client: http.DefaultClient,
+		}, nil
+	case GateModbus:
+		return &modbusCoil{
+			addr: g.Target,
+			unit: byte(g.UnitID),
+			coil: uint16(g.Coil),
+		}, nil
+	case GateTCP:
+		payload, err := gatePayload(g.Payload)
+		if err != nil {
+			return nil, err
+		}
+		return &tcpRelay{addr: g.Target, payload: payload}, nil
+	case GateNoop:
+		return noopGate{}, nil
+	default:
+		return nil, fmt.Errorf("unknown gate driver %q", g.Driver)
+	}
+}
+
+// gatePayload decodes the payload of a GateTCP gate.
+func gatePayload(payload string) ([]byte, error) {
+	if hexPayload, ok := strings.CutPrefix(payload, "hex:"); ok {
+		return hex.DecodeString(strings.ReplaceAll(hexPayload, " ", ""))
+	}
+	return []byte(payload), nil
+}
+
+type noopGate struct{}
+
+func (noopGate) Open(context.Context) error {
+	return nil
+}
+
+// httpRelay triggers a relay with an HTTP request. Any 2xx response means
+// the gate opened.
+type httpRelay struct {
+	method string
+	url    string
+	body   string
+	client *http.Client
+}
+
+func (r *httpRelay) Open(ctx context.Context) error {
+	var body io.Reader
+	if r.method == http.MethodPost {
+		body = strings.NewReader(r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("relay answered %s", res.Status)
+	}
+	return nil
+}
+
+// tcpRelay writes a fixed payload to a TCP connection, the protocol of
+// the simplest network relays. The command is acknowledged once it is
+// written.
+type tcpRelay struct {
+	addr    string
+	payload []byte
+}
+
+func (r *tcpRelay) Open(ctx context.Context) error {
+	conn, err := dialGate(ctx, r.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	_, err = conn.Write(r.payload)
+	return err
+}
+
+// modbusCoil turns on a coil with the Modbus TCP function Write Single
+// Coil. Relays configured as pulses turn it off themselves.
+type modbusCoil struct {
+	addr string
+	unit byte
+	coil uint16
+}
+
+const (
+	modbusWriteSingleCoil = 0x05
+	modbusCoilOn          = 0xFF00
+	// modbusTransaction is the transaction identifier of every request,
+	// there is a single request per connection.
+	modbusTransaction = 1
+)
+
+func (m *modbusCoil) Open(ctx context.Context) error {
+	conn, err := dialGate(ctx, m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	// MBAP header: transaction, protocol (0), length of what follows the
+	// length field, and unit; then the PDU.
+	pdu := binary.BigEndian.AppendUint16([]byte{modbusWriteSingleCoil}, m.coil)
+	pdu = binary.BigEndian.AppendUint16(pdu, modbusCoilOn)
+	req := binary.BigEndian.AppendUint16(nil, modbusTransaction)
+	req = binary.BigEndian.AppendUint16(req, 0)
+	req = binary.BigEndian.AppendUint16(req, uint16(len(pdu)+1))
+	req = append(req, m.unit)
+	req = append(req, pdu...)
+
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("reading modbus response: %w", err)
+	}
+	length := binary.BigEndian.Uint16(header[4:6])
+	if binary.BigEndian.Uint16(header[0:2]) != modbusTransaction || length < 2 || length > 254 {
+		return errors.New("invalid modbus response")
+	}
+	resp := make([]byte, length-1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("reading modbus response: %w", err)
+	}
+
+	if resp[0] == modbusWriteSingleCoil|0x80 {
+		return fmt.Errorf("modbus exception %d", resp[1])
+	}
+	// A successful write echoes the request.
+	if !bytes.Equal(resp, pdu) {
+		return errors.New("unexpected modbus response")
+	}
+	return nil
+}
+
+// dialGate connects to addr, with the deadline of ctx applied to the
+// whole exchange.
+func dialGate(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(gateTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,473 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// GateDriver is how the system talks to the controller of a gate.
+type GateDriver string
+
+const (
+	// GateHTTP calls a relay with an HTTP request, the way most network
+	// relays are triggered.
+	GateHTTP GateDriver = "http"
+	// GateModbus turns on a coil of a Modbus TCP relay.
+	GateModbus GateDriver = "modbus"
+	// GateTCP sends a fixed payload over a TCP connection.
+	GateTCP GateDriver = "tcp"
+	// GateNoop opens nothing, for gates that are still opened by hand.
+	GateNoop GateDriver = "noop"
+)
+
+// GateDrivers lists every driver, in the order they are shown.
+var GateDrivers = []GateDriver{GateHTTP, GateModbus, GateTCP, GateNoop}
+
+func (d GateDriver) String() string {
+	switch d {
+	case GateHTTP:
+		return "Relé HTTP"
+	case GateModbus:
+		return "Modbus TCP"
+	case GateTCP:
+		return "TCP"
+	case GateNoop:
+		return "Sin control"
+	default:
+		return string(d)
+	}
+}
+
+// Gate is a barrier the system can open.
+type Gate struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	Driver        GateDriver
+	// Target is the URL of the relay for GateHTTP, and host:port for
+	// GateModbus and GateTCP.
+	Target string
+	// HTTPMethod is GET or POST, for GateHTTP.
+	HTTPMethod string
+	// Payload is sent by GateTCP. Payloads that start with "hex:" are
+	// hex encoded bytes.
+	Payload string
+	// UnitID and Coil address the relay of GateModbus.
+	UnitID    int64
+	Coil      int64
+	CreatedAt time.Time
+	CreatedBy int64
+}
+
+func (g *Gate) Valid() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return NewUserSafeError("El nombre de la barrera es obligatorio")
+	}
+
+	switch g.Driver {
+	case GateHTTP:
+		u, err := url.Parse(g.Target)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return NewUserSafeError("La URL del relé es inválida")
+		}
+		if g.HTTPMethod != "GET" && g.HTTPMethod != "POST" {
+			return NewUserSafeError("El método debe ser GET o POST")
+		}
+	case GateModbus, GateTCP:
+		if _, _, err := net.SplitHostPort(g.Target); err != nil {
+			return NewUserSafeError("La dirección debe tener la forma host:puerto")
+		}
+		if g.Driver == GateModbus {
+			if g.UnitID < 0 || g.UnitID > 255 {
+				return NewUserSafeError("La unidad Modbus debe estar entre 0 y 255")
+			}
+			if g.Coil < 0 || g.Coil > 65535 {
+				return NewUserSafeError("La bobina Modbus debe estar entre 0 y 65535")
+			}
+		} else if _, err := gatePayload(g.Payload); err != nil || g.Payload == "" {
+			return NewUserSafeError("El mensaje TCP es inválido")
+		}
+	case GateNoop:
+	default:
+		return NewUserSafeError("Controlador inválido")
+	}
+	return nil
+}
+
+// GuardStation is a guardhouse of a condominium. Guards pick the station
+// they work at, and accepted check-ins open its gate.
+type GuardStation struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	// GateID is zero if the station has no gate.
+	GateID int64
+	// WelfareIntervalMinutes is how often guards working alone at the
+	// station confirm they are fine, zero for never. WelfareGraceMinutes is
+	// how long they have to confirm before the admins are told.
+	WelfareIntervalMinutes int64
+	WelfareGraceMinutes    int64
+	CreatedAt              time.Time
+	CreatedBy              int64
+}
+
+type GateStore interface {
+	GateList(ctx context.Context, condoID int64) ([]Gate, error)
+	GateGetByID(ctx context.Context, id int64) (*Gate, error)
+	GateCreate(ctx context.Context, gate *Gate) (*Gate, error)
+	// GateDelete removes the gate, the stations that used it are left
+	// without one.
+	GateDelete(ctx context.Context, id int64) error
+	GuardStationList(ctx context.Context, condoID int64) ([]GuardStation, error)
+	GuardStationGetByID(ctx context.Context, id int64) (*GuardStation, error)
+	GuardStationCreate(ctx context.Context, station *GuardStation) (*GuardStation, error)
+	GuardStationSetGate(ctx context.Context, id int64, gateID int64) error
+	GuardStationSetWelfare(ctx context.Context, id int64, interval int64, grace int64) error
+	GuardStationDelete(ctx context.Context, id int64) error
+}
+
+// gateTimeout bounds how long a guard waits for a gate to open.
+const gateTimeout = 5 * time.Second
+
+// Gates lists the gates of the condominium of the user in ctx.
+func (a *App) Gates(ctx context.Context) ([]Gate, error) {
+	user, err := RequirePermission(ctx, PermGatesManage)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.GateList(ctx, user.CondominiumID)
+}
+
+func (a *App) CreateGate(ctx context.Context, gate Gate) (*Gate, error) {
+	user, err := RequirePermission(ctx, PermGatesManage)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Elige un condominio para registrar la barrera")
+	}
+
+	gate.CondominiumID = user.CondominiumID
+	gate.Name = strings.TrimSpace(gate.Name)
+	gate.Target = strings.TrimSpace(gate.Target)
+	gate.CreatedAt = time.Now()
+	gate.CreatedBy = user.ID
+	if err := gate.Valid(); err != nil {
+		return nil, err
+	}
+
+	var created *Gate
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.GateCreate(ctx, &gate)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message: fmt.Sprintf(
+				"Barrera registrada: %s (%s)", created.Name, created.Driver,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (a *App) DeleteGate(ctx context.Context, id int64) error {
+	gate, err := a.gateForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.GateDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: gate.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message:       fmt.Sprintf("Barrera eliminada: %s", gate.Name),
+		})
+	})
+}
+
+// TestGate opens the gate, so that admins can check its configuration.
+func (a *App) TestGate(ctx context.Context, id int64) error {
+	gate, err := a.gateForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// The error of the driver may carry addresses and responses of the
+	// controller, it is logged rather than shown.
+	if err := a.openGate(ctx, gate, "prueba"); err != nil {
+		a.logger.Error(
+			"Failed to open the gate for a test",
+			"gate_id", gate.ID,
+			"error", err,
+		)
+		return NewUserSafeError(
+			"No se pudo abrir la barrera, revisa su configuración y que el controlador esté en línea",
+		)
+	}
+
+	return a.audit.Record(ctx, AuditRecord{
+		CondominiumID: gate.CondominiumID,
+		Level:         AuditInfo,
+		Action:        ActionGateChanged,
+		Message:       fmt.Sprintf("Prueba de la barrera %s", gate.Name),
+	})
+}
+
+// GuardStations lists the stations of the condominium of the user in ctx,
+// for guards to pick one and for admins to manage them and assign rounds.
+func (a *App) GuardStations(ctx context.Context) ([]GuardStation, error) {
+	user, err := RequireAnyPermission(ctx, PermGatesManage, PermEntriesRecord, PermShiftsWork, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.GuardStationList(ctx, user.CondominiumID)
+}
+
+// GuardStation returns a station of the condominium of the user in ctx.
+func (a *App) GuardStation(ctx context.Context, id int64) (*GuardStation, error) {
+	user, err := RequireAnyPermission(ctx, PermGatesManage, PermEntriesRecord, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+
+	station, err := a.store.GuardStationGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if station.CondominiumID != user.CondominiumID {
+		return nil, NewNotFoundError("Garita no encontrada")
+	}
+	return station, nil
+}
+
+func (a *App) CreateGuardStation(
+	ctx context.Context, name string, gateID int64,
+) (*GuardStation, error) {
+	user, err := RequirePermission(ctx, PermGatesManage)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Elige un condominio para registrar la garita")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, NewUserSafeError("El nombre de la garita es obligatorio")
+	}
+	if gateID != 0 {
+		if _, err := a.gateForAdmin(ctx, gateID); err != nil {
+			return nil, err
+		}
+	}
+
+	var created *GuardStation
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.GuardStationCreate(ctx, &GuardStation{
+			CondominiumID: user.CondominiumID,
+			Name:          name,
+			GateID:        gateID,
+			CreatedAt:     time.Now(),
+			CreatedBy:     user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message:       fmt.Sprintf("Garita registrada: %s", created.Name),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// SetGuardStationGate assigns a gate to the station, zero removes it.
+func (a *App) SetGuardStationGate(ctx context.Context, id int64, gateID int64) error {
+	station, err := a.guardStationForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	gateName := "ninguna"
+	if gateID != 0 {
+		gate, err := a.gateForAdmin(ctx, gateID)
+		if err != nil {
+			return err
+		}
+		gateName = gate.Name
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.GuardStationSetGate(ctx, id, gateID); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: station.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message: fmt.Sprintf(
+				"Barrera de la garita %s: %s", station.Name, gateName,
+			),
+		})
+	})
+}
+
+func (a *App) DeleteGuardStation(ctx context.Context, id int64) error {
+	station, err := a.guardStationForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.GuardStationDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: station.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message:       fmt.Sprintf("Garita eliminada: %s", station.Name),
+		})
+	})
+}
+
+// gateForAdmin returns the gate if the user in ctx manages the gates of its
+// condominium. Gates of other condominiums are not found.
+func (a *App) gateForAdmin(ctx context.Context, id int64) (*Gate, error) {
+	user, err := RequirePermission(ctx, PermGatesManage)
+	if err != nil {
+		return nil, err
+	}
+
+	gate, err := a.store.GateGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if gate.CondominiumID != user.CondominiumID {
+		return nil, NewNotFoundError("Barrera no encontrada")
+	}
+	return gate, nil
+}
+
+func (a *App) guardStationForAdmin(ctx context.Context, id int64) (*GuardStation, error) {
+	if _, err := RequirePermission(ctx, PermGatesManage); err != nil {
+		return nil, err
+	}
+	return a.GuardStation(ctx, id)
+}
+
+// openStationGate opens the gate of the station after an accepted
+// check-in. A gate that fails to open doesn't undo the check-in: the
+// failure is recorded in the entry and in the audit log, and the guard
+// opens the gate by hand.
+func (a *App) openStationGate(ctx context.Context, station *GuardStation, entry *Entry) error {
+	if station == nil || station.GateID == 0 {
+		return nil
+	}
+
+	gate, err := a.store.GateGetByID(ctx, station.GateID)
+	if err != nil {
+		return err
+	}
+
+	err = a.openGate(ctx, gate, entry.VisitID)
+	if err == nil {
+		entry.GateOpened = true
+		return nil
+	}
+
+	entry.GateError = err.Error()
+	a.live.Publish(LiveEvent{
+		Kind:          LiveAlert,
+		CondominiumID: gate.CondominiumID,
+		Message: fmt.Sprintf(
+			"No se pudo abrir la barrera %s de la garita %s, ábrela manualmente.",
+			gate.Name, station.Name,
+		),
+	})
+	return a.audit.Record(ctx, AuditRecord{
+		CondominiumID: gate.CondominiumID,
+		Level:         AuditImportant,
+		Action:        ActionGateFailed,
+		Message: fmt.Sprintf(
+			"No se pudo abrir la barrera %s de la garita %s (código %s): %v",
+			gate.Name, station.Name, entry.VisitID, err,
+		),
+	})
+}
+
+// openGate triggers the controller of the gate, giving up after
+// gateTimeout. reason is only logged.
+func (a *App) openGate(ctx context.Context, gate *Gate, reason string) error {
+	controller, err := NewGateController(gate)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gateTimeout)
+	defer cancel()
+
+	err = controller.Open(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", gateTimeout)
+	}
+	if err != nil {
+		a.logger.Warn(
+			"Failed to open gate",
+			"gate_id", gate.ID,
+			"reason", reason,
+			"error", err,
+		)
+		return err
+	}
+	return nil
+}
+
+// stationForCheckIn returns the station the guard checks in at, nil if it
+// checks in without one.
+func (a *App) stationForCheckIn(
+	ctx context.Context, guard *User, stationID int64,
+) (*GuardStation, error) {
+	if stationID == 0 {
+		return nil, nil
+	}
+
+	station, err := a.store.GuardStationGetByID(ctx, stationID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) || (err == nil && station.CondominiumID != guard.CondominiumID) {
+		return nil, NewUserSafeError("La garita no existe, elige otra")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return station, nil
+}
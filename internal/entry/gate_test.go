@@ -0,0 +1,191 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeTCPServer accepts connections on a local port and hands each one to
+// handle.
+func fakeTCPServer(t *testing.T, handle func(conn net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func openGateWithin(g *Gate, timeout time.Duration) error {
+	controller, err := NewGateController(g)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return controller.Open(ctx)
+}
+
+func TestHTTPRelay(t *testing.T) {
+	var method, body string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(b)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	gate := &Gate{Driver: GateHTTP, Target: server.URL + "/relay/0?turn=on", HTTPMethod: "POST", Payload: "pulse"}
+	if err := openGateWithin(gate, time.Second); err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	if method != "POST" || body != "pulse" {
+		t.Errorf("relay got %s %q, want POST %q", method, body, "pulse")
+	}
+
+	gate.HTTPMethod = "GET"
+	if err := openGateWithin(gate, time.Second); err != nil || method != "GET" || body != "" {
+		t.Errorf("Open() = %v, relay got %s %q, want a GET without body", err, method, body)
+	}
+
+	status = http.StatusInternalServerError
+	if err := openGateWithin(gate, time.Second); err == nil {
+		t.Error("Open() succeeded with the relay answering 500")
+	}
+}
+
+func TestHTTPRelayTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	gate := &Gate{Driver: GateHTTP, Target: server.URL, HTTPMethod: "GET"}
+	if err := openGateWithin(gate, 50*time.Millisecond); err == nil {
+		t.Error("Open() succeeded with a relay that never answers")
+	}
+}
+
+// modbusRequest is a Write Single Coil of coil 17 at unit 3, turning it on.
+var modbusRequest = []byte{0, 1, 0, 0, 0, 6, 3, 0x05, 0, 17, 0xFF, 0}
+
+func TestModbusCoil(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer func(req []byte) []byte
+		ok     bool
+	}{
+		{"echo", func(req []byte) []byte { return req }, true},
+		{"exception", func(req []byte) []byte {
+			return []byte{0, 1, 0, 0, 0, 3, 3, 0x85, 2}
+		}, false},
+		{"other coil", func(req []byte) []byte {
+			resp := bytes.Clone(req)
+			binary.BigEndian.PutUint16(resp[8:10], 18)
+			return resp
+		}, false},
+		{"no answer", func([]byte) []byte { return nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(chan []byte, 1)
+			addr := fakeTCPServer(t, func(conn net.Conn) {
+				req := make([]byte, len(modbusRequest))
+				if _, err := io.ReadFull(conn, req); err != nil {
+					return
+				}
+				got <- req
+				if resp := tt.answer(req); resp != nil {
+					conn.Write(resp)
+				} else {
+					io.Copy(io.Discard, conn)
+				}
+			})
+
+			gate := &Gate{Driver: GateModbus, Target: addr, UnitID: 3, Coil: 17}
+			err := openGateWithin(gate, 200*time.Millisecond)
+			if (err == nil) != tt.ok {
+				t.Errorf("Open() = %v, want ok %v", err, tt.ok)
+			}
+			if req := <-got; !bytes.Equal(req, modbusRequest) {
+				t.Errorf("request = % x, want % x", req, modbusRequest)
+			}
+		})
+	}
+}
+
+func TestTCPRelay(t *testing.T) {
+	got := make(chan []byte, 1)
+	addr := fakeTCPServer(t, func(conn net.Conn) {
+		b, _ := io.ReadAll(conn)
+		got <- b
+	})
+
+	gate := &Gate{Driver: GateTCP, Target: addr, Payload: "hex:A0 01 01 A2"}
+	if err := openGateWithin(gate, time.Second); err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	if b := <-got; !bytes.Equal(b, []byte{0xA0, 0x01, 0x01, 0xA2}) {
+		t.Errorf("relay got % x", b)
+	}
+
+	// Nothing listens on the port once the server is closed.
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := l.Addr().String()
+	l.Close()
+	gate.Target = closed
+	if err := openGateWithin(gate, time.Second); err == nil {
+		t.Error("Open() succeeded without a relay")
+	}
+}
+
+func TestGateValid(t *testing.T) {
+	tests := []struct {
+		name string
+		gate Gate
+		ok   bool
+	}{
+		{"http", Gate{Driver: GateHTTP, Target: "http://10.0.0.5/relay", HTTPMethod: "GET"}, true},
+		{"http without method", Gate{Driver: GateHTTP, Target: "http://10.0.0.5/relay"}, false},
+		{"http bad url", Gate{Driver: GateHTTP, Target: "10.0.0.5", HTTPMethod: "GET"}, false},
+		{"modbus", Gate{Driver: GateModbus, Target: "10.0.0.5:502", UnitID: 1}, true},
+		{"modbus without port", Gate{Driver: GateModbus, Target: "10.0.0.5"}, false},
+		{"modbus bad unit", Gate{Driver: GateModbus, Target: "10.0.0.5:502", UnitID: 300}, false},
+		{"tcp", Gate{Driver: GateTCP, Target: "10.0.0.5:6722", Payload: "11"}, true},
+		{"tcp bad hex", Gate{Driver: GateTCP, Target: "10.0.0.5:6722", Payload: "hex:zz"}, false},
+		{"tcp without payload", Gate{Driver: GateTCP, Target: "10.0.0.5:6722"}, false},
+		{"noop", Gate{Driver: GateNoop}, true},
+		{"unknown driver", Gate{Driver: "serial"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.gate.Name = "Principal"
+			if err := tt.gate.Valid(); (err == nil) != tt.ok {
+				t.Errorf("Valid() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
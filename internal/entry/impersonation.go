@@ -0,0 +1,32 @@
+package entry
+
+import "context"
+
+// While a superadmin impersonates a user, the context carries the
+// impersonated user as the user, so that every authorization check sees what
+// that user would see, and the superadmin as the impersonator, so that the
+// real actor is never lost.
+
+type impersonatorCtxKey struct{}
+
+func WithImpersonator(ctx context.Context, impersonator *User) context.Context {
+	return context.WithValue(ctx, impersonatorCtxKey{}, impersonator)
+}
+
+// ImpersonatorFromCtx returns the superadmin impersonating the user in ctx,
+// or nil if there is none.
+func ImpersonatorFromCtx(ctx context.Context) *User {
+	impersonator, _ := ctx.Value(impersonatorCtxKey{}).(*User)
+	return impersonator
+}
+
+// RequireNotImpersonating blocks sensitive actions, like changing
+// credentials, while impersonating.
+func RequireNotImpersonating(ctx context.Context) error {
+	if ImpersonatorFromCtx(ctx) != nil {
+		return &ForbiddenError{
+			msg: "No puedes hacer esto mientras ves el sistema como otro usuario",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,537 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// IncidentCategory is what kind of incident a guard reports.
+type IncidentCategory string
+
+const (
+	IncidentDamage     IncidentCategory = "damage"
+	IncidentSuspicious IncidentCategory = "suspicious"
+	IncidentNoise      IncidentCategory = "noise"
+	IncidentAccess     IncidentCategory = "access"
+	IncidentOther      IncidentCategory = "other"
+)
+
+// IncidentCategories lists every category, in the order they are shown.
+var IncidentCategories = []IncidentCategory{
+	IncidentDamage, IncidentSuspicious, IncidentNoise, IncidentAccess, IncidentOther,
+}
+
+func (c IncidentCategory) String() string {
+	switch c {
+	case IncidentDamage:
+		return "Daño a instalaciones"
+	case IncidentSuspicious:
+		return "Persona sospechosa"
+	case IncidentNoise:
+		return "Ruido"
+	case IncidentAccess:
+		return "Problema de acceso"
+	case IncidentOther:
+		return "Otro"
+	default:
+		return string(c)
+	}
+}
+
+// IncidentSeverity is how urgent an incident is. Admins are notified of
+// critical incidents right away.
+type IncidentSeverity string
+
+const (
+	SeverityLow      IncidentSeverity = "low"
+	SeverityMedium   IncidentSeverity = "medium"
+	SeverityHigh     IncidentSeverity = "high"
+	SeverityCritical IncidentSeverity = "critical"
+)
+
+// IncidentSeverities lists every severity, from the least severe.
+var IncidentSeverities = []IncidentSeverity{
+	SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical,
+}
+
+func (s IncidentSeverity) String() string {
+	switch s {
+	case SeverityLow:
+		return "Baja"
+	case SeverityMedium:
+		return "Media"
+	case SeverityHigh:
+		return "Alta"
+	case SeverityCritical:
+		return "Crítica"
+	default:
+		return string(s)
+	}
+}
+
+// IncidentStatus is where an incident is in the review of the admins. It
+// only moves forward: open, acknowledged, resolved.
+type IncidentStatus string
+
+const (
+	IncidentOpen         IncidentStatus = "open"
+	IncidentAcknowledged IncidentStatus = "acknowledged"
+	IncidentResolved     IncidentStatus = "resolved"
+)
+
+// IncidentStatuses lists every status, in the order incidents go through
+// them.
+var IncidentStatuses = []IncidentStatus{IncidentOpen, IncidentAcknowledged, IncidentResolved}
+
+func (s IncidentStatus) String() string {
+	switch s {
+	case IncidentOpen:
+		return "Abierto"
+	case IncidentAcknowledged:
+		return "En revisión"
+	case IncidentResolved:
+		return "Resuelto"
+	default:
+		return string(s)
+	}
+}
+
+// Next lists the statuses an incident in s can move to.
+func (s IncidentStatus) Next() []IncidentStatus {
+	i := slices.Index(IncidentStatuses, s)
+	if i < 0 {
+		return nil
+	}
+	return IncidentStatuses[i+1:]
+}
+
+// Incident is something a guard reported for the admins to review.
+type Incident struct {
+	ID            int64
+	CondominiumID int64
+	Category      IncidentCategory
+	Severity      IncidentSeverity
+	Location      string
+	Description   string
+	Status        IncidentStatus
+	// ReportedBy is the guard that reported the incident, zero if it was
+	// deleted.
+	ReportedBy   int64
+	ReporterName string
+	// ShiftID is the shift of the guard, zero if it had none open.
+	ShiftID int64
+	// Residents are the residents involved, and VisitIDs the codes of the
+	// visits involved.
+	Residents []IncidentResident
+	VisitIDs  []string
+	// Attachments are loaded without their data.
+	Attachments []IncidentAttachment
+	Comments    []IncidentComment
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IncidentResident is a resident involved in an incident.
+type IncidentResident struct {
+	ID   int64
+	Name string
+}
+
+// IncidentComment is a note of an admin on an incident, and the status it
+// moved the incident to, if it did.
+type IncidentComment struct {
+	ID         int64
+	IncidentID int64
+	// AuthorID is zero if the author was deleted.
+	AuthorID   int64
+	AuthorName string
+	Body       string
+	// Status is empty for comments that didn't change the status.
+	Status    IncidentStatus
+	CreatedAt time.Time
+}
+
+// IncidentAttachment is a photo or document attached to an incident.
+type IncidentAttachment struct {
+	ID          int64
+	IncidentID  int64
+	Filename    string
+	ContentType string
+	Size        int64
+	// Data is only loaded to serve the attachment.
+	Data      []byte
+	CreatedAt time.Time
+}
+
+const (
+	incidentLocationLength    = 200
+	incidentDescriptionLength = 5000
+	incidentCommentLength     = 2000
+	incidentFilenameLength    = 100
+	// MaxIncidentAttachments is how many files an incident may have.
+	MaxIncidentAttachments = 5
+	// MaxIncidentAttachmentSize is the size of the largest file kept.
+	MaxIncidentAttachmentSize = 5 << 20
+	// incidentListSize is how many incidents the lists show.
+	incidentListSize = 100
+)
+
+// incidentAttachmentTypes are the formats attachments may be in, which
+// browsers show by themselves.
+var incidentAttachmentTypes = []string{
+	"image/jpeg", "image/png", "image/webp", "application/pdf",
+}
+
+// NewIncidentAttachment checks that data is a photo or PDF document.
+func NewIncidentAttachment(filename string, data []byte) (*IncidentAttachment, error) {
+	if len(data) > MaxIncidentAttachmentSize {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Los adjuntos no pueden pesar más de %d MB", MaxIncidentAttachmentSize>>20,
+		))
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(incidentAttachmentTypes, contentType) {
+		return nil, NewUserSafeError("Los adjuntos deben ser fotos JPEG, PNG o WebP, o documentos PDF")
+	}
+
+	filename = strings.TrimSpace(filepath.Base(filename))
+	if filename == "." || filename == string(filepath.Separator) || filename == "" {
+		filename = "adjunto"
+	}
+	if len(filename) > incidentFilenameLength {
+		filename = truncateUTF8(filename, incidentFilenameLength)
+	}
+	return &IncidentAttachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Data:        data,
+	}, nil
+}
+
+func (i *Incident) Valid() error {
+	if !slices.Contains(IncidentCategories, i.Category) {
+		return NewUserSafeError("Categoría inválida")
+	}
+	if !slices.Contains(IncidentSeverities, i.Severity) {
+		return NewUserSafeError("Gravedad inválida")
+	}
+	if i.Location == "" {
+		return NewUserSafeError("El lugar del incidente es obligatorio")
+	}
+	if utf8.RuneCountInString(i.Location) > incidentLocationLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"El lugar no puede tener más de %d caracteres", incidentLocationLength,
+		))
+	}
+	if i.Description == "" {
+		return NewUserSafeError("Describe el incidente")
+	}
+	if utf8.RuneCountInString(i.Description) > incidentDescriptionLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"La descripción no puede tener más de %d caracteres", incidentDescriptionLength,
+		))
+	}
+	return nil
+}
+
+type IncidentStore interface {
+	// IncidentCreate saves the incident with the residents, visits and
+	// attachments involved.
+	IncidentCreate(
+		ctx context.Context, incident *Incident, attachments []IncidentAttachment,
+	) (*Incident, error)
+	// IncidentGetByID returns the incident with everything involved and its
+	// comments, oldest first.
+	IncidentGetByID(ctx context.Context, id int64) (*Incident, error)
+	// IncidentList lists the latest incidents of a condominium in a status,
+	// or in any status if it is empty, without what is involved.
+	IncidentList(
+		ctx context.Context, condoID int64, status IncidentStatus, limit int64,
+	) ([]Incident, error)
+	// IncidentListByShift lists the incidents reported in a shift, oldest
+	// first, without what is involved.
+	IncidentListByShift(ctx context.Context, shiftID int64) ([]Incident, error)
+	// IncidentComment saves the comment and, if it has a status, moves the
+	// incident from the status from to it. It fails with a UserSafeError if
+	// the incident isn't in from anymore.
+	IncidentComment(ctx context.Context, comment *IncidentComment, from IncidentStatus) error
+	// IncidentAttachmentGet returns the attachment with its data.
+	IncidentAttachmentGet(ctx context.Context, id int64) (*IncidentAttachment, error)
+}
+
+// ReportIncident saves an incident the guard reports, and notifies the
+// admins of the condominium right away if it is critical.
+func (a *App) ReportIncident(
+	ctx context.Context,
+	incident Incident,
+	residentIDs []int64,
+	visitCodes []string,
+	attachments []IncidentAttachment,
+) (*Incident, error) {
+	guard, err := RequirePermission(ctx, PermIncidentsReport)
+	if err != nil {
+		return nil, err
+	}
+
+	incident.Location = strings.TrimSpace(incident.Location)
+	incident.Description = strings.TrimSpace(incident.Description)
+	if err := incident.Valid(); err != nil {
+		return nil, err
+	}
+	if len(attachments) > MaxIncidentAttachments {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Solo se pueden adjuntar %d archivos", MaxIncidentAttachments,
+		))
+	}
+
+	incident.Residents = nil
+	for _, id := range residentIDs {
+		if slices.ContainsFunc(incident.Residents, func(r IncidentResident) bool { return r.ID == id }) {
+			continue
+		}
+		resident, err := a.condoResident(ctx, guard, id, PermVisitsCreate)
+		if err != nil {
+			return nil, err
+		}
+		incident.Residents = append(incident.Residents, IncidentResident{
+			ID: resident.ID, Name: resident.FullName(),
+		})
+	}
+
+	incident.VisitIDs = nil
+	for _, code := range visitCodes {
+		code = NormalizeVisitCode(code)
+		if code == "" || slices.Contains(incident.VisitIDs, code) {
+			continue
+		}
+		visit, err := a.store.VisitGetByID(ctx, code)
+		if err == nil && visit.CondominiumID != guard.CondominiumID {
+			err = NewNotFoundError("")
+		}
+		if err != nil {
+			if _, ok := err.(*NotFoundError); ok {
+				return nil, NewUserSafeError(fmt.Sprintf("No existe la visita %s", code))
+			}
+			return nil, err
+		}
+		incident.VisitIDs = append(incident.VisitIDs, visit.ID)
+	}
+
+	incident.ShiftID, err = a.openShiftID(ctx, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	incident.CondominiumID = guard.CondominiumID
+	incident.Status = IncidentOpen
+	incident.ReportedBy = guard.ID
+	incident.CreatedAt = now
+	incident.UpdatedAt = now
+	for i := range attachments {
+		attachments[i].CreatedAt = now
+	}
+
+	var created *Incident
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.IncidentCreate(ctx, &incident, attachments)
+		if err != nil {
+			return err
+		}
+
+		level := AuditInfo
+		if created.Severity == SeverityCritical {
+			level = AuditImportant
+		}
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         level,
+			Action:        ActionIncidentReported,
+			Message: fmt.Sprintf(
+				"Incidente #%d reportado: %s, gravedad %s, en %s",
+				created.ID, created.Category, strings.ToLower(created.Severity.String()), created.Location,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created.Severity == SeverityCritical {
+		if err := a.notifyCriticalIncident(ctx, created); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// notifyCriticalIncident tells every admin of the condominium about the
+// incident.
+func (a *App) notifyCriticalIncident(ctx context.Context, incident *Incident) error {
+	description := incident.Description
+	if utf8.RuneCountInString(description) > 200 {
+		description = truncateUTF8(description, 200) + "…"
+	}
+	return a.notifyAdmins(ctx, PermIncidentsManage, Notification{
+		CondominiumID: incident.CondominiumID,
+		Event:         NotifyIncidentCritical,
+		Title:         fmt.Sprintf("Incidente crítico: %s", incident.Category),
+		Body:          fmt.Sprintf("En %s. %s", incident.Location, description),
+		URL:           fmt.Sprintf("/admin/incidents/%d", incident.ID),
+		Urgent:        true,
+	})
+}
+
+// notifyAdmins sends the notification to every enabled user of its
+// condominium that holds perm, the one to deal with what happened. Admins
+// have no notification preferences, so it should be urgent to reach them.
+func (a *App) notifyAdmins(
+	ctx context.Context, perm Permission, notification Notification,
+) error {
+	users, err := a.condoUsersWith(ctx, notification.CondominiumID, perm)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		notification.UserID = u.ID
+		if err := a.notifier.Notify(ctx, notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GuardIncidents lists the latest incidents of the guard's condominium.
+func (a *App) GuardIncidents(ctx context.Context) ([]Incident, error) {
+	guard, err := RequirePermission(ctx, PermIncidentsReport)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.IncidentList(ctx, guard.CondominiumID, "", incidentListSize)
+}
+
+// Incidents lists the latest incidents of the admin's condominium in a
+// status, or in any if it is empty.
+func (a *App) Incidents(ctx context.Context, status IncidentStatus) ([]Incident, error) {
+	admin, err := RequirePermission(ctx, PermIncidentsManage)
+	if err != nil {
+		return nil, err
+	}
+	if status != "" && !slices.Contains(IncidentStatuses, status) {
+		return nil, NewUserSafeError("Estado inválido")
+	}
+	return a.store.IncidentList(ctx, admin.CondominiumID, status, incidentListSize)
+}
+
+// Incident returns an incident of the condominium of the user, who must be
+// able to report or review incidents.
+func (a *App) Incident(ctx context.Context, id int64) (*Incident, error) {
+	user, err := RequireAnyPermission(ctx, PermIncidentsManage, PermIncidentsReport)
+	if err != nil {
+		return nil, err
+	}
+
+	incident, err := a.store.IncidentGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.CondominiumID != user.CondominiumID {
+		return nil, NewNotFoundError("Incidente no encontrado")
+	}
+	return incident, nil
+}
+
+// CommentIncident adds a comment of the admin to an incident, moving it to
+// status if it isn't empty. Moving an incident requires no comment, but
+// resolving one does.
+func (a *App) CommentIncident(
+	ctx context.Context, id int64, body string, status IncidentStatus,
+) error {
+	admin, err := RequirePermission(ctx, PermIncidentsManage)
+	if err != nil {
+		return err
+	}
+
+	incident, err := a.Incident(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	body = strings.TrimSpace(body)
+	if status == incident.Status {
+		status = ""
+	}
+	switch {
+	case status != "" && !slices.Contains(incident.Status.Next(), status):
+		return NewUserSafeError(fmt.Sprintf(
+			"Un incidente %s no puede pasar a %s",
+			strings.ToLower(incident.Status.String()), strings.ToLower(status.String()),
+		))
+	case body == "" && status == "":
+		return NewUserSafeError("Escribe un comentario")
+	case body == "" && status == IncidentResolved:
+		return NewUserSafeError("Explica cómo se resolvió el incidente")
+	case utf8.RuneCountInString(body) > incidentCommentLength:
+		return NewUserSafeError(fmt.Sprintf(
+			"El comentario no puede tener más de %d caracteres", incidentCommentLength,
+		))
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.IncidentComment(ctx, &IncidentComment{
+			IncidentID: incident.ID,
+			AuthorID:   admin.ID,
+			Body:       body,
+			Status:     status,
+			CreatedAt:  time.Now(),
+		}, incident.Status)
+		if err != nil || status == "" {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: incident.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionIncidentChanged,
+			Message: fmt.Sprintf(
+				"Incidente #%d: %s → %s", incident.ID, incident.Status, status,
+			),
+		})
+	})
+}
+
+// IncidentAttachment returns an attachment of an incident of the user's
+// condominium.
+func (a *App) IncidentAttachment(
+	ctx context.Context, incidentID int64, id int64,
+) (*IncidentAttachment, error) {
+	incident, err := a.Incident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(incident.Attachments, func(at IncidentAttachment) bool {
+		return at.ID == id
+	}) {
+		return nil, NewNotFoundError("Adjunto no encontrado")
+	}
+	return a.store.IncidentAttachmentGet(ctx, id)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
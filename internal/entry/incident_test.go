@@ -0,0 +1,97 @@
+package entry
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIncidentStatusNext(t *testing.T) {
+	tests := []struct {
+		status IncidentStatus
+		want   []IncidentStatus
+	}{
+		{IncidentOpen, []IncidentStatus{IncidentAcknowledged, IncidentResolved}},
+		{IncidentAcknowledged, []IncidentStatus{IncidentResolved}},
+		{IncidentResolved, []IncidentStatus{}},
+		{"closed", nil},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Next(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s.Next() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestIncidentValid(t *testing.T) {
+	valid := Incident{
+		Category:    IncidentDamage,
+		Severity:    SeverityHigh,
+		Location:    "Barrera de entrada",
+		Description: "Un carro golpeó la barrera.",
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Incident)
+		ok     bool
+	}{
+		{"valid", func(*Incident) {}, true},
+		{"unknown category", func(i *Incident) { i.Category = "fire" }, false},
+		{"unknown severity", func(i *Incident) { i.Severity = "urgent" }, false},
+		{"no location", func(i *Incident) { i.Location = "" }, false},
+		{"no description", func(i *Incident) { i.Description = "" }, false},
+		{"long location", func(i *Incident) {
+			i.Location = strings.Repeat("á", incidentLocationLength+1)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := valid
+			tt.change(&incident)
+			if err := incident.Valid(); (err == nil) != tt.ok {
+				t.Errorf("Valid() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewIncidentAttachment(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32))
+	pdf := []byte("%PDF-1.7\n" + strings.Repeat("x", 32))
+
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		wantType string
+		wantName string
+	}{
+		{"png", "barrera.png", png, "image/png", "barrera.png"},
+		{"pdf", "reporte.pdf", pdf, "application/pdf", "reporte.pdf"},
+		{"path", `../../etc/foto.png`, png, "image/png", "foto.png"},
+		{"no name", "", png, "image/png", "adjunto"},
+		{"html", "x.html", []byte("<html><script>alert(1)</script>"), "", ""},
+		{"too large", "big.png", append(png, bytes.Repeat([]byte{0}, MaxIncidentAttachmentSize)...), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewIncidentAttachment(tt.filename, tt.data)
+			if tt.wantType == "" {
+				if err == nil {
+					t.Fatalf("NewIncidentAttachment() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ContentType != tt.wantType || got.Filename != tt.wantName ||
+				got.Size != int64(len(tt.data)) {
+				t.Errorf("NewIncidentAttachment() = %s %q %d, want %s %q %d",
+					got.ContentType, got.Filename, got.Size, tt.wantType, tt.wantName, len(tt.data))
+			}
+		})
+	}
+}
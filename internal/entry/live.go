@@ -0,0 +1,183 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// LiveEventKind is what changed for the dashboards that are open.
+type LiveEventKind string
+
+const (
+	// LiveVisit is sent to the guards when a resident creates a visit
+	// expected today.
+	LiveVisit LiveEventKind = "visit"
+	// LiveEntry is sent to the guards on every check-in.
+	LiveEntry LiveEventKind = "entry"
+	// LiveArrival is sent to the resident whose visit checked in.
+	LiveArrival LiveEventKind = "arrival"
+	// LiveWalkIn is sent to the guards and the resident when a walk-in is
+	// announced, and again when the resident decides.
+	LiveWalkIn LiveEventKind = "walk_in"
+	// LiveAlert is sent to the guards when something needs their
+	// attention, like a gate that didn't open.
+	LiveAlert LiveEventKind = "alert"
+)
+
+// LiveEvent is something the dashboards show as it happens. Only the field
+// of its kind is set.
+type LiveEvent struct {
+	Kind          LiveEventKind
+	CondominiumID int64
+	// UserID is the resident the event is for, zero for events for the
+	// guards of the condominium.
+	UserID  int64
+	Visit   *Visit
+	Entry   *Entry
+	WalkIn  *WalkIn
+	Message string
+}
+
+const (
+	// liveBuffer is how many events a subscriber may fall behind before it
+	// is dropped. Dropped subscribers reconnect and load the page again.
+	liveBuffer = 16
+	// liveMaxSubscribers caps the subscribers of a condominium's guards or
+	// of a resident, the oldest is dropped to make room.
+	liveMaxSubscribers = 64
+)
+
+// ErrHubClosed is returned when subscribing once the hub is closed.
+var ErrHubClosed = errors.New("live updates are shut down")
+
+type liveKey struct {
+	condoID int64
+	userID  int64
+}
+
+// Hub fans live events out to the open dashboards, the guards of each
+// condominium and each resident. Publishing never blocks, subscribers that
+// fall behind are dropped, so memory stays bounded however slow the clients
+// are.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[liveKey][]*Subscription
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[liveKey][]*Subscription)}
+}
+
+// Subscription receives the events of a condominium's guards or of a
+// resident.
+type Subscription struct {
+	hub    *Hub
+	key    liveKey
+	events chan LiveEvent
+	// closed is guarded by hub.mu.
+	closed bool
+}
+
+// Events delivers the events. It is closed when the subscriber falls
+// behind, or when the hub is closed.
+func (s *Subscription) Events() <-chan LiveEvent {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+func (h *Hub) subscribe(key liveKey) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	if subs := h.subs[key]; len(subs) >= liveMaxSubscribers {
+		h.drop(subs[0])
+	}
+	sub := &Subscription{
+		hub:    h,
+		key:    key,
+		events: make(chan LiveEvent, liveBuffer),
+	}
+	h.subs[key] = append(h.subs[key], sub)
+	return sub, nil
+}
+
+// Publish sends the event to the subscribers of its condominium's guards,
+// or of its resident if it has a UserID.
+func (h *Hub) Publish(event LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := liveKey{condoID: event.CondominiumID, userID: event.UserID}
+	for _, sub := range slices.Clone(h.subs[key]) {
+		select {
+		case sub.events <- event:
+		default:
+			h.drop(sub)
+		}
+	}
+}
+
+// Close ends every subscription and refuses new ones, so that the streams
+// return and the server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for _, sub := range slices.Clone(subs) {
+			h.drop(sub)
+		}
+	}
+}
+
+// drop removes the subscription and closes its channel. h.mu must be held.
+func (h *Hub) drop(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+
+	subs := h.subs[sub.key]
+	if i := slices.Index(subs, sub); i >= 0 {
+		subs = slices.Delete(subs, i, i+1)
+	}
+	if len(subs) == 0 {
+		delete(h.subs, sub.key)
+	} else {
+		h.subs[sub.key] = subs
+	}
+}
+
+// GuardUpdates subscribes to the live events of the guards of the user's
+// condominium.
+func (a *App) GuardUpdates(ctx context.Context) (*Subscription, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+	return a.live.subscribe(liveKey{condoID: guard.CondominiumID})
+}
+
+// ResidentUpdates subscribes to the live events of the user in ctx.
+func (a *App) ResidentUpdates(ctx context.Context) (*Subscription, error) {
+	user, err := RequirePermission(ctx, PermVisitsCreate)
+	if err != nil {
+		return nil, err
+	}
+	return a.live.subscribe(liveKey{condoID: user.CondominiumID, userID: user.ID})
+}
@@ -0,0 +1,104 @@
+package entry
+
+import (
+	"errors"
+	"testing"
+)
+
+func receive(t *testing.T, sub *Subscription) (LiveEvent, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		return event, ok
+	default:
+		return LiveEvent{}, false
+	}
+}
+
+func TestHubRoutes(t *testing.T) {
+	hub := NewHub()
+	guards, _ := hub.subscribe(liveKey{condoID: 1})
+	otherGuards, _ := hub.subscribe(liveKey{condoID: 2})
+	resident, _ := hub.subscribe(liveKey{condoID: 1, userID: 7})
+
+	hub.Publish(LiveEvent{Kind: LiveEntry, CondominiumID: 1})
+	if event, ok := receive(t, guards); !ok || event.Kind != LiveEntry {
+		t.Errorf("guards got %+v, %v; want the entry", event, ok)
+	}
+	if _, ok := receive(t, otherGuards); ok {
+		t.Error("the guards of another condominium got the entry")
+	}
+	if _, ok := receive(t, resident); ok {
+		t.Error("a resident got an event for the guards")
+	}
+
+	hub.Publish(LiveEvent{Kind: LiveArrival, CondominiumID: 1, UserID: 7})
+	if event, ok := receive(t, resident); !ok || event.Kind != LiveArrival {
+		t.Errorf("resident got %+v, %v; want the arrival", event, ok)
+	}
+	if _, ok := receive(t, guards); ok {
+		t.Error("the guards got an event for a resident")
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	slow, _ := hub.subscribe(liveKey{condoID: 1})
+	fast, _ := hub.subscribe(liveKey{condoID: 1})
+
+	for range liveBuffer + 1 {
+		hub.Publish(LiveEvent{Kind: LiveEntry, CondominiumID: 1})
+		if _, ok := receive(t, fast); !ok {
+			t.Fatal("a subscriber that keeps up missed an event")
+		}
+	}
+
+	for range liveBuffer {
+		if _, ok := <-slow.Events(); !ok {
+			t.Fatal("the buffered events of a dropped subscriber were lost")
+		}
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Error("a subscriber that fell behind wasn't dropped")
+	}
+	if n := len(hub.subs[liveKey{condoID: 1}]); n != 1 {
+		t.Errorf("hub keeps %d subscribers, want 1", n)
+	}
+}
+
+func TestHubMaxSubscribers(t *testing.T) {
+	hub := NewHub()
+	oldest, _ := hub.subscribe(liveKey{condoID: 1, userID: 7})
+	for range liveMaxSubscribers {
+		if _, err := hub.subscribe(liveKey{condoID: 1, userID: 7}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := <-oldest.Events(); ok {
+		t.Error("the oldest subscriber wasn't dropped to make room")
+	}
+	if n := len(hub.subs[liveKey{condoID: 1, userID: 7}]); n != liveMaxSubscribers {
+		t.Errorf("hub keeps %d subscribers, want %d", n, liveMaxSubscribers)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+	sub, _ := hub.subscribe(liveKey{condoID: 1})
+	sub.Close()
+	sub.Close()
+
+	other, _ := hub.subscribe(liveKey{condoID: 1})
+	hub.Close()
+	if _, ok := <-other.Events(); ok {
+		t.Error("subscription still open after closing the hub")
+	}
+	if len(hub.subs) != 0 {
+		t.Errorf("hub keeps %d keys after closing", len(hub.subs))
+	}
+	if _, err := hub.subscribe(liveKey{condoID: 1}); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("subscribe() = %v, want ErrHubClosed", err)
+	}
+	// Publishing once closed is harmless.
+	hub.Publish(LiveEvent{Kind: LiveEntry, CondominiumID: 1})
+}
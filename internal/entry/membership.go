@@ -0,0 +1,107 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type MembershipStore interface {
+	// MembershipCreate returns a UserSafeError if the user is already a
+	// member of the condominium.
+	MembershipCreate(
+		ctx context.Context,
+		userID int64,
+		membership Membership,
+		createdAt time.Time,
+		createdBy int64,
+	) error
+	// MembershipDelete returns a NotFoundError if the user isn't a member of
+	// the condominium, or if it is its home condominium.
+	MembershipDelete(ctx context.Context, userID int64, condoID int64) error
+}
+
+// AddMembership gives an existing user a role in one more condominium, so
+// that admins and guards can work for several condominiums with one account.
+func (a *App) AddMembership(
+	ctx context.Context, condoID int64, email string, role UserRole,
+) (*UserProfile, error) {
+	actor, err := RequirePermission(ctx, PermSystemManage)
+	if err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case RoleAdmin, RoleGuardian, RoleUser:
+	default:
+		return nil, NewUserSafeError("Rol inválido")
+	}
+
+	condo, err := a.store.CondoGetByID(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := a.store.UserGetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == RoleSuperAdmin {
+		return nil, NewUserSafeError("Los superadmins ya tienen acceso a todos los condominios")
+	}
+	if user.CondominiumID == condoID {
+		return nil, NewUserSafeError("El usuario ya pertenece a este condominio")
+	}
+
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.MembershipCreate(ctx, user.ID, Membership{
+			CondominiumID: condo.ID,
+			Role:          role,
+		}, time.Now(), actor.ID)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: condoID,
+			Level:         AuditImportant,
+			Action:        ActionUserChanged,
+			Message: fmt.Sprintf(
+				"Usuario %s agregado al condominio con el rol %s", user.Email, role,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RemoveMembership takes a user out of a condominium that isn't its home
+// condominium.
+func (a *App) RemoveMembership(ctx context.Context, condoID int64, userID int64) error {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return err
+	}
+
+	user, err := a.store.UserGetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CondominiumID == condoID {
+		return NewUserSafeError("No se puede quitar a un usuario de su condominio principal")
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.MembershipDelete(ctx, userID, condoID); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: condoID,
+			Level:         AuditImportant,
+			Action:        ActionUserChanged,
+			Message:       fmt.Sprintf("Usuario %s quitado del condominio", user.Email),
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPSender sends notifications by email. The connection is upgraded with
+// STARTTLS when the server offers it, which it must do to authenticate.
+type SMTPSender struct {
+	addr     string
+	username string
+	password string
+	from     mail.Address
+}
+
+// NewSMTPSender creates a sender for the server at addr, host:port. An
+// empty username sends without authenticating.
+func NewSMTPSender(addr, username, password, from string) (*SMTPSender, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid SMTP address: %w", err)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	return &SMTPSender{
+		addr:     addr,
+		username: username,
+		password: password,
+		from:     *sender,
+	}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, delivery *NotificationDelivery) error {
+	rcpt, err := mail.ParseAddress(delivery.Recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close() //nolint:errcheck
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	msg := emailMessage(s.from, *rcpt, delivery.Subject, delivery.Body, time.Now())
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// emailMessage formats a plain text email.
+func emailMessage(from, to mail.Address, subject, body string, date time.Time) []byte {
+	subject = strings.Join(strings.Fields(subject), " ")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// SMSGateway sends notifications by SMS through an HTTP gateway. Each
+// message is a POST of {"to": "<phone>", "message": "<text>"}, with the
+// token as a bearer token if there is one; any 2xx response means the
+// gateway took it.
+type SMSGateway struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSMSGateway creates a gateway. A nil client uses one that times out.
+func NewSMSGateway(url, token string, client *http.Client) *SMSGateway {
+	if client == nil {
+		client = &http.Client{Timeout: notificationTimeout}
+	}
+	return &SMSGateway{url: url, token: token, client: client}
+}
+
+type smsMessage struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+func (g *SMSGateway) Send(ctx context.Context, delivery *NotificationDelivery) error {
+	payload, err := json.Marshal(smsMessage{
+		To:      delivery.Recipient,
+		Message: delivery.Subject + ": " + delivery.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,655 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotificationEvent is something residents can be notified about.
+type NotificationEvent string
+
+const (
+	// NotifyVisitArrived is sent to the resident when its visitor checks in.
+	NotifyVisitArrived NotificationEvent = "visit.arrived"
+	// NotifyVisitUsedUp is sent when a check-in uses the last use of a
+	// visit.
+	NotifyVisitUsedUp NotificationEvent = "visit.used_up"
+	// NotifyWalkIn is sent when a guard announces a visitor without a
+	// visit, waiting at the gate.
+	NotifyWalkIn NotificationEvent = "walk_in.waiting"
+	// NotifyParcelArrived is sent when a guard receives a parcel for the
+	// resident, with the code to collect it.
+	NotifyParcelArrived NotificationEvent = "parcel.arrived"
+	// NotifyParcelCollected is sent when the parcel is handed over.
+	NotifyParcelCollected NotificationEvent = "parcel.collected"
+	// NotifyReservationDecided is sent when an admin approves, rejects or
+	// cancels a reservation of the resident.
+	NotifyReservationDecided NotificationEvent = "reservation.decided"
+	// NotifyIncidentCritical is sent to the admins when a guard reports a
+	// critical incident. It is urgent, so it isn't in NotificationEvents.
+	NotifyIncidentCritical NotificationEvent = "incident.critical"
+	// NotifyRoundMissed is sent to the admins when a patrol round is due and
+	// no checkpoint was scanned. It is urgent too.
+	NotifyRoundMissed NotificationEvent = "patrol.round_missed"
+	// NotifyWelfareMissed is sent to the admins when a guard doesn't confirm
+	// a welfare check in time, and again when they finally do. It is urgent.
+	NotifyWelfareMissed NotificationEvent = "welfare.missed"
+	// NotifyOccupancyDenied is sent to the admins when a check-in is denied
+	// because an occupancy limit was reached, so they can let the visitor
+	// in anyway. It is urgent.
+	NotifyOccupancyDenied NotificationEvent = "occupancy.denied"
+)
+
+// NotificationEvents lists every event, in the order they are shown.
+var NotificationEvents = []NotificationEvent{
+	NotifyVisitArrived,
+	NotifyVisitUsedUp,
+	NotifyWalkIn,
+	NotifyParcelArrived,
+	NotifyParcelCollected,
+	NotifyReservationDecided,
+}
+
+func (e NotificationEvent) String() string {
+	switch e {
+	case NotifyVisitArrived:
+		return "Llegó una visita"
+	case NotifyVisitUsedUp:
+		return "Una visita se quedó sin usos"
+	case NotifyWalkIn:
+		return "Una visita sin pase espera en la garita"
+	case NotifyParcelArrived:
+		return "Llegó un paquete"
+	case NotifyParcelCollected:
+		return "Se entregó un paquete"
+	case NotifyReservationDecided:
+		return "Se aprobó, rechazó o canceló una reserva"
+	case NotifyIncidentCritical:
+		return "Incidente crítico"
+	case NotifyRoundMissed:
+		return "Ronda omitida"
+	case NotifyWelfareMissed:
+		return "Control de bienestar sin respuesta"
+	case NotifyOccupancyDenied:
+		return "Ingreso denegado por ocupación"
+	default:
+		return string(e)
+	}
+}
+
+// NotificationChannel is a way of reaching a user.
+type NotificationChannel string
+
+const (
+	// ChannelInApp adds the notification to the inbox of the user.
+	ChannelInApp NotificationChannel = "inapp"
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+	// ChannelPush sends a Web Push notification to every browser the user
+	// subscribed.
+	ChannelPush NotificationChannel = "push"
+)
+
+// NotificationChannels lists every channel, in the order they are shown.
+var NotificationChannels = []NotificationChannel{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS}
+
+func (c NotificationChannel) String() string {
+	switch c {
+	case ChannelInApp:
+		return "En la aplicación"
+	case ChannelEmail:
+		return "Correo"
+	case ChannelSMS:
+		return "SMS"
+	case ChannelPush:
+		return "Navegador"
+	default:
+		return string(c)
+	}
+}
+
+// Notification is an entry of the inbox of a user.
+type Notification struct {
+	ID            int64
+	UserID        int64
+	CondominiumID int64
+	Event         NotificationEvent
+	Title         string
+	Body          string
+	CreatedAt     time.Time
+	// ReadAt is the zero time while the notification is unread.
+	ReadAt time.Time
+
+	// URL is the page that shows what the notification is about, and
+	// Actions the buttons of push notifications. Neither is stored in the
+	// inbox.
+	URL     string
+	Actions []NotificationAction
+	// Urgent notifications are sent through every channel right away,
+	// regardless of the preferences and quiet hours of the user.
+	Urgent bool
+}
+
+// NotificationAction is a button of a push notification. Clicking it posts
+// Form, URL encoded, to URL.
+type NotificationAction struct {
+	Action string `json:"action"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Form   string `json:"form"`
+}
+
+// NotificationPreferences are the channels a user is notified through for
+// each event, and the hours in which it doesn't want to be disturbed.
+type NotificationPreferences struct {
+	UserID   int64
+	Channels map[NotificationEvent][]NotificationChannel
+	// QuietHours holds browser, email and SMS notifications from QuietStart
+	// until QuietEnd, both minutes since midnight in the time zone of the
+	// server. QuietEnd may be before QuietStart, for quiet hours overnight.
+	QuietHours bool
+	QuietStart int64
+	QuietEnd   int64
+}
+
+// DefaultNotificationPreferences are the preferences of users that haven't
+// changed them: every event in the inbox, and nothing else.
+func DefaultNotificationPreferences(userID int64) *NotificationPreferences {
+	prefs := &NotificationPreferences{
+		UserID:   userID,
+		Channels: map[NotificationEvent][]NotificationChannel{},
+	}
+	for _, event := range NotificationEvents {
+		prefs.Channels[event] = []NotificationChannel{ChannelInApp}
+	}
+	return prefs
+}
+
+func (p *NotificationPreferences) Valid() error {
+	for event, channels := range p.Channels {
+		if !slices.Contains(NotificationEvents, event) {
+			return NewUserSafeError(fmt.Sprintf("Evento desconocido: %s", event))
+		}
+		for _, channel := range channels {
+			if !slices.Contains(NotificationChannels, channel) {
+				return NewUserSafeError(fmt.Sprintf("Canal desconocido: %s", channel))
+			}
+		}
+	}
+	if p.QuietHours {
+		if p.QuietStart < 0 || p.QuietStart >= 24*60 || p.QuietEnd < 0 || p.QuietEnd >= 24*60 {
+			return NewUserSafeError("Las horas de silencio son inválidas")
+		}
+		if p.QuietStart == p.QuietEnd {
+			return NewUserSafeError("Las horas de silencio deben empezar y terminar a distinta hora")
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the user wants to be notified of the event through
+// the channel.
+func (p *NotificationPreferences) Wants(event NotificationEvent, channel NotificationChannel) bool {
+	return slices.Contains(p.Channels[event], channel)
+}
+
+// QuietUntil returns when the quiet hours that t falls in end, and false if
+// t isn't in quiet hours.
+func (p *NotificationPreferences) QuietUntil(t time.Time) (time.Time, bool) {
+	if !p.QuietHours {
+		return time.Time{}, false
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	minute := int64(t.Sub(midnight) / time.Minute)
+	at := func(day time.Time, minutes int64) time.Time {
+		return time.Date(
+			day.Year(), day.Month(), day.Day(),
+			0, int(minutes), 0, 0, day.Location(),
+		)
+	}
+
+	if p.QuietStart < p.QuietEnd {
+		if minute >= p.QuietStart && minute < p.QuietEnd {
+			return at(midnight, p.QuietEnd), true
+		}
+		return time.Time{}, false
+	}
+
+	// Overnight, like 22:00 to 07:00.
+	switch {
+	case minute >= p.QuietStart:
+		return at(midnight.AddDate(0, 0, 1), p.QuietEnd), true
+	case minute < p.QuietEnd:
+		return at(midnight, p.QuietEnd), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// NotificationDelivery is a notification waiting to be sent, or already
+// sent, through email, SMS or Web Push.
+type NotificationDelivery struct {
+	ID      int64
+	UserID  int64
+	Channel NotificationChannel
+	// Recipient is the email address or phone number, as it was when the
+	// notification was created, or the ID of the push subscription.
+	Recipient     string
+	Subject       string
+	Body          string
+	URL           string
+	Actions       []NotificationAction
+	Status        WebhookDeliveryStatus
+	Attempts      int64
+	NextAttemptAt time.Time
+	// LastAttemptAt is the zero time until the first attempt.
+	LastAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+type NotificationStore interface {
+	NotificationCreate(ctx context.Context, n *Notification) (*Notification, error)
+	// NotificationList returns the newest notifications of the user.
+	NotificationList(ctx context.Context, userID int64, limit int64) ([]Notification, error)
+	NotificationUnreadCount(ctx context.Context, userID int64) (int64, error)
+	// NotificationMarkRead marks a notification of the user as read, or all
+	// of them if id is zero.
+	NotificationMarkRead(ctx context.Context, userID int64, id int64, at time.Time) error
+	// NotificationPreferencesGet returns DefaultNotificationPreferences if
+	// the user hasn't saved any.
+	NotificationPreferencesGet(ctx context.Context, userID int64) (*NotificationPreferences, error)
+	NotificationPreferencesSave(ctx context.Context, prefs *NotificationPreferences) error
+	NotificationDeliveryCreate(
+		ctx context.Context, d *NotificationDelivery,
+	) (*NotificationDelivery, error)
+	// NotificationDeliveryDue returns pending deliveries whose next attempt
+	// is not after now, oldest first.
+	NotificationDeliveryDue(
+		ctx context.Context, now time.Time, limit int64,
+	) ([]NotificationDelivery, error)
+	NotificationDeliveryUpdate(ctx context.Context, d *NotificationDelivery) error
+	PushSubscriptionStore
+}
+
+// NotificationSender sends notifications through a channel other than the
+// inbox.
+type NotificationSender interface {
+	Send(ctx context.Context, delivery *NotificationDelivery) error
+}
+
+// ErrRecipientGone is wrapped by the errors of senders whose recipient no
+// longer exists, like an expired push subscription. Those deliveries are
+// not retried.
+var ErrRecipientGone = errors.New("recipient is gone")
+
+const (
+	notificationMaxAttempts = 5
+	notificationRetryBase   = time.Minute
+	notificationTimeout     = 15 * time.Second
+	notificationBatchSize   = 50
+	notificationErrorLength = 500
+	notificationInboxSize   = 50
+)
+
+// Notifier routes notifications to the channels each user chose, and sends
+// the ones that don't go to the inbox in the background, with retries.
+type Notifier struct {
+	store   NotificationStore
+	users   UserStore
+	senders map[NotificationChannel]NotificationSender
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewNotifier(store NotificationStore, users UserStore, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		store:   store,
+		users:   users,
+		senders: map[NotificationChannel]NotificationSender{},
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Register enables a channel other than the inbox. Channels without a
+// sender are not offered to users.
+func (n *Notifier) Register(channel NotificationChannel, sender NotificationSender) {
+	n.senders[channel] = sender
+}
+
+// Channels lists the channels users can choose, the inbox first.
+func (n *Notifier) Channels() []NotificationChannel {
+	channels := []NotificationChannel{ChannelInApp}
+	for _, channel := range NotificationChannels {
+		if _, ok := n.senders[channel]; ok {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// Notify sends the notification to the user through the channels it chose
+// for the event. Email, SMS and push are only queued, and held until the
+// quiet hours of the user end.
+func (n *Notifier) Notify(ctx context.Context, notification Notification) error {
+	prefs, err := n.store.NotificationPreferencesGet(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	wants := func(channel NotificationChannel) bool {
+		return notification.Urgent || prefs.Wants(notification.Event, channel)
+	}
+
+	now := n.now()
+	notification.CreatedAt = now
+	if wants(ChannelInApp) {
+		if _, err := n.store.NotificationCreate(ctx, &notification); err != nil {
+			return err
+		}
+	}
+
+	var channels []NotificationChannel
+	for _, channel := range n.Channels() {
+		if channel != ChannelInApp && wants(channel) {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	user, err := n.users.UserGetByID(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	sendAt := now
+	if until, quiet := prefs.QuietUntil(now); quiet && !notification.Urgent {
+		sendAt = until
+	}
+
+	for _, channel := range channels {
+		recipients, err := n.recipients(ctx, user, channel)
+		if err != nil {
+			return err
+		}
+
+		for _, recipient := range recipients {
+			_, err := n.store.NotificationDeliveryCreate(ctx, &NotificationDelivery{
+				UserID:        user.ID,
+				Channel:       channel,
+				Recipient:     recipient,
+				Subject:       notification.Title,
+				Body:          notification.Body,
+				URL:           notification.URL,
+				Actions:       notification.Actions,
+				Status:        DeliveryPending,
+				NextAttemptAt: sendAt,
+				CreatedAt:     now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recipients returns where the user is reached through the channel, none if
+// it can't be.
+func (n *Notifier) recipients(
+	ctx context.Context, user *UserProfile, channel NotificationChannel,
+) ([]string, error) {
+	switch channel {
+	case ChannelEmail:
+		if strings.TrimSpace(user.Email) == "" {
+			return nil, nil
+		}
+		return []string{user.Email}, nil
+	case ChannelSMS:
+		if strings.TrimSpace(user.Phone) == "" {
+			return nil, nil
+		}
+		return []string{user.Phone}, nil
+	case ChannelPush:
+		subs, err := n.store.PushSubscriptionList(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		recipients := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			recipients = append(recipients, strconv.FormatInt(sub.ID, 10))
+		}
+		return recipients, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Run sends the due deliveries every interval until ctx is done.
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			n.logger.Error("Failed to send notifications", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes an attempt for every due delivery. Failed attempts are
+// saved in the delivery and retried later.
+func (n *Notifier) DeliverDue(ctx context.Context) error {
+	deliveries, err := n.store.NotificationDeliveryDue(ctx, n.now(), notificationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		n.attempt(ctx, &delivery)
+		if err := n.store.NotificationDeliveryUpdate(ctx, &delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) attempt(ctx context.Context, delivery *NotificationDelivery) {
+	now := n.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.LastError = ""
+
+	err := errors.New("channel is not configured")
+	if sender, ok := n.senders[delivery.Channel]; ok {
+		sendCtx, cancel := context.WithTimeout(ctx, notificationTimeout)
+		err = sender.Send(sendCtx, delivery)
+		cancel()
+	}
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > notificationErrorLength {
+		delivery.LastError = delivery.LastError[:notificationErrorLength]
+	}
+	if delivery.Attempts >= notificationMaxAttempts || errors.Is(err, ErrRecipientGone) {
+		delivery.Status = DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(notificationRetryBase << (delivery.Attempts - 1))
+	}
+
+	n.logger.Warn(
+		"Notification delivery failed",
+		"delivery_id", delivery.ID,
+		"channel", delivery.Channel,
+		"attempts", delivery.Attempts,
+		"error", err,
+	)
+}
+
+// Notifications returns the newest notifications of the user in ctx.
+func (a *App) Notifications(ctx context.Context) ([]Notification, error) {
+	user, err := RequirePermission(ctx, PermNotificationsReceive)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.NotificationList(ctx, user.ID, notificationInboxSize)
+}
+
+// UnreadNotifications counts the unread notifications of the user in ctx.
+func (a *App) UnreadNotifications(ctx context.Context) (int64, error) {
+	user, err := RequirePermission(ctx, PermNotificationsReceive)
+	if err != nil {
+		return 0, err
+	}
+	return a.store.NotificationUnreadCount(ctx, user.ID)
+}
+
+// MarkNotificationRead marks a notification of the user in ctx as read, or
+// all of them if id is zero.
+func (a *App) MarkNotificationRead(ctx context.Context, id int64) error {
+	user, err := RequirePermission(ctx, PermNotificationsReceive)
+	if err != nil {
+		return err
+	}
+	return a.store.NotificationMarkRead(ctx, user.ID, id, time.Now())
+}
+
+// NotificationPreferences returns the preferences of the user in ctx, and
+// the channels it can choose from.
+func (a *App) NotificationPreferences(
+	ctx context.Context,
+) (*NotificationPreferences, []NotificationChannel, error) {
+	user, err := RequirePermission(ctx, PermNotificationsReceive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefs, err := a.store.NotificationPreferencesGet(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prefs, a.notifier.Channels(), nil
+}
+
+func (a *App) SaveNotificationPreferences(
+	ctx context.Context, prefs NotificationPreferences,
+) error {
+	user, err := RequirePermission(ctx, PermNotificationsReceive)
+	if err != nil {
+		return err
+	}
+
+	prefs.UserID = user.ID
+	if err := prefs.Valid(); err != nil {
+		return err
+	}
+	available := a.notifier.Channels()
+	for _, channels := range prefs.Channels {
+		for _, channel := range channels {
+			if !slices.Contains(available, channel) {
+				return NewUserSafeError(fmt.Sprintf("El canal %s no está disponible", channel))
+			}
+		}
+	}
+
+	return a.store.NotificationPreferencesSave(ctx, &prefs)
+}
+
+// Residents lists the residents of the guard's condominium, those who can
+// invite visitors, to pick the one a visitor without a visit asks for, or a
+// parcel is for.
+func (a *App) Residents(ctx context.Context) ([]UserProfile, error) {
+	guard, err := RequireAnyPermission(
+		ctx, PermEntriesRecord, PermParcelsReceive, PermIncidentsReport,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := a.condoUsersWith(ctx, guard.CondominiumID, PermVisitsCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	residents := make([]UserProfile, 0, len(users))
+	for _, u := range users {
+		if !u.Hidden {
+			residents = append(residents, u)
+		}
+	}
+	return residents, nil
+}
+
+// condoResident returns a resident of the guard's condominium that holds
+// perm, or a NotFoundError if the user isn't one or is disabled.
+func (a *App) condoResident(
+	ctx context.Context, guard *User, residentID int64, perm Permission,
+) (*UserProfile, error) {
+	resident, err := a.store.UserGetByID(ctx, residentID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) || (err == nil && (resident.CondominiumID != guard.CondominiumID ||
+		!resident.Enabled)) {
+		return nil, NewNotFoundError("Residente no encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	perms, err := a.rolePermissions(ctx, guard.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(perms[resident.Role], perm) {
+		return nil, NewNotFoundError("Residente no encontrado")
+	}
+	return resident, nil
+}
+
+// notifyCheckIn tells the resident that created the visit that its visitor
+// arrived, and whether the visit has no uses left.
+func (a *App) notifyCheckIn(ctx context.Context, visit *Visit, entry *Entry, usedUp bool) error {
+	err := a.notifier.Notify(ctx, Notification{
+		UserID:        visit.UserID,
+		CondominiumID: visit.CondominiumID,
+		Event:         NotifyVisitArrived,
+		Title:         "Llegó tu visita",
+		Body: fmt.Sprintf(
+			"%s ingresó a las %s.", visit.VisitorName, entry.CreatedAt.Format("15:04"),
+		),
+		URL: "/neighbor/visits",
+	})
+	if err != nil || !usedUp {
+		return err
+	}
+
+	return a.notifier.Notify(ctx, Notification{
+		UserID:        visit.UserID,
+		CondominiumID: visit.CondominiumID,
+		Event:         NotifyVisitUsedUp,
+		Title:         "Pase agotado",
+		Body: fmt.Sprintf(
+			"El pase de %s ya no tiene usos disponibles.", visit.VisitorName,
+		),
+		URL: "/neighbor/visits",
+	})
+}
@@ -0,0 +1,405 @@
+package entry
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memNotificationStore keeps notifications, preferences and the outbox in
+// memory.
+type memNotificationStore struct {
+	notifications []Notification
+	prefs         map[int64]*NotificationPreferences
+	deliveries    []NotificationDelivery
+	subs          []PushSubscription
+	vapidKey      []byte
+}
+
+func (s *memNotificationStore) NotificationCreate(
+	_ context.Context, n *Notification,
+) (*Notification, error) {
+	n.ID = int64(len(s.notifications) + 1)
+	s.notifications = append(s.notifications, *n)
+	return n, nil
+}
+
+func (s *memNotificationStore) NotificationList(
+	context.Context, int64, int64,
+) ([]Notification, error) {
+	return s.notifications, nil
+}
+
+func (s *memNotificationStore) NotificationUnreadCount(context.Context, int64) (int64, error) {
+	return int64(len(s.notifications)), nil
+}
+
+func (s *memNotificationStore) NotificationMarkRead(
+	context.Context, int64, int64, time.Time,
+) error {
+	return nil
+}
+
+func (s *memNotificationStore) NotificationPreferencesGet(
+	_ context.Context, userID int64,
+) (*NotificationPreferences, error) {
+	if prefs, ok := s.prefs[userID]; ok {
+		return prefs, nil
+	}
+	return DefaultNotificationPreferences(userID), nil
+}
+
+func (s *memNotificationStore) NotificationPreferencesSave(
+	_ context.Context, prefs *NotificationPreferences,
+) error {
+	s.prefs[prefs.UserID] = prefs
+	return nil
+}
+
+func (s *memNotificationStore) NotificationDeliveryCreate(
+	_ context.Context, d *NotificationDelivery,
+) (*NotificationDelivery, error) {
+	d.ID = int64(len(s.deliveries) + 1)
+	s.deliveries = append(s.deliveries, *d)
+	return d, nil
+}
+
+func (s *memNotificationStore) NotificationDeliveryDue(
+	_ context.Context, now time.Time, _ int64,
+) ([]NotificationDelivery, error) {
+	var due []NotificationDelivery
+	for _, d := range s.deliveries {
+		if d.Status == DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (s *memNotificationStore) NotificationDeliveryUpdate(
+	_ context.Context, d *NotificationDelivery,
+) error {
+	s.deliveries[d.ID-1] = *d
+	return nil
+}
+
+func (s *memNotificationStore) PushSubscriptionList(
+	_ context.Context, userID int64,
+) ([]PushSubscription, error) {
+	var subs []PushSubscription
+	for _, sub := range s.subs {
+		if sub.UserID == userID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (s *memNotificationStore) PushSubscriptionGetByID(
+	_ context.Context, id int64,
+) (*PushSubscription, error) {
+	for _, sub := range s.subs {
+		if sub.ID == id {
+			return &sub, nil
+		}
+	}
+	return nil, NewNotFoundError("Dispositivo no encontrado")
+}
+
+func (s *memNotificationStore) PushSubscriptionSave(
+	_ context.Context, sub *PushSubscription,
+) (*PushSubscription, error) {
+	for i := range s.subs {
+		if s.subs[i].Endpoint == sub.Endpoint {
+			sub.ID = s.subs[i].ID
+			s.subs[i] = *sub
+			return sub, nil
+		}
+	}
+	sub.ID = int64(len(s.subs) + 1)
+	s.subs = append(s.subs, *sub)
+	return sub, nil
+}
+
+func (s *memNotificationStore) PushSubscriptionDelete(_ context.Context, id int64) error {
+	s.subs = slices.DeleteFunc(s.subs, func(sub PushSubscription) bool { return sub.ID == id })
+	return nil
+}
+
+func (s *memNotificationStore) VAPIDKeyGet(context.Context) ([]byte, error) {
+	if s.vapidKey == nil {
+		return nil, NewNotFoundError("No hay clave VAPID")
+	}
+	return s.vapidKey, nil
+}
+
+func (s *memNotificationStore) VAPIDKeySave(_ context.Context, key []byte, _ time.Time) error {
+	if s.vapidKey == nil {
+		s.vapidKey = key
+	}
+	return nil
+}
+
+// memUsers is a UserStore with fixed users.
+type memUsers map[int64]*UserProfile
+
+func (u memUsers) UserGetByID(_ context.Context, id int64) (*UserProfile, error) {
+	if user, ok := u[id]; ok {
+		return user, nil
+	}
+	return nil, NewNotFoundError("Usuario no encontrado")
+}
+
+func (u memUsers) UserGetByEmail(context.Context, string) (*UserProfile, error) {
+	return nil, NewNotFoundError("Usuario no encontrado")
+}
+
+func (u memUsers) UserListByCondo(context.Context, int64) ([]UserProfile, error) {
+	return nil, nil
+}
+
+func (u memUsers) UserUpdate(
+	context.Context, int64, func(*UserProfile) (*UserProfile, error),
+) error {
+	return nil
+}
+
+// fakeSMSGateway is an SMS gateway that answers with status and records
+// the messages it receives.
+type fakeSMSGateway struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	auth     []string
+	messages []smsMessage
+}
+
+func newFakeSMSGateway(t *testing.T, status int) *fakeSMSGateway {
+	gw := &fakeSMSGateway{status: status}
+	gw.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg smsMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gw.mu.Lock()
+		gw.auth = append(gw.auth, r.Header.Get("Authorization"))
+		gw.messages = append(gw.messages, msg)
+		gw.mu.Unlock()
+		w.WriteHeader(gw.status)
+	}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func newTestNotifier(
+	store *memNotificationStore, gw *fakeSMSGateway, now *time.Time,
+) *Notifier {
+	users := memUsers{
+		1: {ID: 1, CondominiumID: 1, Email: "vecina@example.com", Phone: "+50255550101"},
+		2: {ID: 2, CondominiumID: 1, Email: "vecino@example.com"},
+	}
+	n := NewNotifier(store, users, slog.New(slog.DiscardHandler))
+	n.Register(ChannelSMS, NewSMSGateway(gw.URL, "sms-token", gw.Client()))
+	n.now = func() time.Time { return *now }
+	return n
+}
+
+func TestNotifierRoutes(t *testing.T) {
+	ctx := context.Background()
+	gw := newFakeSMSGateway(t, http.StatusAccepted)
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{
+			NotifyVisitArrived: {ChannelSMS, ChannelEmail},
+			NotifyWalkIn:       {ChannelInApp},
+		}},
+	}}
+	notifier := newTestNotifier(store, gw, &now)
+
+	err := notifier.Notify(ctx, Notification{
+		UserID: 1, CondominiumID: 1, Event: NotifyVisitArrived,
+		Title: "Llegó tu visita", Body: "Luis ingresó a las 15:00.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only SMS: the user left the inbox out, and email isn't registered.
+	if len(store.notifications) != 0 {
+		t.Errorf("inbox = %+v, want empty", store.notifications)
+	}
+	if len(store.deliveries) != 1 || store.deliveries[0].Channel != ChannelSMS ||
+		store.deliveries[0].Recipient != "+50255550101" {
+		t.Fatalf("deliveries = %+v, want one SMS", store.deliveries)
+	}
+
+	if err := notifier.Notify(ctx, Notification{UserID: 1, Event: NotifyWalkIn, Title: "Te buscan"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.notifications) != 1 || len(store.deliveries) != 1 {
+		t.Errorf("inbox = %d, deliveries = %d; want the walk-in in the inbox only",
+			len(store.notifications), len(store.deliveries))
+	}
+
+	// Users without preferences get the inbox only.
+	if err := notifier.Notify(ctx, Notification{UserID: 2, Event: NotifyVisitUsedUp}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.notifications) != 2 || len(store.deliveries) != 1 {
+		t.Errorf("inbox = %d, deliveries = %d; want the default preferences",
+			len(store.notifications), len(store.deliveries))
+	}
+
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := store.deliveries[0]; d.Status != DeliveryDelivered || d.Attempts != 1 {
+		t.Errorf("delivery = %+v, want delivered", d)
+	}
+	if len(gw.messages) != 1 {
+		t.Fatalf("gateway got %d messages, want 1", len(gw.messages))
+	}
+	want := smsMessage{To: "+50255550101", Message: "Llegó tu visita: Luis ingresó a las 15:00."}
+	if gw.messages[0] != want || gw.auth[0] != "Bearer sms-token" {
+		t.Errorf("gateway got %+v with %q, want %+v", gw.messages[0], gw.auth[0], want)
+	}
+}
+
+func TestNotifierQuietHours(t *testing.T) {
+	ctx := context.Background()
+	gw := newFakeSMSGateway(t, http.StatusOK)
+	now := time.Date(2026, 10, 19, 23, 30, 0, 0, time.Local)
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{
+			NotifyWalkIn: {ChannelInApp, ChannelSMS},
+		}, QuietHours: true, QuietStart: 22 * 60, QuietEnd: 7 * 60},
+	}}
+	notifier := newTestNotifier(store, gw, &now)
+
+	if err := notifier.Notify(ctx, Notification{UserID: 1, Event: NotifyWalkIn}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.notifications) != 1 {
+		t.Error("the inbox should get notifications during quiet hours")
+	}
+	wantAt := time.Date(2026, 10, 20, 7, 0, 0, 0, time.Local)
+	if at := store.deliveries[0].NextAttemptAt; !at.Equal(wantAt) {
+		t.Fatalf("SMS held until %v, want %v", at, wantAt)
+	}
+
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(gw.messages) != 0 {
+		t.Fatal("SMS sent during quiet hours")
+	}
+
+	now = wantAt
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(gw.messages) != 1 {
+		t.Errorf("gateway got %d messages after quiet hours, want 1", len(gw.messages))
+	}
+}
+
+func TestNotifierUrgent(t *testing.T) {
+	ctx := context.Background()
+	gw := newFakeSMSGateway(t, http.StatusOK)
+	now := time.Date(2026, 10, 19, 23, 30, 0, 0, time.Local)
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{},
+			QuietHours: true, QuietStart: 22 * 60, QuietEnd: 7 * 60},
+	}}
+	notifier := newTestNotifier(store, gw, &now)
+
+	err := notifier.Notify(ctx, Notification{
+		UserID: 1, Event: NotifyIncidentCritical, Title: "Incidente crítico", Urgent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.notifications) != 1 {
+		t.Error("urgent notifications should reach the inbox regardless of the preferences")
+	}
+	if len(store.deliveries) != 1 || !store.deliveries[0].NextAttemptAt.Equal(now) {
+		t.Fatalf("deliveries = %+v, want one SMS right away", store.deliveries)
+	}
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(gw.messages) != 1 {
+		t.Errorf("gateway got %d messages during quiet hours, want 1", len(gw.messages))
+	}
+}
+
+func TestNotifierRetries(t *testing.T) {
+	ctx := context.Background()
+	gw := newFakeSMSGateway(t, http.StatusServiceUnavailable)
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{
+			NotifyWalkIn: {ChannelSMS},
+		}},
+	}}
+	notifier := newTestNotifier(store, gw, &now)
+
+	if err := notifier.Notify(ctx, Notification{UserID: 1, Event: NotifyWalkIn}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d := store.deliveries[0]
+	if d.Status != DeliveryPending || d.LastError == "" ||
+		!d.NextAttemptAt.Equal(now.Add(notificationRetryBase)) {
+		t.Fatalf("delivery = %+v, want a retry in %v", d, notificationRetryBase)
+	}
+
+	for store.deliveries[0].Status == DeliveryPending {
+		now = store.deliveries[0].NextAttemptAt
+		if err := notifier.DeliverDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := store.deliveries[0]; d.Status != DeliveryFailed || d.Attempts != notificationMaxAttempts {
+		t.Errorf("delivery = %+v, want failed after %d attempts", d, notificationMaxAttempts)
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := &NotificationPreferences{QuietHours: true, QuietStart: 22 * 60, QuietEnd: 7 * 60}
+	afternoon := &NotificationPreferences{QuietHours: true, QuietStart: 13 * 60, QuietEnd: 15 * 60}
+
+	tests := []struct {
+		name  string
+		prefs *NotificationPreferences
+		t     time.Time
+		want  time.Time
+	}{
+		{"overnight, before midnight", overnight, day(23, 0), day(7, 0).AddDate(0, 0, 1)},
+		{"overnight, after midnight", overnight, day(3, 0), day(7, 0)},
+		{"overnight, at the end", overnight, day(7, 0), time.Time{}},
+		{"overnight, daytime", overnight, day(12, 0), time.Time{}},
+		{"afternoon, inside", afternoon, day(14, 59), day(15, 0)},
+		{"afternoon, before", afternoon, day(12, 59), time.Time{}},
+		{"disabled", &NotificationPreferences{QuietStart: 0, QuietEnd: 23 * 60}, day(12, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, quiet := tt.prefs.QuietUntil(tt.t)
+			if quiet != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Errorf("QuietUntil(%v) = %v, %v; want %v", tt.t, got, quiet, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package entry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Occupant is a visitor inside a condominium: they checked in, no guard
+// recorded their exit, and their visit didn't end.
+type Occupant struct {
+	Entry
+	// ResidentID is the resident whose visit let them in.
+	ResidentID   int64
+	ResidentName string
+	// Unit is the unit the resident lives in, zero if it isn't set or they
+	// don't live in the condominium.
+	Unit Unit
+}
+
+// household is who the limit of visitors per unit applies to: the unit,
+// or the resident for residents without one.
+type household struct {
+	unit       Unit
+	residentID int64
+}
+
+func householdOf(residentID int64, unit Unit) household {
+	if unit.IsZero() {
+		return household{residentID: residentID}
+	}
+	return household{unit: unit}
+}
+
+// Occupancy is who is inside a condominium, and how many visitors it lets
+// in.
+type Occupancy struct {
+	// MaxVisitors and MaxVisitorsPerUnit are the limits of the condominium,
+	// zero for no limit.
+	MaxVisitors        int64
+	MaxVisitorsPerUnit int64
+	Inside             []Occupant
+}
+
+// UnitOccupancy is how many visitors are inside for a unit, or for a
+// resident without one.
+type UnitOccupancy struct {
+	// Name is the unit, or the name of the resident.
+	Name     string
+	Visitors int64
+}
+
+// Units lists the units with visitors inside, the ones with the most
+// first.
+func (o *Occupancy) Units() []UnitOccupancy {
+	byHousehold := map[household]*UnitOccupancy{}
+	var units []*UnitOccupancy
+	for _, occupant := range o.Inside {
+		h := householdOf(occupant.ResidentID, occupant.Unit)
+		unit, ok := byHousehold[h]
+		if !ok {
+			unit = &UnitOccupancy{Name: occupant.ResidentName}
+			if !occupant.Unit.IsZero() {
+				unit.Name = occupant.Unit.String()
+			}
+			byHousehold[h] = unit
+			units = append(units, unit)
+		}
+		unit.Visitors++
+	}
+
+	sorted := make([]UnitOccupancy, 0, len(units))
+	for _, unit := range units {
+		sorted = append(sorted, *unit)
+	}
+	slices.SortStableFunc(sorted, func(a, b UnitOccupancy) int {
+		return cmp.Compare(b.Visitors, a.Visitors)
+	})
+	return sorted
+}
+
+// Full tells whether the condominium reached its limit of visitors.
+func (o *Occupancy) Full() bool {
+	return o.MaxVisitors > 0 && int64(len(o.Inside)) >= o.MaxVisitors
+}
+
+// DenialReason returns why one more visitor of the resident, who lives in
+// unit, can't come in, or an empty string if they can.
+func (o *Occupancy) DenialReason(residentID int64, residentName string, unit Unit) string {
+	if o.Full() {
+		return fmt.Sprintf(
+			"El condominio ya tiene %d visitantes adentro, el máximo es %d",
+			len(o.Inside), o.MaxVisitors,
+		)
+	}
+	if o.MaxVisitorsPerUnit <= 0 {
+		return ""
+	}
+
+	h := householdOf(residentID, unit)
+	var visitors int64
+	for _, occupant := range o.Inside {
+		if householdOf(occupant.ResidentID, occupant.Unit) == h {
+			visitors++
+		}
+	}
+	if visitors < o.MaxVisitorsPerUnit {
+		return ""
+	}
+	if unit.IsZero() {
+		return fmt.Sprintf(
+			"%s ya tiene %d visitantes adentro, el máximo por unidad es %d",
+			residentName, visitors, o.MaxVisitorsPerUnit,
+		)
+	}
+	return fmt.Sprintf(
+		"La unidad %s ya tiene %d visitantes adentro, el máximo es %d",
+		unit, visitors, o.MaxVisitorsPerUnit,
+	)
+}
+
+const overrideReasonLength = 500
+
+type OccupancyStore interface {
+	// EntryGetByID returns a NotFoundError if the entry doesn't exist.
+	EntryGetByID(ctx context.Context, id int64) (*Entry, error)
+	// EntryListInside lists the occupants of the condominium at now, oldest
+	// first.
+	EntryListInside(ctx context.Context, condoID int64, now time.Time) ([]Occupant, error)
+	// EntryExit records the exit of the visitor of an accepted entry. It
+	// returns a NotFoundError if the entry wasn't accepted or they already
+	// left.
+	EntryExit(ctx context.Context, id int64, by int64, at time.Time) error
+	// EntryListOverridable lists the OverLimit entries of the condominium
+	// since since that weren't overridden, newest first.
+	EntryListOverridable(ctx context.Context, condoID int64, since time.Time) ([]Entry, error)
+	// EntryOverridden tells whether an admin already let in the visitor of
+	// an OverLimit entry.
+	EntryOverridden(ctx context.Context, id int64) (bool, error)
+}
+
+// occupancy returns who is inside the condominium at now.
+func (a *App) occupancy(ctx context.Context, condo *Condominium, now time.Time) (*Occupancy, error) {
+	inside, err := a.store.EntryListInside(ctx, condo.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &Occupancy{
+		MaxVisitors:        condo.MaxVisitors,
+		MaxVisitorsPerUnit: condo.MaxVisitorsPerUnit,
+		Inside:             inside,
+	}, nil
+}
+
+// occupancyDenial returns why the visitor of the visit can't come in at
+// now because of the occupancy limits of its condominium, or an empty
+// string if they can.
+func (a *App) occupancyDenial(ctx context.Context, visit *Visit, now time.Time) (string, error) {
+	condo, err := a.store.CondoGetByID(ctx, visit.CondominiumID)
+	if err != nil {
+		return "", err
+	}
+	if condo.MaxVisitors == 0 && condo.MaxVisitorsPerUnit == 0 {
+		return "", nil
+	}
+
+	occupancy, err := a.occupancy(ctx, condo, now)
+	if err != nil {
+		return "", err
+	}
+	// Checking in again with the same visit ends its last entry, so its
+	// visitor doesn't take a second place.
+	occupancy.Inside = slices.DeleteFunc(occupancy.Inside, func(o Occupant) bool {
+		return o.VisitID == visit.ID
+	})
+	resident, err := a.store.UserGetByID(ctx, visit.UserID)
+	if err != nil {
+		return "", err
+	}
+	return occupancy.DenialReason(
+		resident.ID, resident.FullName(), condoUnit(resident, condo.ID),
+	), nil
+}
+
+// Occupancy returns who is inside the guard's condominium. Superadmins
+// have no condominium, so nobody is inside for them.
+func (a *App) Occupancy(ctx context.Context) (*Occupancy, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+	if guard.CondominiumID == 0 {
+		return &Occupancy{}, nil
+	}
+	condo, err := a.store.CondoGetByID(ctx, guard.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	return a.occupancy(ctx, condo, time.Now())
+}
+
+// AdminOccupancy returns who is inside the admin's condominium, and the
+// check-ins denied today because of its limits that the admin can
+// override.
+func (a *App) AdminOccupancy(ctx context.Context) (*Occupancy, []Entry, error) {
+	admin, err := RequirePermission(ctx, PermOccupancyManage)
+	if err != nil {
+		return nil, nil, err
+	}
+	condo, err := a.store.CondoGetByID(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	occupancy, err := a.occupancy(ctx, condo, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	denied, err := a.store.EntryListOverridable(ctx, condo.ID, startOfDay(now))
+	if err != nil {
+		return nil, nil, err
+	}
+	return occupancy, denied, nil
+}
+
+// SetOccupancyLimits changes how many visitors can be inside the admin's
+// condominium at the same time, and how many of them for the same unit.
+// Zero removes a limit.
+func (a *App) SetOccupancyLimits(ctx context.Context, maxVisitors, maxPerUnit int64) error {
+	admin, err := RequirePermission(ctx, PermOccupancyManage)
+	if err != nil {
+		return err
+	}
+	if maxVisitors < 0 || maxPerUnit < 0 {
+		return NewUserSafeError("Los límites no pueden ser negativos")
+	}
+	if maxVisitors > 0 && maxPerUnit > maxVisitors {
+		return NewUserSafeError("El máximo por unidad no puede superar el del condominio")
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.CondoUpdate(ctx, admin.CondominiumID, func(c *Condominium) (*Condominium, error) {
+			c.MaxVisitors = maxVisitors
+			c.MaxVisitorsPerUnit = maxPerUnit
+			c.UpdatedAt = time.Now()
+			c.UpdatedBy = admin.ID
+			return c, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: admin.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionOccupancyChanged,
+			Message: fmt.Sprintf(
+				"Límites de ocupación: %s en el condominio, %s por unidad",
+				occupancyLimit(maxVisitors), occupancyLimit(maxPerUnit),
+			),
+		})
+	})
+}
+
+// occupancyLimit describes a limit of visitors.
+func occupancyLimit(limit int64) string {
+	if limit == 0 {
+		return "sin límite"
+	}
+	return fmt.Sprintf("%d visitantes", limit)
+}
+
+// RecordExit records that the visitor of an accepted entry of the guard's
+// condominium left.
+func (a *App) RecordExit(ctx context.Context, entryID int64) error {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return err
+	}
+
+	entry, err := a.store.EntryGetByID(ctx, entryID)
+	if err != nil {
+		return err
+	}
+	if !guard.Can(PermEntriesRecord, entry.CondominiumID) {
+		return NewNotFoundError("Ingreso no encontrado")
+	}
+	if !entry.Accepted {
+		return NewUserSafeError("El ingreso fue denegado")
+	}
+	if !entry.ExitedAt.IsZero() {
+		return NewUserSafeError("La salida ya fue registrada")
+	}
+
+	now := time.Now()
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.EntryExit(ctx, entry.ID, guard.ID, now); err != nil {
+			return err
+		}
+		entry.ExitedAt = now
+		entry.ExitedBy = guard.ID
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: entry.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionCheckOut,
+			Message: fmt.Sprintf(
+				"Salida de %s (código %s)", entry.VisitorName, entry.VisitID,
+			),
+			Entry: entry,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	a.live.Publish(LiveEvent{
+		Kind:          LiveEntry,
+		CondominiumID: entry.CondominiumID,
+		Entry:         entry,
+	})
+	return nil
+}
+
+// OverrideOccupancy lets in the visitor of a check-in of the admin's
+// condominium that was denied because an occupancy limit was reached. The
+// justification is required and kept in the new entry and the audit log.
+// The visit must still be usable, only the limits are overridden. No gate
+// is opened, the guards are told to let the visitor in.
+func (a *App) OverrideOccupancy(
+	ctx context.Context, entryID int64, justification string,
+) (*Entry, error) {
+	admin, err := RequirePermission(ctx, PermOccupancyManage)
+	if err != nil {
+		return nil, err
+	}
+
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return nil, NewUserSafeError("La justificación es obligatoria")
+	}
+	if utf8.RuneCountInString(justification) > overrideReasonLength {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"La justificación no puede tener más de %d caracteres", overrideReasonLength,
+		))
+	}
+
+	denied, err := a.store.EntryGetByID(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if denied.CondominiumID != admin.CondominiumID {
+		return nil, NewNotFoundError("Ingreso no encontrado")
+	}
+	if !denied.OverLimit {
+		return nil, NewUserSafeError("El ingreso no fue denegado por la ocupación")
+	}
+
+	now := time.Now()
+	visit := &Visit{}
+	usedUp := false
+	var entry *Entry
+	var notFound *NotFoundError
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		overridden, err := a.store.EntryOverridden(ctx, denied.ID)
+		if err != nil {
+			return err
+		}
+		if overridden {
+			return NewUserSafeError("El ingreso ya fue autorizado")
+		}
+
+		err = a.store.VisitUpdate(ctx, denied.VisitID, func(v *Visit) (*Visit, error) {
+			if reason := v.DenialReason(now); reason != "" {
+				return nil, NewUserSafeError(reason)
+			}
+			v.Uses++
+			v.UpdatedAt = now
+			usedUp = v.MaxUses > 0 && v.Uses >= v.MaxUses
+			visit = v
+			return v, nil
+		})
+		if errors.As(err, &notFound) {
+			return NewUserSafeError("La visita ya no existe")
+		}
+		if err != nil {
+			return err
+		}
+
+		entry, err = a.store.EntryCreate(ctx, &Entry{
+			CondominiumID:  denied.CondominiumID,
+			VisitID:        visit.ID,
+			GuardID:        admin.ID,
+			VisitorName:    visit.VisitorName,
+			Accepted:       true,
+			StationID:      denied.StationID,
+			OverrideOf:     denied.ID,
+			OverrideReason: justification,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: entry.CondominiumID,
+			Level:         AuditCritical,
+			Action:        ActionOccupancyOverride,
+			Message: fmt.Sprintf(
+				"Ingreso de %s (código %s) autorizado sobre el límite de ocupación (%s). Justificación: %s",
+				entry.VisitorName, entry.VisitID, denied.Reason, justification,
+			),
+			Entry: entry,
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.publishWebhookEvent(ctx, entry.CondominiumID, EventEntryAccepted, newWebhookEntry(entry))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The admin isn't at the gate, so it isn't opened from here: the guards
+	// are told to let the visitor in.
+	a.arrived(ctx, visit, entry, usedUp)
+	a.live.Publish(LiveEvent{
+		Kind:          LiveAlert,
+		CondominiumID: entry.CondominiumID,
+		Message: fmt.Sprintf(
+			"La administración autorizó el ingreso de %s sobre el límite de ocupación, déjalo pasar: %s",
+			entry.VisitorName, justification,
+		),
+	})
+	a.live.Publish(LiveEvent{
+		Kind:          LiveEntry,
+		CondominiumID: entry.CondominiumID,
+		Entry:         entry,
+	})
+	return entry, nil
+}
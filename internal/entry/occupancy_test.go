@@ -0,0 +1,58 @@
+package entry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOccupancyDenialReason(t *testing.T) {
+	a101 := Unit{Tower: "A", Number: "101"}
+	a102 := Unit{Tower: "A", Number: "102"}
+	inside := []Occupant{
+		{ResidentID: 1, ResidentName: "Vero Vecina", Unit: a101},
+		{ResidentID: 2, ResidentName: "Beto Vecino", Unit: a101},
+		{ResidentID: 3, ResidentName: "Sin Unidad"},
+	}
+
+	tests := []struct {
+		name      string
+		occupancy Occupancy
+		resident  int64
+		unit      Unit
+		denied    bool
+	}{
+		{"no limits", Occupancy{Inside: inside}, 1, a101, false},
+		{"condominium full", Occupancy{MaxVisitors: 3, Inside: inside}, 4, a102, true},
+		{"condominium with room", Occupancy{MaxVisitors: 4, Inside: inside}, 4, a102, false},
+		{"unit full", Occupancy{MaxVisitorsPerUnit: 2, Inside: inside}, 1, a101, true},
+		{"unit full for another resident", Occupancy{MaxVisitorsPerUnit: 2, Inside: inside}, 5, a101, true},
+		{"other unit", Occupancy{MaxVisitorsPerUnit: 2, Inside: inside}, 4, a102, false},
+		{"resident without unit full", Occupancy{MaxVisitorsPerUnit: 1, Inside: inside}, 3, Unit{}, true},
+		{"residents without unit apart", Occupancy{MaxVisitorsPerUnit: 1, Inside: inside}, 6, Unit{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.occupancy.DenialReason(tt.resident, "Residente", tt.unit)
+			if got := reason != ""; got != tt.denied {
+				t.Errorf("DenialReason() = %q, want denied %v", reason, tt.denied)
+			}
+		})
+	}
+}
+
+func TestOccupancyUnits(t *testing.T) {
+	occupancy := Occupancy{Inside: []Occupant{
+		{ResidentID: 3, ResidentName: "Sin Unidad"},
+		{ResidentID: 1, Unit: Unit{Tower: "A", Number: "101"}},
+		{ResidentID: 2, Unit: Unit{Tower: "A", Number: "101"}},
+	}}
+
+	want := []UnitOccupancy{
+		{Name: Unit{Tower: "A", Number: "101"}.String(), Visitors: 2},
+		{Name: "Sin Unidad", Visitors: 1},
+	}
+	if got := occupancy.Units(); !slices.Equal(got, want) {
+		t.Errorf("Units() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,553 @@
+package entry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParcelSize is roughly how big a parcel is, so the guard knows what to
+// look for on the shelves.
+type ParcelSize string
+
+const (
+	ParcelSmall  ParcelSize = "small"
+	ParcelMedium ParcelSize = "medium"
+	ParcelLarge  ParcelSize = "large"
+)
+
+// ParcelSizes lists every size, in the order they are shown.
+var ParcelSizes = []ParcelSize{ParcelSmall, ParcelMedium, ParcelLarge}
+
+func (s ParcelSize) String() string {
+	switch s {
+	case ParcelSmall:
+		return "Pequeño"
+	case ParcelMedium:
+		return "Mediano"
+	case ParcelLarge:
+		return "Grande"
+	default:
+		return string(s)
+	}
+}
+
+// Parcel is a package received at the guardhouse for a resident, a unit,
+// or a resident of a unit.
+type Parcel struct {
+	ID            int64
+	CondominiumID int64
+	// ResidentID is zero for parcels addressed only to the unit.
+	ResidentID   int64
+	ResidentName string
+	// Unit is zero for parcels of a resident that has no unit set. Every
+	// resident of the unit can collect the parcel.
+	Unit           Unit
+	Carrier        string
+	TrackingNumber string
+	Size           ParcelSize
+	HasPhoto       bool
+	// PickupCode is given to whoever collects the parcel, unless a
+	// resident authorized the pickup.
+	PickupCode string
+	// ReceivedBy is the guard that logged the parcel, zero if it was
+	// deleted.
+	ReceivedBy int64
+	// AuthorizedAt is when a resident authorized the pickup without the
+	// code, the zero time if none did.
+	AuthorizedAt time.Time
+	// CollectedAt is the zero time while the parcel waits at the
+	// guardhouse.
+	CollectedAt time.Time
+	// CollectedBy is the name of the person that collected the parcel.
+	CollectedBy string
+	// HandedOverBy is the guard that handed the parcel over, zero if it was
+	// deleted.
+	HandedOverBy int64
+	// ShiftID and CollectedShiftID are the shifts the parcel was received
+	// and handed over in, zero if the guard had none open.
+	ShiftID          int64
+	CollectedShiftID int64
+	CreatedAt        time.Time
+}
+
+const (
+	parcelFieldLength = 100
+	// MaxParcelPhotoSize is the largest photo of a parcel that is kept.
+	MaxParcelPhotoSize = 5 << 20
+)
+
+func (p *Parcel) Valid() error {
+	if p.Carrier == "" {
+		return NewUserSafeError("La empresa de mensajería es obligatoria")
+	}
+	if utf8.RuneCountInString(p.Carrier) > parcelFieldLength ||
+		utf8.RuneCountInString(p.TrackingNumber) > parcelFieldLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"La empresa y el número de guía no pueden tener más de %d caracteres",
+			parcelFieldLength,
+		))
+	}
+	if !slices.Contains(ParcelSizes, p.Size) {
+		return NewUserSafeError("Tamaño de paquete inválido")
+	}
+	return nil
+}
+
+// Recipient describes who the parcel is for.
+func (p *Parcel) Recipient() string {
+	switch {
+	case p.ResidentID != 0 && !p.Unit.IsZero():
+		return fmt.Sprintf("%s (%s)", p.ResidentName, p.Unit)
+	case p.ResidentID != 0:
+		return p.ResidentName
+	case !p.Unit.IsZero():
+		return "Unidad " + p.Unit.String()
+	default:
+		return "Sin destinatario"
+	}
+}
+
+// IsFor reports whether the parcel is for the resident, who lives in unit
+// in the parcel's condominium.
+func (p *Parcel) IsFor(residentID int64, unit Unit) bool {
+	return (p.ResidentID != 0 && p.ResidentID == residentID) ||
+		(!p.Unit.IsZero() && p.Unit == unit)
+}
+
+// describeTo names the parcel for one of the residents it is for.
+func (p *Parcel) describeTo(residentID int64) string {
+	if p.ResidentID == residentID {
+		return "Tu paquete de " + p.Carrier
+	}
+	return fmt.Sprintf("El paquete de %s para la unidad %s", p.Carrier, p.Unit)
+}
+
+// Collected reports whether the parcel left the guardhouse.
+func (p *Parcel) Collected() bool {
+	return !p.CollectedAt.IsZero()
+}
+
+// AgeDays is how many whole days the parcel has been waiting at now.
+func (p *Parcel) AgeDays(now time.Time) int {
+	return int(now.Sub(p.CreatedAt) / (24 * time.Hour))
+}
+
+// ParcelPhoto is the photo a guard took of a parcel.
+type ParcelPhoto struct {
+	ContentType string
+	Data        []byte
+}
+
+// parcelPhotoTypes are the image formats browsers show that photos may be
+// in.
+var parcelPhotoTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// NewParcelPhoto checks that data is an image of a format browsers can show.
+func NewParcelPhoto(data []byte) (*ParcelPhoto, error) {
+	if len(data) > MaxParcelPhotoSize {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"La foto no puede pesar más de %d MB", MaxParcelPhotoSize>>20,
+		))
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(parcelPhotoTypes, contentType) {
+		return nil, NewUserSafeError("La foto debe ser una imagen JPEG, PNG o WebP")
+	}
+	return &ParcelPhoto{ContentType: contentType, Data: data}, nil
+}
+
+type ParcelStore interface {
+	// ParcelCreate saves the parcel and, if it isn't nil, its photo.
+	ParcelCreate(ctx context.Context, parcel *Parcel, photo *ParcelPhoto) (*Parcel, error)
+	ParcelGetByID(ctx context.Context, id int64) (*Parcel, error)
+	// ParcelListPending lists the parcels of a condominium that haven't
+	// been collected, oldest first.
+	ParcelListPending(ctx context.Context, condoID int64) ([]Parcel, error)
+	// ParcelListCollected lists the parcels of a condominium collected
+	// since a time, latest first.
+	ParcelListCollected(ctx context.Context, condoID int64, since time.Time) ([]Parcel, error)
+	// ParcelListForResident lists the parcels of a resident, and of the
+	// unit it lives in unless it is zero, that haven't been collected,
+	// oldest first.
+	ParcelListForResident(
+		ctx context.Context, condoID int64, residentID int64, unit Unit,
+	) ([]Parcel, error)
+	// ParcelAuthorize and ParcelCollect fail with a UserSafeError if the
+	// parcel was already collected.
+	ParcelAuthorize(ctx context.Context, id int64, at time.Time) error
+	ParcelCollect(
+		ctx context.Context, id int64, collectedBy string, guardID, shiftID int64, at time.Time,
+	) error
+	// ParcelListReceivedByShift and ParcelListCollectedByShift list the
+	// parcels received and handed over in a shift, oldest first.
+	ParcelListReceivedByShift(ctx context.Context, shiftID int64) ([]Parcel, error)
+	ParcelListCollectedByShift(ctx context.Context, shiftID int64) ([]Parcel, error)
+	// ParcelPhotoGet returns a NotFoundError if the parcel has no photo.
+	ParcelPhotoGet(ctx context.Context, parcelID int64) (*ParcelPhoto, error)
+}
+
+// ReceiveParcel logs a parcel the guard received for parcel.ResidentID,
+// parcel.Unit or both, and sends the code to collect it with to every
+// resident the parcel is for: the resident, and those who live in the
+// unit. A resident's parcel is for the unit the resident lives in, unless
+// the guard picks another one.
+func (a *App) ReceiveParcel(
+	ctx context.Context, parcel Parcel, photo *ParcelPhoto,
+) (*Parcel, error) {
+	guard, err := RequirePermission(ctx, PermParcelsReceive)
+	if err != nil {
+		return nil, err
+	}
+
+	parcel.CondominiumID = guard.CondominiumID
+	switch {
+	case parcel.ResidentID != 0:
+		resident, err := a.condoResident(ctx, guard, parcel.ResidentID, PermParcelsCollect)
+		if err != nil {
+			return nil, err
+		}
+		if parcel.Unit.IsZero() {
+			parcel.Unit = resident.Unit
+		} else if parcel.Unit != resident.Unit {
+			return nil, NewUserSafeError(fmt.Sprintf(
+				"%s no vive en la unidad %s", resident.FullName(), parcel.Unit,
+			))
+		}
+		parcel.ResidentName = resident.FullName()
+	case parcel.Unit.IsZero():
+		return nil, NewUserSafeError("Elige la unidad o el residente del paquete")
+	}
+
+	parcel.Carrier = strings.TrimSpace(parcel.Carrier)
+	parcel.TrackingNumber = strings.TrimSpace(parcel.TrackingNumber)
+	if err := parcel.Valid(); err != nil {
+		return nil, err
+	}
+
+	recipients, err := a.parcelRecipients(ctx, &parcel)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Nadie que pueda recoger paquetes vive en la unidad %s", parcel.Unit,
+		))
+	}
+
+	parcel.PickupCode, err = generatePickupCode()
+	if err != nil {
+		return nil, err
+	}
+	parcel.ReceivedBy = guard.ID
+	parcel.ShiftID, err = a.openShiftID(ctx, guard)
+	if err != nil {
+		return nil, err
+	}
+	parcel.CreatedAt = time.Now()
+
+	created, err := a.store.ParcelCreate(ctx, &parcel, photo)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resident := range recipients {
+		err := a.notifier.Notify(ctx, Notification{
+			UserID:        resident.ID,
+			CondominiumID: created.CondominiumID,
+			Event:         NotifyParcelArrived,
+			Title:         "Llegó un paquete",
+			Body: fmt.Sprintf(
+				"%s está en la garita. Código de entrega: %s.",
+				created.describeTo(resident.ID), created.PickupCode,
+			),
+			URL: "/neighbor/",
+		})
+		if err != nil {
+			a.logger.Error(
+				"Failed to notify the arrival of a parcel",
+				"parcel_id", created.ID,
+				"user_id", resident.ID,
+				"error", err,
+			)
+		}
+	}
+	return created, nil
+}
+
+// parcelRecipients lists the residents a parcel is for that can collect
+// it.
+func (a *App) parcelRecipients(ctx context.Context, parcel *Parcel) ([]UserProfile, error) {
+	users, err := a.condoUsersWith(ctx, parcel.CondominiumID, PermParcelsCollect)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []UserProfile
+	for _, u := range users {
+		if parcel.IsFor(u.ID, condoUnit(&u, parcel.CondominiumID)) {
+			recipients = append(recipients, u)
+		}
+	}
+	return recipients, nil
+}
+
+// ParcelUnits lists the units of the guard's condominium with residents
+// that can collect parcels, to address parcels to.
+func (a *App) ParcelUnits(ctx context.Context) ([]Unit, error) {
+	guard, err := RequirePermission(ctx, PermParcelsReceive)
+	if err != nil {
+		return nil, err
+	}
+	_, units, err := a.condoUnits(ctx, guard.CondominiumID, PermParcelsCollect)
+	return units, err
+}
+
+// GuardParcels lists the parcels waiting at the guard's condominium, and
+// the ones collected today.
+func (a *App) GuardParcels(ctx context.Context) (pending []Parcel, collected []Parcel, err error) {
+	guard, err := RequirePermission(ctx, PermParcelsReceive)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending, err = a.store.ParcelListPending(ctx, guard.CondominiumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	collected, err = a.store.ParcelListCollected(
+		ctx, guard.CondominiumID, startOfDay(time.Now()),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pending, collected, nil
+}
+
+// PendingParcels lists the parcels waiting for the user in ctx and for the
+// unit it lives in.
+func (a *App) PendingParcels(ctx context.Context) ([]Parcel, error) {
+	user, err := RequirePermission(ctx, PermParcelsCollect)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := a.residentUnit(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.ParcelListForResident(ctx, user.CondominiumID, user.ID, unit)
+}
+
+// AuthorizeParcelPickup lets the guards hand a parcel for the user in ctx,
+// or for its unit, over without the pickup code, to whoever the resident
+// sends for it.
+func (a *App) AuthorizeParcelPickup(ctx context.Context, id int64) error {
+	user, err := RequirePermission(ctx, PermParcelsCollect)
+	if err != nil {
+		return err
+	}
+
+	parcel, err := a.store.ParcelGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	unit, err := a.residentUnit(ctx, user)
+	if err != nil {
+		return err
+	}
+	if parcel.CondominiumID != user.CondominiumID || !parcel.IsFor(user.ID, unit) {
+		return NewNotFoundError("Paquete no encontrado")
+	}
+	return a.store.ParcelAuthorize(ctx, parcel.ID, time.Now())
+}
+
+// CollectParcel records who collected a parcel. The code is required
+// unless a resident authorized the pickup.
+func (a *App) CollectParcel(ctx context.Context, id int64, code string, collectedBy string) error {
+	guard, err := RequirePermission(ctx, PermParcelsReceive)
+	if err != nil {
+		return err
+	}
+
+	parcel, err := a.store.ParcelGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if parcel.CondominiumID != guard.CondominiumID {
+		return NewNotFoundError("Paquete no encontrado")
+	}
+	if parcel.Collected() {
+		return NewUserSafeError("Este paquete ya fue entregado")
+	}
+
+	collectedBy = strings.TrimSpace(collectedBy)
+	if collectedBy == "" {
+		return NewUserSafeError("Escribe el nombre de quien recoge el paquete")
+	}
+	if utf8.RuneCountInString(collectedBy) > parcelFieldLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"El nombre no puede tener más de %d caracteres", parcelFieldLength,
+		))
+	}
+
+	how := "autorizado por un residente"
+	if parcel.AuthorizedAt.IsZero() {
+		code = strings.TrimSpace(code)
+		if subtle.ConstantTimeCompare([]byte(code), []byte(parcel.PickupCode)) != 1 {
+			return NewUserSafeError("El código de entrega no es correcto")
+		}
+		how = "con el código de entrega"
+	}
+
+	shiftID, err := a.openShiftID(ctx, guard)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.ParcelCollect(ctx, parcel.ID, collectedBy, guard.ID, shiftID, now)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: parcel.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionParcelCollected,
+			Message: fmt.Sprintf(
+				"Paquete de %s para %s entregado a %s, %s",
+				parcel.Carrier, parcel.Recipient(), collectedBy, how,
+			),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	recipients, err := a.parcelRecipients(ctx, parcel)
+	if err != nil {
+		return err
+	}
+	for _, resident := range recipients {
+		err := a.notifier.Notify(ctx, Notification{
+			UserID:        resident.ID,
+			CondominiumID: parcel.CondominiumID,
+			Event:         NotifyParcelCollected,
+			Title:         "Entregamos tu paquete",
+			Body: fmt.Sprintf(
+				"%s se entregó a %s a las %s.",
+				parcel.describeTo(resident.ID), collectedBy, now.Format("15:04"),
+			),
+		})
+		if err != nil {
+			a.logger.Error(
+				"Failed to notify the pickup of a parcel",
+				"parcel_id", parcel.ID,
+				"user_id", resident.ID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
+
+// ParcelPhoto returns the photo of a parcel to the guards and admins of its
+// condominium, and to the residents it is for.
+func (a *App) ParcelPhoto(ctx context.Context, id int64) (*ParcelPhoto, error) {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return nil, &UnauthorizedError{msg: "user not authenticated"}
+	}
+
+	parcel, err := a.store.ParcelGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := user.Can(PermParcelsReceive, parcel.CondominiumID) ||
+		user.Can(PermParcelsRead, parcel.CondominiumID)
+	if !allowed && user.CondominiumID == parcel.CondominiumID &&
+		user.Can(PermParcelsCollect, parcel.CondominiumID) {
+		unit, err := a.residentUnit(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		allowed = parcel.IsFor(user.ID, unit)
+	}
+	if !allowed || !parcel.HasPhoto {
+		return nil, NewNotFoundError("Foto no encontrada")
+	}
+	return a.store.ParcelPhotoGet(ctx, parcel.ID)
+}
+
+// ParcelAgeBucket counts the uncollected parcels that have waited between
+// MinDays and MaxDays, both included. MaxDays is zero for the last bucket.
+type ParcelAgeBucket struct {
+	Label   string
+	MinDays int
+	MaxDays int
+	Count   int
+}
+
+// ParcelReport is how long the uncollected parcels of a condominium have
+// been waiting.
+type ParcelReport struct {
+	Buckets []ParcelAgeBucket
+	// Parcels are the uncollected parcels, oldest first.
+	Parcels []Parcel
+	Now     time.Time
+}
+
+// ParcelAging reports how long the parcels of the admin's condominium have
+// been waiting at the guardhouse.
+func (a *App) ParcelAging(ctx context.Context) (*ParcelReport, error) {
+	admin, err := RequirePermission(ctx, PermParcelsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	parcels, err := a.store.ParcelListPending(ctx, admin.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	return newParcelReport(parcels, time.Now()), nil
+}
+
+func newParcelReport(parcels []Parcel, now time.Time) *ParcelReport {
+	report := &ParcelReport{
+		Buckets: []ParcelAgeBucket{
+			{Label: "Hasta 2 días", MinDays: 0, MaxDays: 2},
+			{Label: "De 3 a 7 días", MinDays: 3, MaxDays: 7},
+			{Label: "De 8 a 14 días", MinDays: 8, MaxDays: 14},
+			{Label: "Más de 14 días", MinDays: 15},
+		},
+		Parcels: parcels,
+		Now:     now,
+	}
+	for _, p := range parcels {
+		days := p.AgeDays(now)
+		for i := range report.Buckets {
+			b := &report.Buckets[i]
+			if days >= b.MinDays && (b.MaxDays == 0 || days <= b.MaxDays) {
+				b.Count++
+				break
+			}
+		}
+	}
+	return report
+}
+
+// generatePickupCode returns six random digits, easy to read out at the
+// guardhouse.
+func generatePickupCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,122 @@
+package entry
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParcelReportBuckets(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	waiting := func(d time.Duration) Parcel {
+		return Parcel{CreatedAt: now.Add(-d)}
+	}
+	day := 24 * time.Hour
+	parcels := []Parcel{
+		waiting(20 * day),
+		waiting(15 * day),
+		waiting(14*day + time.Hour),
+		waiting(7 * day),
+		waiting(3 * day),
+		waiting(2*day + 23*time.Hour),
+		waiting(time.Minute),
+	}
+
+	report := newParcelReport(parcels, now)
+	want := []int{2, 2, 1, 2}
+	for i, b := range report.Buckets {
+		if b.Count != want[i] {
+			t.Errorf("bucket %q = %d, want %d", b.Label, b.Count, want[i])
+		}
+	}
+}
+
+func TestParcelValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		parcel Parcel
+		ok     bool
+	}{
+		{"valid", Parcel{Carrier: "Cargo Expreso", Size: ParcelSmall}, true},
+		{"no carrier", Parcel{Size: ParcelSmall}, false},
+		{"bad size", Parcel{Carrier: "DHL", Size: "huge"}, false},
+		// The limit counts characters, not bytes.
+		{"long accented carrier", Parcel{Carrier: strings.Repeat("ñ", 100), Size: ParcelLarge}, true},
+		{"too long carrier", Parcel{Carrier: strings.Repeat("ñ", 101), Size: ParcelLarge}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.parcel.Valid(); (err == nil) != tt.ok {
+				t.Errorf("Valid() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewParcelPhoto(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", img.Bytes(), "image/png"},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), "image/jpeg"},
+		{"html", []byte("<html><script>alert(1)</script>"), ""},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ""},
+		{"too large", append(img.Bytes(), make([]byte, MaxParcelPhotoSize)...), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			photo, err := NewParcelPhoto(tt.data)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("NewParcelPhoto accepted %s as %s", tt.name, photo.ContentType)
+				}
+				return
+			}
+			if err != nil || photo.ContentType != tt.want {
+				t.Errorf("NewParcelPhoto() = %v, %v; want %s", photo, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePickupCode(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9]{6}$`)
+	for range 100 {
+		code, err := generatePickupCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(code) {
+			t.Fatalf("pickup code %q is not six digits", code)
+		}
+	}
+}
+
+func TestParcelRecipient(t *testing.T) {
+	unit := Unit{Tower: "A", Number: "302"}
+	tests := []struct {
+		parcel Parcel
+		want   string
+	}{
+		{Parcel{ResidentID: 4, ResidentName: "Vero Vecina", Unit: unit}, "Vero Vecina (A - 302)"},
+		{Parcel{ResidentID: 4, ResidentName: "Vero Vecina"}, "Vero Vecina"},
+		{Parcel{Unit: unit}, "Unidad A - 302"},
+		{Parcel{}, "Sin destinatario"},
+	}
+	for _, tt := range tests {
+		if got := tt.parcel.Recipient(); got != tt.want {
+			t.Errorf("Recipient() = %q, want %q", got, tt.want)
+		}
+	}
+}
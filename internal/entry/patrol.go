@@ -0,0 +1,917 @@
+package entry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PatrolCheckpoint is a place guards have to visit on their rounds. A QR
+// code with its Code is placed there, and guards scan it to prove they
+// went.
+type PatrolCheckpoint struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	Code          string
+	CreatedAt     time.Time
+	CreatedBy     int64
+}
+
+// PatrolRoute is a round guards have to make through its checkpoints. A
+// round is due every EveryMinutes from StartMinute to EndMinute, minutes
+// from midnight, and has to be done within WindowMinutes. EndMinute may be
+// before StartMinute, for rounds overnight, and equal to it for rounds all
+// day.
+type PatrolRoute struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	// StationID is the guard station whose guards make the round, zero for
+	// the guards of every station.
+	StationID     int64
+	StationName   string
+	StartMinute   int64
+	EndMinute     int64
+	EveryMinutes  int64
+	WindowMinutes int64
+	Checkpoints   []PatrolCheckpoint
+	CreatedAt     time.Time
+	CreatedBy     int64
+}
+
+func (r *PatrolRoute) window() time.Duration {
+	return time.Duration(r.WindowMinutes) * time.Minute
+}
+
+// DueTimes returns when rounds of the route are due from from until to, to
+// excluded, in order.
+func (r *PatrolRoute) DueTimes(from, to time.Time) []time.Time {
+	var due []time.Time
+	if r.EveryMinutes <= 0 {
+		return due
+	}
+
+	length := (r.EndMinute - r.StartMinute + 24*60) % (24 * 60)
+	allDay := length == 0
+	// Rounds overnight started the day before.
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for day = day.AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for m := int64(0); (allDay && m < 24*60) || (!allDay && m <= length); m += r.EveryMinutes {
+			t := time.Date(
+				day.Year(), day.Month(), day.Day(),
+				0, int(r.StartMinute+m), 0, 0, day.Location(),
+			)
+			if !t.Before(from) && t.Before(to) {
+				due = append(due, t)
+			}
+		}
+	}
+	return due
+}
+
+// Schedule describes when rounds of the route are due.
+func (r *PatrolRoute) Schedule() string {
+	schedule := fmt.Sprintf(
+		"De %s a %s, cada %d minutos",
+		Clock(r.StartMinute), Clock(r.EndMinute), r.EveryMinutes,
+	)
+	if r.StartMinute == r.EndMinute {
+		schedule = fmt.Sprintf(
+			"Todo el día desde las %s, cada %d minutos", Clock(r.StartMinute), r.EveryMinutes,
+		)
+	}
+	return fmt.Sprintf("%s, con %d minutos para hacerla", schedule, r.WindowMinutes)
+}
+
+// covers tells whether the shift has to make the round of the route due at
+// due: it was open at the time, at the station of the route if it has one.
+func (r *PatrolRoute) covers(shift *Shift, due time.Time) bool {
+	if r.StationID != 0 && shift.StationID != r.StationID {
+		return false
+	}
+	if due.Before(shift.StartedAt) {
+		return false
+	}
+	return shift.Open() || shift.EndedAt.After(due)
+}
+
+// Clock formats minutes from midnight as HH:MM.
+func Clock(minutes int64) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// PatrolScan is a guard scanning a checkpoint during a shift.
+type PatrolScan struct {
+	ID             int64
+	CondominiumID  int64
+	CheckpointID   int64
+	CheckpointName string
+	// GuardID is zero if the guard was deleted.
+	GuardID   int64
+	ShiftID   int64
+	ScannedAt time.Time
+}
+
+type RoundStatus string
+
+const (
+	// RoundCompleted rounds had every checkpoint scanned in time.
+	RoundCompleted RoundStatus = "completed"
+	// RoundIncomplete rounds had some checkpoints scanned in time.
+	RoundIncomplete RoundStatus = "incomplete"
+	// RoundMissed rounds had no checkpoint scanned in time.
+	RoundMissed RoundStatus = "missed"
+)
+
+func (s RoundStatus) String() string {
+	switch s {
+	case RoundCompleted:
+		return "Completa"
+	case RoundIncomplete:
+		return "Incompleta"
+	case RoundMissed:
+		return "Omitida"
+	default:
+		return string(s)
+	}
+}
+
+// PatrolRound is a round that was due in a shift. Rounds are saved once
+// their window is over, with the names of the route and of the checkpoints
+// that were missing at the time.
+type PatrolRound struct {
+	ID            int64
+	CondominiumID int64
+	// RouteID is zero if the route was deleted.
+	RouteID   int64
+	RouteName string
+	ShiftID   int64
+	// ShiftStartedAt, GuardID and GuardName come from the shift. GuardID is
+	// zero if the guard was deleted.
+	ShiftStartedAt time.Time
+	GuardID        int64
+	GuardName      string
+	DueAt          time.Time
+	Status         RoundStatus
+	Checkpoints    int
+	Scanned        int
+	Missing        []string
+	// EvaluatedAt is the zero time for rounds still in their window.
+	EvaluatedAt time.Time
+}
+
+// evaluateRound checks the scans of the shift against the checkpoints of
+// the route, for the round due at due.
+func evaluateRound(
+	route *PatrolRoute, shift *Shift, due time.Time, scans []PatrolScan,
+) PatrolRound {
+	end := due.Add(route.window())
+	scanned := map[int64]bool{}
+	for _, s := range scans {
+		if s.ShiftID == shift.ID && !s.ScannedAt.Before(due) && s.ScannedAt.Before(end) {
+			scanned[s.CheckpointID] = true
+		}
+	}
+
+	round := PatrolRound{
+		CondominiumID:  route.CondominiumID,
+		RouteID:        route.ID,
+		RouteName:      route.Name,
+		ShiftID:        shift.ID,
+		ShiftStartedAt: shift.StartedAt,
+		GuardID:        shift.GuardID,
+		GuardName:      shift.GuardName,
+		DueAt:          due,
+		Checkpoints:    len(route.Checkpoints),
+	}
+	for _, c := range route.Checkpoints {
+		if scanned[c.ID] {
+			round.Scanned++
+		} else {
+			round.Missing = append(round.Missing, c.Name)
+		}
+	}
+
+	switch {
+	case round.Scanned == round.Checkpoints:
+		round.Status = RoundCompleted
+	case round.Scanned == 0:
+		round.Status = RoundMissed
+	default:
+		round.Status = RoundIncomplete
+	}
+	return round
+}
+
+// PatrolCompliance counts the rounds of a guard, or of a shift, by status.
+type PatrolCompliance struct {
+	GuardID    int64
+	GuardName  string
+	Completed  int
+	Incomplete int
+	Missed     int
+}
+
+func (c *PatrolCompliance) Total() int {
+	return c.Completed + c.Incomplete + c.Missed
+}
+
+// Rate is the percentage of rounds completed.
+func (c *PatrolCompliance) Rate() int {
+	if c.Total() == 0 {
+		return 100
+	}
+	return c.Completed * 100 / c.Total()
+}
+
+func (c *PatrolCompliance) add(round *PatrolRound) {
+	switch round.Status {
+	case RoundCompleted:
+		c.Completed++
+	case RoundIncomplete:
+		c.Incomplete++
+	case RoundMissed:
+		c.Missed++
+	}
+}
+
+// PatrolShiftRounds are the rounds that were due in a shift.
+type PatrolShiftRounds struct {
+	PatrolCompliance
+	ShiftID   int64
+	StartedAt time.Time
+	Rounds    []PatrolRound
+}
+
+// PatrolReport is the compliance with the rounds of the guards of a
+// condominium, in rounds due from From until To.
+type PatrolReport struct {
+	From time.Time
+	To   time.Time
+	// Guards are sorted by name, and Shifts latest first.
+	Guards []PatrolCompliance
+	Shifts []PatrolShiftRounds
+}
+
+// newPatrolReport groups the rounds, sorted by due time, by guard and by
+// shift.
+func newPatrolReport(from, to time.Time, rounds []PatrolRound) *PatrolReport {
+	report := &PatrolReport{From: from, To: to}
+	guards := map[int64]int{}
+	shifts := map[int64]int{}
+	for _, round := range rounds {
+		g, ok := guards[round.GuardID]
+		if !ok {
+			g = len(report.Guards)
+			guards[round.GuardID] = g
+			report.Guards = append(report.Guards, PatrolCompliance{
+				GuardID:   round.GuardID,
+				GuardName: round.GuardName,
+			})
+		}
+		report.Guards[g].add(&round)
+
+		s, ok := shifts[round.ShiftID]
+		if !ok {
+			s = len(report.Shifts)
+			shifts[round.ShiftID] = s
+			report.Shifts = append(report.Shifts, PatrolShiftRounds{
+				PatrolCompliance: PatrolCompliance{
+					GuardID:   round.GuardID,
+					GuardName: round.GuardName,
+				},
+				ShiftID:   round.ShiftID,
+				StartedAt: round.ShiftStartedAt,
+			})
+		}
+		report.Shifts[s].add(&round)
+		report.Shifts[s].Rounds = append(report.Shifts[s].Rounds, round)
+	}
+
+	slices.SortFunc(report.Guards, func(a, b PatrolCompliance) int {
+		return strings.Compare(a.GuardName, b.GuardName)
+	})
+	slices.SortFunc(report.Shifts, func(a, b PatrolShiftRounds) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	return report
+}
+
+const (
+	patrolNameLength = 100
+	// minRoundEvery and minRoundWindow keep rounds reasonable, and the
+	// monitor cheap.
+	minRoundEvery  = 15
+	minRoundWindow = 5
+	// patrolLookback is how far back the monitor evaluates rounds, and
+	// maxPatrolReport how long a report can span.
+	patrolLookback  = 24 * time.Hour
+	maxPatrolReport = 92 * 24 * time.Hour
+)
+
+type PatrolStore interface {
+	PatrolCheckpointCreate(
+		ctx context.Context, checkpoint *PatrolCheckpoint,
+	) (*PatrolCheckpoint, error)
+	PatrolCheckpointGetByID(ctx context.Context, id int64) (*PatrolCheckpoint, error)
+	PatrolCheckpointGetByCode(ctx context.Context, code string) (*PatrolCheckpoint, error)
+	PatrolCheckpointList(ctx context.Context, condoID int64) ([]PatrolCheckpoint, error)
+	// PatrolCheckpointDelete removes the checkpoint from its routes, and its
+	// scans.
+	PatrolCheckpointDelete(ctx context.Context, id int64) error
+	// PatrolRouteCreate saves the route with its checkpoints, in order.
+	PatrolRouteCreate(ctx context.Context, route *PatrolRoute) (*PatrolRoute, error)
+	PatrolRouteGetByID(ctx context.Context, id int64) (*PatrolRoute, error)
+	PatrolRouteList(ctx context.Context, condoID int64) ([]PatrolRoute, error)
+	// PatrolRouteListAll lists the routes of every condominium.
+	PatrolRouteListAll(ctx context.Context) ([]PatrolRoute, error)
+	PatrolRouteDelete(ctx context.Context, id int64) error
+	PatrolScanCreate(ctx context.Context, scan *PatrolScan) (*PatrolScan, error)
+	PatrolScanListByShift(ctx context.Context, shiftID int64) ([]PatrolScan, error)
+	// PatrolRoundCreate saves the round, and returns false if it was saved
+	// already.
+	PatrolRoundCreate(ctx context.Context, round *PatrolRound) (bool, error)
+	// PatrolRoundList lists the rounds of a condominium due from since until
+	// until, sorted by due time.
+	PatrolRoundList(
+		ctx context.Context, condoID int64, since time.Time, until time.Time,
+	) ([]PatrolRound, error)
+}
+
+// NormalizeCheckpointCode removes what guards may add typing a checkpoint
+// code.
+func NormalizeCheckpointCode(code string) string {
+	return NormalizeVisitCode(strings.TrimSpace(code))
+}
+
+func generateCheckpointCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// PatrolCheckpoints lists the checkpoints of the admin's condominium.
+func (a *App) PatrolCheckpoints(ctx context.Context) ([]PatrolCheckpoint, error) {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.PatrolCheckpointList(ctx, user.CondominiumID)
+}
+
+func (a *App) CreatePatrolCheckpoint(
+	ctx context.Context, name string,
+) (*PatrolCheckpoint, error) {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Elige un condominio para registrar el punto de control")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, NewUserSafeError("El nombre del punto de control es obligatorio")
+	}
+	if utf8.RuneCountInString(name) > patrolNameLength {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"El nombre no puede tener más de %d caracteres", patrolNameLength,
+		))
+	}
+
+	code, err := generateCheckpointCode()
+	if err != nil {
+		return nil, err
+	}
+	var created *PatrolCheckpoint
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.PatrolCheckpointCreate(ctx, &PatrolCheckpoint{
+			CondominiumID: user.CondominiumID,
+			Name:          name,
+			Code:          code,
+			CreatedAt:     time.Now(),
+			CreatedBy:     user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionPatrolChanged,
+			Message:       fmt.Sprintf("Punto de control registrado: %s", created.Name),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeletePatrolCheckpoint deletes the checkpoint, which is taken out of its
+// routes. Rounds already evaluated keep it.
+func (a *App) DeletePatrolCheckpoint(ctx context.Context, id int64) error {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := a.store.PatrolCheckpointGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if checkpoint.CondominiumID != user.CondominiumID {
+		return NewNotFoundError("Punto de control no encontrado")
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.PatrolCheckpointDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: checkpoint.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionPatrolChanged,
+			Message:       fmt.Sprintf("Punto de control eliminado: %s", checkpoint.Name),
+		})
+	})
+}
+
+// PatrolRoutes lists the routes of the admin's condominium.
+func (a *App) PatrolRoutes(ctx context.Context) ([]PatrolRoute, error) {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.PatrolRouteList(ctx, user.CondominiumID)
+}
+
+// CreatePatrolRoute saves a route through route.Checkpoints, of which only
+// the IDs are used.
+func (a *App) CreatePatrolRoute(ctx context.Context, route PatrolRoute) (*PatrolRoute, error) {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Elige un condominio para registrar la ronda")
+	}
+
+	route.Name = strings.TrimSpace(route.Name)
+	switch {
+	case route.Name == "":
+		return nil, NewUserSafeError("El nombre de la ronda es obligatorio")
+	case utf8.RuneCountInString(route.Name) > patrolNameLength:
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"El nombre no puede tener más de %d caracteres", patrolNameLength,
+		))
+	case route.StartMinute < 0 || route.StartMinute >= 24*60 ||
+		route.EndMinute < 0 || route.EndMinute >= 24*60:
+		return nil, NewUserSafeError("Horario inválido")
+	case route.EveryMinutes < minRoundEvery || route.EveryMinutes > 24*60:
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Las rondas deben ser cada %d minutos o más, y al menos una vez al día",
+			minRoundEvery,
+		))
+	case route.WindowMinutes < minRoundWindow || route.WindowMinutes > route.EveryMinutes:
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"El tiempo para hacer la ronda debe ser de %d minutos o más, y no más que el tiempo entre rondas",
+			minRoundWindow,
+		))
+	case len(route.Checkpoints) == 0:
+		return nil, NewUserSafeError("Elige al menos un punto de control")
+	}
+
+	seen := map[int64]bool{}
+	for _, c := range route.Checkpoints {
+		if seen[c.ID] {
+			return nil, NewUserSafeError("Un punto de control se repite en la ronda")
+		}
+		seen[c.ID] = true
+
+		checkpoint, err := a.store.PatrolCheckpointGetByID(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint.CondominiumID != user.CondominiumID {
+			return nil, NewNotFoundError("Punto de control no encontrado")
+		}
+	}
+	if route.StationID != 0 {
+		station, err := a.store.GuardStationGetByID(ctx, route.StationID)
+		if err != nil {
+			return nil, err
+		}
+		if station.CondominiumID != user.CondominiumID {
+			return nil, NewNotFoundError("Garita no encontrada")
+		}
+	}
+
+	route.CondominiumID = user.CondominiumID
+	route.CreatedAt = time.Now()
+	route.CreatedBy = user.ID
+	var created *PatrolRoute
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.PatrolRouteCreate(ctx, &route)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionPatrolChanged,
+			Message: fmt.Sprintf(
+				"Ronda registrada: %s, de %s a %s cada %d minutos",
+				created.Name, Clock(created.StartMinute), Clock(created.EndMinute),
+				created.EveryMinutes,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (a *App) DeletePatrolRoute(ctx context.Context, id int64) error {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return err
+	}
+
+	route, err := a.store.PatrolRouteGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if route.CondominiumID != user.CondominiumID {
+		return NewNotFoundError("Ronda no encontrada")
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.PatrolRouteDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: route.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionPatrolChanged,
+			Message:       fmt.Sprintf("Ronda eliminada: %s", route.Name),
+		})
+	})
+}
+
+// PatrolReport returns the compliance with the rounds due from from until
+// to in the admin's condominium.
+func (a *App) PatrolReport(ctx context.Context, from, to time.Time) (*PatrolReport, error) {
+	user, err := RequirePermission(ctx, PermPatrolsManage)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, NewUserSafeError("La fecha de inicio debe ser antes de la fecha de fin")
+	}
+	if to.Sub(from) > maxPatrolReport {
+		return nil, NewUserSafeError("El reporte puede abarcar tres meses como máximo")
+	}
+
+	rounds, err := a.store.PatrolRoundList(ctx, user.CondominiumID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return newPatrolReport(from, to, rounds), nil
+}
+
+// PatrolDuty is a route a guard has to make in their shift.
+type PatrolDuty struct {
+	Route PatrolRoute
+	// Round is the round due now so far, nil if there is none.
+	Round *PatrolRound
+	// Next is when the next round is due, the zero time if not in the next
+	// day.
+	Next time.Time
+}
+
+// RoundEnd is when the time to make the current round is over.
+func (d *PatrolDuty) RoundEnd() time.Time {
+	if d.Round == nil {
+		return time.Time{}
+	}
+	return d.Round.DueAt.Add(d.Route.window())
+}
+
+// GuardPatrol is what a guard sees of their rounds.
+type GuardPatrol struct {
+	// Shift is the open shift of the guard, nil if they have none, in which
+	// case there is nothing else.
+	Shift  *Shift
+	Duties []PatrolDuty
+	// Scans are the scans of the shift, latest first.
+	Scans []PatrolScan
+}
+
+// GuardPatrol returns the rounds the guard has to make in their open
+// shift, and how the current ones are going.
+func (a *App) GuardPatrol(ctx context.Context) (*GuardPatrol, error) {
+	shift, err := a.OpenShift(ctx)
+	if err != nil || shift == nil {
+		return &GuardPatrol{}, err
+	}
+
+	routes, err := a.store.PatrolRouteList(ctx, shift.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	scans, err := a.store.PatrolScanListByShift(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	patrol := &GuardPatrol{Shift: shift}
+	for _, route := range routes {
+		if len(route.Checkpoints) == 0 {
+			continue
+		}
+		if route.StationID != 0 && route.StationID != shift.StationID {
+			continue
+		}
+
+		duty := PatrolDuty{Route: route}
+		for _, due := range route.DueTimes(now.Add(-route.window()), now.Add(24*time.Hour)) {
+			if due.After(now) {
+				duty.Next = due
+				break
+			}
+			round := evaluateRound(&route, shift, due, scans)
+			duty.Round = &round
+		}
+		patrol.Duties = append(patrol.Duties, duty)
+	}
+
+	slices.Reverse(scans)
+	patrol.Scans = scans
+	return patrol, nil
+}
+
+// PatrolCheckpoint returns the checkpoint with the code, which must be of
+// the guard's condominium.
+func (a *App) PatrolCheckpoint(ctx context.Context, code string) (*PatrolCheckpoint, error) {
+	guard, err := RequirePermission(ctx, PermPatrolsWalk)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := a.store.PatrolCheckpointGetByCode(ctx, NormalizeCheckpointCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint.CondominiumID != guard.CondominiumID {
+		return nil, NewNotFoundError("Punto de control no encontrado")
+	}
+	return checkpoint, nil
+}
+
+// ScanCheckpoint records that the guard is at the checkpoint with the code,
+// in their open shift.
+func (a *App) ScanCheckpoint(ctx context.Context, code string) (*PatrolScan, error) {
+	guard, err := RequirePermission(ctx, PermPatrolsWalk)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := a.PatrolCheckpoint(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	shiftID, err := a.openShiftID(ctx, guard)
+	if err != nil {
+		return nil, err
+	}
+	if shiftID == 0 {
+		return nil, NewUserSafeError("Inicia tu turno para registrar tus rondas")
+	}
+
+	return a.store.PatrolScanCreate(ctx, &PatrolScan{
+		CondominiumID:  checkpoint.CondominiumID,
+		CheckpointID:   checkpoint.ID,
+		CheckpointName: checkpoint.Name,
+		GuardID:        guard.ID,
+		ShiftID:        shiftID,
+		ScannedAt:      time.Now(),
+	})
+}
+
+// roundEvaluated stores an evaluated round and, unless it was completed,
+// alerts of it. The round is only stored along with its alert, so a round
+// whose alert failed is evaluated again on the next check.
+func (a *App) roundEvaluated(ctx context.Context, round *PatrolRound) error {
+	alert := false
+	err := a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err := a.store.PatrolRoundCreate(ctx, round)
+		if err != nil || !created || round.Status == RoundCompleted {
+			return err
+		}
+		alert = true
+		return a.alertRound(ctx, round)
+	})
+	if err != nil || !alert {
+		return err
+	}
+
+	message := fmt.Sprintf(
+		"La ronda %s de las %s no se hizo.",
+		round.RouteName, round.DueAt.Format("15:04"),
+	)
+	if round.Status == RoundIncomplete {
+		message = fmt.Sprintf(
+			"La ronda %s de las %s quedó incompleta, faltó: %s.",
+			round.RouteName, round.DueAt.Format("15:04"), strings.Join(round.Missing, ", "),
+		)
+	}
+	a.live.Publish(LiveEvent{
+		Kind:          LiveAlert,
+		CondominiumID: round.CondominiumID,
+		Message:       message,
+	})
+	return nil
+}
+
+// alertRound records a round that wasn't completed in the audit log, and
+// tells the admins if it was missed altogether.
+func (a *App) alertRound(ctx context.Context, round *PatrolRound) error {
+	err := a.audit.Record(ctx, AuditRecord{
+		CondominiumID: round.CondominiumID,
+		UserID:        round.GuardID,
+		Level:         AuditImportant,
+		Action:        ActionRoundMissed,
+		Message: fmt.Sprintf(
+			"Ronda %s de las %s %s: %d de %d puntos de control",
+			round.RouteName, round.DueAt.Format("02/01 15:04"),
+			strings.ToLower(round.Status.String()), round.Scanned, round.Checkpoints,
+		),
+	})
+	if err != nil || round.Status != RoundMissed {
+		return err
+	}
+
+	return a.notifyAdmins(ctx, PermPatrolsManage, Notification{
+		CondominiumID: round.CondominiumID,
+		Event:         NotifyRoundMissed,
+		Title:         fmt.Sprintf("Ronda omitida: %s", round.RouteName),
+		Body: fmt.Sprintf(
+			"%s no hizo la ronda de las %s.",
+			guardNameOr(round.GuardName), round.DueAt.Format("15:04"),
+		),
+		URL:    "/admin/patrols/report",
+		Urgent: true,
+	})
+}
+
+func guardNameOr(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "El guardia"
+	}
+	return name
+}
+
+// PatrolMonitor evaluates the rounds once their window is over, and alerts
+// about the ones that weren't completed. Rounds due while no guard had a
+// shift open at the station of the route aren't anyone's, and aren't
+// evaluated.
+type PatrolMonitor struct {
+	app    *App
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewPatrolMonitor(app *App, logger *slog.Logger) *PatrolMonitor {
+	return &PatrolMonitor{app: app, logger: logger, now: time.Now}
+}
+
+// Run checks the rounds every interval until ctx is done.
+func (m *PatrolMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.CheckRounds(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("Failed to check the patrol rounds", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckRounds evaluates the rounds of the last day whose window is over,
+// and that weren't evaluated yet.
+func (m *PatrolMonitor) CheckRounds(ctx context.Context) error {
+	routes, err := m.app.store.PatrolRouteListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	byCondo := map[int64][]PatrolRoute{}
+	var condos []int64
+	for _, route := range routes {
+		if len(route.Checkpoints) == 0 {
+			continue
+		}
+		if _, ok := byCondo[route.CondominiumID]; !ok {
+			condos = append(condos, route.CondominiumID)
+		}
+		byCondo[route.CondominiumID] = append(byCondo[route.CondominiumID], route)
+	}
+
+	now := m.now()
+	for _, condoID := range condos {
+		if err := m.checkCondominium(ctx, condoID, byCondo[condoID], now); err != nil {
+			m.logger.Error(
+				"Failed to check the patrol rounds of a condominium",
+				"condominium_id", condoID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
+
+func (m *PatrolMonitor) checkCondominium(
+	ctx context.Context, condoID int64, routes []PatrolRoute, now time.Time,
+) error {
+	since := now.Add(-patrolLookback)
+	shifts, err := m.app.store.ShiftListOverlapping(ctx, condoID, since, now)
+	if err != nil || len(shifts) == 0 {
+		return err
+	}
+
+	type roundKey struct {
+		routeID int64
+		shiftID int64
+		due     int64
+	}
+	evaluated := map[roundKey]bool{}
+	rounds, err := m.app.store.PatrolRoundList(ctx, condoID, since, now)
+	if err != nil {
+		return err
+	}
+	for _, r := range rounds {
+		evaluated[roundKey{r.RouteID, r.ShiftID, r.DueAt.Unix()}] = true
+	}
+
+	scans := map[int64][]PatrolScan{}
+	for _, route := range routes {
+		from := since
+		if route.CreatedAt.After(from) {
+			from = route.CreatedAt
+		}
+		for _, due := range route.DueTimes(from, now) {
+			if due.Add(route.window()).After(now) {
+				break
+			}
+			for _, shift := range shifts {
+				if !route.covers(&shift, due) ||
+					evaluated[roundKey{route.ID, shift.ID, due.Unix()}] {
+					continue
+				}
+
+				if _, ok := scans[shift.ID]; !ok {
+					scans[shift.ID], err = m.app.store.PatrolScanListByShift(ctx, shift.ID)
+					if err != nil {
+						return err
+					}
+				}
+
+				round := evaluateRound(&route, &shift, due, scans[shift.ID])
+				round.EvaluatedAt = now
+				// A failed round doesn't hold back the others.
+				if err := m.app.roundEvaluated(ctx, &round); err != nil {
+					m.logger.Error(
+						"Failed to record a patrol round",
+						"route_id", route.ID,
+						"shift_id", shift.ID,
+						"error", err,
+					)
+				}
+			}
+		}
+	}
+	return nil
+}
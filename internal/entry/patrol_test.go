@@ -0,0 +1,171 @@
+package entry
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPatrolRouteDueTimes(t *testing.T) {
+	day := func(d, h, m int) time.Time {
+		return time.Date(2026, 10, d, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		route    PatrolRoute
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			"daytime",
+			PatrolRoute{StartMinute: 8 * 60, EndMinute: 12 * 60, EveryMinutes: 120},
+			day(19, 0, 0), day(20, 0, 0),
+			[]time.Time{day(19, 8, 0), day(19, 10, 0), day(19, 12, 0)},
+		},
+		{
+			"overnight, started the day before",
+			PatrolRoute{StartMinute: 22 * 60, EndMinute: 2 * 60, EveryMinutes: 120},
+			day(20, 1, 0), day(20, 23, 0),
+			[]time.Time{day(20, 2, 0), day(20, 22, 0)},
+		},
+		{
+			"all day",
+			PatrolRoute{StartMinute: 6 * 60, EndMinute: 6 * 60, EveryMinutes: 8 * 60},
+			day(19, 6, 0), day(20, 6, 0),
+			[]time.Time{day(19, 6, 0), day(19, 14, 0), day(19, 22, 0)},
+		},
+		{
+			"to is excluded",
+			PatrolRoute{StartMinute: 8 * 60, EndMinute: 12 * 60, EveryMinutes: 120},
+			day(19, 9, 0), day(19, 12, 0),
+			[]time.Time{day(19, 10, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.route.DueTimes(tt.from, tt.to)
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("DueTimes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatrolRouteCovers(t *testing.T) {
+	at := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	route := PatrolRoute{StationID: 1}
+
+	tests := []struct {
+		name  string
+		shift Shift
+		want  bool
+	}{
+		{"open", Shift{StationID: 1, StartedAt: at.Add(-time.Hour)}, true},
+		{"closed after", Shift{StationID: 1, StartedAt: at.Add(-time.Hour), EndedAt: at.Add(time.Minute)}, true},
+		{"closed before", Shift{StationID: 1, StartedAt: at.Add(-time.Hour), EndedAt: at}, false},
+		{"started after", Shift{StationID: 1, StartedAt: at.Add(time.Minute)}, false},
+		{"other station", Shift{StationID: 2, StartedAt: at.Add(-time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := route.covers(&tt.shift, at); got != tt.want {
+				t.Errorf("covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRound(t *testing.T) {
+	due := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	route := &PatrolRoute{
+		ID:            3,
+		Name:          "Perímetro",
+		WindowMinutes: 30,
+		Checkpoints: []PatrolCheckpoint{
+			{ID: 1, Name: "Portón"},
+			{ID: 2, Name: "Piscina"},
+			{ID: 3, Name: "Bodega"},
+		},
+	}
+	shift := &Shift{ID: 9, GuardID: 4, GuardName: "Gus Guard"}
+	scan := func(checkpointID, shiftID int64, at time.Time) PatrolScan {
+		return PatrolScan{CheckpointID: checkpointID, ShiftID: shiftID, ScannedAt: at}
+	}
+
+	tests := []struct {
+		name    string
+		scans   []PatrolScan
+		status  RoundStatus
+		missing []string
+	}{
+		{
+			"completed",
+			[]PatrolScan{
+				scan(1, 9, due), scan(2, 9, due.Add(10*time.Minute)), scan(3, 9, due.Add(29*time.Minute)),
+			},
+			RoundCompleted, nil,
+		},
+		{
+			"scanned twice",
+			[]PatrolScan{scan(1, 9, due), scan(1, 9, due.Add(time.Minute)), scan(3, 9, due)},
+			RoundIncomplete, []string{"Piscina"},
+		},
+		{
+			"out of the window",
+			[]PatrolScan{scan(1, 9, due.Add(-time.Minute)), scan(2, 9, due.Add(30*time.Minute))},
+			RoundMissed, []string{"Portón", "Piscina", "Bodega"},
+		},
+		{
+			"another shift",
+			[]PatrolScan{scan(1, 8, due)},
+			RoundMissed, []string{"Portón", "Piscina", "Bodega"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			round := evaluateRound(route, shift, due, tt.scans)
+			if round.Status != tt.status {
+				t.Errorf("status = %s, want %s", round.Status, tt.status)
+			}
+			if !slices.Equal(round.Missing, tt.missing) {
+				t.Errorf("missing = %v, want %v", round.Missing, tt.missing)
+			}
+			if round.Scanned+len(round.Missing) != 3 || round.Checkpoints != 3 {
+				t.Errorf("scanned = %d of %d", round.Scanned, round.Checkpoints)
+			}
+			if round.RouteID != 3 || round.ShiftID != 9 || round.GuardName != "Gus Guard" {
+				t.Errorf("round = %+v", round)
+			}
+		})
+	}
+}
+
+func TestNewPatrolReport(t *testing.T) {
+	at := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	rounds := []PatrolRound{
+		{ShiftID: 1, ShiftStartedAt: at, GuardID: 2, GuardName: "Zoe", Status: RoundCompleted},
+		{ShiftID: 1, ShiftStartedAt: at, GuardID: 2, GuardName: "Zoe", Status: RoundMissed},
+		{ShiftID: 2, ShiftStartedAt: at.Add(12 * time.Hour), GuardID: 3, GuardName: "Ana", Status: RoundCompleted},
+		{ShiftID: 3, ShiftStartedAt: at.Add(24 * time.Hour), GuardID: 2, GuardName: "Zoe", Status: RoundIncomplete},
+	}
+
+	report := newPatrolReport(at, at.Add(48*time.Hour), rounds)
+	if len(report.Guards) != 2 || report.Guards[0].GuardName != "Ana" {
+		t.Fatalf("guards = %+v, want Ana and Zoe", report.Guards)
+	}
+	zoe := report.Guards[1]
+	if zoe.Completed != 1 || zoe.Incomplete != 1 || zoe.Missed != 1 || zoe.Rate() != 33 {
+		t.Errorf("Zoe = %+v, rate %d", zoe, zoe.Rate())
+	}
+
+	if len(report.Shifts) != 3 || report.Shifts[0].ShiftID != 3 || report.Shifts[2].ShiftID != 1 {
+		t.Fatalf("shifts = %+v, want latest first", report.Shifts)
+	}
+	if first := report.Shifts[2]; len(first.Rounds) != 2 || first.Total() != 2 || first.Rate() != 50 {
+		t.Errorf("first shift = %+v", first)
+	}
+}
@@ -0,0 +1,389 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Permission is something a user may do in a condominium. Roles are mapped
+// onto permissions, and superadmins can change that mapping per
+// condominium with a PermissionOverride.
+type Permission string
+
+const (
+	PermVisitsCreate         Permission = "visits:create"
+	PermVisitsRevoke         Permission = "visits:revoke"
+	PermEntriesRecord        Permission = "entries:record"
+	PermWalkInsAnswer        Permission = "walk_ins:answer"
+	PermUsersManage          Permission = "users:manage"
+	PermAuditRead            Permission = "audit:read"
+	PermWebhooksManage       Permission = "webhooks:manage"
+	PermGatesManage          Permission = "gates:manage"
+	PermNotificationsReceive Permission = "notifications:receive"
+	PermPushSubscribe        Permission = "push:subscribe"
+	PermParcelsReceive       Permission = "parcels:receive"
+	PermParcelsCollect       Permission = "parcels:collect"
+	PermParcelsRead          Permission = "parcels:read"
+	PermIncidentsReport      Permission = "incidents:report"
+	PermIncidentsManage      Permission = "incidents:manage"
+	PermShiftsWork           Permission = "shifts:work"
+	PermLogbooksRead         Permission = "logbooks:read"
+	PermWelfareConfirm       Permission = "welfare:confirm"
+	PermPatrolsWalk          Permission = "patrols:walk"
+	PermPatrolsManage        Permission = "patrols:manage"
+	PermAnnouncementsRead    Permission = "announcements:read"
+	PermAnnouncementsManage  Permission = "announcements:manage"
+	PermAmenitiesReserve     Permission = "amenities:reserve"
+	PermAmenitiesManage      Permission = "amenities:manage"
+	PermOccupancyManage      Permission = "occupancy:manage"
+	// PermSystemManage covers what isn't bound to a condominium: the
+	// condominiums themselves, memberships, security policies and
+	// impersonation. Overrides can't grant it.
+	PermSystemManage Permission = "system:manage"
+)
+
+// Permissions lists every permission, in the order they are shown.
+var Permissions = append(slices.Clone(CondominiumPermissions), PermSystemManage)
+
+// CondominiumPermissions lists the permissions overrides can grant or
+// revoke, in the order they are shown.
+var CondominiumPermissions = []Permission{
+	PermVisitsCreate,
+	PermVisitsRevoke,
+	PermEntriesRecord,
+	PermWalkInsAnswer,
+	PermUsersManage,
+	PermAuditRead,
+	PermWebhooksManage,
+	PermGatesManage,
+	PermNotificationsReceive,
+	PermPushSubscribe,
+	PermParcelsReceive,
+	PermParcelsCollect,
+	PermParcelsRead,
+	PermIncidentsReport,
+	PermIncidentsManage,
+	PermShiftsWork,
+	PermLogbooksRead,
+	PermWelfareConfirm,
+	PermPatrolsWalk,
+	PermPatrolsManage,
+	PermAnnouncementsRead,
+	PermAnnouncementsManage,
+	PermAmenitiesReserve,
+	PermAmenitiesManage,
+	PermOccupancyManage,
+}
+
+func (p Permission) String() string {
+	switch p {
+	case PermVisitsCreate:
+		return "Crear visitas"
+	case PermVisitsRevoke:
+		return "Revocar visitas de otros"
+	case PermEntriesRecord:
+		return "Registrar ingresos"
+	case PermWalkInsAnswer:
+		return "Responder por visitas sin pase"
+	case PermUsersManage:
+		return "Administrar usuarios"
+	case PermAuditRead:
+		return "Ver la auditoría"
+	case PermWebhooksManage:
+		return "Administrar webhooks"
+	case PermGatesManage:
+		return "Administrar barreras y garitas"
+	case PermNotificationsReceive:
+		return "Recibir notificaciones"
+	case PermPushSubscribe:
+		return "Recibir notificaciones en el navegador"
+	case PermParcelsReceive:
+		return "Recibir y entregar paquetes"
+	case PermParcelsCollect:
+		return "Recoger paquetes"
+	case PermParcelsRead:
+		return "Ver reportes de paquetes"
+	case PermIncidentsReport:
+		return "Reportar incidentes"
+	case PermIncidentsManage:
+		return "Revisar incidentes"
+	case PermShiftsWork:
+		return "Hacer turnos de guardia"
+	case PermLogbooksRead:
+		return "Ver libros de novedades"
+	case PermWelfareConfirm:
+		return "Confirmar controles de bienestar"
+	case PermPatrolsWalk:
+		return "Hacer rondas"
+	case PermPatrolsManage:
+		return "Administrar rondas"
+	case PermAnnouncementsRead:
+		return "Recibir comunicados a residentes"
+	case PermAnnouncementsManage:
+		return "Publicar comunicados"
+	case PermAmenitiesReserve:
+		return "Reservar áreas comunes"
+	case PermAmenitiesManage:
+		return "Administrar áreas comunes"
+	case PermOccupancyManage:
+		return "Administrar la ocupación"
+	case PermSystemManage:
+		return "Administrar el sistema"
+	default:
+		return string(p)
+	}
+}
+
+// builtinPermissions are the permissions of each role before overrides.
+var builtinPermissions = map[UserRole][]Permission{
+	RoleSuperAdmin: Permissions,
+	RoleAdmin: {
+		PermVisitsRevoke, PermUsersManage, PermAuditRead, PermWebhooksManage, PermGatesManage,
+		PermParcelsRead, PermIncidentsManage, PermLogbooksRead, PermPatrolsManage,
+		PermAnnouncementsManage, PermAmenitiesManage, PermOccupancyManage,
+	},
+	RoleGuardian: {
+		PermEntriesRecord, PermParcelsReceive, PermIncidentsReport, PermShiftsWork,
+		PermWelfareConfirm, PermPatrolsWalk,
+	},
+	RoleUser: {
+		PermVisitsCreate, PermWalkInsAnswer, PermNotificationsReceive, PermPushSubscribe,
+		PermParcelsCollect, PermAnnouncementsRead, PermAmenitiesReserve,
+	},
+}
+
+// OverridableRoles are the roles whose permissions can be overridden.
+var OverridableRoles = []UserRole{RoleAdmin, RoleGuardian, RoleUser}
+
+// PermissionOverride grants or revokes a permission to a role in a
+// condominium.
+type PermissionOverride struct {
+	CondominiumID int64
+	Role          UserRole
+	Permission    Permission
+	// Granted is false when the override revokes the permission.
+	Granted bool
+}
+
+func (o *PermissionOverride) Valid() error {
+	if !slices.Contains(OverridableRoles, o.Role) {
+		return NewUserSafeError("Rol inválido")
+	}
+	if !slices.Contains(CondominiumPermissions, o.Permission) {
+		return NewUserSafeError("Permiso inválido")
+	}
+	return nil
+}
+
+// RolePermissions returns the permissions of the role once the overrides of
+// its condominium are applied. Overrides for other roles are ignored.
+func RolePermissions(role UserRole, overrides []PermissionOverride) []Permission {
+	perms := slices.Clone(builtinPermissions[role])
+	for _, o := range overrides {
+		if o.Role != role || !slices.Contains(CondominiumPermissions, o.Permission) {
+			continue
+		}
+		i := slices.Index(perms, o.Permission)
+		switch {
+		case o.Granted && i < 0:
+			perms = append(perms, o.Permission)
+		case !o.Granted && i >= 0:
+			perms = slices.Delete(perms, i, i+1)
+		}
+	}
+	return perms
+}
+
+// Can reports whether the user holds the permission in the condominium.
+func (u *User) Can(perm Permission, condoID int64) bool {
+	if u.Scopes != nil && !slices.Contains(u.Scopes, perm) {
+		return false
+	}
+	if u.Role == RoleSuperAdmin {
+		return slices.Contains(builtinPermissions[RoleSuperAdmin], perm)
+	}
+	for _, m := range u.Memberships {
+		if m.CondominiumID == condoID {
+			return slices.Contains(m.Permissions, perm)
+		}
+	}
+	// Users built without their memberships only have the built-in
+	// permissions of their role.
+	if len(u.Memberships) == 0 && condoID != 0 && u.CondominiumID == condoID {
+		return slices.Contains(builtinPermissions[u.Role], perm)
+	}
+	return false
+}
+
+// PermissionsIn lists the permissions the user holds in the condominium.
+func (u *User) PermissionsIn(condoID int64) []Permission {
+	var perms []Permission
+	for _, perm := range Permissions {
+		if u.Can(perm, condoID) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// RequirePermission requires the user to hold the permission in the
+// condominium it acts in.
+func RequirePermission(ctx context.Context, perm Permission) (*User, error) {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return nil, &UnauthorizedError{msg: "user not authenticated"}
+	}
+	return RequirePermissionIn(ctx, perm, user.CondominiumID)
+}
+
+// RequirePermissionIn requires the user to hold the permission in the
+// condominium, which doesn't need to be the one it acts in.
+func RequirePermissionIn(
+	ctx context.Context, perm Permission, condoID int64,
+) (*User, error) {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return nil, &UnauthorizedError{msg: "user not authenticated"}
+	}
+	if !user.Enabled {
+		return nil, &ForbiddenError{msg: "user is disabled"}
+	}
+	if !user.Can(perm, condoID) {
+		return nil, &ForbiddenError{
+			msg: fmt.Sprintf("missing permission %s", perm),
+		}
+	}
+	return user, nil
+}
+
+// RequireAnyPermission requires the user to hold at least one of the
+// permissions in the condominium it acts in. It guards areas that group
+// pages with different permissions.
+func RequireAnyPermission(ctx context.Context, perms ...Permission) (*User, error) {
+	var err error
+	for _, perm := range perms {
+		var user *User
+		user, err = RequirePermission(ctx, perm)
+		if err == nil {
+			return user, nil
+		}
+	}
+	return nil, err
+}
+
+type PermissionStore interface {
+	PermissionOverrideList(ctx context.Context, condoID int64) ([]PermissionOverride, error)
+	// PermissionOverrideReplace replaces every override of the condominium.
+	PermissionOverrideReplace(
+		ctx context.Context,
+		condoID int64,
+		overrides []PermissionOverride,
+		createdAt time.Time,
+		createdBy int64,
+	) error
+}
+
+// PermissionOverrides lists the overrides of a condominium.
+func (a *App) PermissionOverrides(
+	ctx context.Context, condoID int64,
+) ([]PermissionOverride, error) {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return nil, err
+	}
+	return a.store.PermissionOverrideList(ctx, condoID)
+}
+
+// rolePermissions maps every role to the permissions it holds in the
+// condominium.
+func (a *App) rolePermissions(
+	ctx context.Context, condoID int64,
+) (map[UserRole][]Permission, error) {
+	overrides, err := a.store.PermissionOverrideList(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[UserRole][]Permission, len(OverridableRoles))
+	for _, role := range OverridableRoles {
+		perms[role] = RolePermissions(role, overrides)
+	}
+	return perms, nil
+}
+
+// condoUsersWith lists the enabled users of the condominium whose role
+// holds the permission in it.
+func (a *App) condoUsersWith(
+	ctx context.Context, condoID int64, perm Permission,
+) ([]UserProfile, error) {
+	perms, err := a.rolePermissions(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := a.store.UserListByCondo(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var holders []UserProfile
+	for _, u := range users {
+		if u.Enabled && slices.Contains(perms[u.Role], perm) {
+			holders = append(holders, u)
+		}
+	}
+	return holders, nil
+}
+
+// SetPermissionOverrides replaces the overrides of a condominium. Overrides
+// that match the built-in permissions of the role are dropped, so that only
+// real changes are stored.
+func (a *App) SetPermissionOverrides(
+	ctx context.Context, condoID int64, overrides []PermissionOverride,
+) error {
+	actor, err := RequirePermission(ctx, PermSystemManage)
+	if err != nil {
+		return err
+	}
+	if _, err := a.store.CondoGetByID(ctx, condoID); err != nil {
+		return err
+	}
+
+	changes := make([]PermissionOverride, 0, len(overrides))
+	summary := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		if err := o.Valid(); err != nil {
+			return err
+		}
+		if slices.Contains(builtinPermissions[o.Role], o.Permission) == o.Granted {
+			continue
+		}
+		o.CondominiumID = condoID
+		changes = append(changes, o)
+
+		sign := "-"
+		if o.Granted {
+			sign = "+"
+		}
+		summary = append(summary, fmt.Sprintf("%s %s%s", o.Role, sign, string(o.Permission)))
+	}
+
+	message := "Permisos restablecidos a los de cada rol"
+	if len(summary) > 0 {
+		message = "Permisos actualizados: " + strings.Join(summary, ", ")
+	}
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.PermissionOverrideReplace(ctx, condoID, changes, time.Now(), actor.ID)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: condoID,
+			Level:         AuditCritical,
+			Action:        ActionPermissionsChanged,
+			Message:       message,
+		})
+	})
+}
@@ -0,0 +1,273 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// pushTTL is how long push services keep a notification for a browser
+	// that is offline. Arrivals and walk-ins are stale after that.
+	pushTTL = time.Hour
+	// pushTokenLifetime is how long the VAPID token of a request is valid,
+	// push services reject tokens valid for more than 24 hours.
+	pushTokenLifetime = 12 * time.Hour
+	// pushRecordSize is the record size of the encrypted content, which is
+	// always a single record.
+	pushRecordSize = 4096
+	// pushMaxPayload is the largest payload that fits in a single record,
+	// after the header, the padding delimiter and the authentication tag.
+	pushMaxPayload = pushRecordSize - 86 - 1 - 16
+)
+
+// PushSender sends Web Push notifications, encrypted as RFC 8291 describes
+// and authenticated with VAPID (RFC 8292). Subscriptions the push service
+// reports as gone are deleted.
+type PushSender struct {
+	subs    PushSubscriptionStore
+	key     *ecdsa.PrivateKey
+	subject string
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewPushSender creates a sender identified by key. The subject is a mailto:
+// or https: URL push services can reach the operator at. A nil client uses
+// one that times out.
+func NewPushSender(
+	subs PushSubscriptionStore, key *ecdsa.PrivateKey, subject string, client *http.Client,
+) (*PushSender, error) {
+	u, err := url.Parse(subject)
+	if err != nil || (u.Scheme != "mailto" && u.Scheme != "https") {
+		return nil, fmt.Errorf("the VAPID subject must be a mailto: or https: URL, got %q", subject)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: notificationTimeout}
+	}
+	return &PushSender{subs: subs, key: key, subject: subject, client: client, now: time.Now}, nil
+}
+
+// PublicKey returns the uncompressed public key, base64url encoded, that
+// browsers subscribe with as the applicationServerKey.
+func (p *PushSender) PublicKey() string {
+	key, err := p.key.ECDH()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes())
+}
+
+// pushPayload is what the service worker receives.
+type pushPayload struct {
+	Title   string               `json:"title"`
+	Body    string               `json:"body"`
+	URL     string               `json:"url,omitempty"`
+	Tag     string               `json:"tag"`
+	Actions []NotificationAction `json:"actions,omitempty"`
+}
+
+func (p *PushSender) Send(ctx context.Context, delivery *NotificationDelivery) error {
+	id, err := strconv.ParseInt(delivery.Recipient, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid subscription %q", ErrRecipientGone, delivery.Recipient)
+	}
+	sub, err := p.subs.PushSubscriptionGetByID(ctx, id)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: the subscription was removed", ErrRecipientGone)
+	}
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(pushPayload{
+		Title:   delivery.Subject,
+		Body:    delivery.Body,
+		URL:     delivery.URL,
+		Tag:     fmt.Sprintf("notification-%d", delivery.ID),
+		Actions: delivery.Actions,
+	})
+	if err != nil {
+		return err
+	}
+	body, err := encryptPush(sub, payload)
+	if err != nil {
+		return err
+	}
+	auth, err := p.authorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", auth)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		if err := p.subs.PushSubscriptionDelete(ctx, sub.ID); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: push service answered %s", ErrRecipientGone, res.Status)
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return fmt.Errorf("push service answered %s", res.Status)
+	}
+	return nil
+}
+
+// authorization returns the VAPID Authorization header for the push
+// service of endpoint: a JWT signed with ES256, and the public key.
+func (p *PushSender) authorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}{
+		Aud: u.Scheme + "://" + u.Host,
+		Exp: p.now().Add(pushTokenLifetime).Unix(),
+		Sub: p.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." +
+		enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS signatures are r and s as fixed size big endian integers.
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, enc.EncodeToString(signature), p.PublicKey()), nil
+}
+
+// encryptPush encrypts the payload for the browser of the subscription,
+// with the aes128gcm content encoding of RFC 8188 keyed as RFC 8291 says.
+func encryptPush(sub *PushSubscription, payload []byte) ([]byte, error) {
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return sealPush(sub, payload, asPrivate, salt)
+}
+
+// sealPush encrypts the payload like encryptPush, with the given key of
+// the server and salt, which must be new for every message.
+func sealPush(
+	sub *PushSubscription, payload []byte, asPrivate *ecdh.PrivateKey, salt []byte,
+) ([]byte, error) {
+	if len(payload) > pushMaxPayload {
+		return nil, fmt.Errorf("push payload of %d bytes is too large", len(payload))
+	}
+	uaPublic, err := sub.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(sub.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	cek, nonce, err := pushContentKeys(secret, authSecret, uaPublic.Bytes(), asPublic, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, and the key of the server as key ID.
+	header := append([]byte{}, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// A single record, ended by the delimiter of the last record.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// pushContentKeys derives the content encryption key and nonce from the
+// ECDH secret of the browser key uaPublic and the server key asPublic.
+func pushContentKeys(
+	secret, authSecret, uaPublic, asPublic, salt []byte,
+) (cek []byte, nonce []byte, err error) {
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 ||
+	// ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdf.Key(sha256.New, secret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	cek, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
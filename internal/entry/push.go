@@ -0,0 +1,189 @@
+package entry
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PushSubscription is a browser that subscribed to the Web Push
+// notifications of a user.
+type PushSubscription struct {
+	ID     int64
+	UserID int64
+	// Endpoint is the URL of the push service the notifications are sent
+	// to, unique to the browser.
+	Endpoint string
+	// P256DH and Auth are the keys of the browser, base64url encoded, that
+	// the notifications are encrypted for.
+	P256DH string
+	Auth   string
+	// UserAgent tells the user which device the subscription is.
+	UserAgent string
+	CreatedAt time.Time
+}
+
+const (
+	pushUserAgentLength = 200
+	pushAuthLength      = 16
+)
+
+func (s *PushSubscription) Valid() error {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return NewUserSafeError("La suscripción no tiene un servicio de notificaciones válido")
+	}
+	if _, err := s.publicKey(); err != nil {
+		return NewUserSafeError("La clave de la suscripción es inválida")
+	}
+	if auth, err := base64.RawURLEncoding.DecodeString(s.Auth); err != nil || len(auth) != pushAuthLength {
+		return NewUserSafeError("El secreto de la suscripción es inválido")
+	}
+	return nil
+}
+
+func (s *PushSubscription) publicKey() (*ecdh.PublicKey, error) {
+	key, err := base64.RawURLEncoding.DecodeString(s.P256DH)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.P256().NewPublicKey(key)
+}
+
+type PushSubscriptionStore interface {
+	PushSubscriptionList(ctx context.Context, userID int64) ([]PushSubscription, error)
+	PushSubscriptionGetByID(ctx context.Context, id int64) (*PushSubscription, error)
+	// PushSubscriptionSave creates the subscription, or replaces the one
+	// with the same endpoint, even if it belonged to another user.
+	PushSubscriptionSave(ctx context.Context, sub *PushSubscription) (*PushSubscription, error)
+	PushSubscriptionDelete(ctx context.Context, id int64) error
+	// VAPIDKeyGet returns the PKCS #8 encoded VAPID key, or a NotFoundError
+	// if there is none yet.
+	VAPIDKeyGet(ctx context.Context) ([]byte, error)
+	// VAPIDKeySave saves the key unless there already is one.
+	VAPIDKeySave(ctx context.Context, key []byte, createdAt time.Time) error
+}
+
+// LoadVAPIDKey returns the key that identifies the server to push
+// services, generating it the first time. It must not change, push
+// services reject notifications for subscriptions made with another key.
+func LoadVAPIDKey(ctx context.Context, store PushSubscriptionStore) (*ecdsa.PrivateKey, error) {
+	der, err := store.VAPIDKeyGet(ctx)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.VAPIDKeySave(ctx, der, time.Now()); err != nil {
+			return nil, err
+		}
+		// Another instance may have saved its key first.
+		der, err = store.VAPIDKeyGet(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the VAPID key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, errors.New("the VAPID key is not a P-256 key")
+	}
+	return key, nil
+}
+
+// PushPublicKey returns the key browsers subscribe with, base64url encoded,
+// or an empty string if push notifications are not enabled.
+func (a *App) PushPublicKey() string {
+	sender, ok := a.notifier.senders[ChannelPush].(*PushSender)
+	if !ok {
+		return ""
+	}
+	return sender.PublicKey()
+}
+
+// PushSubscriptions lists the browsers the user in ctx subscribed.
+func (a *App) PushSubscriptions(ctx context.Context) ([]PushSubscription, error) {
+	user, err := RequirePermission(ctx, PermPushSubscribe)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.PushSubscriptionList(ctx, user.ID)
+}
+
+// SubscribePush saves a browser subscription of the user in ctx. Users that
+// didn't choose push notifications for any event get them for arrivals and
+// walk-ins, what subscribing is mostly for.
+func (a *App) SubscribePush(ctx context.Context, sub PushSubscription) error {
+	user, err := RequirePermission(ctx, PermPushSubscribe)
+	if err != nil {
+		return err
+	}
+	if a.PushPublicKey() == "" {
+		return NewUserSafeError("Las notificaciones del navegador no están habilitadas")
+	}
+
+	sub.UserID = user.ID
+	sub.CreatedAt = time.Now()
+	if len(sub.UserAgent) > pushUserAgentLength {
+		sub.UserAgent = sub.UserAgent[:pushUserAgentLength]
+	}
+	if err := sub.Valid(); err != nil {
+		return err
+	}
+	if _, err := a.store.PushSubscriptionSave(ctx, &sub); err != nil {
+		return err
+	}
+
+	prefs, err := a.store.NotificationPreferencesGet(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, channels := range prefs.Channels {
+		if slices.Contains(channels, ChannelPush) {
+			return nil
+		}
+	}
+	for _, event := range []NotificationEvent{NotifyVisitArrived, NotifyWalkIn} {
+		prefs.Channels[event] = append(prefs.Channels[event], ChannelPush)
+	}
+	return a.store.NotificationPreferencesSave(ctx, prefs)
+}
+
+// UnsubscribePush removes a subscription of the user in ctx, by ID or, if
+// id is zero, by endpoint.
+func (a *App) UnsubscribePush(ctx context.Context, id int64, endpoint string) error {
+	subs, err := a.PushSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint = strings.TrimSpace(endpoint)
+	i := slices.IndexFunc(subs, func(s PushSubscription) bool {
+		return (id != 0 && s.ID == id) || (id == 0 && s.Endpoint == endpoint)
+	})
+	if i < 0 {
+		return NewNotFoundError("Dispositivo no encontrado")
+	}
+	return a.store.PushSubscriptionDelete(ctx, subs[i].ID)
+}
@@ -0,0 +1,388 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBrowser holds the keys a browser subscribes with.
+type fakeBrowser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newFakeBrowser(t *testing.T) *fakeBrowser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, pushAuthLength)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+	return &fakeBrowser{key: key, auth: auth}
+}
+
+func (b *fakeBrowser) subscription(endpoint string) PushSubscription {
+	enc := base64.RawURLEncoding
+	return PushSubscription{
+		UserID:   1,
+		Endpoint: endpoint,
+		P256DH:   enc.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     enc.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses encryptPush, as the browser does.
+func (b *fakeBrowser) decrypt(body []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("body too short")
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != pushRecordSize {
+		return nil, fmt.Errorf("record size %d", rs)
+	}
+	idLen := int(body[20])
+	if len(body) < 21+idLen {
+		return nil, errors.New("key ID too short")
+	}
+	asPublic := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	serverKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := b.key.ECDH(serverKey)
+	if err != nil {
+		return nil, err
+	}
+	cek, nonce, err := pushContentKeys(secret, b.auth, b.key.PublicKey().Bytes(), asPublic, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		return nil, errors.New("missing the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
+
+// fakePushService is a push service that checks the VAPID authorization,
+// decrypts the notifications for browser and answers with status.
+type fakePushService struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	browser  *fakeBrowser
+	key      *ecdsa.PublicKey
+	payloads []pushPayload
+	errors   []error
+}
+
+func newFakePushService(t *testing.T, browser *fakeBrowser, key *ecdsa.PublicKey) *fakePushService {
+	ps := &fakePushService{status: http.StatusCreated, browser: browser, key: key}
+	ps.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := ps.receive(r)
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		if err != nil {
+			ps.errors = append(ps.errors, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ps.payloads = append(ps.payloads, payload)
+		w.WriteHeader(ps.status)
+	}))
+	t.Cleanup(ps.Close)
+	return ps
+}
+
+func (ps *fakePushService) receive(r *http.Request) (pushPayload, error) {
+	var payload pushPayload
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+		return payload, errors.New("missing Web Push headers")
+	}
+	if err := ps.verify(r.Header.Get("Authorization")); err != nil {
+		return payload, err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return payload, err
+	}
+	plaintext, err := ps.browser.decrypt(body)
+	if err != nil {
+		return payload, err
+	}
+	err = json.Unmarshal(plaintext, &payload)
+	return payload, err
+}
+
+// verify checks the VAPID JWT was signed by the key of the server, for this
+// push service.
+func (ps *fakePushService) verify(header string) error {
+	token, key, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	if !ok {
+		return fmt.Errorf("authorization %q is not VAPID", header)
+	}
+	enc := base64.RawURLEncoding
+	publicKey, err := ps.key.ECDH()
+	if err != nil {
+		return err
+	}
+	if key != enc.EncodeToString(publicKey.Bytes()) {
+		return errors.New("wrong VAPID public key")
+	}
+
+	i := strings.LastIndex(token, ".")
+	signature, err := enc.DecodeString(token[i+1:])
+	if err != nil || len(signature) != 64 {
+		return errors.New("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(token[:i]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(ps.key, digest[:], r, s) {
+		return errors.New("invalid signature")
+	}
+
+	_, encoded, _ := strings.Cut(token[:i], ".")
+	claims, err := enc.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	var c struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(claims, &c); err != nil {
+		return err
+	}
+	if c.Aud != ps.URL || c.Sub != "mailto:admin@example.com" || c.Exp <= time.Now().Unix() {
+		return fmt.Errorf("unexpected claims %+v", c)
+	}
+	return nil
+}
+
+func newTestPushNotifier(
+	t *testing.T, store *memNotificationStore,
+) (*Notifier, *fakePushService, *fakeBrowser) {
+	ctx := context.Background()
+	key, err := LoadVAPIDKey(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := newFakeBrowser(t)
+	ps := newFakePushService(t, browser, &key.PublicKey)
+
+	sender, err := NewPushSender(store, key, "mailto:admin@example.com", ps.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNotifier(store, memUsers{1: {ID: 1, CondominiumID: 1}}, slog.New(slog.DiscardHandler))
+	n.Register(ChannelPush, sender)
+
+	sub := browser.subscription(ps.URL + "/push/abc")
+	if _, err := store.PushSubscriptionSave(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	return n, ps, browser
+}
+
+// The example of RFC 8291, section 5.
+func TestEncryptPushRFC8291(t *testing.T) {
+	b64 := func(s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	var (
+		plaintext = b64("V2hlbiBJIGdyb3cgdXAsIEkgd2FudCB0byBiZSBhIHdhdGVybWVsb24")
+		asPrivate = b64("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw")
+		asPublic  = b64("BP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A8")
+		uaPublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		secret    = b64("kyrL1jIIOHEzg3sM2ZWRHDRB62YACZhhSlknJ672kSs")
+		auth      = "BTBZMqHH6r4Tts7J_aSIgg"
+		salt      = b64("DGv6ra1nlYgDCS1FRnbzlw")
+		cek       = b64("oIhVW04MRdy2XN9CiKLxTg")
+		nonce     = b64("4h_95klXJ5E_qnoN")
+		encrypted = b64("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+	)
+
+	gotCEK, gotNonce, err := pushContentKeys(secret, b64(auth), b64(uaPublic), asPublic, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotCEK, cek) {
+		t.Errorf("content encryption key = %x, want %x", gotCEK, cek)
+	}
+	if !bytes.Equal(gotNonce, nonce) {
+		t.Errorf("nonce = %x, want %x", gotNonce, nonce)
+	}
+
+	key, err := ecdh.P256().NewPrivateKey(asPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := &PushSubscription{P256DH: uaPublic, Auth: auth}
+	got, err := sealPush(sub, plaintext, key, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, encrypted) {
+		t.Errorf("encrypted = %s\nwant %s",
+			base64.RawURLEncoding.EncodeToString(got), base64.RawURLEncoding.EncodeToString(encrypted))
+	}
+}
+
+func TestPushSender(t *testing.T) {
+	ctx := context.Background()
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{
+			NotifyWalkIn: {ChannelPush},
+		}},
+	}}
+	notifier, ps, _ := newTestPushNotifier(t, store)
+
+	actions := []NotificationAction{
+		{Action: "allowed", Title: "Sí, puede pasar", URL: "/neighbor/walk-ins/7", Form: "decision=allowed"},
+		{Action: "denied", Title: "No puede pasar", URL: "/neighbor/walk-ins/7", Form: "decision=denied"},
+	}
+	err := notifier.Notify(ctx, Notification{
+		UserID: 1, Event: NotifyWalkIn, Title: "Te buscan en la garita",
+		Body: "Luis te espera.", URL: "/neighbor/notifications", Actions: actions,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deliveries) != 1 || store.deliveries[0].Recipient != "1" {
+		t.Fatalf("deliveries = %+v, want one to the subscription", store.deliveries)
+	}
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ps.errors) != 0 {
+		t.Fatalf("push service rejected the notification: %v", ps.errors)
+	}
+	if d := store.deliveries[0]; d.Status != DeliveryDelivered {
+		t.Errorf("delivery = %+v, want delivered", d)
+	}
+	if len(ps.payloads) != 1 {
+		t.Fatalf("push service got %d notifications, want 1", len(ps.payloads))
+	}
+	got := ps.payloads[0]
+	if got.Title != "Te buscan en la garita" || got.Body != "Luis te espera." ||
+		got.URL != "/neighbor/notifications" || len(got.Actions) != 2 || got.Actions[0] != actions[0] {
+		t.Errorf("payload = %+v", got)
+	}
+}
+
+func TestPushSenderPrunesGoneSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	store := &memNotificationStore{prefs: map[int64]*NotificationPreferences{
+		1: {UserID: 1, Channels: map[NotificationEvent][]NotificationChannel{
+			NotifyVisitArrived: {ChannelPush},
+		}},
+	}}
+	notifier, ps, _ := newTestPushNotifier(t, store)
+	ps.status = http.StatusGone
+
+	if err := notifier.Notify(ctx, Notification{UserID: 1, Event: NotifyVisitArrived}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := store.deliveries[0]; d.Status != DeliveryFailed || d.Attempts != 1 {
+		t.Errorf("delivery = %+v, want failed without retries", d)
+	}
+	if len(store.subs) != 0 {
+		t.Errorf("subscriptions = %+v, want the gone one deleted", store.subs)
+	}
+
+	// Without subscriptions there is nothing to deliver.
+	if err := notifier.Notify(ctx, Notification{UserID: 1, Event: NotifyVisitArrived}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deliveries) != 1 {
+		t.Errorf("deliveries = %d, want no new ones", len(store.deliveries))
+	}
+}
+
+func TestLoadVAPIDKeyKeepsTheKey(t *testing.T) {
+	ctx := context.Background()
+	store := &memNotificationStore{}
+	first, err := LoadVAPIDKey(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadVAPIDKey(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Equal(second) {
+		t.Error("LoadVAPIDKey generated a new key over the saved one")
+	}
+}
+
+func TestPushSubscriptionValid(t *testing.T) {
+	valid := newFakeBrowser(t).subscription("https://push.example.com/send/abc")
+
+	tests := []struct {
+		name   string
+		change func(*PushSubscription)
+		ok     bool
+	}{
+		{"valid", func(*PushSubscription) {}, true},
+		{"http endpoint", func(s *PushSubscription) { s.Endpoint = "http://push.example.com/abc" }, false},
+		{"no endpoint", func(s *PushSubscription) { s.Endpoint = "" }, false},
+		{"bad key", func(s *PushSubscription) { s.P256DH = "AAAA" }, false},
+		{"padded key", func(s *PushSubscription) { s.P256DH += "=" }, false},
+		{"short auth", func(s *PushSubscription) { s.Auth = "AAAA" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := valid
+			tt.change(&sub)
+			if err := sub.Valid(); (err == nil) != tt.ok {
+				t.Errorf("Valid() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
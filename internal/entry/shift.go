@@ -0,0 +1,372 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Shift is the time a guard is on duty. Entries, parcels and incidents are
+// attributed to the open shift of the guard that records them.
+type Shift struct {
+	ID            int64
+	CondominiumID int64
+	// GuardID is zero if the guard was deleted.
+	GuardID   int64
+	GuardName string
+	// StationID is the guard station the shift was started at, zero if the
+	// guard picked none.
+	StationID   int64
+	StationName string
+	StartedAt   time.Time
+	// EndedAt is the zero time while the shift is open.
+	EndedAt time.Time
+	// HandoverNotes are what the guard left for the next shift.
+	HandoverNotes string
+	// Logbook is generated when the shift is closed, nil while it is open.
+	Logbook *Logbook
+	// WelfareCheckedAt is when the guard last confirmed they were fine, the
+	// zero time if they haven't yet. WelfareEscalatedAt is when the admins
+	// were told that they didn't, the zero time unless the last check was
+	// missed.
+	WelfareCheckedAt   time.Time
+	WelfareEscalatedAt time.Time
+}
+
+func (s *Shift) Open() bool {
+	return s.EndedAt.IsZero()
+}
+
+// Logbook is the "libro de novedades" of a shift: what happened at the gate
+// while it was open. It is archived with the shift when it is closed.
+type Logbook struct {
+	Entries          []LogbookEntry    `json:"entries"`
+	Incidents        []LogbookIncident `json:"incidents"`
+	ParcelsReceived  []LogbookParcel   `json:"parcels_received"`
+	ParcelsCollected []LogbookParcel   `json:"parcels_collected"`
+}
+
+// LogbookEntry is a check-in attempt in a logbook.
+type LogbookEntry struct {
+	At          time.Time `json:"at"`
+	VisitID     string    `json:"visit_id"`
+	VisitorName string    `json:"visitor_name"`
+	Accepted    bool      `json:"accepted"`
+	Reason      string    `json:"reason"`
+}
+
+// LogbookIncident is an incident reported in a logbook.
+type LogbookIncident struct {
+	ID       int64            `json:"id"`
+	At       time.Time        `json:"at"`
+	Category IncidentCategory `json:"category"`
+	Severity IncidentSeverity `json:"severity"`
+	Location string           `json:"location"`
+}
+
+// LogbookParcel is a parcel received or handed over in a logbook. At is
+// when it happened, and CollectedBy is empty for parcels received.
+// ResidentName is who the parcel is for, see Parcel.Recipient.
+type LogbookParcel struct {
+	ID           int64     `json:"id"`
+	At           time.Time `json:"at"`
+	ResidentName string    `json:"resident_name"`
+	Carrier      string    `json:"carrier"`
+	CollectedBy  string    `json:"collected_by"`
+}
+
+// Accepted counts the accepted check-ins of the logbook.
+func (l *Logbook) Accepted() int {
+	return len(l.Entries) - l.Denied()
+}
+
+// Denied counts the denied check-ins of the logbook.
+func (l *Logbook) Denied() int {
+	denied := 0
+	for _, e := range l.Entries {
+		if !e.Accepted {
+			denied++
+		}
+	}
+	return denied
+}
+
+// newLogbook summarizes what was recorded in a shift.
+func newLogbook(
+	entries []Entry, incidents []Incident, received []Parcel, collected []Parcel,
+) *Logbook {
+	logbook := &Logbook{
+		Entries:          make([]LogbookEntry, 0, len(entries)),
+		Incidents:        make([]LogbookIncident, 0, len(incidents)),
+		ParcelsReceived:  make([]LogbookParcel, 0, len(received)),
+		ParcelsCollected: make([]LogbookParcel, 0, len(collected)),
+	}
+	for _, e := range entries {
+		logbook.Entries = append(logbook.Entries, LogbookEntry{
+			At:          e.CreatedAt,
+			VisitID:     e.VisitID,
+			VisitorName: e.VisitorName,
+			Accepted:    e.Accepted,
+			Reason:      e.Reason,
+		})
+	}
+	for _, i := range incidents {
+		logbook.Incidents = append(logbook.Incidents, LogbookIncident{
+			ID:       i.ID,
+			At:       i.CreatedAt,
+			Category: i.Category,
+			Severity: i.Severity,
+			Location: i.Location,
+		})
+	}
+	for _, p := range received {
+		logbook.ParcelsReceived = append(logbook.ParcelsReceived, LogbookParcel{
+			ID:           p.ID,
+			At:           p.CreatedAt,
+			ResidentName: p.Recipient(),
+			Carrier:      p.Carrier,
+		})
+	}
+	for _, p := range collected {
+		logbook.ParcelsCollected = append(logbook.ParcelsCollected, LogbookParcel{
+			ID:           p.ID,
+			At:           p.CollectedAt,
+			ResidentName: p.Recipient(),
+			Carrier:      p.Carrier,
+			CollectedBy:  p.CollectedBy,
+		})
+	}
+	return logbook
+}
+
+const (
+	handoverNotesLength = 2000
+	// shiftListSize is how many closed shifts the archive shows.
+	shiftListSize = 100
+)
+
+type ShiftStore interface {
+	// ShiftCreate fails with a UserSafeError if the guard already has a
+	// shift open.
+	ShiftCreate(ctx context.Context, shift *Shift) (*Shift, error)
+	ShiftGetByID(ctx context.Context, id int64) (*Shift, error)
+	// ShiftGetOpen returns the open shift of a guard, or a NotFoundError if
+	// it has none.
+	ShiftGetOpen(ctx context.Context, guardID int64) (*Shift, error)
+	// ShiftListClosed lists the latest closed shifts of a condominium,
+	// latest first.
+	ShiftListClosed(ctx context.Context, condoID int64, limit int64) ([]Shift, error)
+	// ShiftListOverlapping lists the shifts of a condominium that were open
+	// at some point from since until until, earliest first.
+	ShiftListOverlapping(
+		ctx context.Context, condoID int64, since time.Time, until time.Time,
+	) ([]Shift, error)
+	// ShiftClose ends the shift and archives its logbook. It fails with a
+	// UserSafeError if the shift was already closed.
+	ShiftClose(
+		ctx context.Context, id int64, at time.Time, notes string, logbook *Logbook,
+	) error
+	// ShiftListWelfareOverdue lists the open shifts whose welfare check is
+	// past its grace period at, and wasn't escalated yet.
+	ShiftListWelfareOverdue(ctx context.Context, at time.Time) ([]Shift, error)
+	// ShiftEscalateWelfare marks the welfare check of the shift as escalated,
+	// and returns false if it already was.
+	ShiftEscalateWelfare(ctx context.Context, id int64, at time.Time) (bool, error)
+	// ShiftConfirmWelfare records that the guard confirmed they are fine. It
+	// fails with a UserSafeError if the shift was closed.
+	ShiftConfirmWelfare(ctx context.Context, id int64, at time.Time) error
+}
+
+// openShiftID returns the ID of the open shift of the guard, zero if it has
+// none.
+func (a *App) openShiftID(ctx context.Context, guard *User) (int64, error) {
+	shift, err := a.store.ShiftGetOpen(ctx, guard.ID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return shift.ID, nil
+}
+
+// OpenShift returns the open shift of the guard, nil if it has none.
+func (a *App) OpenShift(ctx context.Context) (*Shift, error) {
+	guard, err := RequirePermission(ctx, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := a.store.ShiftGetOpen(ctx, guard.ID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	return shift, err
+}
+
+// LastHandover returns the last shift closed in the guard's condominium,
+// with the notes it left for the next one, nil if there is none.
+func (a *App) LastHandover(ctx context.Context) (*Shift, error) {
+	guard, err := RequirePermission(ctx, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+
+	shifts, err := a.store.ShiftListClosed(ctx, guard.CondominiumID, 1)
+	if err != nil || len(shifts) == 0 {
+		return nil, err
+	}
+	return &shifts[0], nil
+}
+
+// StartShift opens a shift for the guard at the guard station stationID,
+// zero for none.
+func (a *App) StartShift(ctx context.Context, stationID int64) (*Shift, error) {
+	guard, err := RequirePermission(ctx, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+	if guard.CondominiumID == 0 {
+		return nil, NewUserSafeError("Solo los guardias de un condominio tienen turnos")
+	}
+	if stationID != 0 {
+		if _, err := a.GuardStation(ctx, stationID); err != nil {
+			return nil, err
+		}
+	}
+
+	var shift *Shift
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		shift, err = a.store.ShiftCreate(ctx, &Shift{
+			CondominiumID: guard.CondominiumID,
+			GuardID:       guard.ID,
+			StationID:     stationID,
+			StartedAt:     time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: shift.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionShiftStarted,
+			Message:       fmt.Sprintf("%s inició su turno", shift.GuardName),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// CloseShift closes the open shift of the guard with notes for the next
+// shift, and archives its logbook.
+func (a *App) CloseShift(ctx context.Context, notes string) (*Shift, error) {
+	guard, err := RequirePermission(ctx, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+
+	notes = strings.TrimSpace(notes)
+	if utf8.RuneCountInString(notes) > handoverNotesLength {
+		return nil, NewUserSafeError(fmt.Sprintf(
+			"Las notas no pueden tener más de %d caracteres", handoverNotesLength,
+		))
+	}
+
+	shift, err := a.store.ShiftGetOpen(ctx, guard.ID)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, NewUserSafeError("No tienes un turno abierto")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		// The logbook is read in the transaction, so that nothing recorded
+		// while the shift closes is left out of it.
+		logbook, err := a.shiftLogbook(ctx, shift.ID)
+		if err != nil {
+			return err
+		}
+		if err := a.store.ShiftClose(ctx, shift.ID, time.Now(), notes, logbook); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: shift.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionShiftClosed,
+			Message: fmt.Sprintf(
+				"%s cerró su turno: %d ingresos, %d denegados, %d incidentes",
+				shift.GuardName, logbook.Accepted(), logbook.Denied(), len(logbook.Incidents),
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.store.ShiftGetByID(ctx, shift.ID)
+}
+
+// shiftLogbook summarizes what was recorded in the shift so far.
+func (a *App) shiftLogbook(ctx context.Context, shiftID int64) (*Logbook, error) {
+	entries, err := a.store.EntryListByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := a.store.IncidentListByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	received, err := a.store.ParcelListReceivedByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	collected, err := a.store.ParcelListCollectedByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	return newLogbook(entries, incidents, received, collected), nil
+}
+
+// Shift returns a shift of the condominium of the user, who must be a guard
+// or able to read the logbooks. Open shifts come with their logbook so far.
+func (a *App) Shift(ctx context.Context, id int64) (*Shift, error) {
+	user, err := RequireAnyPermission(ctx, PermLogbooksRead, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := a.store.ShiftGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shift.CondominiumID != user.CondominiumID {
+		return nil, NewNotFoundError("Turno no encontrado")
+	}
+	if shift.Logbook == nil {
+		shift.Logbook, err = a.shiftLogbook(ctx, shift.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shift, nil
+}
+
+// Logbooks lists the latest closed shifts of the condominium of the user,
+// with their logbooks.
+func (a *App) Logbooks(ctx context.Context) ([]Shift, error) {
+	user, err := RequireAnyPermission(ctx, PermLogbooksRead, PermShiftsWork)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.ShiftListClosed(ctx, user.CondominiumID, shiftListSize)
+}
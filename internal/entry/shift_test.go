@@ -0,0 +1,68 @@
+package entry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogbook(t *testing.T) {
+	at := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{VisitID: "ABC123", VisitorName: "Luis", Accepted: true, CreatedAt: at},
+		{VisitID: "", Reason: "Código no encontrado", CreatedAt: at.Add(time.Hour)},
+		{VisitID: "XYZ789", VisitorName: "Ana", Reason: "Visita revocada", CreatedAt: at.Add(2 * time.Hour)},
+	}
+	incidents := []Incident{
+		{ID: 4, Category: IncidentNoise, Severity: SeverityLow, Location: "Casa 12", CreatedAt: at},
+	}
+	received := []Parcel{
+		{ID: 7, ResidentName: "Vero Vecina", Carrier: "DHL", PickupCode: "123456", CreatedAt: at},
+	}
+	collected := []Parcel{
+		{ID: 3, ResidentName: "Vero Vecina", Carrier: "UPS", PickupCode: "654321",
+			CollectedBy: "Juan", CreatedAt: at.Add(-48 * time.Hour), CollectedAt: at.Add(3 * time.Hour)},
+	}
+
+	logbook := newLogbook(entries, incidents, received, collected)
+	if len(logbook.Entries) != 3 || logbook.Accepted() != 1 || logbook.Denied() != 2 {
+		t.Errorf("entries = %d, accepted = %d, denied = %d; want 3, 1 and 2",
+			len(logbook.Entries), logbook.Accepted(), logbook.Denied())
+	}
+	if len(logbook.Incidents) != 1 || logbook.Incidents[0].Location != "Casa 12" {
+		t.Errorf("incidents = %+v", logbook.Incidents)
+	}
+	if got := logbook.ParcelsCollected[0]; !got.At.Equal(at.Add(3*time.Hour)) || got.CollectedBy != "Juan" {
+		t.Errorf("collected parcel = %+v, want it at the time it was handed over", got)
+	}
+
+	// Archived logbooks must not keep the pickup codes.
+	encoded, err := json.Marshal(logbook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Logbook
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"123456", "654321"} {
+		if strings.Contains(string(encoded), code) {
+			t.Errorf("archived logbook %s holds the pickup code %s", encoded, code)
+		}
+	}
+	if decoded.Denied() != 2 || len(decoded.ParcelsReceived) != 1 {
+		t.Errorf("decoded logbook = %+v", decoded)
+	}
+}
+
+func TestNewLogbookEmpty(t *testing.T) {
+	encoded, err := json.Marshal(newLogbook(nil, nil, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"entries":[],"incidents":[],"parcels_received":[],"parcels_collected":[]}`
+	if string(encoded) != want {
+		t.Errorf("empty logbook = %s, want %s", encoded, want)
+	}
+}
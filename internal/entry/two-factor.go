@@ -0,0 +1,111 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TwoFactorRequirement makes two-factor authentication mandatory for every
+// user with Role. A zero CondominiumID applies the requirement to all
+// condominiums.
+type TwoFactorRequirement struct {
+	ID            int64
+	CondominiumID int64
+	Role          UserRole
+	CreatedAt     time.Time
+	CreatedBy     int64
+}
+
+type TwoFactorStore interface {
+	TwoFactorRequirementList(ctx context.Context) ([]TwoFactorRequirement, error)
+	TwoFactorRequirementCreate(
+		ctx context.Context, req *TwoFactorRequirement,
+	) (*TwoFactorRequirement, error)
+	TwoFactorRequirementDelete(ctx context.Context, id int64) error
+}
+
+func (a *App) TwoFactorRequirements(
+	ctx context.Context,
+) ([]TwoFactorRequirement, error) {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return nil, err
+	}
+	return a.store.TwoFactorRequirementList(ctx)
+}
+
+func (a *App) RequireTwoFactor(
+	ctx context.Context, condoID int64, role UserRole,
+) (*TwoFactorRequirement, error) {
+	user, err := RequirePermission(ctx, PermSystemManage)
+	if err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case RoleSuperAdmin, RoleAdmin, RoleGuardian, RoleUser:
+	default:
+		return nil, NewUserSafeError("Rol inválido")
+	}
+
+	var req *TwoFactorRequirement
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		req, err = a.store.TwoFactorRequirementCreate(ctx, &TwoFactorRequirement{
+			CondominiumID: condoID,
+			Role:          role,
+			CreatedAt:     time.Now(),
+			CreatedBy:     user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: condoID,
+			Level:         AuditImportant,
+			Action:        ActionTwoFactorChanged,
+			Message: fmt.Sprintf(
+				"Verificación en dos pasos obligatoria para el rol %s", role,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (a *App) RemoveTwoFactorRequirement(ctx context.Context, id int64) error {
+	if _, err := RequirePermission(ctx, PermSystemManage); err != nil {
+		return err
+	}
+
+	requirements, err := a.store.TwoFactorRequirementList(ctx)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(requirements, func(r TwoFactorRequirement) bool {
+		return r.ID == id
+	})
+	if idx < 0 {
+		return NewNotFoundError("Requisito no encontrado")
+	}
+	req := requirements[idx]
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.TwoFactorRequirementDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: req.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionTwoFactorChanged,
+			Message: fmt.Sprintf(
+				"Verificación en dos pasos ya no es obligatoria para el rol %s", req.Role,
+			),
+		})
+	})
+}
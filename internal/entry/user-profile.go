@@ -0,0 +1,252 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// UserProfile is the full user record, used to manage users. Authorization
+// only needs User.
+type UserProfile struct {
+	ID            int64
+	CondominiumID int64
+	FirstName     string
+	LastName      string
+	Email         string
+	Phone         string
+	Role          UserRole
+	Enabled       bool
+	Hidden        bool
+	// Unit is where the user lives in its home condominium, zero if an
+	// admin didn't set it.
+	Unit      Unit
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy int64
+	UpdatedBy int64
+}
+
+func (u *UserProfile) FullName() string {
+	return u.FirstName + " " + u.LastName
+}
+
+// Unit is the home of a resident: a tower, or building, and the unit in it.
+// Condominiums of houses leave the tower empty.
+type Unit struct {
+	Tower  string
+	Number string
+}
+
+const unitFieldLength = 50
+
+func (u Unit) IsZero() bool {
+	return u.Number == ""
+}
+
+func (u Unit) String() string {
+	if u.Tower == "" {
+		return u.Number
+	}
+	return u.Tower + " - " + u.Number
+}
+
+// Key identifies the unit in forms, see ParseUnitKey.
+func (u Unit) Key() string {
+	return url.QueryEscape(u.Tower) + "/" + url.QueryEscape(u.Number)
+}
+
+// ParseUnitKey parses a key made by Unit.Key.
+func ParseUnitKey(key string) (Unit, error) {
+	tower, number, ok := strings.Cut(key, "/")
+	if !ok {
+		return Unit{}, NewUserSafeError("Unidad inválida")
+	}
+	var u Unit
+	var err error
+	if u.Tower, err = url.QueryUnescape(tower); err != nil {
+		return Unit{}, NewUserSafeError("Unidad inválida")
+	}
+	if u.Number, err = url.QueryUnescape(number); err != nil || u.Number == "" {
+		return Unit{}, NewUserSafeError("Unidad inválida")
+	}
+	return u, nil
+}
+
+type UserStore interface {
+	// UserGetByID returns a NotFoundError if the user doesn't exist.
+	UserGetByID(ctx context.Context, id int64) (*UserProfile, error)
+	// UserGetByEmail returns a NotFoundError if the user doesn't exist.
+	UserGetByEmail(ctx context.Context, email string) (*UserProfile, error)
+	UserListByCondo(ctx context.Context, condoID int64) ([]UserProfile, error)
+	UserUpdate(
+		ctx context.Context,
+		id int64,
+		updateFn func(user *UserProfile) (*UserProfile, error),
+	) error
+}
+
+func (a *App) ListUsers(ctx context.Context, condoID int64) ([]UserProfile, error) {
+	if _, err := RequirePermissionIn(ctx, PermUsersManage, condoID); err != nil {
+		return nil, err
+	}
+	return a.store.UserListByCondo(ctx, condoID)
+}
+
+// SetUserEnabled enables or disables a user of the admin's condominium.
+// Enabling a hidden user approves its registration.
+func (a *App) SetUserEnabled(
+	ctx context.Context, userID int64, enabled bool,
+) (*UserProfile, error) {
+	user, err := a.store.UserGetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := RequirePermissionIn(ctx, PermUsersManage, user.CondominiumID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID == user.ID {
+		return nil, NewUserSafeError("No puedes deshabilitar tu propia cuenta")
+	}
+	if user.Role == RoleSuperAdmin && !actor.Can(PermSystemManage, 0) {
+		return nil, &ForbiddenError{msg: "insufficient permissions"}
+	}
+
+	var updated *UserProfile
+	var approved bool
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.UserUpdate(ctx, userID, func(u *UserProfile) (*UserProfile, error) {
+			approved = enabled && u.Hidden
+			u.Enabled = enabled
+			if enabled {
+				u.Hidden = false
+			}
+			u.UpdatedAt = time.Now()
+			u.UpdatedBy = actor.ID
+			updated = u
+			return u, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("Usuario deshabilitado: %s", updated.Email)
+		if enabled {
+			message = fmt.Sprintf("Usuario habilitado: %s", updated.Email)
+		}
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: updated.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionUserChanged,
+			Message:       message,
+		})
+		if err != nil || !approved {
+			return err
+		}
+
+		return a.publishWebhookEvent(
+			ctx, updated.CondominiumID, EventUserApproved, newWebhookUser(updated),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info(
+		"User enabled changed",
+		"user_id", userID,
+		"enabled", enabled,
+		"actor_id", actor.ID,
+	)
+
+	return updated, nil
+}
+
+// SetUserUnit sets where a user of the admin's condominium lives. A zero
+// unit clears it.
+func (a *App) SetUserUnit(ctx context.Context, userID int64, unit Unit) error {
+	user, err := a.store.UserGetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	actor, err := RequirePermissionIn(ctx, PermUsersManage, user.CondominiumID)
+	if err != nil {
+		return err
+	}
+
+	unit.Tower = strings.TrimSpace(unit.Tower)
+	unit.Number = strings.TrimSpace(unit.Number)
+	if unit.Number == "" && unit.Tower != "" {
+		return NewUserSafeError("Indica la unidad dentro de la torre")
+	}
+	if utf8.RuneCountInString(unit.Tower) > unitFieldLength ||
+		utf8.RuneCountInString(unit.Number) > unitFieldLength {
+		return NewUserSafeError(fmt.Sprintf(
+			"La torre y la unidad no pueden tener más de %d caracteres", unitFieldLength,
+		))
+	}
+
+	message := fmt.Sprintf("Unidad de %s: ninguna", user.Email)
+	if !unit.IsZero() {
+		message = fmt.Sprintf("Unidad de %s: %s", user.Email, unit)
+	}
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.UserUpdate(ctx, userID, func(u *UserProfile) (*UserProfile, error) {
+			u.Unit = unit
+			u.UpdatedAt = time.Now()
+			u.UpdatedBy = actor.ID
+			return u, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: user.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionUserChanged,
+			Message:       message,
+		})
+	})
+}
+
+// condoUnits lists the towers and the units the residents of the
+// condominium that hold perm live in, sorted.
+func (a *App) condoUnits(
+	ctx context.Context, condoID int64, perm Permission,
+) ([]string, []Unit, error) {
+	users, err := a.condoUsersWith(ctx, condoID, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var towers []string
+	var units []Unit
+	for _, u := range users {
+		if u.CondominiumID != condoID || u.Unit.IsZero() {
+			continue
+		}
+		if u.Unit.Tower != "" && !slices.Contains(towers, u.Unit.Tower) {
+			towers = append(towers, u.Unit.Tower)
+		}
+		if !slices.Contains(units, u.Unit) {
+			units = append(units, u.Unit)
+		}
+	}
+
+	slices.Sort(towers)
+	slices.SortFunc(units, func(a, b Unit) int {
+		if c := strings.Compare(a.Tower, b.Tower); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Number, b.Number)
+	})
+	return towers, units, nil
+}
@@ -14,3 +14,17 @@ func (e UserSafeError) Error() string {
 func NewUserSafeError(msg string) UserSafeError {
 	return UserSafeError{msg: msg}
 }
+
+// NotFoundError is returned when the requested entity doesn't exist, or the
+// user is not allowed to know it exists.
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.msg
+}
+
+func NewNotFoundError(msg string) *NotFoundError {
+	return &NotFoundError{msg: msg}
+}
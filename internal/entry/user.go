@@ -13,11 +13,34 @@ const (
 
 // User represents a user in the domain layer.
 // Contains only the information needed for domain-level authorization.
+// CondominiumID and Role are the ones of the selected condominium.
 type User struct {
 	ID            int64
 	CondominiumID int64
 	Role          UserRole
 	Enabled       bool
+	// Memberships are all the condominiums the user holds a role in,
+	// including the selected one.
+	Memberships []Membership
+	// Scopes limit the permissions of requests authenticated with an API
+	// token. Nil means the user isn't limited.
+	Scopes []Permission
+}
+
+// Membership is a role held in a condominium. Every user, except
+// superadmins, has a home membership, and may have more.
+type Membership struct {
+	CondominiumID   int64
+	CondominiumName string
+	Role            UserRole
+	// Home is the membership stored with the user.
+	Home bool
+	// Permissions are the permissions of the role in the condominium, see
+	// RolePermissions.
+	Permissions []Permission
+	// TwoFactorRequired reports whether a superadmin made two-factor
+	// authentication mandatory for the role in the condominium.
+	TwoFactorRequired bool
 }
 
 type userCtxKey struct{}
@@ -46,32 +69,3 @@ type ForbiddenError struct {
 func (e *ForbiddenError) Error() string {
 	return e.msg
 }
-
-func RequireRole(ctx context.Context, role UserRole) (*User, error) {
-	user := UserFromCtx(ctx)
-	if user == nil {
-		return nil, &UnauthorizedError{msg: "user not authenticated"}
-	}
-	if !user.Enabled {
-		return nil, &ForbiddenError{msg: "user is disabled"}
-	}
-	if user.Role != role && user.Role != RoleSuperAdmin {
-		return nil, &ForbiddenError{msg: "insufficient permissions"}
-	}
-	return user, nil
-}
-
-func RequireRoleAndCondo(
-	ctx context.Context, role UserRole, condoID int64,
-) (*User, error) {
-	user, err := RequireRole(ctx, role)
-	if err != nil {
-		return nil, err
-	}
-	if user.CondominiumID != condoID && user.Role != RoleSuperAdmin {
-		return nil, &ForbiddenError{
-			msg: "insufficient permissions for this condominium",
-		}
-	}
-	return user, nil
-}
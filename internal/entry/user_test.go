@@ -0,0 +1,84 @@
+package entry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequirePermissionIn(t *testing.T) {
+	user := &User{
+		ID:            1,
+		CondominiumID: 1,
+		Role:          RoleAdmin,
+		Enabled:       true,
+		Memberships: []Membership{
+			{
+				CondominiumID: 1,
+				Role:          RoleAdmin,
+				Home:          true,
+				Permissions:   RolePermissions(RoleAdmin, nil),
+			},
+			{
+				CondominiumID: 2,
+				Role:          RoleGuardian,
+				Permissions:   RolePermissions(RoleGuardian, nil),
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		perm    Permission
+		condoID int64
+		allowed bool
+	}{
+		{"home condominium", PermUsersManage, 1, true},
+		{"other membership", PermEntriesRecord, 2, true},
+		{"missing in membership", PermUsersManage, 2, false},
+		{"not a member", PermUsersManage, 3, false},
+		{"system", PermSystemManage, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithUser(context.Background(), user)
+			_, err := RequirePermissionIn(ctx, tt.perm, tt.condoID)
+			if got := err == nil; got != tt.allowed {
+				t.Errorf("allowed = %t; want %t (err: %v)", got, tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	overrides := []PermissionOverride{
+		{Role: RoleGuardian, Permission: PermVisitsRevoke, Granted: true},
+		{Role: RoleAdmin, Permission: PermUsersManage, Granted: false},
+		{Role: RoleAdmin, Permission: PermSystemManage, Granted: true},
+	}
+
+	tests := []struct {
+		name    string
+		role    UserRole
+		perm    Permission
+		allowed bool
+	}{
+		{"built-in", RoleGuardian, PermEntriesRecord, true},
+		{"granted", RoleGuardian, PermVisitsRevoke, true},
+		{"revoked", RoleAdmin, PermUsersManage, false},
+		{"kept", RoleAdmin, PermAuditRead, true},
+		{"system can't be granted", RoleAdmin, PermSystemManage, false},
+		{"other role", RoleUser, PermVisitsRevoke, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms := RolePermissions(tt.role, overrides)
+			got := false
+			for _, p := range perms {
+				got = got || p == tt.perm
+			}
+			if got != tt.allowed {
+				t.Errorf("allowed = %t; want %t (perms: %v)", got, tt.allowed, perms)
+			}
+		})
+	}
+}
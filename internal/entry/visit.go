@@ -2,6 +2,11 @@ package entry
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -14,8 +19,45 @@ type Visit struct {
 	Uses          int64
 	ValidFrom     time.Time
 	ValidTo       time.Time
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// RevokedAt is the zero time while the visit hasn't been revoked.
+	RevokedAt time.Time
+	RevokedBy int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v *Visit) Valid() error {
+	if strings.TrimSpace(v.VisitorName) == "" {
+		return NewUserSafeError("El nombre del visitante es obligatorio")
+	}
+	if v.MaxUses < 0 {
+		return NewUserSafeError("Los usos máximos no pueden ser negativos")
+	}
+	if v.ValidTo.Before(v.ValidFrom) {
+		return NewUserSafeError("La fecha final debe ser posterior a la inicial")
+	}
+	return nil
+}
+
+func (v *Visit) Revoked() bool {
+	return !v.RevokedAt.IsZero()
+}
+
+// DenialReason returns why the visit can't be used at now, or an empty
+// string if it can.
+func (v *Visit) DenialReason(now time.Time) string {
+	switch {
+	case v.Revoked():
+		return "La visita fue revocada"
+	case now.Before(v.ValidFrom):
+		return "La visita aún no es válida"
+	case now.After(v.ValidTo):
+		return "La visita expiró"
+	case v.MaxUses > 0 && v.Uses >= v.MaxUses:
+		return "La visita ya no tiene usos disponibles"
+	default:
+		return ""
+	}
 }
 
 type VisitStore interface {
@@ -26,4 +68,188 @@ type VisitStore interface {
 		id string,
 		updateFn func(visit *Visit) (*Visit, error),
 	) error
+	VisitListByUser(ctx context.Context, userID int64) ([]Visit, error)
+	// VisitListExpected lists the visits of a condominium that can still
+	// be used at some point between since and until.
+	VisitListExpected(
+		ctx context.Context, condoID int64, since time.Time, until time.Time,
+	) ([]Visit, error)
+}
+
+// CreateVisit registers a visit for the user in ctx. The ID of the returned
+// visit is the code the visitor shows at the gate.
+func (a *App) CreateVisit(ctx context.Context, visit Visit) (*Visit, error) {
+	user, err := RequirePermission(ctx, PermVisitsCreate)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Tu usuario no pertenece a un condominio")
+	}
+	if err := visit.Valid(); err != nil {
+		return nil, err
+	}
+
+	code, err := generateVisitCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	visit.ID = code
+	visit.CondominiumID = user.CondominiumID
+	visit.UserID = user.ID
+	visit.VisitorName = strings.TrimSpace(visit.VisitorName)
+	visit.Uses = 0
+	visit.RevokedAt = time.Time{}
+	visit.RevokedBy = 0
+	visit.CreatedAt = now
+	visit.UpdatedAt = now
+
+	var created *Visit
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.VisitCreate(ctx, &visit)
+		if err != nil {
+			return err
+		}
+		return a.visitCreated(ctx, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.publishVisit(created)
+	return created, nil
+}
+
+// visitCreated records the new visit in the audit log and queues it for
+// the webhooks. It runs in the transaction that creates the visit.
+func (a *App) visitCreated(ctx context.Context, created *Visit) error {
+	err := a.audit.Record(ctx, AuditRecord{
+		CondominiumID: created.CondominiumID,
+		Level:         AuditInfo,
+		Action:        ActionVisitCreated,
+		Message: fmt.Sprintf(
+			"Visita creada para %s (código %s)", created.VisitorName, created.ID,
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.publishWebhookEvent(
+		ctx, created.CondominiumID, EventVisitCreated, newWebhookVisit(created),
+	)
+}
+
+// publishVisit shows a new visit on the dashboards of the guards if it can
+// be used today.
+func (a *App) publishVisit(created *Visit) {
+	if today := startOfDay(time.Now()); created.ValidFrom.Before(today.AddDate(0, 0, 1)) &&
+		!created.ValidTo.Before(today) {
+		a.live.Publish(LiveEvent{
+			Kind:          LiveVisit,
+			CondominiumID: created.CondominiumID,
+			Visit:         created,
+		})
+	}
+}
+
+// ExpectedVisits lists the visits of the guard's condominium that can still
+// be used today.
+func (a *App) ExpectedVisits(ctx context.Context) ([]Visit, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	today := startOfDay(time.Now())
+	return a.store.VisitListExpected(ctx, guard.CondominiumID, today, today.AddDate(0, 0, 1))
+}
+
+// MyVisits lists the visits of the user in ctx.
+func (a *App) MyVisits(ctx context.Context) ([]Visit, error) {
+	user, err := RequirePermission(ctx, PermVisitsCreate)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.VisitListByUser(ctx, user.ID)
+}
+
+// RevokeVisit stops a visit from being used. Residents can revoke their own
+// visits, users with PermVisitsRevoke any visit of the condominium.
+func (a *App) RevokeVisit(ctx context.Context, id string) error {
+	user := UserFromCtx(ctx)
+	if user == nil {
+		return &UnauthorizedError{msg: "user not authenticated"}
+	}
+
+	visit, err := a.store.VisitGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if visit.UserID != user.ID {
+		_, err := RequirePermissionIn(ctx, PermVisitsRevoke, visit.CondominiumID)
+		if err != nil {
+			return NewNotFoundError("Visita no encontrada")
+		}
+	} else if _, err := RequirePermissionIn(ctx, PermVisitsCreate, visit.CondominiumID); err != nil {
+		return err
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		err := a.store.VisitUpdate(ctx, id, func(v *Visit) (*Visit, error) {
+			if v.Revoked() {
+				return nil, NewUserSafeError("La visita ya fue revocada")
+			}
+			now := time.Now()
+			v.RevokedAt = now
+			v.RevokedBy = user.ID
+			v.UpdatedAt = now
+			visit = v
+			return v, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = a.audit.Record(ctx, AuditRecord{
+			CondominiumID: visit.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionVisitRevoked,
+			Message: fmt.Sprintf(
+				"Visita revocada para %s (código %s)", visit.VisitorName, visit.ID,
+			),
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.publishWebhookEvent(
+			ctx, visit.CondominiumID, EventVisitRevoked, newWebhookVisit(visit),
+		)
+	})
+}
+
+// NormalizeVisitCode removes the formatting a guard may type along with a
+// visit code.
+func NormalizeVisitCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+var errVisitDenied = errors.New("visit denied")
+
+func generateVisitCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
 }
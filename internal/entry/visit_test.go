@@ -0,0 +1,38 @@
+package entry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVisitDenialReason(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	base := Visit{
+		VisitorName: "Juan",
+		ValidFrom:   now.Add(-time.Hour),
+		ValidTo:     now.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(v *Visit)
+		denied bool
+	}{
+		{"valid", func(v *Visit) {}, false},
+		{"unlimited uses", func(v *Visit) { v.Uses = 100 }, false},
+		{"uses left", func(v *Visit) { v.MaxUses = 2; v.Uses = 1 }, false},
+		{"uses exhausted", func(v *Visit) { v.MaxUses = 2; v.Uses = 2 }, true},
+		{"not started", func(v *Visit) { v.ValidFrom = now.Add(time.Minute) }, true},
+		{"expired", func(v *Visit) { v.ValidTo = now.Add(-time.Minute) }, true},
+		{"revoked", func(v *Visit) { v.RevokedAt = now.Add(-time.Minute) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := base
+			tt.modify(&v)
+			if got := v.DenialReason(now) != ""; got != tt.denied {
+				t.Errorf("denied = %t; want %t", got, tt.denied)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WalkInDecision is what the resident answered about a walk-in.
+type WalkInDecision string
+
+const (
+	WalkInPending WalkInDecision = ""
+	WalkInAllowed WalkInDecision = "allowed"
+	WalkInDenied  WalkInDecision = "denied"
+)
+
+func (d WalkInDecision) String() string {
+	switch d {
+	case WalkInPending:
+		return "Esperando respuesta"
+	case WalkInAllowed:
+		return "Puede pasar"
+	case WalkInDenied:
+		return "No puede pasar"
+	default:
+		return string(d)
+	}
+}
+
+// WalkIn is a visitor without a visit, announced by a guard to the
+// resident it came to see.
+type WalkIn struct {
+	ID            int64
+	CondominiumID int64
+	ResidentID    int64
+	ResidentName  string
+	VisitorName   string
+	// AnnouncedBy is the guard, zero if it was deleted.
+	AnnouncedBy int64
+	Decision    WalkInDecision
+	// DecidedAt is the zero time while the walk-in is pending.
+	DecidedAt time.Time
+	CreatedAt time.Time
+}
+
+type WalkInStore interface {
+	WalkInCreate(ctx context.Context, walkIn *WalkIn) (*WalkIn, error)
+	WalkInGetByID(ctx context.Context, id int64) (*WalkIn, error)
+	WalkInListByCondo(ctx context.Context, condoID int64, since time.Time) ([]WalkIn, error)
+	WalkInListPending(ctx context.Context, residentID int64, since time.Time) ([]WalkIn, error)
+	// WalkInDecide fails with a UserSafeError if the walk-in was already
+	// decided.
+	WalkInDecide(ctx context.Context, id int64, decision WalkInDecision, at time.Time) error
+}
+
+// AnnounceWalkIn tells a resident that a visitor without a visit is waiting
+// at the gate, and lets it decide whether the visitor may come in.
+func (a *App) AnnounceWalkIn(ctx context.Context, residentID int64, visitorName string) error {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return err
+	}
+
+	visitorName = strings.TrimSpace(visitorName)
+	if visitorName == "" {
+		return NewUserSafeError("El nombre del visitante es obligatorio")
+	}
+
+	resident, err := a.condoResident(ctx, guard, residentID, PermWalkInsAnswer)
+	if err != nil {
+		return err
+	}
+
+	// The walk-in is only stored if the resident is told about it.
+	var walkIn *WalkIn
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		walkIn, err = a.store.WalkInCreate(ctx, &WalkIn{
+			CondominiumID: resident.CondominiumID,
+			ResidentID:    resident.ID,
+			VisitorName:   visitorName,
+			AnnouncedBy:   guard.ID,
+			CreatedAt:     time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.notifier.Notify(ctx, Notification{
+			UserID:        resident.ID,
+			CondominiumID: resident.CondominiumID,
+			Event:         NotifyWalkIn,
+			Title:         "Te buscan en la garita",
+			Body:          fmt.Sprintf("%s te espera en la garita, sin pase de visita.", visitorName),
+			URL:           "/neighbor/notifications",
+			Actions:       walkInActions(walkIn),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	a.publishWalkIn(walkIn)
+	return nil
+}
+
+// walkInActions lets the resident answer a walk-in from the notification.
+func walkInActions(walkIn *WalkIn) []NotificationAction {
+	url := fmt.Sprintf("/neighbor/walk-ins/%d", walkIn.ID)
+	return []NotificationAction{
+		{Action: string(WalkInAllowed), Title: "Sí, puede pasar", URL: url, Form: "decision=allowed"},
+		{Action: string(WalkInDenied), Title: "No puede pasar", URL: url, Form: "decision=denied"},
+	}
+}
+
+// TodayWalkIns lists the walk-ins of the guard's condominium since
+// midnight.
+func (a *App) TodayWalkIns(ctx context.Context) ([]WalkIn, error) {
+	guard, err := RequirePermission(ctx, PermEntriesRecord)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.WalkInListByCondo(ctx, guard.CondominiumID, startOfDay(time.Now()))
+}
+
+// PendingWalkIns lists today's walk-ins the user in ctx hasn't answered.
+func (a *App) PendingWalkIns(ctx context.Context) ([]WalkIn, error) {
+	user, err := RequirePermission(ctx, PermWalkInsAnswer)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.WalkInListPending(ctx, user.ID, startOfDay(time.Now()))
+}
+
+// DecideWalkIn records whether the visitor of a walk-in announced to the
+// user in ctx may come in, and tells the guards. Walk-ins from before
+// today can't be answered anymore.
+func (a *App) DecideWalkIn(ctx context.Context, id int64, decision WalkInDecision) error {
+	user, err := RequirePermission(ctx, PermWalkInsAnswer)
+	if err != nil {
+		return err
+	}
+	if decision != WalkInAllowed && decision != WalkInDenied {
+		return NewUserSafeError("Respuesta inválida")
+	}
+
+	walkIn, err := a.store.WalkInGetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if walkIn.ResidentID != user.ID {
+		return NewNotFoundError("Visita no encontrada")
+	}
+
+	now := time.Now()
+	if walkIn.CreatedAt.Before(startOfDay(now)) {
+		return NewUserSafeError("Esta visita ya no espera en la garita")
+	}
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.WalkInDecide(ctx, walkIn.ID, decision, now); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: walkIn.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionWalkInDecided,
+			Message: fmt.Sprintf(
+				"Visita sin pase de %s: %s", walkIn.VisitorName, decision,
+			),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	walkIn.Decision = decision
+	walkIn.DecidedAt = now
+
+	a.publishWalkIn(walkIn)
+	return nil
+}
+
+// publishWalkIn updates the dashboards of the guards and of the resident.
+func (a *App) publishWalkIn(walkIn *WalkIn) {
+	event := LiveEvent{
+		Kind:          LiveWalkIn,
+		CondominiumID: walkIn.CondominiumID,
+		WalkIn:        walkIn,
+	}
+	a.live.Publish(event)
+
+	event.UserID = walkIn.ResidentID
+	a.live.Publish(event)
+}
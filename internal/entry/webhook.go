@@ -0,0 +1,793 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WebhookEvent is a kind of event condominiums can be notified of.
+type WebhookEvent string
+
+const (
+	EventVisitCreated  WebhookEvent = "visit.created"
+	EventVisitRevoked  WebhookEvent = "visit.revoked"
+	EventEntryAccepted WebhookEvent = "entry.accepted"
+	EventEntryDenied   WebhookEvent = "entry.denied"
+	// EventUserApproved is sent when an admin enables a user whose
+	// registration was pending.
+	EventUserApproved WebhookEvent = "user.approved"
+)
+
+// WebhookEvents lists every event, in the order they are shown.
+var WebhookEvents = []WebhookEvent{
+	EventVisitCreated,
+	EventVisitRevoked,
+	EventEntryAccepted,
+	EventEntryDenied,
+	EventUserApproved,
+}
+
+func (e WebhookEvent) String() string {
+	switch e {
+	case EventVisitCreated:
+		return "Visita creada"
+	case EventVisitRevoked:
+		return "Visita revocada"
+	case EventEntryAccepted:
+		return "Ingreso aceptado"
+	case EventEntryDenied:
+		return "Ingreso denegado"
+	case EventUserApproved:
+		return "Usuario aprobado"
+	default:
+		return string(e)
+	}
+}
+
+// Webhook is an endpoint of a condominium that receives the events it
+// subscribed to.
+type Webhook struct {
+	ID            int64
+	CondominiumID int64
+	URL           string
+	// Secret signs the deliveries, so that the receiver can verify them.
+	Secret    string
+	Events    []WebhookEvent
+	CreatedAt time.Time
+	CreatedBy int64
+}
+
+func (w *Webhook) Valid() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || u.User != nil {
+		return NewUserSafeError("La URL del webhook es inválida")
+	}
+	if u.Scheme != "https" {
+		return NewUserSafeError("La URL del webhook debe usar https")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	ip := net.ParseIP(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		ip != nil && internalAddress(ip) {
+		return errWebhookInternal
+	}
+	if len(w.Events) == 0 {
+		return NewUserSafeError("Elige al menos un evento")
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return NewUserSafeError("Evento inválido")
+		}
+	}
+	return nil
+}
+
+var errWebhookInternal = NewUserSafeError("La URL del webhook no puede apuntar a una red interna")
+
+// internalAddress reports whether ip belongs to the server or its network,
+// where webhooks must not reach.
+func internalAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// checkWebhookHost resolves the host of a webhook and rejects it if any of
+// its addresses is internal. The dispatcher checks again when it connects,
+// the host may resolve to something else by then.
+func checkWebhookHost(ctx context.Context, w *Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return NewUserSafeError("No se encontró el servidor del webhook")
+	}
+	for _, addr := range addrs {
+		if internalAddress(addr.IP) {
+			return errWebhookInternal
+		}
+	}
+	return nil
+}
+
+// webhookDialControl refuses connections to internal addresses. It runs
+// after the host is resolved, so a host can't pass the check when the
+// webhook is saved and resolve to an internal address later.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || internalAddress(ip) {
+		return fmt.Errorf("webhook: refusing to connect to the internal address %s", host)
+	}
+	return nil
+}
+
+func (w *Webhook) Subscribed(event WebhookEvent) bool {
+	return slices.Contains(w.Events, event)
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// DeliveryFailed deliveries ran out of attempts, they are only sent
+	// again if an admin asks for it.
+	DeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+func (s WebhookDeliveryStatus) String() string {
+	switch s {
+	case DeliveryPending:
+		return "Pendiente"
+	case DeliveryDelivered:
+		return "Entregado"
+	case DeliveryFailed:
+		return "Fallido"
+	default:
+		return string(s)
+	}
+}
+
+// WebhookDelivery is an event waiting to be sent to a webhook, or already
+// sent. Deliveries are the outbox of the webhooks: they are stored along
+// with the change that caused them, and sent later by the
+// WebhookDispatcher.
+type WebhookDelivery struct {
+	ID        int64
+	WebhookID int64
+	// EventID is shared by the redeliveries of an event, so that receivers
+	// can discard duplicates.
+	EventID       string
+	Event         WebhookEvent
+	Payload       string
+	Status        WebhookDeliveryStatus
+	Attempts      int64
+	NextAttemptAt time.Time
+	// LastAttemptAt is the zero time until the first attempt.
+	LastAttemptAt time.Time
+	// ResponseStatus is the status code of the last response, zero if
+	// there was none.
+	ResponseStatus int64
+	LastError      string
+	CreatedAt      time.Time
+}
+
+type WebhookStore interface {
+	WebhookList(ctx context.Context, condoID int64) ([]Webhook, error)
+	WebhookGetByID(ctx context.Context, id int64) (*Webhook, error)
+	WebhookCreate(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	WebhookDelete(ctx context.Context, id int64) error
+	WebhookDeliveryCreate(
+		ctx context.Context, delivery *WebhookDelivery,
+	) (*WebhookDelivery, error)
+	WebhookDeliveryGetByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	// WebhookDeliveryList returns the latest deliveries of the webhook,
+	// newest first.
+	WebhookDeliveryList(
+		ctx context.Context, webhookID int64, limit int64,
+	) ([]WebhookDelivery, error)
+	// WebhookDeliveryDue returns the pending deliveries whose next attempt
+	// is due at now, oldest first.
+	WebhookDeliveryDue(
+		ctx context.Context, now time.Time, limit int64,
+	) ([]WebhookDelivery, error)
+	// WebhookDeliveryUpdate saves the result of an attempt.
+	WebhookDeliveryUpdate(ctx context.Context, delivery *WebhookDelivery) error
+}
+
+// webhookDeliveryLog is how many deliveries are shown per webhook.
+const webhookDeliveryLog = 50
+
+// Webhooks lists the webhooks of the condominium of the user in ctx.
+func (a *App) Webhooks(ctx context.Context) ([]Webhook, error) {
+	user, err := RequirePermission(ctx, PermWebhooksManage)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.WebhookList(ctx, user.CondominiumID)
+}
+
+// Webhook returns a webhook along with its latest deliveries.
+func (a *App) Webhook(
+	ctx context.Context, id int64,
+) (*Webhook, []WebhookDelivery, error) {
+	webhook, err := a.webhookForAdmin(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	deliveries, err := a.store.WebhookDeliveryList(ctx, id, webhookDeliveryLog)
+	if err != nil {
+		return nil, nil, err
+	}
+	return webhook, deliveries, nil
+}
+
+// CreateWebhook registers an endpoint in the condominium of the user in
+// ctx. The secret is generated here, the admin copies it to the receiver.
+func (a *App) CreateWebhook(
+	ctx context.Context, endpoint string, events []WebhookEvent,
+) (*Webhook, error) {
+	user, err := RequirePermission(ctx, PermWebhooksManage)
+	if err != nil {
+		return nil, err
+	}
+	if user.CondominiumID == 0 {
+		return nil, NewUserSafeError("Elige un condominio para registrar el webhook")
+	}
+
+	secret, err := randomToken("whsec_", 24)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &Webhook{
+		CondominiumID: user.CondominiumID,
+		URL:           strings.TrimSpace(endpoint),
+		Secret:        secret,
+		CreatedAt:     time.Now(),
+		CreatedBy:     user.ID,
+	}
+	for _, event := range events {
+		if !slices.Contains(WebhookEvents, event) {
+			return nil, NewUserSafeError("Evento inválido")
+		}
+	}
+	// Keep the order of WebhookEvents, whatever the order of the form.
+	for _, event := range WebhookEvents {
+		if slices.Contains(events, event) {
+			webhook.Events = append(webhook.Events, event)
+		}
+	}
+	if err := webhook.Valid(); err != nil {
+		return nil, err
+	}
+	if err := checkWebhookHost(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	var created *Webhook
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		created, err = a.store.WebhookCreate(ctx, webhook)
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: created.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionWebhookChanged,
+			Message: fmt.Sprintf(
+				"Webhook registrado: %s (%s)", created.URL, joinWebhookEvents(created.Events),
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// DeleteWebhook removes a webhook along with its deliveries.
+func (a *App) DeleteWebhook(ctx context.Context, id int64) error {
+	webhook, err := a.webhookForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.WebhookDelete(ctx, id); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: webhook.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionWebhookChanged,
+			Message:       fmt.Sprintf("Webhook eliminado: %s", webhook.URL),
+		})
+	})
+}
+
+// RedeliverWebhook sends an event again, whatever the result of its
+// previous deliveries. The previous deliveries are kept in the log, the
+// new one keeps their event ID.
+func (a *App) RedeliverWebhook(
+	ctx context.Context, webhookID int64, deliveryID int64,
+) (*WebhookDelivery, error) {
+	webhook, err := a.webhookForAdmin(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := a.store.WebhookDeliveryGetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.WebhookID != webhook.ID {
+		return nil, NewNotFoundError("Entrega no encontrada")
+	}
+
+	now := time.Now()
+	var delivery *WebhookDelivery
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		delivery, err = a.store.WebhookDeliveryCreate(ctx, &WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       previous.EventID,
+			Event:         previous.Event,
+			Payload:       previous.Payload,
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: webhook.CondominiumID,
+			Level:         AuditInfo,
+			Action:        ActionWebhookChanged,
+			Message: fmt.Sprintf(
+				"Evento %s reenviado a %s", previous.EventID, webhook.URL,
+			),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// webhookForAdmin returns the webhook if the user in ctx manages the
+// webhooks of its condominium. Webhooks of other condominiums are not
+// found.
+func (a *App) webhookForAdmin(ctx context.Context, id int64) (*Webhook, error) {
+	if _, err := RequirePermission(ctx, PermWebhooksManage); err != nil {
+		return nil, err
+	}
+
+	webhook, err := a.store.WebhookGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := RequirePermissionIn(ctx, PermWebhooksManage, webhook.CondominiumID); err != nil {
+		return nil, NewNotFoundError("Webhook no encontrado")
+	}
+	return webhook, nil
+}
+
+// WebhookPayload is the body of every delivery. Data depends on the event:
+// WebhookVisit for visit events, WebhookEntry for entry events and
+// WebhookUser for user events.
+type WebhookPayload struct {
+	ID            string       `json:"id"`
+	Event         WebhookEvent `json:"event"`
+	CondominiumID int64        `json:"condominium_id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Data          any          `json:"data"`
+}
+
+type WebhookVisit struct {
+	Code        string     `json:"code"`
+	VisitorName string     `json:"visitor_name"`
+	MaxUses     int64      `json:"max_uses"`
+	Uses        int64      `json:"uses"`
+	ValidFrom   time.Time  `json:"valid_from"`
+	ValidTo     time.Time  `json:"valid_to"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newWebhookVisit(v *Visit) WebhookVisit {
+	visit := WebhookVisit{
+		Code:        v.ID,
+		VisitorName: v.VisitorName,
+		MaxUses:     v.MaxUses,
+		Uses:        v.Uses,
+		ValidFrom:   v.ValidFrom,
+		ValidTo:     v.ValidTo,
+		CreatedAt:   v.CreatedAt,
+	}
+	if v.Revoked() {
+		visit.RevokedAt = &v.RevokedAt
+	}
+	return visit
+}
+
+type WebhookEntry struct {
+	ID          int64     `json:"id"`
+	VisitCode   string    `json:"visit_code"`
+	VisitorName string    `json:"visitor_name"`
+	Accepted    bool      `json:"accepted"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newWebhookEntry(e *Entry) WebhookEntry {
+	return WebhookEntry{
+		ID:          e.ID,
+		VisitCode:   e.VisitID,
+		VisitorName: e.VisitorName,
+		Accepted:    e.Accepted,
+		Reason:      e.Reason,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+type WebhookUser struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+}
+
+func newWebhookUser(u *UserProfile) WebhookUser {
+	return WebhookUser{
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Role:      string(u.Role),
+	}
+}
+
+// publishWebhookEvent queues a delivery of the event for every webhook of
+// the condominium subscribed to it. It must run in the transaction of the
+// change that caused the event.
+func (a *App) publishWebhookEvent(
+	ctx context.Context, condoID int64, event WebhookEvent, data any,
+) error {
+	return enqueueWebhookEvent(ctx, a.store, condoID, event, data, time.Now())
+}
+
+func enqueueWebhookEvent(
+	ctx context.Context,
+	store WebhookStore,
+	condoID int64,
+	event WebhookEvent,
+	data any,
+	now time.Time,
+) error {
+	webhooks, err := store.WebhookList(ctx, condoID)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	var eventID string
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event) {
+			continue
+		}
+
+		// The payload is built once, every webhook gets the same event.
+		if payload == nil {
+			eventID, err = randomToken("evt_", 16)
+			if err != nil {
+				return err
+			}
+			payload, err = json.Marshal(WebhookPayload{
+				ID:            eventID,
+				Event:         event,
+				CondominiumID: condoID,
+				CreatedAt:     now,
+				Data:          data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := store.WebhookDeliveryCreate(ctx, &WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Headers of the deliveries.
+const (
+	WebhookEventHeader     = "X-Entry-Watch-Event"
+	WebhookDeliveryHeader  = "X-Entry-Watch-Delivery"
+	WebhookSignatureHeader = "X-Entry-Watch-Signature"
+)
+
+// SignWebhook returns the signature header of a payload sent at timestamp.
+// The signature is an HMAC-SHA256 of "<timestamp>.<payload>", where the
+// timestamp is in Unix seconds, so that old deliveries can't be replayed.
+func SignWebhook(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + webhookMAC(secret, t, payload)
+}
+
+// VerifyWebhookSignature checks a signature header made by SignWebhook.
+// Signatures older than tolerance are rejected.
+func VerifyWebhookSignature(
+	secret string,
+	header string,
+	payload []byte,
+	now time.Time,
+	tolerance time.Duration,
+) error {
+	var t, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || signature == "" {
+		return errors.New("malformed signature header")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp out of tolerance")
+	}
+	if !hmac.Equal([]byte(signature), []byte(webhookMAC(secret, t, payload))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func webhookMAC(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+const (
+	// webhookMaxAttempts is how many times a delivery is tried before it
+	// is marked as failed. With webhookRetryBase, the last attempt is about
+	// an hour after the first.
+	webhookMaxAttempts = 8
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+	webhookTimeout     = 10 * time.Second
+	webhookBatchSize   = 50
+	// webhookErrorLength limits the error stored for an attempt.
+	webhookErrorLength = 500
+)
+
+// webhookRetryDelay doubles the wait after every failed attempt.
+func webhookRetryDelay(attempts int64) time.Duration {
+	delay := webhookRetryBase
+	for i := int64(1); i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+// WebhookDispatcher sends the pending deliveries.
+type WebhookDispatcher struct {
+	store  WebhookStore
+	client *http.Client
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewWebhookDispatcher creates a dispatcher. A nil client uses one that
+// times out, doesn't connect to internal addresses and doesn't follow
+// redirects, a redirect counts as a failure.
+func NewWebhookDispatcher(
+	store WebhookStore, client *http.Client, logger *slog.Logger,
+) *WebhookDispatcher {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// A proxy would be the address checked, not the webhook.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: webhookDialControl,
+		}).DialContext
+		client = &http.Client{
+			Transport: transport,
+			Timeout:   webhookTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &WebhookDispatcher{
+		store:  store,
+		client: client,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run sends the due deliveries every interval until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("Failed to deliver webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes an attempt for every due delivery. Failed attempts are
+// not errors, they are saved in the delivery and retried later.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) error {
+	deliveries, err := d.store.WebhookDeliveryDue(ctx, d.now(), webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	webhooks := map[int64]*Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.store.WebhookGetByID(ctx, delivery.WebhookID)
+			var notFound *NotFoundError
+			if errors.As(err, &notFound) {
+				// Deleted while the delivery was loaded.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			webhooks[webhook.ID] = webhook
+		}
+
+		d.attempt(ctx, webhook, &delivery)
+		if err := d.store.WebhookDeliveryUpdate(ctx, &delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt sends the delivery once and records the result in it.
+func (d *WebhookDispatcher) attempt(
+	ctx context.Context, webhook *Webhook, delivery *WebhookDelivery,
+) {
+	now := d.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.LastError = ""
+
+	status, err := d.send(ctx, webhook, delivery, now)
+	delivery.ResponseStatus = int64(status)
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > webhookErrorLength {
+		delivery.LastError = delivery.LastError[:webhookErrorLength]
+	}
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(webhookRetryDelay(delivery.Attempts))
+	}
+
+	d.logger.Warn(
+		"Webhook delivery failed",
+		"webhook_id", webhook.ID,
+		"delivery_id", delivery.ID,
+		"attempts", delivery.Attempts,
+		"error", err,
+	)
+}
+
+// send posts the payload and returns the status of the response. Responses
+// other than 2xx are errors.
+func (d *WebhookDispatcher) send(
+	ctx context.Context, webhook *Webhook, delivery *WebhookDelivery, now time.Time,
+) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload),
+	)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Entry-Watch-Webhooks/1")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookDeliveryHeader, delivery.EventID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, now, payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	// Drain a bit of the body, so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func joinWebhookEvents(events []WebhookEvent) string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, string(event))
+	}
+	return strings.Join(names, " ")
+}
+
+// randomToken returns prefix followed by n random bytes, base64url
+// encoded.
+func randomToken(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,300 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// WelfareCheck is the next time a guard working alone has to confirm they
+// are fine.
+type WelfareCheck struct {
+	Shift Shift
+	// StationName is the station the guard works at.
+	StationName string
+	DueAt       time.Time
+	// EscalatesAt is when the admins are told, if the guard hasn't
+	// confirmed by then.
+	EscalatesAt time.Time
+}
+
+// Escalated tells whether the admins were told that the guard didn't
+// confirm.
+func (c *WelfareCheck) Escalated() bool {
+	return !c.Shift.WelfareEscalatedAt.IsZero()
+}
+
+const (
+	minWelfareInterval = 10
+	maxWelfareInterval = 12 * 60
+	maxWelfareGrace    = 60
+)
+
+// welfareCheck returns the next welfare check of the shift at the station,
+// nil if the station has none.
+func welfareCheck(shift *Shift, station *GuardStation) *WelfareCheck {
+	if station.WelfareIntervalMinutes <= 0 {
+		return nil
+	}
+
+	last := shift.StartedAt
+	if !shift.WelfareCheckedAt.IsZero() {
+		last = shift.WelfareCheckedAt
+	}
+	due := last.Add(time.Duration(station.WelfareIntervalMinutes) * time.Minute)
+	return &WelfareCheck{
+		Shift:       *shift,
+		StationName: station.Name,
+		DueAt:       due,
+		EscalatesAt: due.Add(time.Duration(station.WelfareGraceMinutes) * time.Minute),
+	}
+}
+
+// SetGuardStationWelfare sets how often guards at the station confirm they
+// are fine, in minutes, and the grace period they have to do it. An
+// interval of zero turns the checks off.
+func (a *App) SetGuardStationWelfare(
+	ctx context.Context, id int64, interval int64, grace int64,
+) error {
+	station, err := a.guardStationForAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Control de bienestar de la garita %s: desactivado", station.Name)
+	if interval == 0 {
+		grace = 0
+	} else {
+		if interval < minWelfareInterval || interval > maxWelfareInterval {
+			return NewUserSafeError(fmt.Sprintf(
+				"El control de bienestar debe ser cada %d a %d minutos",
+				minWelfareInterval, maxWelfareInterval,
+			))
+		}
+		if grace < 1 || grace > maxWelfareGrace {
+			return NewUserSafeError(fmt.Sprintf(
+				"El tiempo para confirmar debe ser de 1 a %d minutos", maxWelfareGrace,
+			))
+		}
+		message = fmt.Sprintf(
+			"Control de bienestar de la garita %s: cada %d minutos, %d para confirmar",
+			station.Name, interval, grace,
+		)
+	}
+
+	return a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.GuardStationSetWelfare(ctx, id, interval, grace); err != nil {
+			return err
+		}
+
+		return a.audit.Record(ctx, AuditRecord{
+			CondominiumID: station.CondominiumID,
+			Level:         AuditImportant,
+			Action:        ActionGateChanged,
+			Message:       message,
+		})
+	})
+}
+
+// DueWelfareCheck returns the welfare check the guard has to confirm now,
+// nil if there is none: they don't confirm checks, have no shift open,
+// their station has no checks, or the next one isn't due yet.
+func (a *App) DueWelfareCheck(ctx context.Context) (*WelfareCheck, error) {
+	guard := UserFromCtx(ctx)
+	if guard == nil {
+		return nil, &UnauthorizedError{msg: "user not authenticated"}
+	}
+	if !guard.Can(PermWelfareConfirm, guard.CondominiumID) ||
+		!guard.Can(PermShiftsWork, guard.CondominiumID) {
+		return nil, nil
+	}
+
+	shift, err := a.OpenShift(ctx)
+	if err != nil || shift == nil || shift.StationID == 0 {
+		return nil, err
+	}
+
+	station, err := a.store.GuardStationGetByID(ctx, shift.StationID)
+	if err != nil {
+		return nil, err
+	}
+	check := welfareCheck(shift, station)
+	if check == nil || time.Now().Before(check.DueAt) {
+		return nil, nil
+	}
+	return check, nil
+}
+
+// ConfirmWelfare records that the guard is fine, which restarts the
+// interval to the next check. If the admins were told the guard didn't
+// confirm, they are told the guard did after all.
+func (a *App) ConfirmWelfare(ctx context.Context) error {
+	if _, err := RequirePermission(ctx, PermWelfareConfirm); err != nil {
+		return err
+	}
+	shift, err := a.OpenShift(ctx)
+	if err != nil {
+		return err
+	}
+	if shift == nil {
+		return NewUserSafeError("No tienes un turno abierto")
+	}
+
+	record := AuditRecord{
+		CondominiumID: shift.CondominiumID,
+		Level:         AuditInfo,
+		Action:        ActionWelfareConfirmed,
+		Message:       fmt.Sprintf("%s confirmó que está bien", shift.GuardName),
+	}
+	if !shift.WelfareEscalatedAt.IsZero() {
+		record.Level = AuditImportant
+		record.Message = fmt.Sprintf(
+			"%s confirmó que está bien, después del aviso a la administración de las %s",
+			shift.GuardName, shift.WelfareEscalatedAt.Format("15:04"),
+		)
+	}
+	err = a.store.InTx(ctx, func(ctx context.Context) error {
+		if err := a.store.ShiftConfirmWelfare(ctx, shift.ID, time.Now()); err != nil {
+			return err
+		}
+		return a.audit.Record(ctx, record)
+	})
+	if err != nil || shift.WelfareEscalatedAt.IsZero() {
+		return err
+	}
+	return a.notifyAdmins(ctx, PermGatesManage, Notification{
+		CondominiumID: shift.CondominiumID,
+		Event:         NotifyWelfareMissed,
+		Title:         "Control de bienestar confirmado",
+		Body:          fmt.Sprintf("%s confirmó que está bien.", guardNameOr(shift.GuardName)),
+		URL:           "/admin/audit",
+		Urgent:        true,
+	})
+}
+
+// escalateWelfare tells the admins, and the other guards of the
+// condominium, that the guard didn't confirm the check in time. The shift
+// is only marked as escalated along with the alert, so an alert that
+// failed is tried again on the next check.
+func (a *App) escalateWelfare(ctx context.Context, check *WelfareCheck, now time.Time) error {
+	escalated := false
+	err := a.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		escalated, err = a.store.ShiftEscalateWelfare(ctx, check.Shift.ID, now)
+		if err != nil || !escalated {
+			return err
+		}
+		return a.alertWelfare(ctx, check)
+	})
+	if err != nil || !escalated {
+		return err
+	}
+
+	a.live.Publish(LiveEvent{
+		Kind:          LiveAlert,
+		CondominiumID: check.Shift.CondominiumID,
+		Message: fmt.Sprintf(
+			"%s no ha confirmado que está bien en la garita %s desde las %s.",
+			guardNameOr(check.Shift.GuardName), check.StationName, check.DueAt.Format("15:04"),
+		),
+	})
+	return nil
+}
+
+// alertWelfare records the missed check in the audit log and tells the
+// admins.
+func (a *App) alertWelfare(ctx context.Context, check *WelfareCheck) error {
+	guard := guardNameOr(check.Shift.GuardName)
+	err := a.audit.Record(ctx, AuditRecord{
+		CondominiumID: check.Shift.CondominiumID,
+		UserID:        check.Shift.GuardID,
+		Level:         AuditCritical,
+		Action:        ActionWelfareMissed,
+		Message: fmt.Sprintf(
+			"%s no confirmó el control de bienestar de las %s en la garita %s",
+			guard, check.DueAt.Format("15:04"), check.StationName,
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.notifyAdmins(ctx, PermGatesManage, Notification{
+		CondominiumID: check.Shift.CondominiumID,
+		Event:         NotifyWelfareMissed,
+		Title:         "Control de bienestar sin respuesta",
+		Body: fmt.Sprintf(
+			"%s no ha confirmado que está bien en la garita %s desde las %s. Intenta comunicarte con la garita.",
+			guard, check.StationName, check.DueAt.Format("15:04"),
+		),
+		URL:    "/admin/audit",
+		Urgent: true,
+	})
+}
+
+// WelfareMonitor escalates the welfare checks that guards don't confirm
+// within their grace period.
+type WelfareMonitor struct {
+	app    *App
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewWelfareMonitor(app *App, logger *slog.Logger) *WelfareMonitor {
+	return &WelfareMonitor{app: app, logger: logger, now: time.Now}
+}
+
+// Run checks for overdue welfare checks every interval until ctx is done.
+func (m *WelfareMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.EscalateOverdue(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("Failed to check the welfare checks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EscalateOverdue escalates every welfare check past its grace period, once.
+func (m *WelfareMonitor) EscalateOverdue(ctx context.Context) error {
+	now := m.now()
+	shifts, err := m.app.store.ShiftListWelfareOverdue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	// A failed shift doesn't hold back the others.
+	for _, shift := range shifts {
+		station, err := m.app.store.GuardStationGetByID(ctx, shift.StationID)
+		if err != nil {
+			m.logger.Error(
+				"Failed to get the station of a welfare check",
+				"shift_id", shift.ID,
+				"station_id", shift.StationID,
+				"error", err,
+			)
+			continue
+		}
+		check := welfareCheck(&shift, station)
+		if check == nil || now.Before(check.EscalatesAt) {
+			continue
+		}
+
+		if err := m.app.escalateWelfare(ctx, check, now); err != nil {
+			m.logger.Error(
+				"Failed to escalate a welfare check",
+				"shift_id", shift.ID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
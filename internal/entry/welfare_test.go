@@ -0,0 +1,38 @@
+package entry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWelfareCheck(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	station := &GuardStation{Name: "Norte", WelfareIntervalMinutes: 30, WelfareGraceMinutes: 5}
+
+	check := welfareCheck(&Shift{StartedAt: start}, station)
+	if check == nil {
+		t.Fatal("welfareCheck() = nil, want a check")
+	}
+	if !check.DueAt.Equal(start.Add(30*time.Minute)) || !check.EscalatesAt.Equal(start.Add(35*time.Minute)) {
+		t.Errorf("check due at %v, escalates at %v; want 22:30 and 22:35", check.DueAt, check.EscalatesAt)
+	}
+	if check.StationName != "Norte" || check.Escalated() {
+		t.Errorf("check = %+v", check)
+	}
+
+	// Confirming restarts the interval.
+	confirmed := start.Add(33 * time.Minute)
+	check = welfareCheck(&Shift{StartedAt: start, WelfareCheckedAt: confirmed}, station)
+	if !check.DueAt.Equal(confirmed.Add(30 * time.Minute)) {
+		t.Errorf("check due at %v, want 30 minutes after the last confirmation", check.DueAt)
+	}
+
+	escalated := &Shift{StartedAt: start, WelfareEscalatedAt: start.Add(35 * time.Minute)}
+	if !welfareCheck(escalated, station).Escalated() {
+		t.Error("Escalated() = false for an escalated shift")
+	}
+
+	if check := welfareCheck(&Shift{StartedAt: start}, &GuardStation{}); check != nil {
+		t.Errorf("welfareCheck() = %+v for a station without checks, want nil", check)
+	}
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetAmenities(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		amenities, reservations, err := app.AdminAmenities(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Amenities(amenities, reservations).Render(r.Context(), w)
+	})
+}
+
+func hPostAmenity(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		amenity := entry.Amenity{
+			Name:             r.FormValue("name"),
+			RequiresApproval: r.FormValue("requires_approval") == "on",
+		}
+		var err error
+		amenity.Capacity, err = strconv.ParseInt(r.FormValue("capacity"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Capacidad inválida")
+		}
+		amenity.OpensMinute, err = parseClock(r.FormValue("opens"))
+		if err != nil {
+			return err
+		}
+		amenity.ClosesMinute, err = parseClock(r.FormValue("closes"))
+		if err != nil {
+			return err
+		}
+		amenity.SlotMinutes, err = strconv.ParseInt(r.FormValue("slot_minutes"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Duración de las reservas inválida")
+		}
+		amenity.MaxPerMonth, err = strconv.ParseInt(r.FormValue("max_per_month"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Máximo de reservas al mes inválido")
+		}
+		amenity.LeadHours, err = strconv.ParseInt(r.FormValue("lead_hours"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Anticipación inválida")
+		}
+
+		if _, err := app.CreateAmenity(r.Context(), amenity); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/amenities", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostDeleteAmenity(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Área común no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.DeleteAmenity(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/amenities", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hPostReservationAction approves, rejects or cancels a reservation, with
+// action being one of the methods of entry.App that do it.
+func hPostReservationAction(
+	app *entry.App,
+	action func(app *entry.App, ctx context.Context, id int64) error,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Reserva no encontrada", http.StatusNotFound)
+		}
+
+		if err := action(app, r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/amenities", http.StatusSeeOther)
+		return nil
+	})
+}
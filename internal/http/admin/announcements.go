@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetAnnouncements(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		announcements, err := app.AdminAnnouncements(r.Context())
+		if err != nil {
+			return err
+		}
+		towers, units, err := app.AnnouncementTargets(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Announcements(announcements, towers, units).Render(r.Context(), w)
+	})
+}
+
+func hPostAnnouncement(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		announcement := entry.Announcement{
+			Title:    r.FormValue("title"),
+			Body:     r.FormValue("body"),
+			Audience: entry.AnnouncementAudience(r.FormValue("audience")),
+			Towers:   r.PostForm["tower"],
+			Pinned:   r.FormValue("pinned") == "on",
+		}
+		for _, key := range r.PostForm["unit"] {
+			unit, err := entry.ParseUnitKey(key)
+			if err != nil {
+				return err
+			}
+			announcement.Units = append(announcement.Units, unit)
+		}
+		if raw := r.FormValue("expires_on"); raw != "" {
+			day, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+			if err != nil {
+				return entry.NewUserSafeError("Fecha de vencimiento inválida")
+			}
+			// The announcement is shown until the end of the day.
+			announcement.ExpiresAt = day.AddDate(0, 0, 1)
+		}
+
+		if _, err := app.CreateAnnouncement(r.Context(), announcement); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hGetAnnouncement shows an announcement and who of its recipients read
+// it.
+func hGetAnnouncement(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Comunicado no encontrado", http.StatusNotFound)
+		}
+
+		announcement, receipts, err := app.AnnouncementReceipts(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Announcement(announcement, receipts).Render(r.Context(), w)
+	})
+}
+
+func hPostAnnouncementPinned(
+	app *entry.App,
+	pinned bool,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Comunicado no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.SetAnnouncementPinned(r.Context(), id, pinned); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostDeleteAnnouncement(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Comunicado no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.DeleteAnnouncement(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetGates(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return renderGates(w, r, app, "")
+	})
+}
+
+func renderGates(
+	w http.ResponseWriter,
+	r *http.Request,
+	app *entry.App,
+	notice string,
+) error {
+	gates, err := app.Gates(r.Context())
+	if err != nil {
+		return err
+	}
+	stations, err := app.GuardStations(r.Context())
+	if err != nil {
+		return err
+	}
+	return templates.Gates(gates, stations, notice).Render(r.Context(), w)
+}
+
+func hPostGate(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		gate := entry.Gate{
+			Name:    r.FormValue("name"),
+			Driver:  entry.GateDriver(r.FormValue("driver")),
+			Target:  r.FormValue("target"),
+			Payload: r.FormValue("payload"),
+		}
+		if gate.Driver == entry.GateHTTP {
+			gate.HTTPMethod = r.FormValue("http_method")
+		}
+		if gate.Driver == entry.GateModbus {
+			var err error
+			gate.UnitID, err = strconv.ParseInt(r.FormValue("unit_id"), 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Unidad Modbus inválida")
+			}
+			gate.Coil, err = strconv.ParseInt(r.FormValue("coil"), 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Bobina Modbus inválida")
+			}
+		}
+
+		if _, err := app.CreateGate(r.Context(), gate); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostTestGate(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Barrera no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.TestGate(r.Context(), id); err != nil {
+			return err
+		}
+
+		return renderGates(w, r, app, "La barrera se abrió")
+	})
+}
+
+func hPostDeleteGate(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Barrera no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.DeleteGate(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostStation(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		gateID, err := strconv.ParseInt(r.FormValue("gate_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Barrera inválida")
+		}
+
+		if _, err := app.CreateGuardStation(r.Context(), r.FormValue("name"), gateID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostStationGate(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Garita no encontrada", http.StatusNotFound)
+		}
+		gateID, err := strconv.ParseInt(r.FormValue("gate_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Barrera inválida")
+		}
+
+		if err := app.SetGuardStationGate(r.Context(), id, gateID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostStationWelfare(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Garita no encontrada", http.StatusNotFound)
+		}
+		interval, err := strconv.ParseInt(r.FormValue("interval"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Intervalo inválido")
+		}
+		grace, err := strconv.ParseInt(r.FormValue("grace"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Tiempo para confirmar inválido")
+		}
+
+		if err := app.SetGuardStationWelfare(r.Context(), id, interval, grace); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostDeleteStation(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Garita no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.DeleteGuardStation(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/gates", http.StatusSeeOther)
+		return nil
+	})
+}
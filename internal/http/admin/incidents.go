@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetIncidents(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		status := entry.IncidentStatus(r.URL.Query().Get("status"))
+		incidents, err := app.Incidents(r.Context(), status)
+		if err != nil {
+			return err
+		}
+		return templates.Incidents(incidents, status).Render(r.Context(), w)
+	})
+}
+
+func hGetIncident(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Incidente no encontrado", http.StatusNotFound)
+		}
+
+		if _, err := entry.RequirePermission(r.Context(), entry.PermIncidentsManage); err != nil {
+			return err
+		}
+		incident, err := app.Incident(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Incident(incident).Render(r.Context(), w)
+	})
+}
+
+// hPostIncidentComment comments on an incident, moving it to the status in
+// the form if there is one.
+func hPostIncidentComment(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Incidente no encontrado", http.StatusNotFound)
+		}
+
+		err = app.CommentIncident(
+			r.Context(), id, r.FormValue("body"), entry.IncidentStatus(r.FormValue("status")),
+		)
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/incidents/%d", id), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetIncidentAttachment(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.ServeIncidentAttachment(w, r, app)
+	})
+}
@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetLockouts(
+	throttler *auth.Throttler,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		accounts, err := throttler.LockedAccounts(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Lockouts(accounts).Render(r.Context(), w)
+	})
+}
+
+func hPostUnlock(
+	throttler *auth.Throttler,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+
+		if err := throttler.Unlock(r.Context(), userID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/lockouts", http.StatusSeeOther)
+		return nil
+	})
+}
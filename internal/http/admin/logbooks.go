@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetLogbooks(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		shifts, err := app.Logbooks(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Logbooks(shifts).Render(r.Context(), w)
+	})
+}
+
+func hGetLogbook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Turno no encontrado", http.StatusNotFound)
+		}
+
+		shift, err := app.Shift(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Logbook(shift).Render(r.Context(), w)
+	})
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetOccupancy(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		occupancy, denied, err := app.AdminOccupancy(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Occupancy(occupancy, denied).Render(r.Context(), w)
+	})
+}
+
+func hPostOccupancyLimits(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		maxVisitors, err := strconv.ParseInt(r.FormValue("max_visitors"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Máximo de visitantes inválido")
+		}
+		maxPerUnit, err := strconv.ParseInt(r.FormValue("max_visitors_per_unit"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Máximo de visitantes por unidad inválido")
+		}
+
+		if err := app.SetOccupancyLimits(r.Context(), maxVisitors, maxPerUnit); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/occupancy", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hPostOccupancyOverride lets in a visitor denied because of the occupancy
+// limits, with the justification of the admin.
+func hPostOccupancyOverride(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Ingreso no encontrado", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		if _, err := app.OverrideOccupancy(r.Context(), id, r.FormValue("justification")); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/occupancy", http.StatusSeeOther)
+		return nil
+	})
+}
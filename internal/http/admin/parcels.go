@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetParcels(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		report, err := app.ParcelAging(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.ParcelAging(report).Render(r.Context(), w)
+	})
+}
+
+func hGetParcelPhoto(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.ServeParcelPhoto(w, r, app)
+	})
+}
@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+	"github.com/skip2/go-qrcode"
+)
+
+func hGetPatrols(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		checkpoints, err := app.PatrolCheckpoints(r.Context())
+		if err != nil {
+			return err
+		}
+		routes, err := app.PatrolRoutes(r.Context())
+		if err != nil {
+			return err
+		}
+		stations, err := app.GuardStations(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Patrols(checkpoints, routes, stations).Render(r.Context(), w)
+	})
+}
+
+func hPostPatrolCheckpoint(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		if _, err := app.CreatePatrolCheckpoint(r.Context(), r.FormValue("name")); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/patrols", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostDeletePatrolCheckpoint(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Punto de control no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.DeletePatrolCheckpoint(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/patrols", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hGetPatrolCheckpointsPrint shows the QR codes of every checkpoint, to be
+// printed and placed at them.
+func hGetPatrolCheckpointsPrint(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		checkpoints, err := app.PatrolCheckpoints(r.Context())
+		if err != nil {
+			return err
+		}
+
+		codes := make([]string, 0, len(checkpoints))
+		for _, checkpoint := range checkpoints {
+			code, err := checkpointQRCode(r, checkpoint.Code)
+			if err != nil {
+				return err
+			}
+			codes = append(codes, code)
+		}
+		return templates.PatrolCheckpointsPrint(checkpoints, codes).Render(r.Context(), w)
+	})
+}
+
+// checkpointQRCode renders the link guards follow to scan the checkpoint as
+// a PNG data URI. The link is absolute, so that any QR reader opens it. The
+// app is always served over HTTPS, since its cookies are secure.
+func checkpointQRCode(r *http.Request, code string) (string, error) {
+	url := "https://" + r.Host + "/guard/patrol/checkpoints/" + code
+	png, err := qrcode.Encode(url, qrcode.Medium, 256)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+func hPostPatrolRoute(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		route := entry.PatrolRoute{Name: r.FormValue("name")}
+		var err error
+		route.StationID, err = strconv.ParseInt(r.FormValue("station_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Garita inválida")
+		}
+		route.StartMinute, err = parseClock(r.FormValue("start"))
+		if err != nil {
+			return err
+		}
+		route.EndMinute, err = parseClock(r.FormValue("end"))
+		if err != nil {
+			return err
+		}
+		route.EveryMinutes, err = strconv.ParseInt(r.FormValue("every"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Frecuencia inválida")
+		}
+		route.WindowMinutes, err = strconv.ParseInt(r.FormValue("window"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Tiempo para hacer la ronda inválido")
+		}
+		for _, raw := range r.PostForm["checkpoint_id"] {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Punto de control inválido")
+			}
+			route.Checkpoints = append(route.Checkpoints, entry.PatrolCheckpoint{ID: id})
+		}
+
+		if _, err := app.CreatePatrolRoute(r.Context(), route); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/patrols", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostDeletePatrolRoute(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Ronda no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.DeletePatrolRoute(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/patrols", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hGetPatrolReport shows the compliance with the rounds from the from date
+// to the to date, both included, the last week by default.
+func hGetPatrolReport(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		from := today.AddDate(0, 0, -6)
+		to := today.AddDate(0, 0, 1)
+
+		query := r.URL.Query()
+		if raw := query.Get("from"); raw != "" {
+			var err error
+			from, err = time.ParseInLocation(time.DateOnly, raw, time.Local)
+			if err != nil {
+				return entry.NewUserSafeError("Fecha inicial inválida")
+			}
+		}
+		if raw := query.Get("to"); raw != "" {
+			day, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+			if err != nil {
+				return entry.NewUserSafeError("Fecha final inválida")
+			}
+			to = day.AddDate(0, 0, 1)
+		}
+
+		report, err := app.PatrolReport(r.Context(), from, to)
+		if err != nil {
+			return err
+		}
+		return templates.PatrolReport(report).Render(r.Context(), w)
+	})
+}
+
+// parseClock parses a HH:MM time input into minutes since midnight.
+func parseClock(v string) (int64, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, entry.NewUserSafeError("Hora inválida")
+	}
+	return int64(t.Hour()*60 + t.Minute()), nil
+}
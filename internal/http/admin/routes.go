@@ -5,17 +5,98 @@ import (
 	"net/http"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
 	"github.com/Polo123456789/entry-watch/internal/http/util"
 )
 
 func Handle(
 	app *entry.App,
 	logger *slog.Logger,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
+	throttler *auth.Throttler,
 ) http.Handler {
 	mux := http.NewServeMux()
 
 	// Setup routes
 	mux.Handle("/admin/", hGet(app, logger))
+	mux.Handle("GET /admin/users", hGetUsers(app, logger))
+	mux.Handle(
+		"POST /admin/users/{id}/enable",
+		hPostUserEnabled(app, session, userCache, true, logger),
+	)
+	mux.Handle(
+		"POST /admin/users/{id}/disable",
+		hPostUserEnabled(app, session, userCache, false, logger),
+	)
+	mux.Handle("POST /admin/users/{id}/unit", hPostUserUnit(app, logger))
+	mux.Handle("GET /admin/audit", hGetAudit(app, logger))
+	mux.Handle("GET /admin/audit/export", hGetAuditExport(app, logger))
+	mux.Handle("GET /admin/audit/verify", hGetAuditVerify(app, logger))
+	mux.Handle("GET /admin/audit/checkpoints/{id}", hGetAuditCheckpoint(app, logger))
+	mux.Handle("GET /admin/lockouts", hGetLockouts(throttler, logger))
+	mux.Handle("POST /admin/lockouts/{id}/unlock", hPostUnlock(throttler, logger))
+	mux.Handle("GET /admin/webhooks", hGetWebhooks(app, logger))
+	mux.Handle("POST /admin/webhooks", hPostWebhook(app, logger))
+	mux.Handle("GET /admin/webhooks/{id}", hGetWebhook(app, logger))
+	mux.Handle("POST /admin/webhooks/{id}/delete", hPostDeleteWebhook(app, logger))
+	mux.Handle(
+		"POST /admin/webhooks/{id}/deliveries/{delivery}/redeliver",
+		hPostRedeliverWebhook(app, logger),
+	)
+	mux.Handle("GET /admin/gates", hGetGates(app, logger))
+	mux.Handle("POST /admin/gates", hPostGate(app, logger))
+	mux.Handle("POST /admin/gates/{id}/test", hPostTestGate(app, logger))
+	mux.Handle("POST /admin/gates/{id}/delete", hPostDeleteGate(app, logger))
+	mux.Handle("POST /admin/stations", hPostStation(app, logger))
+	mux.Handle("POST /admin/stations/{id}", hPostStationGate(app, logger))
+	mux.Handle("POST /admin/stations/{id}/welfare", hPostStationWelfare(app, logger))
+	mux.Handle("POST /admin/stations/{id}/delete", hPostDeleteStation(app, logger))
+	mux.Handle("GET /admin/parcels", hGetParcels(app, logger))
+	mux.Handle("GET /admin/parcels/{id}/photo", hGetParcelPhoto(app, logger))
+	mux.Handle("GET /admin/incidents", hGetIncidents(app, logger))
+	mux.Handle("GET /admin/incidents/{id}", hGetIncident(app, logger))
+	mux.Handle("POST /admin/incidents/{id}/comments", hPostIncidentComment(app, logger))
+	mux.Handle(
+		"GET /admin/incidents/{id}/attachments/{attachment}",
+		hGetIncidentAttachment(app, logger),
+	)
+	mux.Handle("GET /admin/logbooks", hGetLogbooks(app, logger))
+	mux.Handle("GET /admin/logbooks/{id}", hGetLogbook(app, logger))
+	mux.Handle("GET /admin/patrols", hGetPatrols(app, logger))
+	mux.Handle("POST /admin/patrols/checkpoints", hPostPatrolCheckpoint(app, logger))
+	mux.Handle("GET /admin/patrols/checkpoints/print", hGetPatrolCheckpointsPrint(app, logger))
+	mux.Handle(
+		"POST /admin/patrols/checkpoints/{id}/delete",
+		hPostDeletePatrolCheckpoint(app, logger),
+	)
+	mux.Handle("POST /admin/patrols/routes", hPostPatrolRoute(app, logger))
+	mux.Handle("POST /admin/patrols/routes/{id}/delete", hPostDeletePatrolRoute(app, logger))
+	mux.Handle("GET /admin/patrols/report", hGetPatrolReport(app, logger))
+	mux.Handle("GET /admin/announcements", hGetAnnouncements(app, logger))
+	mux.Handle("POST /admin/announcements", hPostAnnouncement(app, logger))
+	mux.Handle("GET /admin/announcements/{id}", hGetAnnouncement(app, logger))
+	mux.Handle("POST /admin/announcements/{id}/pin", hPostAnnouncementPinned(app, true, logger))
+	mux.Handle("POST /admin/announcements/{id}/unpin", hPostAnnouncementPinned(app, false, logger))
+	mux.Handle("POST /admin/announcements/{id}/delete", hPostDeleteAnnouncement(app, logger))
+	mux.Handle("GET /admin/amenities", hGetAmenities(app, logger))
+	mux.Handle("POST /admin/amenities", hPostAmenity(app, logger))
+	mux.Handle("POST /admin/amenities/{id}/delete", hPostDeleteAmenity(app, logger))
+	mux.Handle(
+		"POST /admin/reservations/{id}/approve",
+		hPostReservationAction(app, (*entry.App).ApproveReservation, logger),
+	)
+	mux.Handle(
+		"POST /admin/reservations/{id}/reject",
+		hPostReservationAction(app, (*entry.App).RejectReservation, logger),
+	)
+	mux.Handle(
+		"POST /admin/reservations/{id}/cancel",
+		hPostReservationAction(app, (*entry.App).CancelReservation, logger),
+	)
+	mux.Handle("GET /admin/occupancy", hGetOccupancy(app, logger))
+	mux.Handle("POST /admin/occupancy/limits", hPostOccupancyLimits(app, logger))
+	mux.Handle("POST /admin/occupancy/entries/{id}/override", hPostOccupancyOverride(app, logger))
 
 	var handler http.Handler = mux
 	handler = authMiddleware(handler, logger)
@@ -27,7 +108,20 @@ func authMiddleware(
 	logger *slog.Logger,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := entry.RequireRole(r.Context(), entry.RoleAdmin)
+		_, err := entry.RequireAnyPermission(
+			r.Context(),
+			entry.PermUsersManage,
+			entry.PermAuditRead,
+			entry.PermWebhooksManage,
+			entry.PermGatesManage,
+			entry.PermParcelsRead,
+			entry.PermIncidentsManage,
+			entry.PermLogbooksRead,
+			entry.PermPatrolsManage,
+			entry.PermAnnouncementsManage,
+			entry.PermAmenitiesManage,
+			entry.PermOccupancyManage,
+		)
 		if err != nil {
 			util.HandleError(w, r, logger, err)
 			return
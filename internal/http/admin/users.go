@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetUsers(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		user := entry.UserFromCtx(r.Context())
+
+		users, err := app.ListUsers(r.Context(), user.CondominiumID)
+		if err != nil {
+			return err
+		}
+		return templates.Users(users, user).Render(r.Context(), w)
+	})
+}
+
+func hPostUserEnabled(
+	app *entry.App,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
+	enabled bool,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+
+		if _, err := app.SetUserEnabled(r.Context(), userID, enabled); err != nil {
+			return err
+		}
+
+		// A disabled user is logged out everywhere right away, instead of
+		// when the cached user expires.
+		if !enabled {
+			if err := session.RevokeUserSessions(r.Context(), userID); err != nil {
+				return err
+			}
+		}
+		userCache.Forget(userID)
+
+		http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostUserUnit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		err = app.SetUserUnit(r.Context(), userID, entry.Unit{
+			Tower:  r.FormValue("tower"),
+			Number: r.FormValue("unit"),
+		})
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/admin"
+)
+
+func hGetWebhooks(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		webhooks, err := app.Webhooks(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Webhooks(webhooks).Render(r.Context(), w)
+	})
+}
+
+func hPostWebhook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		events := make([]entry.WebhookEvent, 0, len(r.PostForm["events"]))
+		for _, event := range r.PostForm["events"] {
+			events = append(events, entry.WebhookEvent(event))
+		}
+
+		webhook, err := app.CreateWebhook(r.Context(), r.FormValue("url"), events)
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/webhooks/%d", webhook.ID), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetWebhook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Webhook no encontrado", http.StatusNotFound)
+		}
+
+		webhook, deliveries, err := app.Webhook(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Webhook(webhook, deliveries).Render(r.Context(), w)
+	})
+}
+
+func hPostDeleteWebhook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Webhook no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.DeleteWebhook(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostRedeliverWebhook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Webhook no encontrado", http.StatusNotFound)
+		}
+		deliveryID, err := strconv.ParseInt(r.PathValue("delivery"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Entrega no encontrada", http.StatusNotFound)
+		}
+
+		if _, err := app.RedeliverWebhook(r.Context(), id, deliveryID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/webhooks/%d", id), http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+func hGetCondominiums(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		condos, err := app.ListCondominiums(r.Context())
+		if err != nil {
+			return err
+		}
+
+		data := make([]condominiumSummaryJSON, 0, len(condos))
+		for _, c := range condos {
+			data = append(data, condominiumSummaryJSON{
+				condominiumJSON: newCondominiumJSON(c.Condominium),
+				Users:           c.Users,
+				Visits:          c.Visits,
+			})
+		}
+		util.WriteJSON(w, http.StatusOK, listJSON[condominiumSummaryJSON]{Data: data})
+		return nil
+	})
+}
+
+func hGetCondominium(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+
+		condo, err := app.Condominium(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		util.WriteJSON(w, http.StatusOK, newCondominiumJSON(*condo))
+		return nil
+	})
+}
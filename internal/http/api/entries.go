@@ -0,0 +1,56 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+func hGetEntries(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		entries, err := app.TodayEntries(r.Context())
+		if err != nil {
+			return err
+		}
+
+		data := make([]entryJSON, 0, len(entries))
+		for _, e := range entries {
+			data = append(data, newEntryJSON(e))
+		}
+		util.WriteJSON(w, http.StatusOK, listJSON[entryJSON]{Data: data})
+		return nil
+	})
+}
+
+type checkInJSON struct {
+	Code      string `json:"code"`
+	StationID int64  `json:"station_id"`
+}
+
+// hPostEntry checks in a visit. Like in the guard area, denials are not
+// errors: the entry is created either way and tells whether it was
+// accepted.
+func hPostEntry(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var body checkInJSON
+		if err := util.DecodeJSON(r, &body); err != nil {
+			return err
+		}
+
+		result, err := app.CheckIn(r.Context(), body.Code, body.StationID)
+		if err != nil {
+			return err
+		}
+
+		util.WriteJSON(w, http.StatusCreated, newEntryJSON(*result))
+		return nil
+	})
+}
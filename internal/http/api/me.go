@@ -0,0 +1,32 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// hGetMe describes the user of the token and what it can do, so that
+// clients can adapt their interface.
+func hGetMe(logger *slog.Logger) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		user := entry.UserFromCtx(r.Context())
+
+		perms := make([]string, 0, len(entry.Permissions))
+		for _, perm := range entry.Permissions {
+			if user.Can(perm, user.CondominiumID) {
+				perms = append(perms, string(perm))
+			}
+		}
+
+		util.WriteJSON(w, http.StatusOK, meJSON{
+			ID:            user.ID,
+			CondominiumID: user.CondominiumID,
+			Role:          string(user.Role),
+			Permissions:   perms,
+		})
+		return nil
+	})
+}
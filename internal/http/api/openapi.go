@@ -0,0 +1,23 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// OpenAPIPath is where the OpenAPI document of the API is published. It is
+// public, so that clients can be generated before having a token.
+const OpenAPIPath = "/api/v1/openapi.json"
+
+// openAPIDocument describes the routes registered in Handle. The tests
+// check that both stay in sync, update it with every change to the API.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+func hGetOpenAPI() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument)
+	})
+}
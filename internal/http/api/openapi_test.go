@@ -0,0 +1,411 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+
+	"github.com/Polo123456789/entry-watch/db"
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/sqlc"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "patch"}
+
+func loadOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(openAPIDocument, &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Fatalf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+	return doc
+}
+
+// operations lists the documented operations as route patterns, like
+// "POST /api/v1/visits".
+func operations(doc map[string]any) map[string]map[string]any {
+	ops := map[string]map[string]any{}
+	for path, item := range doc["paths"].(map[string]any) {
+		for method, op := range item.(map[string]any) {
+			if slices.Contains(httpMethods, method) {
+				ops[strings.ToUpper(method)+" "+path] = op.(map[string]any)
+			}
+		}
+	}
+	return ops
+}
+
+func TestOpenAPIRoutesExist(t *testing.T) {
+	doc := loadOpenAPI(t)
+	mux := routes(nil, slog.New(slog.DiscardHandler), nil, nil)
+
+	for pattern := range operations(doc) {
+		method, path, _ := strings.Cut(pattern, " ")
+		r := httptest.NewRequest(method, strings.ReplaceAll(path, "{id}", "1"), nil)
+		if _, got := mux.Handler(r); got != pattern {
+			t.Errorf("%s is documented but handled by %q", pattern, got)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsPublic(t *testing.T) {
+	handler := Handle(nil, slog.New(slog.DiscardHandler), nil, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", OpenAPIPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), openAPIDocument) {
+		t.Error("the served document differs from the embedded one")
+	}
+}
+
+// apiFixture is a condominium with one user of each role, backed by an in
+// memory database.
+type apiFixture struct {
+	handler http.Handler
+	users   map[entry.UserRole]*entry.User
+}
+
+func newAPIFixture(t *testing.T) *apiFixture {
+	t.Helper()
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a different database.
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+	goose.SetLogger(goose.NopLogger())
+	if err := db.AutoMigrate(conn, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	store := sqlc.NewStore(conn)
+	userStore := sqlc.NewUserStore(conn)
+	app := entry.NewApp(
+		logger,
+		store,
+		entry.NewAuditLogger(store, logger),
+		entry.NewNotifier(store, store, logger),
+		entry.NewHub(),
+	)
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[entry.UserRole]*entry.User{}
+	for _, role := range []entry.UserRole{
+		entry.RoleSuperAdmin, entry.RoleAdmin, entry.RoleGuardian, entry.RoleUser,
+	} {
+		condoID := condo.ID
+		if role == entry.RoleSuperAdmin {
+			condoID = 0
+		}
+		created, err := userStore.CreateUser(ctx, &auth.User{
+			CondominiumID: condoID,
+			FirstName:     string(role),
+			LastName:      "Prueba",
+			Email:         string(role) + "@example.com",
+			Role:          role,
+			Enabled:       true,
+		}, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, _, err := userStore.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[role] = user.ToEntryUser()
+	}
+
+	session := auth.NewSessionStore(
+		sqlc.NewSessionStore(conn), logger, &sessions.Options{},
+		[]byte("0123456789abcdef0123456789abcdef"),
+	)
+	userCache := auth.NewUserCache(userStore, time.Second)
+
+	return &apiFixture{
+		handler: Handle(app, logger, session, userCache),
+		users:   users,
+	}
+}
+
+func (f *apiFixture) do(
+	t *testing.T, role entry.UserRole, method, path string, body any,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	if user := f.users[role]; user != nil {
+		r = r.WithContext(entry.WithUser(r.Context(), user))
+	}
+	w := httptest.NewRecorder()
+	f.handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestOpenAPIResponsesMatchSchemas(t *testing.T) {
+	doc := loadOpenAPI(t)
+	ops := operations(doc)
+	mux := routes(nil, slog.New(slog.DiscardHandler), nil, nil)
+	f := newAPIFixture(t)
+	now := time.Now()
+
+	var visitCode string
+	neighborID := f.users[entry.RoleUser].ID
+	tests := []struct {
+		name   string
+		role   entry.UserRole
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"me", entry.RoleUser, "GET", "/api/v1/me", nil, 200},
+		{"me without token", "", "GET", "/api/v1/me", nil, 401},
+		{"create visit", entry.RoleUser, "POST", "/api/v1/visits", map[string]any{
+			"visitor_name": "Ana",
+			"max_uses":     2,
+			"valid_from":   now.Add(-time.Hour),
+			"valid_to":     now.Add(time.Hour),
+		}, 201},
+		{"create visit with unknown fields", entry.RoleUser, "POST", "/api/v1/visits",
+			map[string]any{"visitor": "Ana"}, 400},
+		{"create visit as guard", entry.RoleGuardian, "POST", "/api/v1/visits", map[string]any{
+			"visitor_name": "Ana",
+			"valid_from":   now,
+			"valid_to":     now.Add(time.Hour),
+		}, 403},
+		{"list visits", entry.RoleUser, "GET", "/api/v1/visits", nil, 200},
+		{"check in", entry.RoleGuardian, "POST", "/api/v1/entries", map[string]any{"code": "{visit}"}, 201},
+		{"check in unknown code", entry.RoleGuardian, "POST", "/api/v1/entries",
+			map[string]any{"code": "NOPE"}, 201},
+		{"check in at unknown station", entry.RoleGuardian, "POST", "/api/v1/entries",
+			map[string]any{"code": "{visit}", "station_id": 999}, 400},
+		{"list entries", entry.RoleGuardian, "GET", "/api/v1/entries", nil, 200},
+		{"list entries as neighbor", entry.RoleUser, "GET", "/api/v1/entries", nil, 403},
+		{"revoke visit", entry.RoleUser, "POST", "/api/v1/visits/{visit}/revoke", nil, 204},
+		{"revoke unknown visit", entry.RoleUser, "POST", "/api/v1/visits/NOPE/revoke", nil, 404},
+		{"list users", entry.RoleAdmin, "GET", "/api/v1/users", nil, 200},
+		{"disable user", entry.RoleAdmin, "POST",
+			fmt.Sprintf("/api/v1/users/%d/disable", neighborID), nil, 200},
+		{"enable user", entry.RoleAdmin, "POST",
+			fmt.Sprintf("/api/v1/users/%d/enable", neighborID), nil, 200},
+		{"enable unknown user", entry.RoleAdmin, "POST", "/api/v1/users/999/enable", nil, 404},
+		{"list condominiums", entry.RoleSuperAdmin, "GET", "/api/v1/condominiums", nil, 200},
+		{"list condominiums as admin", entry.RoleAdmin, "GET", "/api/v1/condominiums", nil, 403},
+		{"condominium", entry.RoleSuperAdmin, "GET", "/api/v1/condominiums/1", nil, 200},
+		{"unknown condominium", entry.RoleSuperAdmin, "GET", "/api/v1/condominiums/999", nil, 404},
+	}
+
+	succeeded := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := strings.ReplaceAll(tt.path, "{visit}", visitCode)
+			body := tt.body
+			if m, ok := body.(map[string]any); ok && m["code"] == "{visit}" {
+				m = maps.Clone(m)
+				m["code"] = visitCode
+				body = m
+			}
+
+			w := f.do(t, tt.role, tt.method, path, body)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+
+			_, pattern := mux.Handler(httptest.NewRequest(tt.method, path, nil))
+			op, ok := ops[pattern]
+			if !ok {
+				t.Fatalf("%s %s is not documented", tt.method, path)
+			}
+			if err := checkResponse(doc, op, w); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code < 300 {
+				succeeded[pattern] = true
+			}
+
+			if tt.name == "create visit" {
+				var visit visitJSON
+				if err := json.Unmarshal(w.Body.Bytes(), &visit); err != nil {
+					t.Fatal(err)
+				}
+				visitCode = visit.Code
+			}
+		})
+	}
+
+	for pattern := range ops {
+		if !succeeded[pattern] {
+			t.Errorf("no successful response of %s was checked", pattern)
+		}
+	}
+}
+
+// checkResponse checks that the status of the response is documented for
+// the operation, and that its body matches the schema.
+func checkResponse(doc, op map[string]any, w *httptest.ResponseRecorder) error {
+	responses := op["responses"].(map[string]any)
+	response, ok := responses[fmt.Sprint(w.Code)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("status %d is not documented", w.Code)
+	}
+	response = resolve(doc, response)
+
+	content, ok := response["content"].(map[string]any)
+	if !ok {
+		if w.Body.Len() != 0 {
+			return fmt.Errorf("status %d has no documented body, got %s", w.Code, w.Body)
+		}
+		return nil
+	}
+
+	media, ok := content[w.Header().Get("Content-Type")].(map[string]any)
+	if !ok {
+		return fmt.Errorf("content type %q is not documented", w.Header().Get("Content-Type"))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(w.Body.Bytes()))
+	decoder.UseNumber()
+	var body any
+	if err := decoder.Decode(&body); err != nil {
+		return err
+	}
+	return validateSchema(doc, media["schema"].(map[string]any), body, "body")
+}
+
+// resolve follows a local $ref, like "#/components/schemas/Visit".
+func resolve(doc, node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	var target any = doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		target = target.(map[string]any)[part]
+	}
+	return resolve(doc, target.(map[string]any))
+}
+
+// validateSchema checks the subset of JSON Schema the document uses.
+func validateSchema(doc, schema map[string]any, v any, at string) error {
+	schema = resolve(doc, schema)
+
+	if types, ok := schema["type"]; ok {
+		var allowed []string
+		switch types := types.(type) {
+		case string:
+			allowed = []string{types}
+		case []any:
+			for _, t := range types {
+				allowed = append(allowed, t.(string))
+			}
+		}
+		if !slices.Contains(allowed, jsonType(v)) &&
+			!(jsonType(v) == "integer" && slices.Contains(allowed, "number")) {
+			return fmt.Errorf("%s: got %s, want %v", at, jsonType(v), allowed)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, v) {
+		return fmt.Errorf("%s: %v is not one of %v", at, v, enum)
+	}
+
+	switch v := v.(type) {
+	case string:
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, v)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateSchema(doc, items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					return fmt.Errorf("%s: missing %s", at, name)
+				}
+			}
+		}
+		for name, value := range v {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: undocumented property %s", at, name)
+				}
+				continue
+			}
+			if err := validateSchema(doc, property, value, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
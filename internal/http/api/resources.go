@@ -0,0 +1,137 @@
+package api
+
+import (
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// The resources of the API. Domain types are never encoded directly, so
+// that refactoring them can't change the API.
+
+type meJSON struct {
+	ID            int64    `json:"id"`
+	CondominiumID int64    `json:"condominium_id"`
+	Role          string   `json:"role"`
+	Permissions   []string `json:"permissions"`
+}
+
+type visitJSON struct {
+	Code          string     `json:"code"`
+	CondominiumID int64      `json:"condominium_id"`
+	VisitorName   string     `json:"visitor_name"`
+	MaxUses       int64      `json:"max_uses"`
+	Uses          int64      `json:"uses"`
+	ValidFrom     time.Time  `json:"valid_from"`
+	ValidTo       time.Time  `json:"valid_to"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newVisitJSON(v entry.Visit) visitJSON {
+	visit := visitJSON{
+		Code:          v.ID,
+		CondominiumID: v.CondominiumID,
+		VisitorName:   v.VisitorName,
+		MaxUses:       v.MaxUses,
+		Uses:          v.Uses,
+		ValidFrom:     v.ValidFrom,
+		ValidTo:       v.ValidTo,
+		CreatedAt:     v.CreatedAt,
+	}
+	if v.Revoked() {
+		visit.RevokedAt = &v.RevokedAt
+	}
+	return visit
+}
+
+type entryJSON struct {
+	ID            int64     `json:"id"`
+	CondominiumID int64     `json:"condominium_id"`
+	VisitCode     string    `json:"visit_code"`
+	VisitorName   string    `json:"visitor_name"`
+	Accepted      bool      `json:"accepted"`
+	Reason        string    `json:"reason"`
+	StationID     *int64    `json:"station_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Gate is only set on check-ins that tried to open a gate.
+	Gate *gateResultJSON `json:"gate,omitempty"`
+}
+
+type gateResultJSON struct {
+	Opened bool   `json:"opened"`
+	Error  string `json:"error"`
+}
+
+func newEntryJSON(e entry.Entry) entryJSON {
+	out := entryJSON{
+		ID:            e.ID,
+		CondominiumID: e.CondominiumID,
+		VisitCode:     e.VisitID,
+		VisitorName:   e.VisitorName,
+		Accepted:      e.Accepted,
+		Reason:        e.Reason,
+		CreatedAt:     e.CreatedAt,
+	}
+	if e.StationID != 0 {
+		out.StationID = &e.StationID
+	}
+	if e.GateOpened || e.GateError != "" {
+		out.Gate = &gateResultJSON{Opened: e.GateOpened, Error: e.GateError}
+	}
+	return out
+}
+
+type userJSON struct {
+	ID            int64  `json:"id"`
+	CondominiumID int64  `json:"condominium_id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Role          string `json:"role"`
+	Enabled       bool   `json:"enabled"`
+}
+
+func newUserJSON(u entry.UserProfile) userJSON {
+	return userJSON{
+		ID:            u.ID,
+		CondominiumID: u.CondominiumID,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Email:         u.Email,
+		Phone:         u.Phone,
+		Role:          string(u.Role),
+		Enabled:       u.Enabled,
+	}
+}
+
+type condominiumJSON struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newCondominiumJSON(c entry.Condominium) condominiumJSON {
+	return condominiumJSON{
+		ID:        c.ID,
+		Name:      c.Name,
+		Address:   c.Address,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+type condominiumSummaryJSON struct {
+	condominiumJSON
+	Users  int64 `json:"users"`
+	Visits int64 `json:"visits"`
+}
+
+// listJSON wraps lists, so that fields like pagination can be added without
+// breaking clients.
+type listJSON[T any] struct {
+	Data []T `json:"data"`
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// Handle sets up the JSON API. Requests are authenticated with API tokens,
+// see auth.TokenIdentity, sessions are never used. Only the OpenAPI
+// document is public.
+func Handle(
+	app *entry.App,
+	logger *slog.Logger,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
+) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+OpenAPIPath, hGetOpenAPI())
+	mux.Handle("/api/", authMiddleware(routes(app, logger, session, userCache), logger))
+	return mux
+}
+
+// routes registers the routes that require a token. Every one of them must
+// be described in the OpenAPI document.
+func routes(
+	app *entry.App,
+	logger *slog.Logger,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
+) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /api/v1/me", hGetMe(logger))
+	mux.Handle("GET /api/v1/visits", hGetVisits(app, logger))
+	mux.Handle("POST /api/v1/visits", hPostVisit(app, logger))
+	mux.Handle("POST /api/v1/visits/{id}/revoke", hPostRevokeVisit(app, logger))
+	mux.Handle("GET /api/v1/entries", hGetEntries(app, logger))
+	mux.Handle("POST /api/v1/entries", hPostEntry(app, logger))
+	mux.Handle("GET /api/v1/users", hGetUsers(app, logger))
+	mux.Handle(
+		"POST /api/v1/users/{id}/enable",
+		hPostUserEnabled(app, session, userCache, true, logger),
+	)
+	mux.Handle(
+		"POST /api/v1/users/{id}/disable",
+		hPostUserEnabled(app, session, userCache, false, logger),
+	)
+	mux.Handle("GET /api/v1/condominiums", hGetCondominiums(app, logger))
+	mux.Handle("GET /api/v1/condominiums/{id}", hGetCondominium(app, logger))
+	mux.Handle("/api/", util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.NewErrorWithCode("Ruta no encontrada", http.StatusNotFound)
+	}))
+	return mux
+}
+
+func authMiddleware(
+	next http.Handler,
+	logger *slog.Logger,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry.UserFromCtx(r.Context()) == nil {
+			util.HandleAPIError(w, r, logger, util.NewErrorWithCode(
+				"Token de API inválido o vencido", http.StatusUnauthorized,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
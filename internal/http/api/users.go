@@ -0,0 +1,64 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+func hGetUsers(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		user := entry.UserFromCtx(r.Context())
+
+		users, err := app.ListUsers(r.Context(), user.CondominiumID)
+		if err != nil {
+			return err
+		}
+
+		data := make([]userJSON, 0, len(users))
+		for _, u := range users {
+			data = append(data, newUserJSON(u))
+		}
+		util.WriteJSON(w, http.StatusOK, listJSON[userJSON]{Data: data})
+		return nil
+	})
+}
+
+func hPostUserEnabled(
+	app *entry.App,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
+	enabled bool,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+
+		updated, err := app.SetUserEnabled(r.Context(), userID, enabled)
+		if err != nil {
+			return err
+		}
+
+		// Same as in the admin area, a disabled user is logged out right
+		// away.
+		if !enabled {
+			if err := session.RevokeUserSessions(r.Context(), userID); err != nil {
+				return err
+			}
+		}
+		userCache.Forget(userID)
+
+		util.WriteJSON(w, http.StatusOK, newUserJSON(*updated))
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+func hGetVisits(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		visits, err := app.MyVisits(r.Context())
+		if err != nil {
+			return err
+		}
+
+		data := make([]visitJSON, 0, len(visits))
+		for _, v := range visits {
+			data = append(data, newVisitJSON(v))
+		}
+		util.WriteJSON(w, http.StatusOK, listJSON[visitJSON]{Data: data})
+		return nil
+	})
+}
+
+type createVisitJSON struct {
+	VisitorName string    `json:"visitor_name"`
+	MaxUses     int64     `json:"max_uses"`
+	ValidFrom   time.Time `json:"valid_from"`
+	ValidTo     time.Time `json:"valid_to"`
+}
+
+func hPostVisit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var body createVisitJSON
+		if err := util.DecodeJSON(r, &body); err != nil {
+			return err
+		}
+
+		visit, err := app.CreateVisit(r.Context(), entry.Visit{
+			VisitorName: body.VisitorName,
+			MaxUses:     body.MaxUses,
+			ValidFrom:   body.ValidFrom,
+			ValidTo:     body.ValidTo,
+		})
+		if err != nil {
+			return err
+		}
+
+		util.WriteJSON(w, http.StatusCreated, newVisitJSON(*visit))
+		return nil
+	})
+}
+
+func hPostRevokeVisit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := app.RevokeVisit(r.Context(), r.PathValue("id")); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/auth"
+)
+
+// apiTokenPrefix makes the tokens easy to recognize, for example by secret
+// scanners.
+const apiTokenPrefix = "ew_"
+
+// APITokenLifetimes are the lifetimes offered when creating a token, in
+// days.
+var APITokenLifetimes = []int{30, 90, 365}
+
+// APIToken authenticates requests to the JSON API as its user, limited to
+// its scopes and to the condominium it was created in.
+type APIToken struct {
+	ID     int64
+	UserID int64
+	// CondominiumID is zero for tokens of superadmins.
+	CondominiumID int64
+	Name          string
+	Scopes        []entry.Permission
+	CreatedAt     time.Time
+	// LastUsedAt is the zero time until the token is used.
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// APITokenStore persists API tokens. Like sessions, only the hash of the
+// token is stored.
+type APITokenStore interface {
+	CreateAPIToken(ctx context.Context, token APIToken, tokenHash string) (APIToken, error)
+	// GetAPIToken returns (APIToken{}, false, nil) if the token doesn't
+	// exist or expired.
+	GetAPIToken(ctx context.Context, tokenHash string, now time.Time) (APIToken, bool, error)
+	TouchAPIToken(ctx context.Context, id int64, now time.Time) error
+	ListUserAPITokens(ctx context.Context, userID int64, now time.Time) ([]APIToken, error)
+	// DeleteUserAPIToken returns false if the token doesn't belong to the
+	// user.
+	DeleteUserAPIToken(ctx context.Context, userID int64, id int64) (bool, error)
+}
+
+// TokenIdentity retrieves the user of the API token in the Authorization
+// header. The user acts in the condominium of the token, with the
+// permissions it holds there limited to the scopes of the token. Tokens of
+// users that can't log in, or that left the condominium, are ignored.
+func TokenIdentity(
+	tokens APITokenStore,
+	users UserGetter,
+	r *http.Request,
+) (Identity, bool, error) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(raw, apiTokenPrefix) {
+		return Identity{}, false, nil
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+
+	token, found, err := tokens.GetAPIToken(ctx, hashSessionToken(raw), now)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	if !found {
+		return Identity{}, false, nil
+	}
+
+	user, found, err := users.GetByID(ctx, token.UserID)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	if !found || !user.Enabled {
+		return Identity{}, false, nil
+	}
+
+	scoped := *user
+	if token.CondominiumID != 0 {
+		member, ok := user.InCondominium(token.CondominiumID)
+		if !ok {
+			return Identity{}, false, nil
+		}
+		scoped = *member
+	}
+	scoped.Scopes = token.Scopes
+
+	if now.Sub(token.LastUsedAt) > touchInterval {
+		if err := tokens.TouchAPIToken(ctx, token.ID, now); err != nil {
+			return Identity{}, false, err
+		}
+	}
+
+	return Identity{User: &scoped}, true, nil
+}
+
+// grantablePermissions lists the permissions the user can give to a new
+// token, the ones it holds in the condominium it acts in.
+func grantablePermissions(user *User) []entry.Permission {
+	entryUser := user.ToEntryUser()
+	perms := make([]entry.Permission, 0, len(entry.Permissions))
+	for _, perm := range entry.Permissions {
+		if entryUser.Can(perm, user.CondominiumID) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+func hGetAPITokens(
+	session *SessionStore,
+	store UserStore,
+	tokens APITokenStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		return renderAPITokens(w, r, tokens, user, "")
+	})
+}
+
+func hPostAPIToken(
+	session *SessionStore,
+	store UserStore,
+	tokens APITokenStore,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			return entry.NewUserSafeError("El nombre del token es obligatorio")
+		}
+
+		days, err := strconv.Atoi(r.FormValue("lifetime"))
+		if err != nil || !slices.Contains(APITokenLifetimes, days) {
+			return entry.NewUserSafeError("Vigencia inválida")
+		}
+
+		grantable := grantablePermissions(user)
+		scopes := make([]entry.Permission, 0, len(r.PostForm["scopes"]))
+		for _, scope := range r.PostForm["scopes"] {
+			perm := entry.Permission(scope)
+			if !slices.Contains(grantable, perm) {
+				return entry.NewUserSafeError("No puedes dar al token un permiso que no tienes")
+			}
+			scopes = append(scopes, perm)
+		}
+		if len(scopes) == 0 {
+			return entry.NewUserSafeError("Elige al menos un permiso para el token")
+		}
+
+		raw, err := generateSessionToken()
+		if err != nil {
+			return err
+		}
+		raw = apiTokenPrefix + raw
+
+		now := time.Now()
+		err = store.InTx(ctx, func(ctx context.Context) error {
+			token, err := tokens.CreateAPIToken(ctx, APIToken{
+				UserID:        user.ID,
+				CondominiumID: user.CondominiumID,
+				Name:          name,
+				Scopes:        scopes,
+				CreatedAt:     now,
+				ExpiresAt:     now.AddDate(0, 0, days),
+			}, hashSessionToken(raw))
+			if err != nil {
+				return err
+			}
+
+			return audit.Record(ctx, entry.AuditRecord{
+				CondominiumID: user.CondominiumID,
+				Level:         entry.AuditImportant,
+				Action:        entry.ActionAPITokenChanged,
+				Message: fmt.Sprintf(
+					"Token de API %q creado con los permisos %s",
+					token.Name, joinPermissions(token.Scopes),
+				),
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		return renderAPITokens(w, r, tokens, user, raw)
+	})
+}
+
+func hPostRevokeAPIToken(
+	session *SessionStore,
+	store UserStore,
+	tokens APITokenStore,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Token no encontrado", http.StatusNotFound)
+		}
+
+		err = store.InTx(ctx, func(ctx context.Context) error {
+			ok, err := tokens.DeleteUserAPIToken(ctx, user.ID, id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return util.NewErrorWithCode("Token no encontrado", http.StatusNotFound)
+			}
+
+			return audit.Record(ctx, entry.AuditRecord{
+				CondominiumID: user.CondominiumID,
+				Level:         entry.AuditImportant,
+				Action:        entry.ActionAPITokenChanged,
+				Message:       fmt.Sprintf("Token de API %d revocado", id),
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/auth/tokens", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// renderAPITokens shows the tokens of the user. created is the token that
+// was just created, it is shown only once.
+func renderAPITokens(
+	w http.ResponseWriter,
+	r *http.Request,
+	tokens APITokenStore,
+	user *User,
+	created string,
+) error {
+	active, err := tokens.ListUserAPITokens(r.Context(), user.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	views := make([]templates.APITokenView, 0, len(active))
+	for _, t := range active {
+		views = append(views, templates.APITokenView{
+			ID:         t.ID,
+			Name:       t.Name,
+			Scopes:     t.Scopes,
+			CreatedAt:  t.CreatedAt,
+			LastUsedAt: t.LastUsedAt,
+			ExpiresAt:  t.ExpiresAt,
+		})
+	}
+
+	return templates.APITokens(templates.APITokensPage{
+		Tokens:      views,
+		Permissions: grantablePermissions(user),
+		Lifetimes:   APITokenLifetimes,
+		Created:     created,
+	}).Render(r.Context(), w)
+}
+
+func joinPermissions(perms []entry.Permission) string {
+	names := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		names = append(names, string(perm))
+	}
+	return strings.Join(names, " ")
+}
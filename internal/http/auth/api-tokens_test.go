@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+type memTokens map[string]APIToken
+
+func (m memTokens) CreateAPIToken(_ context.Context, token APIToken, hash string) (APIToken, error) {
+	m[hash] = token
+	return token, nil
+}
+
+func (m memTokens) GetAPIToken(_ context.Context, hash string, now time.Time) (APIToken, bool, error) {
+	token, ok := m[hash]
+	if !ok || !token.ExpiresAt.After(now) {
+		return APIToken{}, false, nil
+	}
+	return token, true, nil
+}
+
+func (m memTokens) TouchAPIToken(context.Context, int64, time.Time) error {
+	return nil
+}
+
+func (m memTokens) ListUserAPITokens(context.Context, int64, time.Time) ([]APIToken, error) {
+	return nil, nil
+}
+
+func (m memTokens) DeleteUserAPIToken(context.Context, int64, int64) (bool, error) {
+	return false, nil
+}
+
+func TestTokenIdentity(t *testing.T) {
+	users := memUsers{
+		1: {
+			ID: 1, CondominiumID: 1, Role: entry.RoleAdmin, Enabled: true,
+			Memberships: []entry.Membership{
+				{CondominiumID: 1, Role: entry.RoleAdmin, Home: true},
+				{CondominiumID: 2, Role: entry.RoleGuardian},
+			},
+		},
+		2: {ID: 2, CondominiumID: 1, Role: entry.RoleUser, Enabled: false},
+	}
+	expires := time.Now().Add(time.Hour)
+	tokens := memTokens{
+		hashSessionToken("ew_home"): {UserID: 1, CondominiumID: 1, ExpiresAt: expires,
+			Scopes: []entry.Permission{entry.PermAuditRead}},
+		hashSessionToken("ew_other"): {UserID: 1, CondominiumID: 2, ExpiresAt: expires,
+			Scopes: []entry.Permission{entry.PermEntriesRecord}},
+		hashSessionToken("ew_left"): {UserID: 1, CondominiumID: 3, ExpiresAt: expires},
+		hashSessionToken("ew_expired"): {UserID: 1, CondominiumID: 1,
+			ExpiresAt: time.Now().Add(-time.Hour)},
+		hashSessionToken("ew_disabled"): {UserID: 2, CondominiumID: 1, ExpiresAt: expires},
+	}
+
+	tests := []struct {
+		name      string
+		header    string
+		ok        bool
+		wantCondo int64
+		wantRole  entry.UserRole
+	}{
+		{"home condominium", "Bearer ew_home", true, 1, entry.RoleAdmin},
+		{"other membership", "Bearer ew_other", true, 2, entry.RoleGuardian},
+		{"no header", "", false, 0, ""},
+		{"not a bearer", "Basic ew_home", false, 0, ""},
+		{"unknown", "Bearer ew_unknown", false, 0, ""},
+		{"left the condominium", "Bearer ew_left", false, 0, ""},
+		{"expired", "Bearer ew_expired", false, 0, ""},
+		{"disabled user", "Bearer ew_disabled", false, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/me", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			identity, ok, err := TokenIdentity(tokens, users, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("ok = %t; want %t", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if identity.User.CondominiumID != tt.wantCondo || identity.User.Role != tt.wantRole {
+				t.Errorf(
+					"user acts as %s in %d; want %s in %d",
+					identity.User.Role, identity.User.CondominiumID, tt.wantRole, tt.wantCondo,
+				)
+			}
+			if identity.User.Scopes == nil {
+				t.Error("token user isn't limited to its scopes")
+			}
+		})
+	}
+}
+
+func TestTokenScopesLimitPermissions(t *testing.T) {
+	user := &User{
+		ID: 1, CondominiumID: 1, Role: entry.RoleAdmin, Enabled: true,
+		Memberships: []entry.Membership{{
+			CondominiumID: 1,
+			Role:          entry.RoleAdmin,
+			Permissions:   entry.RolePermissions(entry.RoleAdmin, nil),
+		}},
+		Scopes: []entry.Permission{entry.PermAuditRead},
+	}
+
+	granted := grantablePermissions(&User{
+		ID: 1, CondominiumID: 1, Role: entry.RoleAdmin, Enabled: true,
+		Memberships: user.Memberships,
+	})
+	if !slices.Contains(granted, entry.PermUsersManage) {
+		t.Errorf("grantable = %v; want it to include %s", granted, entry.PermUsersManage)
+	}
+
+	entryUser := user.ToEntryUser()
+	if !entryUser.Can(entry.PermAuditRead, 1) {
+		t.Errorf("token can't use its scope %s", entry.PermAuditRead)
+	}
+	if entryUser.Can(entry.PermUsersManage, 1) {
+		t.Errorf("token can use %s, which isn't one of its scopes", entry.PermUsersManage)
+	}
+}
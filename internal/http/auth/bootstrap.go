@@ -4,8 +4,6 @@ import (
 	"context"
 	"log/slog"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/Polo123456789/entry-watch/internal/entry"
 )
 
@@ -30,10 +28,7 @@ func EnsureSuperAdminExists(ctx context.Context, store UserStore, logger *slog.L
 		"warning", "CHANGE PASSWORD IMMEDIATELY AFTER FIRST LOGIN",
 	)
 
-	passwordHash, err := bcrypt.GenerateFromPassword(
-		[]byte("changeme"),
-		bcrypt.DefaultCost,
-	)
+	passwordHash, err := HashPassword("changeme")
 	if err != nil {
 		return err
 	}
@@ -48,7 +43,7 @@ func EnsureSuperAdminExists(ctx context.Context, store UserStore, logger *slog.L
 		Hidden:        false,
 	}
 
-	createdUser, err := store.CreateUser(ctx, user, string(passwordHash))
+	createdUser, err := store.CreateUser(ctx, user, passwordHash)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// hPostSelectCondominium changes the condominium the user acts in, for users
+// that are members of more than one. Condominiums that require two-factor
+// authentication for the role are only available once it is enabled.
+func hPostSelectCondominium(
+	session *SessionStore,
+	users UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		identity, ok, err := CurrentIdentity(session, users, r)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return util.NewErrorWithCode(
+				"Debes iniciar sesión", http.StatusUnauthorized,
+			)
+		}
+
+		condoID, err := strconv.ParseInt(r.FormValue("condominium_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Condominio inválido")
+		}
+		scoped, ok := identity.User.InCondominium(condoID)
+		if !ok {
+			return util.NewErrorWithCode(
+				"No perteneces a ese condominio", http.StatusForbidden,
+			)
+		}
+
+		if scoped.MissingTwoFactor() {
+			return entry.NewUserSafeError(
+				"Ese condominio requiere verificación en dos pasos, actívala en Seguridad",
+			)
+		}
+
+		s, err := session.Get(r, SessionName)
+		if err != nil {
+			return err
+		}
+		s.Values["condominium_id"] = condoID
+		if err := s.Save(r, w); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, getRedirectForRole(scoped.Role), http.StatusSeeOther)
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/sessions"
 	"golang.org/x/crypto/bcrypt"
@@ -13,16 +14,24 @@ import (
 	templates "github.com/Polo123456789/entry-watch/internal/templates/auth"
 )
 
-const authSessionKey = "entry-watch-auth"
+// SessionName is the name of the session that holds the logged in user.
+const SessionName = "entry-watch-auth"
+
+// CSRFTokenKey is the value of the session that holds its CSRF token.
+const CSRFTokenKey = "csrf_token"
 
 func hGetLogin(
-	session sessions.Store,
+	session *SessionStore,
+	users UserGetter,
 	logger *slog.Logger,
 ) http.Handler {
 	return util.Handler(logger, func(
 		w http.ResponseWriter, r *http.Request,
 	) error {
-		user, ok := CurrentUser(session, r)
+		user, ok, err := CurrentUser(session, users, r)
+		if err != nil {
+			return err
+		}
 		if ok {
 			redirectURL := getRedirectForRole(user.Role)
 			http.Redirect(w, r, redirectURL, http.StatusSeeOther)
@@ -37,8 +46,9 @@ func hGetLogin(
 }
 
 func hPostLogin(
-	session sessions.Store,
+	session *SessionStore,
 	store UserStore,
+	throttler *Throttler,
 	logger *slog.Logger,
 ) http.Handler {
 	return util.Handler(logger, func(
@@ -51,14 +61,34 @@ func hPostLogin(
 		email := r.FormValue("email")
 		password := r.FormValue("password")
 
-		user, err := attemptLogin(r.Context(), store, email, password)
+		user, err := attemptLogin(
+			r.Context(), store, throttler, email, password, clientIP(r),
+		)
+		if err != nil {
+			return err
+		}
+
+		required, err := store.IsTwoFactorRequired(
+			r.Context(), user.Role, user.CondominiumID,
+		)
 		if err != nil {
 			return err
 		}
 
+		if user.TwoFactorEnabled || required {
+			if err := setPendingUser(w, r, session, user); err != nil {
+				return err
+			}
+			http.Redirect(w, r, "/auth/2fa", http.StatusSeeOther)
+			return nil
+		}
+
 		if err := setCurrentUser(w, r, session, user); err != nil {
 			return err
 		}
+		if err := throttler.Succeed(r.Context(), user, clientIP(r)); err != nil {
+			return err
+		}
 
 		redirectURL := getRedirectForRole(user.Role)
 		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
@@ -66,26 +96,41 @@ func hPostLogin(
 	})
 }
 
-func hGetLogout(
-	session sessions.Store,
+func hPostLogout(
+	session *SessionStore,
 	logger *slog.Logger,
 ) http.Handler {
 	return util.Handler(logger, func(
 		w http.ResponseWriter, r *http.Request,
 	) error {
-		s, _ := session.Get(r, authSessionKey)
+		s, _ := session.Get(r, SessionName)
 		s.Options.MaxAge = -1
 		_ = s.Save(r, w)
-		http.Redirect(w, r, "/auth/login", http.StatusFound)
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
 		return nil
 	})
 }
 
+// dummyPasswordHash is compared against when the email doesn't exist, so
+// that the response time doesn't reveal which accounts exist.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword(
+		[]byte("entry-watch-dummy-password"),
+		bcrypt.DefaultCost,
+	)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
 func attemptLogin(
 	ctx context.Context,
 	store UserStore,
+	throttler *Throttler,
 	email string,
 	password string,
+	ip string,
 ) (*User, error) {
 	// Use generic error to prevent user enumeration
 	wrongCredsErr := util.NewErrorWithCode(
@@ -93,12 +138,29 @@ func attemptLogin(
 		http.StatusBadRequest,
 	)
 
+	if err := throttler.Allow(ctx, email, ip); err != nil {
+		return nil, err
+	}
+
 	userWithPass, ok, err := store.GetByEmailForAuth(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
-	if !ok {
+	hash := dummyPasswordHash()
+	if ok {
+		hash = []byte(userWithPass.PasswordHash)
+	}
+
+	err = bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if !ok || err != nil {
+		var user *User
+		if ok {
+			user = userWithPass.User
+		}
+		if err := throttler.Fail(ctx, email, ip, user); err != nil {
+			return nil, err
+		}
 		return nil, wrongCredsErr
 	}
 
@@ -109,63 +171,47 @@ func attemptLogin(
 		)
 	}
 
-	err = bcrypt.CompareHashAndPassword(
-		[]byte(userWithPass.PasswordHash),
-		[]byte(password),
-	)
-	if err != nil {
-		return nil, wrongCredsErr
-	}
-
 	return userWithPass.User, nil
 }
 
 func setCurrentUser(
 	w http.ResponseWriter,
 	r *http.Request,
-	session sessions.Store,
+	session *SessionStore,
 	user *User,
 ) error {
-	s, err := session.Get(r, authSessionKey)
+	s, err := session.Get(r, SessionName)
 	if err != nil {
 		return err
 	}
 
+	if err := session.Renew(r, s); err != nil {
+		return err
+	}
+
 	s.Values["user_id"] = user.ID
-	s.Values["role"] = string(user.Role)
-	s.Values["condominium_id"] = user.CondominiumID
-	s.Values["enabled"] = user.Enabled
+	delete(s.Values, "pending_user_id")
+	delete(s.Values, "pending_at")
+	delete(s.Values, "impersonated_user_id")
+	delete(s.Values, "impersonated_at")
+	delete(s.Values, "condominium_id")
 
 	return s.Save(r, w)
 }
 
-// CurrentUser retrieves the user from the session.
+// CurrentUser retrieves the user of the session.
+// The user is resolved through users on every call, so that disabled users
+// and role changes take effect right away instead of when the session
+// expires. Disabled users are treated as logged out. While impersonating,
+// the impersonated user is returned, see CurrentIdentity.
 // Returns an auth.User which can be converted to entry.User with toEntryUser().
 func CurrentUser(
 	session sessions.Store,
+	users UserGetter,
 	r *http.Request,
-) (*User, bool) {
-	s, _ := session.Get(r, authSessionKey)
-
-	userID, ok := s.Values["user_id"].(int64)
-	if !ok {
-		return nil, false
-	}
-
-	roleStr, ok := s.Values["role"].(string)
-	if !ok {
-		return nil, false
-	}
-
-	condoID, _ := s.Values["condominium_id"].(int64)
-	enabled, _ := s.Values["enabled"].(bool)
-
-	return &User{
-		ID:            userID,
-		Role:          entry.UserRole(roleStr),
-		CondominiumID: condoID,
-		Enabled:       enabled,
-	}, true
+) (*User, bool, error) {
+	identity, ok, err := CurrentIdentity(session, users, r)
+	return identity.User, ok, err
 }
 
 func getRedirectForRole(role entry.UserRole) string {
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/sessions"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// impersonationTTL ends forgotten impersonations, the superadmin is back to
+// its own account after it.
+const impersonationTTL = time.Hour
+
+// Identity is who a request acts as. While a superadmin impersonates a user,
+// User is the impersonated user and Impersonator the superadmin.
+type Identity struct {
+	User         *User
+	Impersonator *User
+}
+
+// CurrentIdentity retrieves the user of the session, and the superadmin
+// impersonating it if there is one. Like CurrentUser, the users are resolved
+// on every call. An impersonation of a user that can't log in is ignored.
+// The user acts in the condominium selected in the session, or in its home
+// condominium if it isn't a member of it anymore or if it requires two-factor
+// authentication the user didn't enable.
+func CurrentIdentity(
+	session sessions.Store,
+	users UserGetter,
+	r *http.Request,
+) (Identity, bool, error) {
+	identity, ok, err := sessionIdentity(session, users, r)
+	if err != nil || !ok {
+		return identity, ok, err
+	}
+
+	s, _ := session.Get(r, SessionName)
+	if condoID, ok := s.Values["condominium_id"].(int64); ok {
+		scoped, ok := identity.User.InCondominium(condoID)
+		if ok && !scoped.MissingTwoFactor() {
+			identity.User = scoped
+		}
+	}
+	return identity, true, nil
+}
+
+func sessionIdentity(
+	session sessions.Store,
+	users UserGetter,
+	r *http.Request,
+) (Identity, bool, error) {
+	s, _ := session.Get(r, SessionName)
+
+	userID, ok := s.Values["user_id"].(int64)
+	if !ok {
+		return Identity{}, false, nil
+	}
+
+	user, found, err := users.GetByID(r.Context(), userID)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	if !found || !user.Enabled {
+		return Identity{}, false, nil
+	}
+
+	targetID, ok := s.Values["impersonated_user_id"].(int64)
+	if !ok || !user.ToEntryUser().Can(entry.PermSystemManage, 0) {
+		return Identity{User: user}, true, nil
+	}
+	startedAt, _ := s.Values["impersonated_at"].(int64)
+	if time.Since(time.Unix(startedAt, 0)) > impersonationTTL {
+		return Identity{User: user}, true, nil
+	}
+
+	target, found, err := users.GetByID(r.Context(), targetID)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	if !found || !target.Enabled {
+		return Identity{User: user}, true, nil
+	}
+
+	return Identity{User: target, Impersonator: user}, true, nil
+}
+
+func hPostImpersonate(
+	session *SessionStore,
+	users UserGetter,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		if err := entry.RequireNotImpersonating(ctx); err != nil {
+			return err
+		}
+		actor, err := entry.RequirePermission(ctx, entry.PermSystemManage)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+		target, found, err := users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return entry.NewNotFoundError("Usuario no encontrado")
+		}
+		if target.Role == entry.RoleSuperAdmin {
+			return entry.NewUserSafeError("No puedes ver el sistema como otro superadmin")
+		}
+		if !target.Enabled {
+			return entry.NewUserSafeError("El usuario está deshabilitado")
+		}
+
+		// The impersonation is recorded before it starts, it is refused if
+		// it can't be.
+		err = audit.Record(ctx, entry.AuditRecord{
+			CondominiumID:  target.CondominiumID,
+			UserID:         target.ID,
+			ImpersonatorID: actor.ID,
+			Level:          entry.AuditCritical,
+			Action:         entry.ActionImpersonation,
+			Message:        fmt.Sprintf("Inicio de suplantación de %s", target.Email),
+		})
+		if err != nil {
+			return err
+		}
+
+		s, err := session.Get(r, SessionName)
+		if err != nil {
+			return err
+		}
+		s.Values["impersonated_user_id"] = target.ID
+		s.Values["impersonated_at"] = time.Now().Unix()
+		delete(s.Values, "condominium_id")
+		if err := s.Save(r, w); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, getRedirectForRole(target.Role), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostStopImpersonating(
+	session *SessionStore,
+	users UserGetter,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		identity, ok, err := CurrentIdentity(session, users, r)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return util.NewErrorWithCode(
+				"Debes iniciar sesión", http.StatusUnauthorized,
+			)
+		}
+
+		s, err := session.Get(r, SessionName)
+		if err != nil {
+			return err
+		}
+		delete(s.Values, "impersonated_user_id")
+		delete(s.Values, "impersonated_at")
+		delete(s.Values, "condominium_id")
+		if err := s.Save(r, w); err != nil {
+			return err
+		}
+
+		if identity.Impersonator == nil {
+			http.Redirect(w, r, getRedirectForRole(identity.User.Role), http.StatusSeeOther)
+			return nil
+		}
+
+		// The impersonation already ended, a failure to record it is logged
+		// by the audit logger and doesn't keep the superadmin from leaving.
+		_ = audit.Record(r.Context(), entry.AuditRecord{
+			CondominiumID:  identity.User.CondominiumID,
+			UserID:         identity.User.ID,
+			ImpersonatorID: identity.Impersonator.ID,
+			Level:          entry.AuditImportant,
+			Action:         entry.ActionImpersonation,
+			Message: fmt.Sprintf(
+				"Fin de suplantación de %s", identity.User.Email,
+			),
+		})
+
+		http.Redirect(w, r, "/super/", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+type memUsers map[int64]*User
+
+func (m memUsers) GetByID(_ context.Context, id int64) (*User, bool, error) {
+	user, ok := m[id]
+	return user, ok, nil
+}
+
+func TestCurrentIdentity(t *testing.T) {
+	users := memUsers{
+		1: {ID: 1, Role: entry.RoleSuperAdmin, Enabled: true},
+		2: {ID: 2, Role: entry.RoleAdmin, Enabled: true},
+		3: {ID: 3, Role: entry.RoleUser, Enabled: true},
+		4: {ID: 4, Role: entry.RoleUser, Enabled: false},
+	}
+
+	tests := []struct {
+		name             string
+		userID           int64
+		targetID         int64
+		startedAt        time.Time
+		wantUser         int64
+		wantImpersonator int64
+	}{
+		{"not impersonating", 1, 0, time.Time{}, 1, 0},
+		{"impersonating", 1, 3, time.Now(), 3, 1},
+		{"expired", 1, 3, time.Now().Add(-2 * impersonationTTL), 1, 0},
+		{"disabled target", 1, 4, time.Now(), 1, 0},
+		{"missing target", 1, 9, time.Now(), 1, 0},
+		{"not a superadmin", 2, 3, time.Now(), 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+			r := httptest.NewRequest("GET", "/", nil)
+			s, _ := store.Get(r, SessionName)
+			s.Values["user_id"] = tt.userID
+			if tt.targetID != 0 {
+				s.Values["impersonated_user_id"] = tt.targetID
+				s.Values["impersonated_at"] = tt.startedAt.Unix()
+			}
+
+			identity, ok, err := CurrentIdentity(store, users, r)
+			if err != nil || !ok {
+				t.Fatalf("CurrentIdentity() = %t, %v", ok, err)
+			}
+			if identity.User.ID != tt.wantUser {
+				t.Errorf("user = %d; want %d", identity.User.ID, tt.wantUser)
+			}
+			var impersonator int64
+			if identity.Impersonator != nil {
+				impersonator = identity.Impersonator.ID
+			}
+			if impersonator != tt.wantImpersonator {
+				t.Errorf("impersonator = %d; want %d", impersonator, tt.wantImpersonator)
+			}
+		})
+	}
+}
+
+func TestCurrentIdentityTwoFactorRequired(t *testing.T) {
+	memberships := []entry.Membership{
+		{CondominiumID: 1, Role: entry.RoleAdmin, Home: true},
+		{CondominiumID: 2, Role: entry.RoleAdmin, TwoFactorRequired: true},
+	}
+	users := memUsers{
+		1: {ID: 1, CondominiumID: 1, Role: entry.RoleAdmin, Enabled: true, Memberships: memberships},
+		2: {
+			ID: 2, CondominiumID: 1, Role: entry.RoleAdmin, Enabled: true,
+			TwoFactorEnabled: true, Memberships: memberships,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		userID    int64
+		wantCondo int64
+	}{
+		{"without two-factor", 1, 1},
+		{"with two-factor", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+			r := httptest.NewRequest("GET", "/", nil)
+			s, _ := store.Get(r, SessionName)
+			s.Values["user_id"] = tt.userID
+			s.Values["condominium_id"] = int64(2)
+
+			identity, ok, err := CurrentIdentity(store, users, r)
+			if err != nil || !ok {
+				t.Fatalf("CurrentIdentity() = %t, %v", ok, err)
+			}
+			if identity.User.CondominiumID != tt.wantCondo {
+				t.Errorf("condominium = %d; want %d", identity.User.CondominiumID, tt.wantCondo)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// MinPasswordLength is the minimum length of the passwords set by people.
+const MinPasswordLength = 8
+
+// HashPassword checks the password length and hashes it for storage.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", entry.NewUserSafeError(
+			"La contraseña debe tener al menos 8 caracteres",
+		)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -4,30 +4,100 @@ import (
 	"log/slog"
 	"net/http"
 
-	"github.com/gorilla/sessions"
+	"github.com/Polo123456789/entry-watch/internal/entry"
 )
 
 // Handle sets up all authentication routes.
 // Unauthenticated routes: /auth/login, /auth/logout
+// Session routes: /auth/sessions, /auth/sessions/{id}/revoke,
+// /auth/sessions/revoke-all
+// Second factor routes: /auth/2fa, /auth/2fa/setup, /auth/2fa/disable,
+// /auth/2fa/recovery-codes
+// Impersonation routes: /auth/impersonate/{id}, /auth/impersonate/stop
+// Condominium switcher: /auth/condominium
+// API token routes: /auth/tokens, /auth/tokens/{id}/revoke
 // The session store is passed in to be used by all auth handlers.
 func Handle(
 	logger *slog.Logger,
-	session sessions.Store,
+	session *SessionStore,
 	userStore UserStore,
+	throttler *Throttler,
+	tokens APITokenStore,
+	audit *entry.AuditLogger,
 ) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.Handle(
 		"GET /auth/login",
-		hGetLogin(session, logger),
+		hGetLogin(session, userStore, logger),
 	)
 	mux.Handle(
 		"POST /auth/login",
-		hPostLogin(session, userStore, logger),
+		hPostLogin(session, userStore, throttler, logger),
 	)
 	mux.Handle(
-		"GET /auth/logout",
-		hGetLogout(session, logger),
+		"GET /auth/2fa",
+		hGetTwoFactor(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/2fa",
+		hPostTwoFactor(session, userStore, throttler, logger),
+	)
+	mux.Handle(
+		"GET /auth/2fa/setup",
+		hGetTwoFactorSetup(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/2fa/setup",
+		hPostTwoFactorSetup(session, userStore, throttler, audit, logger),
+	)
+	mux.Handle(
+		"POST /auth/2fa/disable",
+		hPostTwoFactorDisable(session, userStore, audit, logger),
+	)
+	mux.Handle(
+		"POST /auth/2fa/recovery-codes",
+		hPostRecoveryCodes(session, userStore, logger),
+	)
+	mux.Handle(
+		"GET /auth/sessions",
+		hGetSessions(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/sessions/{id}/revoke",
+		hPostRevokeSession(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/sessions/revoke-all",
+		hPostRevokeAllSessions(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/impersonate/{id}",
+		hPostImpersonate(session, userStore, audit, logger),
+	)
+	mux.Handle(
+		"POST /auth/impersonate/stop",
+		hPostStopImpersonating(session, userStore, audit, logger),
+	)
+	mux.Handle(
+		"GET /auth/tokens",
+		hGetAPITokens(session, userStore, tokens, logger),
+	)
+	mux.Handle(
+		"POST /auth/tokens",
+		hPostAPIToken(session, userStore, tokens, audit, logger),
+	)
+	mux.Handle(
+		"POST /auth/tokens/{id}/revoke",
+		hPostRevokeAPIToken(session, userStore, tokens, audit, logger),
+	)
+	mux.Handle(
+		"POST /auth/condominium",
+		hPostSelectCondominium(session, userStore, logger),
+	)
+	mux.Handle(
+		"POST /auth/logout",
+		hPostLogout(session, logger),
 	)
 
 	return mux
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// Session is a server side session, as shown to its owner.
+type Session struct {
+	ID         int64
+	UserID     int64
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+// SessionRecord is a session as persisted by the SessionBackend.
+type SessionRecord struct {
+	Session
+	TokenHash string
+	Data      []byte
+}
+
+// SessionBackend persists sessions. Only the hash of the session token is
+// stored, a leaked database doesn't allow hijacking sessions.
+type SessionBackend interface {
+	// GetSession returns (SessionRecord{}, false, nil) if the session doesn't
+	// exist or expired.
+	GetSession(ctx context.Context, tokenHash string, now time.Time) (SessionRecord, bool, error)
+	// CreateSession returns the ID of the new session.
+	CreateSession(ctx context.Context, record SessionRecord) (int64, error)
+	// UpdateSession returns false if the session doesn't exist or expired
+	// at now, it is never created again.
+	UpdateSession(ctx context.Context, record SessionRecord, now time.Time) (bool, error)
+	TouchSession(ctx context.Context, tokenHash string, ip string, now time.Time) error
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) error
+
+	ListUserSessions(ctx context.Context, userID int64, now time.Time) ([]Session, error)
+	// DeleteUserSession returns false if the session doesn't belong to the
+	// user.
+	DeleteUserSession(ctx context.Context, userID int64, sessionID int64) (bool, error)
+	DeleteUserSessions(ctx context.Context, userID int64) error
+}
+
+// touchInterval limits how often last_seen_at is written, so that regular
+// browsing doesn't turn every request into a write.
+const touchInterval = time.Minute
+
+// sessionIDKey is the value of the session that holds the ID it is stored
+// with, set when it is loaded.
+const sessionIDKey = "session_id"
+
+// SessionStore is a sessions.Store that keeps the session values in the
+// database. The cookie only holds a signed random token, so sessions can be
+// listed and revoked.
+type SessionStore struct {
+	backend SessionBackend
+	codecs  []securecookie.Codec
+	logger  *slog.Logger
+	Options *sessions.Options
+}
+
+var _ sessions.Store = (*SessionStore)(nil)
+
+func NewSessionStore(
+	backend SessionBackend,
+	logger *slog.Logger,
+	options *sessions.Options,
+	keyPairs ...[]byte,
+) *SessionStore {
+	return &SessionStore{
+		backend: backend,
+		codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		logger:  logger,
+		Options: options,
+	}
+}
+
+// Get returns a session for the given name, cached for the request.
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session referenced by the cookie, or returns a new one if
+// there is none.
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	now := time.Now()
+	tokenHash := hashSessionToken(token)
+	record, ok, err := s.backend.GetSession(r.Context(), tokenHash, now)
+	if err != nil {
+		return session, err
+	}
+	if !ok {
+		return session, nil
+	}
+
+	err = securecookie.GobEncoder{}.Deserialize(record.Data, &session.Values)
+	if err != nil {
+		s.logger.Warn("Discarding undecodable session", "error", err)
+		return session, nil
+	}
+
+	session.ID = token
+	session.IsNew = false
+	session.Values[sessionIDKey] = record.ID
+
+	if now.Sub(record.LastSeenAt) > touchInterval {
+		err := s.backend.TouchSession(r.Context(), tokenHash, clientIP(r), now)
+		if err != nil {
+			s.logger.Warn("Failed to update session", "error", err)
+		}
+	}
+
+	return session, nil
+}
+
+// Save persists the session and sets its cookie. A negative MaxAge deletes
+// the session. Sessions are only created when they have no token yet: one
+// that was revoked or expired while the request ran stays ended, and its
+// cookie is deleted.
+func (s *SessionStore) Save(
+	r *http.Request, w http.ResponseWriter, session *sessions.Session,
+) error {
+	ctx := r.Context()
+
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.backend.DeleteSession(ctx, hashSessionToken(session.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	now := time.Now()
+	created := session.ID == ""
+	if created {
+		token, err := generateSessionToken()
+		if err != nil {
+			return err
+		}
+		session.ID = token
+		delete(session.Values, sessionIDKey)
+
+		// Opportunistic cleanup, new sessions are rare enough.
+		if err := s.backend.DeleteExpiredSessions(ctx, now); err != nil {
+			s.logger.Warn("Failed to delete expired sessions", "error", err)
+		}
+	}
+
+	data, err := securecookie.GobEncoder{}.Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := session.Values["user_id"].(int64)
+	record := SessionRecord{
+		Session: Session{
+			UserID:     userID,
+			UserAgent:  r.UserAgent(),
+			IP:         clientIP(r),
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(time.Duration(session.Options.MaxAge) * time.Second),
+		},
+		TokenHash: hashSessionToken(session.ID),
+		Data:      data,
+	}
+	if created {
+		id, err := s.backend.CreateSession(ctx, record)
+		if err != nil {
+			return err
+		}
+		session.Values[sessionIDKey] = id
+	} else {
+		ok, err := s.backend.UpdateSession(ctx, record, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			opts := *session.Options
+			opts.MaxAge = -1
+			http.SetCookie(w, sessions.NewCookie(session.Name(), "", &opts))
+			return nil
+		}
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Renew discards the stored session and gives it a new token on the next
+// Save, keeping its values. Used on login to prevent session fixation. The
+// CSRF token is dropped too, the one issued before login may be known to
+// whoever planted the session, and a new one is issued on the next request.
+func (s *SessionStore) Renew(r *http.Request, session *sessions.Session) error {
+	delete(session.Values, CSRFTokenKey)
+	delete(session.Values, sessionIDKey)
+	if session.ID == "" {
+		return nil
+	}
+	if err := s.backend.DeleteSession(r.Context(), hashSessionToken(session.ID)); err != nil {
+		return err
+	}
+	session.ID = ""
+	session.IsNew = true
+	return nil
+}
+
+// UserSessions lists the active sessions of the user.
+func (s *SessionStore) UserSessions(ctx context.Context, userID int64) ([]Session, error) {
+	return s.backend.ListUserSessions(ctx, userID, time.Now())
+}
+
+// RevokeSession ends a session of the user, on whatever device it is.
+func (s *SessionStore) RevokeSession(ctx context.Context, userID int64, sessionID int64) (bool, error) {
+	return s.backend.DeleteUserSession(ctx, userID, sessionID)
+}
+
+// RevokeUserSessions ends every session of the user.
+func (s *SessionStore) RevokeUserSessions(ctx context.Context, userID int64) error {
+	return s.backend.DeleteUserSessions(ctx, userID)
+}
+
+// CurrentSessionID returns the ID of the session of the request, used to
+// highlight it in the session list.
+func (s *SessionStore) CurrentSessionID(r *http.Request) int64 {
+	session, err := s.Get(r, SessionName)
+	if err != nil {
+		return 0
+	}
+	id, _ := session.Values[sessionIDKey].(int64)
+	return id
+}
+
+func generateSessionToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/auth"
+)
+
+func hGetSessions(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		active, err := session.UserSessions(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		current := session.CurrentSessionID(r)
+		views := make([]templates.SessionView, 0, len(active))
+		for _, s := range active {
+			views = append(views, templates.SessionView{
+				ID:         s.ID,
+				Device:     describeUserAgent(s.UserAgent),
+				IP:         s.IP,
+				CreatedAt:  s.CreatedAt,
+				LastSeenAt: s.LastSeenAt,
+				Current:    s.ID == current,
+			})
+		}
+
+		return templates.Sessions(views).Render(ctx, w)
+	})
+}
+
+func hPostRevokeSession(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Sesión no encontrada", http.StatusNotFound)
+		}
+
+		ok, err := session.RevokeSession(ctx, user.ID, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return util.NewErrorWithCode("Sesión no encontrada", http.StatusNotFound)
+		}
+
+		if id == session.CurrentSessionID(r) {
+			http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+			return nil
+		}
+
+		http.Redirect(w, r, "/auth/sessions", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostRevokeAllSessions(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		if err := session.RevokeUserSessions(ctx, user.ID); err != nil {
+			return err
+		}
+
+		logger.Info("Signed out everywhere", "user_id", user.ID)
+
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// describeUserAgent summarizes a user agent as "browser en OS". It only
+// knows the common browsers, good enough to recognize your own devices.
+func describeUserAgent(ua string) string {
+	browser := "Navegador desconocido"
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+
+	os := "sistema desconocido"
+	switch {
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	return browser + " en " + os
+}
@@ -0,0 +1,380 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+type ThrottleKind string
+
+const (
+	ThrottleAccount ThrottleKind = "account"
+	ThrottleIP      ThrottleKind = "ip"
+)
+
+// LoginThrottle tracks the recent login failures of an account or an IP.
+type LoginThrottle struct {
+	Kind          ThrottleKind
+	Subject       string
+	Failures      int64
+	LastFailureAt time.Time
+	// LockedUntil is the zero time if the subject is not locked.
+	LockedUntil time.Time
+}
+
+// LockedAccount is a user that can't log in until LockedUntil, or until an
+// admin unlocks it.
+type LockedAccount struct {
+	UserID        int64
+	CondominiumID int64
+	FirstName     string
+	LastName      string
+	Email         string
+	Failures      int64
+	LockedUntil   time.Time
+}
+
+// ThrottleStore persists login failures, so that throttling survives
+// restarts.
+type ThrottleStore interface {
+	// GetLoginThrottle returns (LoginThrottle{}, false, nil) if the subject
+	// has no recorded failures.
+	GetLoginThrottle(ctx context.Context, kind ThrottleKind, subject string) (LoginThrottle, bool, error)
+	SaveLoginThrottle(ctx context.Context, throttle LoginThrottle) error
+	DeleteLoginThrottle(ctx context.Context, kind ThrottleKind, subject string) error
+	// DeleteStaleLoginThrottles forgets the failures of the subjects of the
+	// kind that last failed before failedBefore and aren't locked at now.
+	DeleteStaleLoginThrottles(
+		ctx context.Context, kind ThrottleKind, failedBefore time.Time, now time.Time,
+	) error
+
+	// ListLockedAccounts lists the accounts locked at now. A zero condoID
+	// lists the accounts of every condominium.
+	ListLockedAccounts(ctx context.Context, condoID int64, now time.Time) ([]LockedAccount, error)
+}
+
+// ThrottlePolicy describes how failures of a single subject are penalized.
+type ThrottlePolicy struct {
+	// FreeAttempts is the number of failures allowed before the backoff
+	// starts.
+	FreeAttempts int64
+	// BaseDelay is the wait after the first penalized failure. It doubles
+	// with each further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockAfter is the number of failures that lock the subject for
+	// LockDuration.
+	LockAfter    int64
+	LockDuration time.Duration
+	// ResetAfter is how long it takes for failures to be forgotten.
+	ResetAfter time.Duration
+}
+
+// Delay returns how long a subject with the given failures has to wait
+// before trying again.
+func (p ThrottlePolicy) Delay(failures int64) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
+
+var (
+	// Accounts get few attempts, as a single account is the usual target.
+	accountPolicy = ThrottlePolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	// IPs are more lenient, several residents may share a connection.
+	ipPolicy = ThrottlePolicy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockAfter:    50,
+		LockDuration: time.Hour,
+		ResetAfter:   time.Hour,
+	}
+)
+
+// Throttler limits password and second factor attempts per account and per
+// IP, with exponential backoff and temporary lockouts. Every outcome is
+// recorded in the audit log.
+type Throttler struct {
+	store  ThrottleStore
+	users  UserStore
+	audit  *entry.AuditLogger
+	logger *slog.Logger
+	now    func() time.Time
+
+	// mu serializes failure updates, the read-modify-write would otherwise
+	// lose failures under concurrent attempts.
+	mu sync.Mutex
+}
+
+func NewThrottler(
+	store ThrottleStore,
+	users UserStore,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) *Throttler {
+	return &Throttler{
+		store:  store,
+		users:  users,
+		audit:  audit,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Allow returns an error shown to the user if the account or the IP must
+// wait before trying again.
+func (t *Throttler) Allow(ctx context.Context, email string, ip string) error {
+	now := t.now()
+
+	for _, subject := range throttleSubjects(email, ip) {
+		throttle, ok, err := t.store.GetLoginThrottle(ctx, subject.kind, subject.value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if now.Before(throttle.LockedUntil) {
+			return util.NewErrorWithCode(
+				"Demasiados intentos fallidos. El acceso está bloqueado temporalmente",
+				http.StatusTooManyRequests,
+			)
+		}
+
+		if now.Sub(throttle.LastFailureAt) > subject.policy.ResetAfter {
+			continue
+		}
+
+		retryAt := throttle.LastFailureAt.Add(subject.policy.Delay(throttle.Failures))
+		if now.Before(retryAt) {
+			wait := retryAt.Sub(now).Round(time.Second)
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return util.NewErrorWithCode(
+				fmt.Sprintf(
+					"Demasiados intentos fallidos. Intenta de nuevo en %d segundos",
+					int(wait.Seconds()),
+				),
+				http.StatusTooManyRequests,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Fail records a failed attempt. user is nil when the email doesn't belong
+// to any user.
+func (t *Throttler) Fail(
+	ctx context.Context, email string, ip string, user *User,
+) error {
+	var userID, condoID int64
+	if user != nil {
+		userID = user.ID
+		condoID = user.CondominiumID
+	}
+
+	err := t.audit.Record(ctx, entry.AuditRecord{
+		CondominiumID: condoID,
+		UserID:        userID,
+		Level:         entry.AuditImportant,
+		Action:        entry.ActionLoginFailed,
+		Message: fmt.Sprintf(
+			"Inicio de sesión fallido para %s desde %s", normalizeEmail(email), ip,
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+
+	for _, subject := range throttleSubjects(email, ip) {
+		throttle, ok, err := t.store.GetLoginThrottle(ctx, subject.kind, subject.value)
+		if err != nil {
+			return err
+		}
+
+		expired := !throttle.LockedUntil.IsZero() && !now.Before(throttle.LockedUntil)
+		stale := now.Sub(throttle.LastFailureAt) > subject.policy.ResetAfter
+		if !ok || expired || stale {
+			throttle = LoginThrottle{Kind: subject.kind, Subject: subject.value}
+		}
+		if !ok {
+			// Opportunistic cleanup, so that failures for emails that
+			// don't exist don't pile up. Forgotten failures no longer
+			// count anyway.
+			err := t.store.DeleteStaleLoginThrottles(
+				ctx, subject.kind, now.Add(-subject.policy.ResetAfter), now,
+			)
+			if err != nil {
+				t.logger.Warn("Failed to delete stale login throttles", "error", err)
+			}
+		}
+
+		throttle.Failures++
+		throttle.LastFailureAt = now
+
+		locked := throttle.LockedUntil.IsZero() &&
+			throttle.Failures >= subject.policy.LockAfter
+		if locked {
+			throttle.LockedUntil = now.Add(subject.policy.LockDuration)
+		}
+
+		if err := t.store.SaveLoginThrottle(ctx, throttle); err != nil {
+			return err
+		}
+
+		if locked {
+			t.logger.Warn(
+				"Login locked",
+				"kind", subject.kind,
+				"subject", subject.value,
+				"until", throttle.LockedUntil,
+			)
+
+			record := entry.AuditRecord{
+				Level:  entry.AuditCritical,
+				Action: entry.ActionLoginLocked,
+				Message: fmt.Sprintf(
+					"Login bloqueado hasta %s por %d intentos fallidos (%s: %s)",
+					throttle.LockedUntil.Format(time.DateTime),
+					throttle.Failures,
+					subject.kind,
+					subject.value,
+				),
+			}
+			if subject.kind == ThrottleAccount {
+				record.UserID = userID
+				record.CondominiumID = condoID
+			}
+			if err := t.audit.Record(ctx, record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Succeed records the login and forgets the failures of the account.
+// Failures of the IP are kept, otherwise a valid account could be used to
+// reset them.
+func (t *Throttler) Succeed(ctx context.Context, user *User, ip string) error {
+	err := t.audit.Record(ctx, entry.AuditRecord{
+		CondominiumID: user.CondominiumID,
+		UserID:        user.ID,
+		Level:         entry.AuditInfo,
+		Action:        entry.ActionLogin,
+		Message:       fmt.Sprintf("Inicio de sesión de %s desde %s", user.Email, ip),
+	})
+	if err != nil {
+		return err
+	}
+
+	return t.store.DeleteLoginThrottle(ctx, ThrottleAccount, normalizeEmail(user.Email))
+}
+
+// LockedAccounts lists the locked accounts the user in ctx can unlock.
+func (t *Throttler) LockedAccounts(ctx context.Context) ([]LockedAccount, error) {
+	user, err := entry.RequirePermission(ctx, entry.PermUsersManage)
+	if err != nil {
+		return nil, err
+	}
+
+	var condoID int64
+	if !user.Can(entry.PermSystemManage, 0) {
+		condoID = user.CondominiumID
+	}
+
+	return t.store.ListLockedAccounts(ctx, condoID, t.now())
+}
+
+// Unlock lets the user log in again. Only users that manage the users of
+// its condominium can unlock it.
+func (t *Throttler) Unlock(ctx context.Context, userID int64) error {
+	user, ok, err := t.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+	}
+
+	_, err = entry.RequirePermissionIn(ctx, entry.PermUsersManage, user.CondominiumID)
+	if err != nil {
+		return err
+	}
+
+	return t.users.InTx(ctx, func(ctx context.Context) error {
+		err := t.store.DeleteLoginThrottle(ctx, ThrottleAccount, normalizeEmail(user.Email))
+		if err != nil {
+			return err
+		}
+
+		return t.audit.Record(ctx, entry.AuditRecord{
+			CondominiumID: user.CondominiumID,
+			Level:         entry.AuditImportant,
+			Action:        entry.ActionAccountUnlocked,
+			Message:       fmt.Sprintf("Cuenta desbloqueada: %s", user.Email),
+		})
+	})
+}
+
+type throttleSubject struct {
+	kind   ThrottleKind
+	value  string
+	policy ThrottlePolicy
+}
+
+func throttleSubjects(email string, ip string) []throttleSubject {
+	return []throttleSubject{
+		{kind: ThrottleAccount, value: normalizeEmail(email), policy: accountPolicy},
+		{kind: ThrottleIP, value: ip, policy: ipPolicy},
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// clientIP returns the IP of the connection. Proxy headers are ignored, as
+// they can be forged by the client.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+type memThrottleStore struct {
+	throttles map[ThrottleKind]map[string]LoginThrottle
+}
+
+func newMemThrottleStore() *memThrottleStore {
+	return &memThrottleStore{
+		throttles: map[ThrottleKind]map[string]LoginThrottle{
+			ThrottleAccount: {},
+			ThrottleIP:      {},
+		},
+	}
+}
+
+func (s *memThrottleStore) GetLoginThrottle(
+	_ context.Context, kind ThrottleKind, subject string,
+) (LoginThrottle, bool, error) {
+	t, ok := s.throttles[kind][subject]
+	return t, ok, nil
+}
+
+func (s *memThrottleStore) SaveLoginThrottle(_ context.Context, t LoginThrottle) error {
+	s.throttles[t.Kind][t.Subject] = t
+	return nil
+}
+
+func (s *memThrottleStore) DeleteLoginThrottle(
+	_ context.Context, kind ThrottleKind, subject string,
+) error {
+	delete(s.throttles[kind], subject)
+	return nil
+}
+
+func (s *memThrottleStore) DeleteStaleLoginThrottles(
+	_ context.Context, kind ThrottleKind, failedBefore time.Time, now time.Time,
+) error {
+	for subject, t := range s.throttles[kind] {
+		if t.LastFailureAt.Before(failedBefore) && !t.LockedUntil.After(now) {
+			delete(s.throttles[kind], subject)
+		}
+	}
+	return nil
+}
+
+func (s *memThrottleStore) ListLockedAccounts(
+	context.Context, int64, time.Time,
+) ([]LockedAccount, error) {
+	return nil, nil
+}
+
+type memAuditStore struct {
+	entry.AuditChainStore // Not used by the throttler
+	records               []entry.AuditRecord
+}
+
+func (s *memAuditStore) AuditCreate(
+	_ context.Context, record entry.AuditRecord, _ time.Time,
+) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memAuditStore) AuditList(
+	context.Context, entry.AuditFilter,
+) ([]entry.AuditEntry, error) {
+	return nil, nil
+}
+
+func (s *memAuditStore) AuditCount(context.Context, entry.AuditFilter) (int64, error) {
+	return 0, nil
+}
+
+func (s *memAuditStore) count(action entry.AuditAction) int {
+	n := 0
+	for _, r := range s.records {
+		if r.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func TestThrottlePolicyDelay(t *testing.T) {
+	want := []time.Duration{
+		0, 0, 0, 0, // free attempts
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		16 * time.Second, 32 * time.Second, time.Minute, time.Minute,
+	}
+	for failures, d := range want {
+		if got := accountPolicy.Delay(int64(failures)); got != d {
+			t.Errorf("Delay(%d) = %s; want %s", failures, got, d)
+		}
+	}
+}
+
+func TestThrottlerLockout(t *testing.T) {
+	ctx := context.Background()
+	store := newMemThrottleStore()
+	audits := &memAuditStore{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	throttler := NewThrottler(store, nil, entry.NewAuditLogger(audits, logger), logger)
+
+	now := time.Unix(1_700_000_000, 0)
+	throttler.now = func() time.Time { return now }
+
+	const email, ip = "Vecino@Example.com", "192.0.2.1"
+	user := &User{ID: 1, CondominiumID: 1, Email: "vecino@example.com"}
+
+	for i := range accountPolicy.LockAfter {
+		if err := throttler.Allow(ctx, email, ip); err != nil {
+			t.Fatalf("attempt %d: unexpected throttle: %v", i+1, err)
+		}
+		if err := throttler.Fail(ctx, email, ip, user); err != nil {
+			t.Fatal(err)
+		}
+
+		// Retrying right away is only allowed during the free attempts.
+		err := throttler.Allow(ctx, email, ip)
+		if i+1 <= accountPolicy.FreeAttempts && err != nil {
+			t.Fatalf("attempt %d: unexpected throttle: %v", i+1, err)
+		}
+		if i+1 > accountPolicy.FreeAttempts && err == nil {
+			t.Fatalf("attempt %d: expected throttle", i+1)
+		}
+
+		now = now.Add(accountPolicy.MaxDelay)
+	}
+
+	if got := audits.count(entry.ActionLoginLocked); got != 1 {
+		t.Fatalf("got %d lock audit entries; want 1", got)
+	}
+	if got := audits.count(entry.ActionLoginFailed); got != int(accountPolicy.LockAfter) {
+		t.Fatalf("got %d failure audit entries; want %d", got, accountPolicy.LockAfter)
+	}
+	if err := throttler.Allow(ctx, "vecino@example.com", ip); err == nil {
+		t.Fatal("expected the account to be locked")
+	}
+	if err := throttler.Allow(ctx, "otro@example.com", ip); err != nil {
+		t.Fatalf("other accounts from the same IP should be allowed: %v", err)
+	}
+
+	now = now.Add(accountPolicy.LockDuration)
+	if err := throttler.Allow(ctx, email, ip); err != nil {
+		t.Fatalf("lock should have expired: %v", err)
+	}
+
+	if err := throttler.Succeed(ctx, user, ip); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.throttles[ThrottleAccount]["vecino@example.com"]; ok {
+		t.Fatal("success should forget the account failures")
+	}
+}
+
+func TestThrottlerForgetsStaleFailures(t *testing.T) {
+	ctx := context.Background()
+	store := newMemThrottleStore()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	throttler := NewThrottler(store, nil, entry.NewAuditLogger(&memAuditStore{}, logger), logger)
+
+	now := time.Unix(1_700_000_000, 0)
+	throttler.now = func() time.Time { return now }
+
+	// Emails that don't belong to anyone, like the ones of a spraying
+	// attack.
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if err := throttler.Fail(ctx, email, "192.0.2.1", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now = now.Add(accountPolicy.ResetAfter + time.Second)
+	if err := throttler.Fail(ctx, "c@example.com", "192.0.2.2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(store.throttles[ThrottleAccount]); got != 1 {
+		t.Errorf("got %d account throttles; want only the last one", got)
+	}
+	if got := len(store.throttles[ThrottleIP]); got != 1 {
+		t.Errorf("got %d IP throttles; want only the last one", got)
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238, Appendix B (SHA1), truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range tests {
+		got := totpCode(secret, tc.unix/totpPeriod)
+		if got != tc.want {
+			t.Errorf("totpCode(%d) = %s; want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		code   string
+		wantOk bool
+	}{
+		{"current", totpCode(key, step), true},
+		{"previous", totpCode(key, step-1), true},
+		{"next", totpCode(key, step+1), true},
+		{"too old", totpCode(key, step-2), false},
+		{"malformed", "12345", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok := validateTOTP(secret, tc.code, now)
+			if ok != tc.wantOk {
+				t.Fatalf("validateTOTP(%q) ok = %t; want %t", tc.code, ok, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes; want %d", len(codes), len(hashes), recoveryCodeCount)
+	}
+
+	// Users may type the code in upper case and without the dash.
+	code := codes[0]
+	typed := " " + strings.ToUpper(code[:4]+code[5:]) + " "
+	if hashRecoveryCode(typed) != hashes[0] {
+		t.Fatalf("hash of %q doesn't match hash of %q", typed, code)
+	}
+}
@@ -0,0 +1,476 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/auth"
+)
+
+// pendingLoginTTL is how long a user has to complete the second step of the
+// login after entering a valid password.
+const pendingLoginTTL = 5 * time.Minute
+
+func hGetTwoFactor(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		user, ok, err := pendingUser(r.Context(), session, store, r)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			http.Redirect(w, r, "/auth/login", http.StatusFound)
+			return nil
+		}
+
+		if !user.TwoFactorEnabled {
+			http.Redirect(w, r, "/auth/2fa/setup", http.StatusFound)
+			return nil
+		}
+
+		return templates.TwoFactorVerify().Render(r.Context(), w)
+	})
+}
+
+func hPostTwoFactor(
+	session *SessionStore,
+	store UserStore,
+	throttler *Throttler,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		user, ok, err := pendingUser(r.Context(), session, store, r)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return util.NewErrorWithCode(
+				"La sesión expiró, vuelve a iniciar sesión",
+				http.StatusBadRequest,
+			)
+		}
+
+		ip := clientIP(r)
+		if err := throttler.Allow(r.Context(), user.Email, ip); err != nil {
+			return err
+		}
+
+		valid, err := verifySecondFactor(
+			r.Context(), store, user, r.FormValue("code"), true,
+		)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			if err := throttler.Fail(r.Context(), user.Email, ip, user); err != nil {
+				return err
+			}
+			return invalidCodeErr()
+		}
+
+		if err := setCurrentUser(w, r, session, user); err != nil {
+			return err
+		}
+		if err := throttler.Succeed(r.Context(), user, ip); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, getRedirectForRole(user.Role), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetTwoFactorSetup(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		ctx := r.Context()
+
+		user, pending, err := enrollingUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			http.Redirect(w, r, "/auth/login", http.StatusFound)
+			return nil
+		}
+
+		if user.TwoFactorEnabled {
+			if pending {
+				http.Redirect(w, r, "/auth/2fa", http.StatusFound)
+				return nil
+			}
+
+			remaining, err := store.CountRecoveryCodes(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			required, err := store.IsTwoFactorRequired(
+				ctx, user.Role, user.CondominiumID,
+			)
+			if err != nil {
+				return err
+			}
+			return templates.TwoFactorManage(remaining, required).Render(ctx, w)
+		}
+
+		secret, err := generateTOTPSecret()
+		if err != nil {
+			return err
+		}
+		if err := store.StartTwoFactorEnrollment(ctx, user.ID, secret); err != nil {
+			return err
+		}
+
+		qr, err := totpQRCode(user.Email, secret)
+		if err != nil {
+			return err
+		}
+
+		return templates.TwoFactorSetup(qr, secret, pending).Render(ctx, w)
+	})
+}
+
+func hPostTwoFactorSetup(
+	session *SessionStore,
+	store UserStore,
+	throttler *Throttler,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		ctx := r.Context()
+
+		user, pending, err := enrollingUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return util.NewErrorWithCode(
+				"La sesión expiró, vuelve a iniciar sesión",
+				http.StatusBadRequest,
+			)
+		}
+
+		tf, err := store.GetTwoFactor(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if tf.Enabled {
+			return util.NewErrorWithCode(
+				"La verificación en dos pasos ya está activa",
+				http.StatusBadRequest,
+			)
+		}
+		if tf.Secret == "" {
+			return util.NewErrorWithCode(
+				"Recarga la página para generar un nuevo código QR",
+				http.StatusBadRequest,
+			)
+		}
+
+		step, ok := validateTOTP(tf.Secret, r.FormValue("code"), time.Now())
+		if !ok {
+			return invalidCodeErr()
+		}
+		if _, err := store.AdvanceTwoFactorStep(ctx, user.ID, step); err != nil {
+			return err
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		err = store.InTx(ctx, func(ctx context.Context) error {
+			if err := store.EnableTwoFactor(ctx, user.ID, hashes); err != nil {
+				return err
+			}
+
+			return audit.Record(ctx, entry.AuditRecord{
+				CondominiumID: user.CondominiumID,
+				UserID:        user.ID,
+				Level:         entry.AuditImportant,
+				Action:        entry.ActionTwoFactorChanged,
+				Message: fmt.Sprintf(
+					"Verificación en dos pasos activada por %s", user.Email,
+				),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		logger.Info("Two-factor authentication enabled", "user_id", user.ID)
+
+		if pending {
+			if err := setCurrentUser(w, r, session, user); err != nil {
+				return err
+			}
+			if err := throttler.Succeed(ctx, user, clientIP(r)); err != nil {
+				return err
+			}
+		}
+
+		return templates.RecoveryCodes(
+			codes, getRedirectForRole(user.Role),
+		).Render(ctx, w)
+	})
+}
+
+func hPostTwoFactorDisable(
+	session *SessionStore,
+	store UserStore,
+	audit *entry.AuditLogger,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		required, err := store.IsTwoFactorRequired(
+			ctx, user.Role, user.CondominiumID,
+		)
+		if err != nil {
+			return err
+		}
+		if required {
+			return util.NewErrorWithCode(
+				"La verificación en dos pasos es obligatoria para tu cuenta",
+				http.StatusForbidden,
+			)
+		}
+
+		valid, err := verifySecondFactor(ctx, store, user, r.FormValue("code"), true)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return invalidCodeErr()
+		}
+
+		err = store.InTx(ctx, func(ctx context.Context) error {
+			if err := store.DisableTwoFactor(ctx, user.ID); err != nil {
+				return err
+			}
+
+			return audit.Record(ctx, entry.AuditRecord{
+				CondominiumID: user.CondominiumID,
+				UserID:        user.ID,
+				Level:         entry.AuditImportant,
+				Action:        entry.ActionTwoFactorChanged,
+				Message: fmt.Sprintf(
+					"Verificación en dos pasos desactivada por %s", user.Email,
+				),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		logger.Info("Two-factor authentication disabled", "user_id", user.ID)
+
+		http.Redirect(w, r, "/auth/2fa/setup", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostRecoveryCodes(
+	session *SessionStore,
+	store UserStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(
+		w http.ResponseWriter, r *http.Request,
+	) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		ctx := r.Context()
+
+		user, err := loggedInUser(ctx, session, store, r)
+		if err != nil {
+			return err
+		}
+
+		// Recovery codes can't be used to mint new recovery codes.
+		valid, err := verifySecondFactor(ctx, store, user, r.FormValue("code"), false)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return invalidCodeErr()
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		if err := store.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+			return err
+		}
+
+		return templates.RecoveryCodes(
+			codes, getRedirectForRole(user.Role),
+		).Render(ctx, w)
+	})
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code if allowRecovery
+// is set. Accepted codes are consumed.
+func verifySecondFactor(
+	ctx context.Context,
+	store UserStore,
+	user *User,
+	code string,
+	allowRecovery bool,
+) (bool, error) {
+	tf, err := store.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if !tf.Enabled {
+		return false, nil
+	}
+
+	if step, ok := validateTOTP(tf.Secret, code, time.Now()); ok {
+		return store.AdvanceTwoFactorStep(ctx, user.ID, step)
+	}
+
+	if !allowRecovery {
+		return false, nil
+	}
+
+	return store.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+}
+
+func invalidCodeErr() error {
+	return util.NewErrorWithCode("Código inválido", http.StatusBadRequest)
+}
+
+// setPendingUser remembers a user that entered a valid password but still
+// has to complete the second factor. It doesn't grant access to anything.
+func setPendingUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	session *SessionStore,
+	user *User,
+) error {
+	s, err := session.Get(r, SessionName)
+	if err != nil {
+		return err
+	}
+
+	s.Values["pending_user_id"] = user.ID
+	s.Values["pending_at"] = time.Now().Unix()
+
+	return s.Save(r, w)
+}
+
+func pendingUser(
+	ctx context.Context,
+	session *SessionStore,
+	store UserStore,
+	r *http.Request,
+) (*User, bool, error) {
+	s, _ := session.Get(r, SessionName)
+
+	userID, ok := s.Values["pending_user_id"].(int64)
+	if !ok {
+		return nil, false, nil
+	}
+
+	pendingAt, _ := s.Values["pending_at"].(int64)
+	if time.Since(time.Unix(pendingAt, 0)) > pendingLoginTTL {
+		return nil, false, nil
+	}
+
+	user, ok, err := store.GetByID(ctx, userID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if !user.Enabled {
+		return nil, false, nil
+	}
+
+	return user, true, nil
+}
+
+// enrollingUser returns the user that is allowed to enroll: either a logged
+// in user, or one that must enroll before completing the login.
+func enrollingUser(
+	ctx context.Context,
+	session *SessionStore,
+	store UserStore,
+	r *http.Request,
+) (*User, bool, error) {
+	user, ok, err := CurrentUser(session, store, r)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		if err := entry.RequireNotImpersonating(ctx); err != nil {
+			return nil, false, err
+		}
+		return user, false, nil
+	}
+
+	user, ok, err = pendingUser(ctx, session, store, r)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+func loggedInUser(
+	ctx context.Context,
+	session *SessionStore,
+	store UserStore,
+	r *http.Request,
+) (*User, error) {
+	user, ok, err := CurrentUser(session, store, r)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, util.NewErrorWithCode(
+			"Debes iniciar sesión", http.StatusUnauthorized,
+		)
+	}
+	// The credentials and sessions of the impersonated user are off limits.
+	if err := entry.RequireNotImpersonating(ctx); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// UserGetter retrieves users by ID, see UserStore.GetByID.
+type UserGetter interface {
+	GetByID(ctx context.Context, id int64) (*User, bool, error)
+}
+
+type cachedUser struct {
+	user      *User
+	found     bool
+	fetchedAt time.Time
+}
+
+// UserCache is a UserGetter that keeps users for a short time, so that
+// resolving the user of every request doesn't always hit the database.
+// Changes to a user take at most the TTL to be seen.
+type UserCache struct {
+	store UserGetter
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	users map[int64]cachedUser
+}
+
+var _ UserGetter = (*UserCache)(nil)
+
+func NewUserCache(store UserGetter, ttl time.Duration) *UserCache {
+	return &UserCache{
+		store: store,
+		ttl:   ttl,
+		users: make(map[int64]cachedUser),
+	}
+}
+
+func (c *UserCache) GetByID(ctx context.Context, id int64) (*User, bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.users[id]
+	c.mu.Unlock()
+
+	if ok && now.Sub(cached.fetchedAt) < c.ttl {
+		return cached.user, cached.found, nil
+	}
+
+	user, found, err := c.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	c.users[id] = cachedUser{user: user, found: found, fetchedAt: now}
+	// Drop stale entries once in a while, so that the map doesn't grow
+	// with every user that ever logged in.
+	if len(c.users) > 1024 {
+		for id, cached := range c.users {
+			if now.Sub(cached.fetchedAt) >= c.ttl {
+				delete(c.users, id)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	return user, found, nil
+}
+
+// Forget drops the cached user, so that the next request sees its changes.
+func (c *UserCache) Forget(id int64) {
+	c.mu.Lock()
+	delete(c.users, id)
+	c.mu.Unlock()
+}
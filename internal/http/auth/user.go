@@ -18,6 +18,14 @@ type User struct {
 	Role          entry.UserRole
 	Enabled       bool
 	Hidden        bool
+	// TwoFactorEnabled reports whether the user completed TOTP enrollment.
+	TwoFactorEnabled bool
+	// Memberships are the condominiums the user holds a role in, the home
+	// one first.
+	Memberships []entry.Membership
+	// Scopes are the permissions of the API token the request was
+	// authenticated with, nil for sessions.
+	Scopes []entry.Permission
 }
 
 // UserWithPassword extends User with the password hash for authentication.
@@ -26,10 +34,25 @@ type UserWithPassword struct {
 	PasswordHash string
 }
 
+// TwoFactor holds the TOTP state of a user.
+type TwoFactor struct {
+	// Secret is the base32 encoded shared secret. It is set as soon as the
+	// enrollment starts, but only trusted once Enabled is true.
+	Secret  string
+	Enabled bool
+	// LastStep is the last accepted time step, codes from it or earlier
+	// steps are rejected to prevent replays.
+	LastStep int64
+}
+
 // UserStore defines the interface for user storage operations.
 // Implementations are responsible for converting between the SQLC model
 // and the auth model.
 type UserStore interface {
+	// InTx runs the changes made with the context fn gets in a
+	// transaction, along with their audit entries. See entry.TxStore.
+	entry.TxStore
+
 	// GetByEmailForAuth retrieves a user by their email address with its password hash.
 	// WARNING: This method returns the password hash and should ONLY be used for
 	// authentication purposes. Never use this for general user retrieval.
@@ -48,6 +71,38 @@ type UserStore interface {
 
 	// CountSuperAdmins returns the number of enabled superadmins.
 	CountSuperAdmins(ctx context.Context) (int64, error)
+
+	// GetTwoFactor retrieves the TOTP state of a user.
+	GetTwoFactor(ctx context.Context, userID int64) (TwoFactor, error)
+
+	// StartTwoFactorEnrollment stores a new, not yet enabled, TOTP secret.
+	// Any previous secret is discarded.
+	StartTwoFactorEnrollment(ctx context.Context, userID int64, secret string) error
+
+	// EnableTwoFactor marks the enrollment as complete and replaces the
+	// recovery codes of the user with the given hashes.
+	EnableTwoFactor(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+
+	// DisableTwoFactor removes the TOTP secret and the recovery codes.
+	DisableTwoFactor(ctx context.Context, userID int64) error
+
+	// ReplaceRecoveryCodes replaces the recovery codes of the user.
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+
+	// AdvanceTwoFactorStep records step as the last accepted time step.
+	// Returns false if the step, or a later one, was already used.
+	AdvanceTwoFactorStep(ctx context.Context, userID int64, step int64) (bool, error)
+
+	// UseRecoveryCode marks the recovery code as used.
+	// Returns false if the code doesn't exist or was already used.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+
+	// CountRecoveryCodes returns the number of unused recovery codes.
+	CountRecoveryCodes(ctx context.Context, userID int64) (int64, error)
+
+	// IsTwoFactorRequired reports whether a superadmin made two-factor
+	// authentication mandatory for the role in the condominium.
+	IsTwoFactorRequired(ctx context.Context, role entry.UserRole, condoID int64) (bool, error)
 }
 
 func (u *User) ToEntryUser() *entry.User {
@@ -59,5 +114,35 @@ func (u *User) ToEntryUser() *entry.User {
 		CondominiumID: u.CondominiumID,
 		Role:          u.Role,
 		Enabled:       u.Enabled,
+		Memberships:   u.Memberships,
+		Scopes:        u.Scopes,
+	}
+}
+
+// InCondominium returns a copy of the user acting in the condominium, with
+// the role it holds there. It returns false if the user isn't a member.
+func (u *User) InCondominium(condoID int64) (*User, bool) {
+	for _, m := range u.Memberships {
+		if m.CondominiumID == condoID {
+			scoped := *u
+			scoped.CondominiumID = m.CondominiumID
+			scoped.Role = m.Role
+			return &scoped, true
+		}
+	}
+	return nil, false
+}
+
+// MissingTwoFactor reports whether the condominium the user acts in requires
+// two-factor authentication for its role and the user didn't enable it.
+func (u *User) MissingTwoFactor() bool {
+	if u.TwoFactorEnabled {
+		return false
+	}
+	for _, m := range u.Memberships {
+		if m.CondominiumID == u.CondominiumID {
+			return m.TwoFactorRequired
+		}
 	}
+	return false
 }
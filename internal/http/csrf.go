@@ -0,0 +1,165 @@
+package http
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	"github.com/Polo123456789/entry-watch/internal/templates/common"
+)
+
+const (
+	csrfTokenKey     = auth.CSRFTokenKey
+	csrfFormField    = "csrf_token"
+	csrfHeader       = "X-CSRF-Token"
+	csrfStaticPrefix = "/static/"
+	// csrfCookieName is the signed cookie that holds the token of visitors
+	// without a stored session, so that every anonymous request doesn't
+	// store one.
+	csrfCookieName = "entry-watch-csrf"
+)
+
+// CSRFMiddleware rejects state-changing requests that don't carry the token
+// of their session, either in the csrf_token form field or in the
+// X-CSRF-Token header. The token is issued once per session and made
+// available to the templates through common.CSRFToken. Until there is a
+// stored session, like before logging in, the token is kept in a cookie
+// signed with key instead. The API is exempt, it is authenticated with
+// tokens that browsers don't send on their own.
+func CSRFMiddleware(
+	logger *slog.Logger,
+	session sessions.Store,
+	key []byte,
+	next http.Handler,
+) http.Handler {
+	codec := securecookie.New(key, nil)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Static files and the API don't need a session, and shouldn't
+		// create one.
+		if strings.HasPrefix(r.URL.Path, csrfStaticPrefix) || isAPIRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s, _ := session.Get(r, auth.SessionName)
+		var token string
+		if s.IsNew {
+			token = anonymousCSRFToken(r, codec)
+		} else {
+			token, _ = s.Values[csrfTokenKey].(string)
+			// The token from before the session was stored is done.
+			if _, err := r.Cookie(csrfCookieName); err == nil {
+				http.SetCookie(w, csrfCookie("", -1))
+			}
+		}
+
+		if !isSafeMethod(r.Method) {
+			sent := r.Header.Get(csrfHeader)
+			if sent == "" {
+				sent = r.PostFormValue(csrfFormField)
+			}
+
+			valid := token != "" &&
+				subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+			if !valid {
+				logger.Warn("CSRF token mismatch", "url", r.URL.String(), "method", r.Method)
+				util.HandleError(w, r, logger, util.NewErrorWithCode(
+					"La página expiró. Recárgala e intenta de nuevo",
+					http.StatusForbidden,
+				))
+				return
+			}
+		}
+
+		if token == "" {
+			var err error
+			token, err = generateCSRFToken()
+			if err != nil {
+				util.HandleError(w, r, logger, err)
+				return
+			}
+			if err := saveCSRFToken(w, r, s, codec, token); err != nil {
+				util.HandleError(w, r, logger, err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(common.WithCSRFToken(r.Context(), token)))
+	})
+}
+
+// anonymousCSRFToken returns the token of the signed cookie, if it is
+// valid.
+func anonymousCSRFToken(r *http.Request, codec securecookie.Codec) string {
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil {
+		return ""
+	}
+	var token string
+	if err := codec.Decode(csrfCookieName, cookie.Value, &token); err != nil {
+		return ""
+	}
+	return token
+}
+
+// saveCSRFToken keeps the token in the session if it is stored, or in the
+// signed cookie otherwise.
+func saveCSRFToken(
+	w http.ResponseWriter,
+	r *http.Request,
+	s *sessions.Session,
+	codec securecookie.Codec,
+	token string,
+) error {
+	if !s.IsNew {
+		s.Values[csrfTokenKey] = token
+		return s.Save(r, w)
+	}
+
+	encoded, err := codec.Encode(csrfCookieName, token)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, csrfCookie(encoded, 0))
+	return nil
+}
+
+// csrfCookie lasts until the browser is closed, like the pages it
+// protects. A negative maxAge deletes it.
+func csrfCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func generateCSRFToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+
+	"github.com/Polo123456789/entry-watch/db"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/sqlc"
+	"github.com/Polo123456789/entry-watch/internal/templates/common"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	key := []byte("0123456789abcdef0123456789abcdef")
+	store := sessions.NewCookieStore(key)
+
+	var seenToken string
+	handler := CSRFMiddleware(logger, store, key, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			seenToken = common.CSRFToken(r.Context())
+		},
+	))
+
+	// A first GET issues the token.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: got status %d", rec.Code)
+	}
+	if seenToken == "" {
+		t.Fatal("GET: no token in context")
+	}
+	token := seenToken
+	cookies := rec.Result().Cookies()
+
+	post := func(body url.Values, header string) int {
+		req := httptest.NewRequest(
+			http.MethodPost, "/", strings.NewReader(body.Encode()),
+		)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name   string
+		body   url.Values
+		header string
+		want   int
+	}{
+		{"missing", url.Values{}, "", http.StatusForbidden},
+		{"wrong field", url.Values{"csrf_token": {"nope"}}, "", http.StatusForbidden},
+		{"wrong header", url.Values{}, "nope", http.StatusForbidden},
+		{"field", url.Values{"csrf_token": {token}}, "", http.StatusOK},
+		{"header", url.Values{}, token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(tt.body, tt.header); got != tt.want {
+				t.Errorf("got status %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Without a session there is no token to match.
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-CSRF-Token", token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("no session: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+// TestCSRFTokenRenewedOnLogin checks that the token issued before login
+// stops working once the session is stored on login, with the session
+// store the server uses.
+func TestCSRFTokenRenewedOnLogin(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a different database.
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+	goose.SetLogger(goose.NopLogger())
+	if err := db.AutoMigrate(conn, logger); err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("0123456789abcdef0123456789abcdef")
+	store := auth.NewSessionStore(
+		sqlc.NewSessionStore(conn), logger, &sessions.Options{Path: "/", MaxAge: 60 * 60}, key,
+	)
+
+	mux := http.NewServeMux()
+	var seenToken string
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		seenToken = common.CSRFToken(r.Context())
+	})
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		s, err := store.Get(r, auth.SessionName)
+		if err == nil {
+			err = store.Renew(r, s)
+		}
+		if err == nil {
+			err = s.Save(r, w)
+		}
+		if err != nil {
+			t.Errorf("login: %v", err)
+		}
+	})
+	mux.HandleFunc("POST /visits", func(w http.ResponseWriter, r *http.Request) {})
+	handler := CSRFMiddleware(logger, store, key, mux)
+
+	var cookies []*http.Cookie
+	do := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("X-CSRF-Token", token)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if set := rec.Result().Cookies(); len(set) > 0 {
+			cookies = set
+		}
+		return rec.Code
+	}
+
+	if got := do(http.MethodGet, "/", ""); got != http.StatusOK || seenToken == "" {
+		t.Fatalf("GET before login: status %d, token %q", got, seenToken)
+	}
+	anonymous := seenToken
+
+	// Visitors that didn't log in don't get a stored session.
+	var stored int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 0 {
+		t.Errorf("GET before login stored %d sessions, want 0", stored)
+	}
+
+	if got := do(http.MethodPost, "/login", anonymous); got != http.StatusOK {
+		t.Fatalf("login: got status %d", got)
+	}
+	if got := do(http.MethodPost, "/visits", anonymous); got != http.StatusForbidden {
+		t.Errorf("token from before login: got status %d, want %d", got, http.StatusForbidden)
+	}
+
+	if got := do(http.MethodGet, "/", ""); got != http.StatusOK {
+		t.Fatalf("GET after login: got status %d", got)
+	}
+	if seenToken == "" || seenToken == anonymous {
+		t.Fatalf("GET after login: token %q wasn't renewed", seenToken)
+	}
+	if got := do(http.MethodPost, "/visits", seenToken); got != http.StatusOK {
+		t.Errorf("token from after login: got status %d, want %d", got, http.StatusOK)
+	}
+}
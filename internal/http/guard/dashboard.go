@@ -1,19 +1,185 @@
 package guard
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
 	"github.com/Polo123456789/entry-watch/internal/http/util"
 	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
 )
 
 func hGet(
 	app *entry.App,
+	session *auth.SessionStore,
 	logger *slog.Logger,
 ) http.Handler {
 	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
-		return templates.Dashboard().Render(r.Context(), w)
+		return renderDashboard(w, r, app, session, templates.DashboardData{})
+	})
+}
+
+// hPostCheckIn checks in at the station the guard picked, opening its gate
+// if the check-in is accepted.
+func hPostCheckIn(
+	app *entry.App,
+	session *auth.SessionStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		station, err := currentStation(app, session, r)
+		if err != nil {
+			return err
+		}
+		var stationID int64
+		if station != nil {
+			stationID = station.ID
+		}
+
+		result, err := app.CheckIn(r.Context(), r.FormValue("code"), stationID)
+		if err != nil {
+			return err
+		}
+
+		return renderDashboard(w, r, app, session, templates.DashboardData{Result: result})
+	})
+}
+
+// renderDashboard fills in what the dashboard always shows and renders it.
+func renderDashboard(
+	w http.ResponseWriter,
+	r *http.Request,
+	app *entry.App,
+	session *auth.SessionStore,
+	data templates.DashboardData,
+) error {
+	var err error
+	data.Entries, err = app.TodayEntries(r.Context())
+	if err != nil {
+		return err
+	}
+	data.Stations, err = app.GuardStations(r.Context())
+	if err != nil {
+		return err
+	}
+	data.Station, err = currentStation(app, session, r)
+	if err != nil {
+		return err
+	}
+	user := entry.UserFromCtx(r.Context())
+	if user.Can(entry.PermShiftsWork, user.CondominiumID) {
+		data.Shift, err = app.OpenShift(r.Context())
+		if err != nil {
+			return err
+		}
+	}
+	data.Residents, err = app.Residents(r.Context())
+	if err != nil {
+		return err
+	}
+	data.Visits, err = app.ExpectedVisits(r.Context())
+	if err != nil {
+		return err
+	}
+	data.WalkIns, err = app.TodayWalkIns(r.Context())
+	if err != nil {
+		return err
+	}
+	data.Announcements, err = app.Announcements(r.Context())
+	if err != nil {
+		return err
+	}
+	data.Occupancy, err = app.Occupancy(r.Context())
+	if err != nil {
+		return err
+	}
+	return templates.Dashboard(data).Render(r.Context(), w)
+}
+
+func hPostAnnouncementRead(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Comunicado no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.MarkAnnouncementRead(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hPostWalkIn tells a resident that a visitor without a visit is waiting
+// at the gate.
+func hPostWalkIn(
+	app *entry.App,
+	session *auth.SessionStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		residentID, err := strconv.ParseInt(r.FormValue("resident_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Elige un residente")
+		}
+		visitorName := r.FormValue("visitor_name")
+		if err := app.AnnounceWalkIn(r.Context(), residentID, visitorName); err != nil {
+			return err
+		}
+
+		return renderDashboard(w, r, app, session, templates.DashboardData{
+			Notice: fmt.Sprintf("Se avisó al residente que %s lo espera", strings.TrimSpace(visitorName)),
+		})
+	})
+}
+
+// hPostExit records that the visitor of an entry left, freeing their place
+// in the occupancy of the condominium.
+func hPostExit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Ingreso no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.RecordExit(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostRevokeVisit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := app.RevokeVisit(r.Context(), r.PathValue("id")); err != nil {
+			return err
+		}
+		http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+		return nil
 	})
 }
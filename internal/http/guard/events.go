@@ -0,0 +1,72 @@
+package guard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+// hGetEvents streams the sections of the dashboard that change as visits
+// are created, visitors check in and leave, and residents answer walk-ins.
+func hGetEvents(app *entry.App, logger *slog.Logger) http.Handler {
+	return util.EventStream(logger, app.GuardUpdates, func(
+		ctx context.Context, event entry.LiveEvent,
+	) ([]util.Fragment, error) {
+		switch event.Kind {
+		case entry.LiveVisit:
+			visits, err := app.ExpectedVisits(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []util.Fragment{{Event: "visits", Component: templates.ExpectedVisits(visits)}}, nil
+
+		case entry.LiveEntry:
+			// Check-ins use up visits and change who is inside, and exits
+			// free places, so all three sections change.
+			entries, err := app.TodayEntries(ctx)
+			if err != nil {
+				return nil, err
+			}
+			visits, err := app.ExpectedVisits(ctx)
+			if err != nil {
+				return nil, err
+			}
+			occupancy, err := app.Occupancy(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []util.Fragment{
+				{Event: "entries", Component: templates.Entries(entries)},
+				{Event: "visits", Component: templates.ExpectedVisits(visits)},
+				{Event: "occupancy", Component: templates.Occupancy(occupancy)},
+			}, nil
+
+		case entry.LiveWalkIn:
+			walkIns, err := app.TodayWalkIns(ctx)
+			if err != nil {
+				return nil, err
+			}
+			fragments := []util.Fragment{{Event: "walk-ins", Component: templates.WalkIns(walkIns)}}
+			if w := event.WalkIn; w.Decision != entry.WalkInPending {
+				fragments = append(fragments, util.Fragment{
+					Event: "alert",
+					Component: templates.Alert(fmt.Sprintf(
+						"%s respondió sobre %s: %s", w.ResidentName, w.VisitorName, w.Decision,
+					)),
+				})
+			}
+			return fragments, nil
+
+		case entry.LiveAlert:
+			return []util.Fragment{{Event: "alert", Component: templates.Alert(event.Message)}}, nil
+
+		default:
+			return nil, nil
+		}
+	})
+}
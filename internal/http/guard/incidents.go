@@ -0,0 +1,153 @@
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+// maxIncidentForm is the largest incident form accepted, every attachment
+// and the fields around them.
+const maxIncidentForm = entry.MaxIncidentAttachments*entry.MaxIncidentAttachmentSize + 1<<20
+
+func hGetIncidents(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return renderIncidents(w, r, app, "")
+	})
+}
+
+// hPostIncident reports an incident with the residents and visits involved
+// and the files the guard attached.
+func hPostIncident(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		r.Body = http.MaxBytesReader(w, r.Body, maxIncidentForm)
+		if err := r.ParseMultipartForm(maxIncidentForm); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return entry.NewUserSafeError(fmt.Sprintf(
+					"Los adjuntos no pueden pesar más de %d MB cada uno",
+					entry.MaxIncidentAttachmentSize>>20,
+				))
+			}
+			return err
+		}
+		defer r.MultipartForm.RemoveAll() //nolint:errcheck
+
+		var residentIDs []int64
+		for _, v := range r.MultipartForm.Value["resident_id"] {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Residente inválido")
+			}
+			residentIDs = append(residentIDs, id)
+		}
+
+		files := r.MultipartForm.File["attachments"]
+		if len(files) > entry.MaxIncidentAttachments {
+			return entry.NewUserSafeError(fmt.Sprintf(
+				"Solo se pueden adjuntar %d archivos", entry.MaxIncidentAttachments,
+			))
+		}
+		var attachments []entry.IncidentAttachment
+		for _, header := range files {
+			// Browsers send an empty part when no file was chosen.
+			if header.Filename == "" && header.Size == 0 {
+				continue
+			}
+			file, err := header.Open()
+			if err != nil {
+				return err
+			}
+			data, err := io.ReadAll(io.LimitReader(file, entry.MaxIncidentAttachmentSize+1))
+			file.Close() //nolint:errcheck
+			if err != nil {
+				return err
+			}
+			attachment, err := entry.NewIncidentAttachment(header.Filename, data)
+			if err != nil {
+				return err
+			}
+			attachments = append(attachments, *attachment)
+		}
+
+		incident, err := app.ReportIncident(r.Context(), entry.Incident{
+			Category:    entry.IncidentCategory(r.FormValue("category")),
+			Severity:    entry.IncidentSeverity(r.FormValue("severity")),
+			Location:    r.FormValue("location"),
+			Description: r.FormValue("description"),
+		}, residentIDs, strings.Split(r.FormValue("visits"), ","), attachments)
+		if err != nil {
+			return err
+		}
+
+		notice := fmt.Sprintf("Se reportó el incidente #%d", incident.ID)
+		if incident.Severity == entry.SeverityCritical {
+			notice += " y se avisó a la administración"
+		}
+		return renderIncidents(w, r, app, notice)
+	})
+}
+
+func hGetIncident(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Incidente no encontrado", http.StatusNotFound)
+		}
+
+		incident, err := app.Incident(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Incident(incident).Render(r.Context(), w)
+	})
+}
+
+func hGetIncidentAttachment(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.ServeIncidentAttachment(w, r, app)
+	})
+}
+
+// renderIncidents renders the incidents page, with notice confirming the
+// last report.
+func renderIncidents(
+	w http.ResponseWriter,
+	r *http.Request,
+	app *entry.App,
+	notice string,
+) error {
+	incidents, err := app.GuardIncidents(r.Context())
+	if err != nil {
+		return err
+	}
+	residents, err := app.Residents(r.Context())
+	if err != nil {
+		return err
+	}
+	return templates.Incidents(templates.IncidentsData{
+		Incidents: incidents,
+		Residents: residents,
+		Notice:    notice,
+	}).Render(r.Context(), w)
+}
@@ -0,0 +1,156 @@
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+// maxParcelForm is the largest parcel form accepted, a photo and the
+// fields around it.
+const maxParcelForm = entry.MaxParcelPhotoSize + 1<<20
+
+func hGetParcels(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return renderParcels(w, r, app, "")
+	})
+}
+
+// hPostParcel logs a parcel for a unit or a resident, with the photo the
+// guard took of it if there is one.
+func hPostParcel(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		r.Body = http.MaxBytesReader(w, r.Body, maxParcelForm)
+		if err := r.ParseMultipartForm(maxParcelForm); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return entry.NewUserSafeError(fmt.Sprintf(
+					"La foto no puede pesar más de %d MB", entry.MaxParcelPhotoSize>>20,
+				))
+			}
+			return err
+		}
+		defer r.MultipartForm.RemoveAll() //nolint:errcheck
+
+		var parcel entry.Parcel
+		if id := r.FormValue("resident_id"); id != "" {
+			var err error
+			parcel.ResidentID, err = strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Residente inválido")
+			}
+		}
+		if key := r.FormValue("unit"); key != "" {
+			var err error
+			parcel.Unit, err = entry.ParseUnitKey(key)
+			if err != nil {
+				return err
+			}
+		}
+		parcel.Carrier = r.FormValue("carrier")
+		parcel.TrackingNumber = r.FormValue("tracking_number")
+		parcel.Size = entry.ParcelSize(r.FormValue("size"))
+
+		var photo *entry.ParcelPhoto
+		file, _, err := r.FormFile("photo")
+		switch {
+		case errors.Is(err, http.ErrMissingFile):
+		case err != nil:
+			return err
+		default:
+			defer file.Close() //nolint:errcheck
+			data, err := io.ReadAll(io.LimitReader(file, entry.MaxParcelPhotoSize+1))
+			if err != nil {
+				return err
+			}
+			photo, err = entry.NewParcelPhoto(data)
+			if err != nil {
+				return err
+			}
+		}
+
+		created, err := app.ReceiveParcel(r.Context(), parcel, photo)
+		if err != nil {
+			return err
+		}
+
+		return renderParcels(w, r, app, fmt.Sprintf(
+			"Se avisó que llegó el paquete de %s para %s", created.Carrier, created.Recipient(),
+		))
+	})
+}
+
+// hPostCollectParcel hands a parcel over to the person in the form.
+func hPostCollectParcel(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Paquete no encontrado", http.StatusNotFound)
+		}
+
+		collectedBy := r.FormValue("collected_by")
+		if err := app.CollectParcel(r.Context(), id, r.FormValue("code"), collectedBy); err != nil {
+			return err
+		}
+
+		return renderParcels(w, r, app, "Paquete entregado")
+	})
+}
+
+func hGetParcelPhoto(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.ServeParcelPhoto(w, r, app)
+	})
+}
+
+// renderParcels renders the parcels page, with notice confirming the last
+// action.
+func renderParcels(
+	w http.ResponseWriter,
+	r *http.Request,
+	app *entry.App,
+	notice string,
+) error {
+	pending, collected, err := app.GuardParcels(r.Context())
+	if err != nil {
+		return err
+	}
+	residents, err := app.Residents(r.Context())
+	if err != nil {
+		return err
+	}
+	units, err := app.ParcelUnits(r.Context())
+	if err != nil {
+		return err
+	}
+	return templates.Parcels(templates.ParcelsData{
+		Pending:   pending,
+		Collected: collected,
+		Residents: residents,
+		Units:     units,
+		Notice:    notice,
+	}).Render(r.Context(), w)
+}
@@ -0,0 +1,73 @@
+package guard
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+func hGetPatrol(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return renderPatrol(w, r, app, "")
+	})
+}
+
+func renderPatrol(
+	w http.ResponseWriter,
+	r *http.Request,
+	app *entry.App,
+	notice string,
+) error {
+	patrol, err := app.GuardPatrol(r.Context())
+	if err != nil {
+		return err
+	}
+	return templates.Patrol(templates.PatrolData{
+		Patrol: patrol,
+		Notice: notice,
+	}).Render(r.Context(), w)
+}
+
+// hGetPatrolCheckpoint is where the QR code of a checkpoint leads. Scanning
+// is confirmed with a POST, so that opening the link doesn't record it.
+func hGetPatrolCheckpoint(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		checkpoint, err := app.PatrolCheckpoint(r.Context(), r.PathValue("code"))
+		if err != nil {
+			return err
+		}
+		return templates.PatrolCheckpoint(checkpoint).Render(r.Context(), w)
+	})
+}
+
+// hPostPatrolScan records the checkpoint the guard scanned, or typed the
+// code of.
+func hPostPatrolScan(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		scan, err := app.ScanCheckpoint(r.Context(), r.FormValue("code"))
+		if err != nil {
+			return err
+		}
+
+		return renderPatrol(w, r, app, fmt.Sprintf(
+			"%s registrado a las %s", scan.CheckpointName, scan.ScannedAt.Format("15:04"),
+		))
+	})
+}
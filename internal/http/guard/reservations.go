@@ -0,0 +1,25 @@
+package guard
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+// hGetReservations shows the reservations of the amenities today, with the
+// guests expected at them.
+func hGetReservations(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		reservations, err := app.TodayReservations(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Reservations(reservations).Render(r.Context(), w)
+	})
+}
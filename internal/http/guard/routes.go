@@ -5,17 +5,47 @@ import (
 	"net/http"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
 	"github.com/Polo123456789/entry-watch/internal/http/util"
 )
 
 func Handle(
 	app *entry.App,
 	logger *slog.Logger,
+	session *auth.SessionStore,
 ) http.Handler {
 	mux := http.NewServeMux()
 
 	// Setup routes
-	mux.Handle("/guard/", hGet(app, logger))
+	mux.Handle("/guard/", hGet(app, session, logger))
+	mux.Handle("GET /guard/events", hGetEvents(app, logger))
+	mux.Handle("POST /guard/check-in", hPostCheckIn(app, session, logger))
+	mux.Handle("POST /guard/station", hPostStation(app, session, logger))
+	mux.Handle("POST /guard/walk-ins", hPostWalkIn(app, session, logger))
+	mux.Handle("POST /guard/entries/{id}/exit", hPostExit(app, logger))
+	mux.Handle("POST /guard/visits/{id}/revoke", hPostRevokeVisit(app, logger))
+	mux.Handle("POST /guard/announcements/{id}/read", hPostAnnouncementRead(app, logger))
+	mux.Handle("GET /guard/parcels", hGetParcels(app, logger))
+	mux.Handle("POST /guard/parcels", hPostParcel(app, logger))
+	mux.Handle("POST /guard/parcels/{id}/collect", hPostCollectParcel(app, logger))
+	mux.Handle("GET /guard/parcels/{id}/photo", hGetParcelPhoto(app, logger))
+	mux.Handle("GET /guard/reservations", hGetReservations(app, logger))
+	mux.Handle("GET /guard/incidents", hGetIncidents(app, logger))
+	mux.Handle("POST /guard/incidents", hPostIncident(app, logger))
+	mux.Handle("GET /guard/incidents/{id}", hGetIncident(app, logger))
+	mux.Handle(
+		"GET /guard/incidents/{id}/attachments/{attachment}",
+		hGetIncidentAttachment(app, logger),
+	)
+	mux.Handle("GET /guard/shift", hGetShift(app, session, logger))
+	mux.Handle("POST /guard/shift/start", hPostStartShift(app, session, logger))
+	mux.Handle("POST /guard/shift/close", hPostCloseShift(app, logger))
+	mux.Handle("GET /guard/logbooks/{id}", hGetLogbook(app, logger))
+	mux.Handle("GET /guard/welfare", hGetWelfare(app, logger))
+	mux.Handle("POST /guard/welfare", hPostWelfare(app, logger))
+	mux.Handle("GET /guard/patrol", hGetPatrol(app, logger))
+	mux.Handle("POST /guard/patrol/scans", hPostPatrolScan(app, logger))
+	mux.Handle("GET /guard/patrol/checkpoints/{code}", hGetPatrolCheckpoint(app, logger))
 
 	var handler http.Handler = mux
 	handler = authMiddleware(handler, logger)
@@ -27,7 +57,15 @@ func authMiddleware(
 	logger *slog.Logger,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := entry.RequireRole(r.Context(), entry.RoleGuardian)
+		_, err := entry.RequireAnyPermission(
+			r.Context(),
+			entry.PermEntriesRecord,
+			entry.PermParcelsReceive,
+			entry.PermIncidentsReport,
+			entry.PermShiftsWork,
+			entry.PermWelfareConfirm,
+			entry.PermPatrolsWalk,
+		)
 		if err != nil {
 			util.HandleError(w, r, logger, err)
 			return
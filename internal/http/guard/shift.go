@@ -0,0 +1,110 @@
+package guard
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+func hGetShift(
+	app *entry.App,
+	session *auth.SessionStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var data templates.ShiftData
+		open, err := app.OpenShift(r.Context())
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			// Loaded through Shift for the logbook so far.
+			data.Shift, err = app.Shift(r.Context(), open.ID)
+			if err != nil {
+				return err
+			}
+		}
+		data.Handover, err = app.LastHandover(r.Context())
+		if err != nil {
+			return err
+		}
+		data.Station, err = currentStation(app, session, r)
+		if err != nil {
+			return err
+		}
+		data.Logbooks, err = app.Logbooks(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Shift(data).Render(r.Context(), w)
+	})
+}
+
+// hPostStartShift starts a shift at the station the guard picked.
+func hPostStartShift(
+	app *entry.App,
+	session *auth.SessionStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		station, err := currentStation(app, session, r)
+		if err != nil {
+			return err
+		}
+		var stationID int64
+		if station != nil {
+			stationID = station.ID
+		}
+
+		if _, err := app.StartShift(r.Context(), stationID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// hPostCloseShift closes the shift of the guard and shows its logbook.
+func hPostCloseShift(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		shift, err := app.CloseShift(r.Context(), r.FormValue("notes"))
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/guard/logbooks/%d", shift.ID), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetLogbook(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Turno no encontrado", http.StatusNotFound)
+		}
+
+		shift, err := app.Shift(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Logbook(shift).Render(r.Context(), w)
+	})
+}
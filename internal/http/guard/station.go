@@ -0,0 +1,76 @@
+package guard
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// stationKey is the session value with the guard station the guard works
+// at.
+const stationKey = "station_id"
+
+// currentStation returns the station the guard picked, nil if it picked
+// none. A station that no longer exists, or that belongs to another
+// condominium since the guard changed condominium, counts as none.
+func currentStation(
+	app *entry.App,
+	session *auth.SessionStore,
+	r *http.Request,
+) (*entry.GuardStation, error) {
+	s, err := session.Get(r, auth.SessionName)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := s.Values[stationKey].(int64)
+	if id == 0 {
+		return nil, nil
+	}
+
+	station, err := app.GuardStation(r.Context(), id)
+	var notFound *entry.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	return station, err
+}
+
+// hPostStation sets the station of the guard, zero for none.
+func hPostStation(
+	app *entry.App,
+	session *auth.SessionStore,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.FormValue("station_id"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Garita inválida")
+		}
+		if id != 0 {
+			if _, err := app.GuardStation(r.Context(), id); err != nil {
+				return err
+			}
+		}
+
+		s, err := session.Get(r, auth.SessionName)
+		if err != nil {
+			return err
+		}
+		s.Values[stationKey] = id
+		if err := s.Save(r, w); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package guard
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/guard"
+)
+
+// hGetWelfare renders the welfare check the guard has to confirm, if there
+// is one. Every guard page asks for it periodically.
+func hGetWelfare(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		check, err := app.DueWelfareCheck(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.WelfarePrompt(check).Render(r.Context(), w)
+	})
+}
+
+// hPostWelfare confirms the guard is fine. htmx requests get the prompt
+// back, empty, and the rest are sent to the dashboard.
+func hPostWelfare(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := app.ConfirmWelfare(r.Context()); err != nil {
+			return err
+		}
+
+		if r.Header.Get("HX-Request") == "" {
+			http.Redirect(w, r, "/guard/", http.StatusSeeOther)
+			return nil
+		}
+		return templates.WelfarePrompt(nil).Render(r.Context(), w)
+	})
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/sessions"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	"github.com/Polo123456789/entry-watch/internal/templates/common"
 )
 
 type wrappedWritter struct {
@@ -22,18 +25,49 @@ func (w *wrappedWritter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the connection, to flush live
+// update streams.
+func (w *wrappedWritter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func CanonicalLoggerMiddleware(
 	logger *slog.Logger,
 	session sessions.Store,
+	tokens auth.APITokenStore,
+	users auth.UserGetter,
 	next http.Handler,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		authUser, userOk := auth.CurrentUser(session, r)
+		// The user is resolved on every request, so that disabled users and
+		// role changes take effect without waiting for the session to end.
+		// The API only accepts tokens, a session cookie sent along by a
+		// browser must not authenticate it.
+		var identity auth.Identity
+		var userOk bool
+		var err error
+		if isAPIRequest(r) {
+			identity, userOk, err = auth.TokenIdentity(tokens, users, r)
+		} else {
+			identity, userOk, err = auth.CurrentIdentity(session, users, r)
+		}
+		if err != nil {
+			util.HandleError(w, r, logger, err)
+			return
+		}
+		authUser := identity.User
 		if userOk {
 			user := authUser.ToEntryUser()
 			ctx := entry.WithUser(r.Context(), user)
+			if identity.Impersonator != nil {
+				ctx = entry.WithImpersonator(ctx, identity.Impersonator.ToEntryUser())
+				ctx = common.WithImpersonation(ctx, common.Impersonation{
+					UserName:  authUser.FirstName + " " + authUser.LastName,
+					UserEmail: authUser.Email,
+				})
+			}
 			r = r.WithContext(ctx)
 		}
 
@@ -55,6 +89,12 @@ func CanonicalLoggerMiddleware(
 				slog.String("user_role", string(authUser.Role)),
 				slog.Int64("user_condo", authUser.CondominiumID),
 			)
+			if identity.Impersonator != nil {
+				attrs = append(
+					attrs,
+					slog.Int64("impersonator_id", identity.Impersonator.ID),
+				)
+			}
 		} else {
 			attrs = append(attrs, slog.String("user_id", "anonymous"))
 		}
@@ -68,6 +108,44 @@ func CanonicalLoggerMiddleware(
 	})
 }
 
+// ImpersonationAuditMiddleware records every state changing request made
+// while impersonating, before it runs, so that failed attempts are recorded
+// too. Requests are refused if they can't be recorded.
+func ImpersonationAuditMiddleware(
+	logger *slog.Logger,
+	audit *entry.AuditLogger,
+	next http.Handler,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if isSafeMethod(r.Method) || entry.ImpersonatorFromCtx(ctx) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := entry.UserFromCtx(ctx)
+		err := audit.Record(ctx, entry.AuditRecord{
+			CondominiumID: user.CondominiumID,
+			Level:         entry.AuditImportant,
+			Action:        entry.ActionImpersonatedAction,
+			Message:       fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+		})
+		if err != nil {
+			util.HandleError(w, r, logger, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiPrefix is the path of the JSON API, see the api package.
+const apiPrefix = "/api/"
+
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPrefix)
+}
+
 func RecoverMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
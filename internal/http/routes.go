@@ -4,10 +4,9 @@ import (
 	"log/slog"
 	"net/http"
 
-	"github.com/gorilla/sessions"
-
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/admin"
+	"github.com/Polo123456789/entry-watch/internal/http/api"
 	"github.com/Polo123456789/entry-watch/internal/http/auth"
 	"github.com/Polo123456789/entry-watch/internal/http/guard"
 	"github.com/Polo123456789/entry-watch/internal/http/superadmin"
@@ -19,13 +18,18 @@ func setupRoutes(
 	mux *http.ServeMux,
 	app *entry.App,
 	logger *slog.Logger,
-	session sessions.Store,
+	session *auth.SessionStore,
+	userCache *auth.UserCache,
 	userStore auth.UserStore,
+	throttler *auth.Throttler,
+	tokens auth.APITokenStore,
+	audit *entry.AuditLogger,
 ) {
-	mux.Handle("/auth/", auth.Handle(logger, session, userStore))
+	mux.Handle("/auth/", auth.Handle(logger, session, userStore, throttler, tokens, audit))
 	mux.Handle("/super/", superadmin.Handle(app, logger))
-	mux.Handle("/admin/", admin.Handle(app, logger))
-	mux.Handle("/guard/", guard.Handle(app, logger))
+	mux.Handle("/admin/", admin.Handle(app, logger, session, userCache, throttler))
+	mux.Handle("/guard/", guard.Handle(app, logger, session))
 	mux.Handle("/neighbor/", user.Handle(app, logger))
+	mux.Handle("/api/", api.Handle(app, logger, session, userCache))
 	mux.Handle("GET /static/", http.FileServerFS(web.StaticFiles))
 }
@@ -11,7 +11,6 @@ import (
 	"github.com/Polo123456789/assert"
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/auth"
-	"github.com/gorilla/sessions"
 )
 
 func NewServer(
@@ -19,8 +18,13 @@ func NewServer(
 	port int,
 	app *entry.App,
 	logger *slog.Logger,
-	session sessions.Store,
+	session *auth.SessionStore,
+	sessionKey []byte,
+	userCache *auth.UserCache,
 	userStore auth.UserStore,
+	throttler *auth.Throttler,
+	tokens auth.APITokenStore,
+	audit *entry.AuditLogger,
 ) *http.Server {
 	assert.NotEquals(address, "")
 	assert.MoreThan(port, 0)
@@ -33,12 +37,18 @@ func NewServer(
 		app,
 		logger,
 		session,
+		userCache,
 		userStore,
+		throttler,
+		tokens,
+		audit,
 	)
 
 	// Global middlewares
 	var handler http.Handler = mux
-	handler = CanonicalLoggerMiddleware(logger, session, handler)
+	handler = ImpersonationAuditMiddleware(logger, audit, handler)
+	handler = CSRFMiddleware(logger, session, sessionKey, handler)
+	handler = CanonicalLoggerMiddleware(logger, session, tokens, userCache, handler)
 	handler = RecoverMiddleware(logger, handler)
 
 	server := &http.Server{
@@ -0,0 +1,110 @@
+package superadmin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	"github.com/Polo123456789/entry-watch/internal/templates/common"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/superadmin"
+)
+
+func hGetAudit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		filter, page, err := util.ParseAuditFilter(query)
+		if err != nil {
+			return err
+		}
+		filter.Limit = entry.AuditPageSize
+		filter.Offset = (page - 1) * entry.AuditPageSize
+
+		entries, total, err := app.AuditLog(r.Context(), filter)
+		if err != nil {
+			return err
+		}
+
+		return templates.AuditLog(common.AuditLogPage{
+			BasePath:  "/super/audit",
+			AllCondos: true,
+			Query:     query,
+			Entries:   entries,
+			Page:      page,
+			Total:     total,
+		}).Render(r.Context(), w)
+	})
+}
+
+func hGetAuditExport(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		filter, _, err := util.ParseAuditFilter(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		entries, _, err := app.AuditLog(r.Context(), filter)
+		if err != nil {
+			return err
+		}
+
+		return util.WriteAuditCSV(w, entries)
+	})
+}
+
+func hGetAuditVerify(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var condoID int64
+		if raw := r.URL.Query().Get("condominium_id"); raw != "" {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || id < 0 {
+				return entry.NewUserSafeError("Condominio inválido")
+			}
+			condoID = id
+		}
+
+		verification, err := app.VerifyAuditLog(r.Context(), condoID)
+		if err != nil {
+			return err
+		}
+		checkpoints, err := app.AuditCheckpoints(r.Context(), condoID)
+		if err != nil {
+			return err
+		}
+
+		return templates.AuditChain(common.AuditChainPage{
+			BasePath:     "/super/audit",
+			AllCondos:    true,
+			Verification: verification,
+			Checkpoints:  checkpoints,
+		}).Render(r.Context(), w)
+	})
+}
+
+func hGetAuditCheckpoint(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Punto de control no encontrado", http.StatusNotFound)
+		}
+
+		checkpoint, err := app.AuditCheckpoint(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return util.WriteAuditCheckpoint(w, checkpoint)
+	})
+}
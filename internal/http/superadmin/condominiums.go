@@ -0,0 +1,150 @@
+package superadmin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/superadmin"
+)
+
+func hGetNewCondominium(
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return templates.CondominiumForm(nil, nil).Render(r.Context(), w)
+	})
+}
+
+func hPostCondominium(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		passwordHash, err := auth.HashPassword(r.FormValue("admin_password"))
+		if err != nil {
+			return err
+		}
+
+		condo := entry.Condominium{
+			Name:    r.FormValue("name"),
+			Address: r.FormValue("address"),
+		}
+		admin := entry.NewCondominiumAdmin{
+			FirstName:    r.FormValue("admin_first_name"),
+			LastName:     r.FormValue("admin_last_name"),
+			Email:        r.FormValue("admin_email"),
+			Phone:        r.FormValue("admin_phone"),
+			PasswordHash: passwordHash,
+		}
+		if _, _, err := app.CreateCondominium(r.Context(), condo, admin); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/super/", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetCondominium(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+
+		condo, err := app.Condominium(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		users, err := app.ListUsers(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.CondominiumForm(condo, users).Render(r.Context(), w)
+	})
+}
+
+func hPostCondominiumUpdate(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		_, err = app.UpdateCondominium(
+			r.Context(), id, r.FormValue("name"), r.FormValue("address"),
+		)
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/super/condominiums/%d", id), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostMembership(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		_, err = app.AddMembership(
+			r.Context(), id, r.FormValue("email"), entry.UserRole(r.FormValue("role")),
+		)
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/super/condominiums/%d", id), http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostMembershipDelete(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+		userID, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Usuario no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.RemoveMembership(r.Context(), id, userID); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/super/condominiums/%d", id), http.StatusSeeOther)
+		return nil
+	})
+}
@@ -14,6 +14,10 @@ func hGet(
 	logger *slog.Logger,
 ) http.Handler {
 	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
-		return templates.Dashboard().Render(r.Context(), w)
+		condos, err := app.ListCondominiums(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Dashboard(condos).Render(r.Context(), w)
 	})
 }
@@ -0,0 +1,74 @@
+package superadmin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/superadmin"
+)
+
+func hGetPermissions(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+
+		condo, err := app.Condominium(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		overrides, err := app.PermissionOverrides(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		return templates.Permissions(condo, overrides).Render(r.Context(), w)
+	})
+}
+
+// hPostPermissions receives, for each role, the checked permissions. Every
+// permission is sent as an override, the domain keeps only the ones that
+// differ from the role.
+func hPostPermissions(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Condominio no encontrado", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		var overrides []entry.PermissionOverride
+		for _, role := range entry.OverridableRoles {
+			checked := r.PostForm[string(role)]
+			for _, perm := range entry.CondominiumPermissions {
+				overrides = append(overrides, entry.PermissionOverride{
+					Role:       role,
+					Permission: perm,
+					Granted:    slices.Contains(checked, string(perm)),
+				})
+			}
+		}
+
+		if err := app.SetPermissionOverrides(r.Context(), id, overrides); err != nil {
+			return err
+		}
+
+		http.Redirect(
+			w, r, fmt.Sprintf("/super/condominiums/%d/permissions", id), http.StatusSeeOther,
+		)
+		return nil
+	})
+}
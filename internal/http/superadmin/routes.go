@@ -16,6 +16,24 @@ func Handle(
 
 	// Setup routes
 	mux.Handle("/super/", hGet(app, logger))
+	mux.Handle("GET /super/condominiums/new", hGetNewCondominium(logger))
+	mux.Handle("POST /super/condominiums", hPostCondominium(app, logger))
+	mux.Handle("GET /super/condominiums/{id}", hGetCondominium(app, logger))
+	mux.Handle("POST /super/condominiums/{id}", hPostCondominiumUpdate(app, logger))
+	mux.Handle("GET /super/condominiums/{id}/permissions", hGetPermissions(app, logger))
+	mux.Handle("POST /super/condominiums/{id}/permissions", hPostPermissions(app, logger))
+	mux.Handle("POST /super/condominiums/{id}/members", hPostMembership(app, logger))
+	mux.Handle(
+		"POST /super/condominiums/{id}/members/{user}/delete",
+		hPostMembershipDelete(app, logger),
+	)
+	mux.Handle("GET /super/2fa", hGetTwoFactor(app, logger))
+	mux.Handle("POST /super/2fa", hPostTwoFactor(app, logger))
+	mux.Handle("POST /super/2fa/{id}/delete", hPostTwoFactorDelete(app, logger))
+	mux.Handle("GET /super/audit", hGetAudit(app, logger))
+	mux.Handle("GET /super/audit/export", hGetAuditExport(app, logger))
+	mux.Handle("GET /super/audit/verify", hGetAuditVerify(app, logger))
+	mux.Handle("GET /super/audit/checkpoints/{id}", hGetAuditCheckpoint(app, logger))
 
 	var handler http.Handler = mux
 	handler = authMiddleware(handler, logger)
@@ -27,7 +45,7 @@ func authMiddleware(
 	logger *slog.Logger,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := entry.RequireRole(r.Context(), entry.RoleSuperAdmin)
+		_, err := entry.RequirePermission(r.Context(), entry.PermSystemManage)
 		if err != nil {
 			util.HandleError(w, r, logger, err)
 			return
@@ -0,0 +1,71 @@
+package superadmin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/superadmin"
+)
+
+func hGetTwoFactor(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		requirements, err := app.TwoFactorRequirements(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.TwoFactorRequirements(requirements).Render(r.Context(), w)
+	})
+}
+
+func hPostTwoFactor(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		var condoID int64
+		if raw := r.FormValue("condominium_id"); raw != "" {
+			var err error
+			condoID, err = strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return entry.NewUserSafeError("Condominio inválido")
+			}
+		}
+
+		role := entry.UserRole(r.FormValue("role"))
+		if _, err := app.RequireTwoFactor(r.Context(), condoID, role); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/super/2fa", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostTwoFactorDelete(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Not found", http.StatusNotFound)
+		}
+
+		if err := app.RemoveTwoFactorRequirement(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/super/2fa", http.StatusSeeOther)
+		return nil
+	})
+}
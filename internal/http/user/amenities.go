@@ -0,0 +1,83 @@
+package user
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/user"
+)
+
+func hGetAmenities(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		amenities, err := app.Amenities(r.Context())
+		if err != nil {
+			return err
+		}
+		reservations, err := app.MyReservations(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Amenities(amenities, reservations, time.Now()).Render(r.Context(), w)
+	})
+}
+
+// hPostReservation reserves a slot of an amenity. The guests come one per
+// line.
+func hPostReservation(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Área común no encontrada", http.StatusNotFound)
+		}
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		day, err := time.ParseInLocation(time.DateOnly, r.FormValue("day"), time.Local)
+		if err != nil {
+			return entry.NewUserSafeError("Fecha inválida")
+		}
+		start, err := strconv.ParseInt(r.FormValue("start"), 10, 64)
+		if err != nil {
+			return entry.NewUserSafeError("Horario inválido")
+		}
+		guests := strings.Split(r.FormValue("guests"), "\n")
+
+		if _, err := app.ReserveAmenity(r.Context(), id, day, start, guests); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/amenities", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hPostCancelReservation(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Reserva no encontrada", http.StatusNotFound)
+		}
+
+		if err := app.CancelReservation(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/amenities", http.StatusSeeOther)
+		return nil
+	})
+}
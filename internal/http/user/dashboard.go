@@ -20,15 +20,47 @@ func hGet(
 	) error {
 		today := time.Now()
 
+		var parcels []entry.Parcel
+		user := entry.UserFromCtx(r.Context())
+		if user.Can(entry.PermParcelsCollect, user.CondominiumID) {
+			var err error
+			parcels, err = app.PendingParcels(r.Context())
+			if err != nil {
+				return err
+			}
+		}
+		announcements, err := app.Announcements(r.Context())
+		if err != nil {
+			return err
+		}
+
 		return templates.Dashboard(entry.Visit{
 			MaxUses:   1,
 			ValidFrom: today,
 			ValidTo:   today,
-		}).Render(r.Context(), w)
+		}, parcels, announcements).Render(r.Context(), w)
+	})
+}
+
+func hPostAnnouncementRead(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Comunicado no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.MarkAnnouncementRead(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/", http.StatusSeeOther)
+		return nil
 	})
 }
 
-//nolint:unused // Will be used when visit creation is implemented
 func hPost(
 	app *entry.App,
 	logger *slog.Logger,
@@ -47,34 +79,37 @@ func hPost(
 		if limitUses {
 			var err error
 			maxUses, err = strconv.Atoi(r.FormValue("max_uses"))
-			if err != nil {
-				return err
+			if err != nil || maxUses < 1 {
+				return entry.NewUserSafeError("Usos máximos inválidos")
 			}
 		}
 
-		validFrom, err := time.Parse(
-			time.DateOnly, r.FormValue("valid_from"),
+		validFrom, err := time.ParseInLocation(
+			time.DateOnly, r.FormValue("valid_from"), time.Local,
 		)
 		if err != nil {
-			return err
+			return entry.NewUserSafeError("Fecha inicial inválida")
 		}
 
-		validTo, err := time.Parse(
-			time.DateOnly, r.FormValue("valid_to"),
+		validTo, err := time.ParseInLocation(
+			time.DateOnly, r.FormValue("valid_to"), time.Local,
 		)
 		if err != nil {
-			return err
+			return entry.NewUserSafeError("Fecha final inválida")
 		}
 
-		// visit, err := app.CreateVisit( ... )
-		// http.Redirect( ... )
-		// TODO: Come back to this
-
-		_ = visitor
-		_ = maxUses
-		_ = validFrom
-		_ = validTo
+		_, err = app.CreateVisit(r.Context(), entry.Visit{
+			VisitorName: visitor,
+			MaxUses:     int64(maxUses),
+			ValidFrom:   validFrom,
+			// The visit is valid until the end of the last day.
+			ValidTo: validTo.AddDate(0, 0, 1).Add(-time.Second),
+		})
+		if err != nil {
+			return err
+		}
 
+		http.Redirect(w, r, "/neighbor/visits", http.StatusSeeOther)
 		return nil
 	})
 }
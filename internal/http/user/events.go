@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/user"
+)
+
+// hGetEvents streams the arrivals of the user's visitors, and the walk-ins
+// waiting for an answer.
+func hGetEvents(app *entry.App, logger *slog.Logger) http.Handler {
+	return util.EventStream(logger, app.ResidentUpdates, func(
+		ctx context.Context, event entry.LiveEvent,
+	) ([]util.Fragment, error) {
+		switch event.Kind {
+		case entry.LiveArrival:
+			return []util.Fragment{{
+				Event:     "arrival",
+				Component: templates.Arrival(event.Visit, event.Entry),
+			}}, nil
+
+		case entry.LiveWalkIn:
+			walkIns, err := app.PendingWalkIns(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []util.Fragment{{
+				Event:     "walk-ins",
+				Component: templates.PendingWalkIns(walkIns),
+			}}, nil
+
+		default:
+			return nil, nil
+		}
+	})
+}
+
+// hPostWalkIn answers whether the visitor of a walk-in may come in.
+func hPostWalkIn(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Visita no encontrada", http.StatusNotFound)
+		}
+
+		decision := entry.WalkInDecision(r.FormValue("decision"))
+		if err := app.DecideWalkIn(r.Context(), id, decision); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/notifications", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/user"
+)
+
+func hGetNotifications(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		notifications, err := app.Notifications(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Inbox(notifications).Render(r.Context(), w)
+	})
+}
+
+// hPostNotificationsRead marks the notification in the form as read, or all
+// of them if there is none.
+func hPostNotificationsRead(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		var id int64
+		if v := r.FormValue("id"); v != "" {
+			var err error
+			id, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return util.NewErrorWithCode("Aviso no encontrado", http.StatusNotFound)
+			}
+		}
+
+		if err := app.MarkNotificationRead(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/notifications", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetNotificationPreferences(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		prefs, channels, err := app.NotificationPreferences(r.Context())
+		if err != nil {
+			return err
+		}
+		devices, err := app.PushSubscriptions(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.NotificationPreferences(
+			prefs, channels, app.PushPublicKey(), devices,
+		).Render(r.Context(), w)
+	})
+}
+
+func hPostNotificationPreferences(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+
+		prefs := entry.NotificationPreferences{
+			Channels: map[entry.NotificationEvent][]entry.NotificationChannel{},
+		}
+		for _, event := range entry.NotificationEvents {
+			channels := []entry.NotificationChannel{}
+			for _, channel := range r.PostForm[string(event)] {
+				channels = append(channels, entry.NotificationChannel(channel))
+			}
+			prefs.Channels[event] = channels
+		}
+
+		if r.FormValue("quiet_hours") != "" {
+			start, err := parseClock(r.FormValue("quiet_start"))
+			if err != nil {
+				return err
+			}
+			end, err := parseClock(r.FormValue("quiet_end"))
+			if err != nil {
+				return err
+			}
+			prefs.QuietHours = true
+			prefs.QuietStart = start
+			prefs.QuietEnd = end
+		}
+
+		if err := app.SaveNotificationPreferences(r.Context(), prefs); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/notifications/preferences", http.StatusSeeOther)
+		return nil
+	})
+}
+
+// parseClock parses a HH:MM time input into minutes since midnight.
+func parseClock(v string) (int64, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, entry.NewUserSafeError("Hora inválida")
+	}
+	return int64(t.Hour()*60 + t.Minute()), nil
+}
@@ -0,0 +1,40 @@
+package user
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// hPostAuthorizeParcel lets the guards hand a parcel over without the
+// pickup code.
+func hPostAuthorizeParcel(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			return util.NewErrorWithCode("Paquete no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.AuthorizeParcelPickup(r.Context(), id); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/", http.StatusSeeOther)
+		return nil
+	})
+}
+
+func hGetParcelPhoto(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		return util.ServeParcelPhoto(w, r, app)
+	})
+}
@@ -0,0 +1,92 @@
+package user
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+)
+
+// pushSubscriptionJSON is a PushSubscription as the browser serializes it.
+type pushSubscriptionJSON struct {
+	Endpoint string `json:"endpoint"`
+	// ExpirationTime is sent by browsers, but push services report expired
+	// subscriptions anyway.
+	ExpirationTime *int64 `json:"expirationTime"`
+	Keys           struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// hPostPushSubscription saves the push subscription of the browser that
+// sent it.
+func hPostPushSubscription(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var body pushSubscriptionJSON
+		if err := util.DecodeJSON(r, &body); err != nil {
+			return err
+		}
+
+		err := app.SubscribePush(r.Context(), entry.PushSubscription{
+			Endpoint:  body.Endpoint,
+			P256DH:    body.Keys.P256DH,
+			Auth:      body.Keys.Auth,
+			UserAgent: r.UserAgent(),
+		})
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// hPostPushUnsubscribe removes the push subscription of the browser that
+// sent it, by its endpoint.
+func hPostPushUnsubscribe(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Endpoint string `json:"endpoint"`
+		}
+		if err := util.DecodeJSON(r, &body); err != nil {
+			return err
+		}
+
+		if err := app.UnsubscribePush(r.Context(), 0, body.Endpoint); err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// hPostDeletePushSubscription removes a device from the preferences page.
+func hPostDeletePushSubscription(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id == 0 {
+			return util.NewErrorWithCode("Dispositivo no encontrado", http.StatusNotFound)
+		}
+
+		if err := app.UnsubscribePush(r.Context(), id, ""); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/notifications/preferences", http.StatusSeeOther)
+		return nil
+	})
+}
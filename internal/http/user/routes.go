@@ -6,6 +6,7 @@ import (
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/user"
 )
 
 func Handle(
@@ -15,18 +16,89 @@ func Handle(
 	mux := http.NewServeMux()
 
 	mux.Handle("/neighbor/", hGet(app, logger))
+	mux.Handle("POST /neighbor/{$}", hPost(app, logger))
+	mux.Handle("GET /neighbor/visits", hGetVisits(app, logger))
+	mux.Handle("POST /neighbor/visits/{id}/revoke", hPostRevokeVisit(app, logger))
+	mux.Handle("GET /neighbor/events", hGetEvents(app, logger))
+	mux.Handle("POST /neighbor/walk-ins/{id}", hPostWalkIn(app, logger))
+	mux.Handle("GET /neighbor/notifications", hGetNotifications(app, logger))
+	mux.Handle("POST /neighbor/notifications/read", hPostNotificationsRead(app, logger))
+	mux.Handle(
+		"GET /neighbor/notifications/preferences",
+		hGetNotificationPreferences(app, logger),
+	)
+	mux.Handle(
+		"POST /neighbor/notifications/preferences",
+		hPostNotificationPreferences(app, logger),
+	)
+	mux.Handle("POST /neighbor/announcements/{id}/read", hPostAnnouncementRead(app, logger))
+	mux.Handle("GET /neighbor/amenities", hGetAmenities(app, logger))
+	mux.Handle("POST /neighbor/amenities/{id}/reservations", hPostReservation(app, logger))
+	mux.Handle("POST /neighbor/reservations/{id}/cancel", hPostCancelReservation(app, logger))
+	mux.Handle("POST /neighbor/parcels/{id}/authorize", hPostAuthorizeParcel(app, logger))
+	mux.Handle("GET /neighbor/parcels/{id}/photo", hGetParcelPhoto(app, logger))
+	mux.Handle("POST /neighbor/push/subscriptions", hPostPushSubscription(app, logger))
+	mux.Handle("POST /neighbor/push/subscriptions/delete", hPostPushUnsubscribe(app, logger))
+	mux.Handle(
+		"POST /neighbor/push/subscriptions/{id}/delete",
+		hPostDeletePushSubscription(app, logger),
+	)
 
 	var handler http.Handler = mux
+	handler = navbarMiddleware(handler, app, logger)
 	handler = authMiddleware(handler, logger)
 	return handler
 }
 
+// navbarMiddleware loads what the navbar shows on every page: the unread
+// notifications of the user, and the walk-ins waiting for its answer, as
+// far as the user has those.
+func navbarMiddleware(
+	next http.Handler,
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := entry.UserFromCtx(r.Context())
+		var err error
+		var unread int64
+		if user.Can(entry.PermNotificationsReceive, user.CondominiumID) {
+			unread, err = app.UnreadNotifications(r.Context())
+			if err != nil {
+				util.HandleError(w, r, logger, err)
+				return
+			}
+		}
+		var walkIns []entry.WalkIn
+		if user.Can(entry.PermWalkInsAnswer, user.CondominiumID) {
+			walkIns, err = app.PendingWalkIns(r.Context())
+			if err != nil {
+				util.HandleError(w, r, logger, err)
+				return
+			}
+		}
+
+		ctx := templates.WithUnreadNotifications(r.Context(), unread)
+		ctx = templates.WithPendingWalkIns(ctx, walkIns)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func authMiddleware(
 	next http.Handler,
 	logger *slog.Logger,
 ) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := entry.RequireRole(r.Context(), entry.RoleUser)
+		_, err := entry.RequireAnyPermission(
+			r.Context(),
+			entry.PermVisitsCreate,
+			entry.PermWalkInsAnswer,
+			entry.PermNotificationsReceive,
+			entry.PermPushSubscribe,
+			entry.PermParcelsCollect,
+			entry.PermAnnouncementsRead,
+			entry.PermAmenitiesReserve,
+		)
 		if err != nil {
 			util.HandleError(w, r, logger, err)
 			return
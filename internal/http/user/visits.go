@@ -0,0 +1,38 @@
+package user
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/util"
+	templates "github.com/Polo123456789/entry-watch/internal/templates/user"
+)
+
+func hGetVisits(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		visits, err := app.MyVisits(r.Context())
+		if err != nil {
+			return err
+		}
+		return templates.Visits(visits, time.Now()).Render(r.Context(), w)
+	})
+}
+
+func hPostRevokeVisit(
+	app *entry.App,
+	logger *slog.Logger,
+) http.Handler {
+	return util.Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		if err := app.RevokeVisit(r.Context(), r.PathValue("id")); err != nil {
+			return err
+		}
+
+		http.Redirect(w, r, "/neighbor/visits", http.StatusSeeOther)
+		return nil
+	})
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// ParseAuditFilter reads the audit log filters and the page number from the
+// query string. The condominium filter is read too, entry.App.AuditLog
+// ignores it for admins.
+func ParseAuditFilter(query url.Values) (entry.AuditFilter, int64, error) {
+	var filter entry.AuditFilter
+
+	if raw := query.Get("condominium_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, 0, entry.NewUserSafeError("Condominio inválido")
+		}
+		filter.CondominiumID = id
+	}
+
+	if raw := query.Get("level"); raw != "" {
+		level, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, 0, entry.NewUserSafeError("Nivel inválido")
+		}
+		filter.MinLevel = entry.AuditLevel(level)
+	}
+
+	filter.Action = entry.AuditAction(query.Get("action"))
+	filter.Search = strings.TrimSpace(query.Get("q"))
+
+	if raw := query.Get("from"); raw != "" {
+		from, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+		if err != nil {
+			return filter, 0, entry.NewUserSafeError("Fecha inicial inválida")
+		}
+		filter.From = from
+	}
+
+	if raw := query.Get("to"); raw != "" {
+		to, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+		if err != nil {
+			return filter, 0, entry.NewUserSafeError("Fecha final inválida")
+		}
+		// The whole day is included.
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	page := int64(1)
+	if raw := query.Get("page"); raw != "" {
+		p, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || p < 1 {
+			return filter, 0, entry.NewUserSafeError("Página inválida")
+		}
+		page = p
+	}
+
+	return filter, page, nil
+}
+
+// WriteAuditCSV sends the entries as a CSV download.
+func WriteAuditCSV(w http.ResponseWriter, entries []entry.AuditEntry) error {
+	filename := fmt.Sprintf("auditoria-%s.csv", time.Now().Format(time.DateOnly))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s"`, filename),
+	)
+
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{
+		"Fecha", "Nivel", "Acción", "Condominio", "Usuario", "Correo",
+		"Suplantado por", "Mensaje",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err := cw.Write([]string{
+			e.CreatedAt.Format(time.DateTime),
+			e.Level.String(),
+			e.Action.String(),
+			csvSafe(e.CondominiumName),
+			csvSafe(e.UserName),
+			csvSafe(e.UserEmail),
+			csvSafe(e.ImpersonatorName),
+			csvSafe(e.Message),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe prevents spreadsheets from evaluating user provided values as
+// formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// WriteAuditCheckpoint sends the checkpoint as a JSON download, to be kept
+// outside the system.
+func WriteAuditCheckpoint(w http.ResponseWriter, checkpoint *entry.AuditCheckpoint) error {
+	filename := fmt.Sprintf(
+		"auditoria-%d-%d.json", checkpoint.ChainID, checkpoint.ID,
+	)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s"`, filename),
+	)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(checkpoint)
+}
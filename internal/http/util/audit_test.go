@@ -0,0 +1,63 @@
+package util
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func TestParseAuditFilter(t *testing.T) {
+	query := url.Values{
+		"level":  {"2"},
+		"action": {"check_in"},
+		"from":   {"2026-10-01"},
+		"to":     {"2026-10-01"},
+		"page":   {"3"},
+	}
+
+	filter, page, err := ParseAuditFilter(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page != 3 {
+		t.Errorf("page = %d; want 3", page)
+	}
+	if filter.MinLevel != entry.AuditImportant || filter.Action != entry.ActionCheckIn {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if got := filter.To.Sub(filter.From); got != 24*time.Hour {
+		t.Errorf("a single day range spans %s; want 24h", got)
+	}
+
+	if _, _, err := ParseAuditFilter(url.Values{"page": {"0"}}); err == nil {
+		t.Error("expected an error for page 0")
+	}
+}
+
+func TestWriteAuditCSVEscapesFormulas(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteAuditCSV(rec, []entry.AuditEntry{{
+		Level:   entry.AuditInfo,
+		Action:  entry.ActionVisitCreated,
+		Message: "=HYPERLINK(\"http://example.com\")",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records; want 2", len(records))
+	}
+	// The message is the last column.
+	if got := records[1][len(records[1])-1]; got[0] != '\'' {
+		t.Errorf("formula was not escaped: %q", got)
+	}
+}
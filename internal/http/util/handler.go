@@ -29,6 +29,8 @@ func HandleError(
 		errorModal(w, r, e.Error(), e.code)
 	} else if e, ok := errorAs[*entry.ForbiddenError](err); ok {
 		errorModal(w, r, e.Error(), http.StatusForbidden)
+	} else if e, ok := errorAs[*entry.NotFoundError](err); ok {
+		errorModal(w, r, e.Error(), http.StatusNotFound)
 	} else if _, ok := errorAs[*entry.UnauthorizedError](err); ok {
 		http.Redirect(w, r, "/auth/login", http.StatusFound)
 	} else if e, ok := errorAs[entry.UserSafeError](err); ok {
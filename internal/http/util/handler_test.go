@@ -10,7 +10,6 @@ import (
 	"log/slog"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
-	"github.com/Polo123456789/entry-watch/internal/templates/common"
 )
 
 func TestHandleErrorResponses(t *testing.T) {
@@ -34,11 +33,11 @@ func TestHandleErrorResponses(t *testing.T) {
 		{
 			name: "Forbidden",
 			builder: func(r *http.Request, logger *slog.Logger) (*http.Request, http.Handler) {
-				// create a disabled user so RequireRole returns ForbiddenError
+				// create a disabled user so RequirePermission returns ForbiddenError
 				user := &entry.User{Enabled: false, Role: entry.RoleUser}
 				req2 := r.WithContext(entry.WithUser(r.Context(), user))
 				return req2, Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
-					_, err := entry.RequireRole(r.Context(), entry.RoleUser)
+					_, err := entry.RequirePermission(r.Context(), entry.PermVisitsCreate)
 					return err
 				})
 			},
@@ -47,10 +46,10 @@ func TestHandleErrorResponses(t *testing.T) {
 		{
 			name: "Unauthorized",
 			builder: func(r *http.Request, logger *slog.Logger) (*http.Request, http.Handler) {
-				// no user in context -> RequireRole returns UnauthorizedError
+				// no user in context -> RequirePermission returns UnauthorizedError
 				// Redirect to the login page
 				return r, Handler(logger, func(w http.ResponseWriter, r *http.Request) error {
-					_, err := entry.RequireRole(r.Context(), entry.RoleUser)
+					_, err := entry.RequirePermission(r.Context(), entry.PermVisitsCreate)
 					return err
 				})
 			},
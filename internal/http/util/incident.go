@@ -0,0 +1,37 @@
+package util
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// ServeIncidentAttachment serves the attachment in the attachment path
+// value of the incident in the id path value, to whoever app lets see it.
+func ServeIncidentAttachment(w http.ResponseWriter, r *http.Request, app *entry.App) error {
+	incidentID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return NewErrorWithCode("Adjunto no encontrado", http.StatusNotFound)
+	}
+	id, err := strconv.ParseInt(r.PathValue("attachment"), 10, 64)
+	if err != nil {
+		return NewErrorWithCode("Adjunto no encontrado", http.StatusNotFound)
+	}
+
+	attachment, err := app.IncidentAttachment(r.Context(), incidentID, id)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set(
+		"Content-Disposition",
+		mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}),
+	)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = w.Write(attachment.Data)
+	return err
+}
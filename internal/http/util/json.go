@@ -0,0 +1,130 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// Error codes of the JSON API. They are part of the API contract, clients
+// branch on them, so existing codes must never change.
+const (
+	CodeInvalidRequest  = "invalid_request"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeTooManyRequests = "too_many_requests"
+	CodeInternal        = "internal_error"
+)
+
+// maxJSONBody limits the size of JSON request bodies.
+const maxJSONBody = 1 << 20
+
+// APIError is the body of every error response of the JSON API.
+type APIError struct {
+	Error APIErrorBody `json:"error"`
+}
+
+type APIErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIHandler is like Handler, for JSON endpoints.
+func APIHandler(
+	logger *slog.Logger,
+	h func(w http.ResponseWriter, r *http.Request) error,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := h(w, r)
+		if err != nil {
+			HandleAPIError(w, r, logger, err)
+		}
+	})
+}
+
+// HandleAPIError writes the error as JSON. It maps errors like HandleError,
+// but unauthenticated requests get a 401 instead of a redirect to the
+// login.
+func HandleAPIError(
+	w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error,
+) {
+	if e, ok := errorAs[*ErrorWithCode](err); ok {
+		WriteAPIError(w, e.code, e.Error())
+	} else if e, ok := errorAs[*entry.ForbiddenError](err); ok {
+		WriteAPIError(w, http.StatusForbidden, e.Error())
+	} else if e, ok := errorAs[*entry.NotFoundError](err); ok {
+		WriteAPIError(w, http.StatusNotFound, e.Error())
+	} else if _, ok := errorAs[*entry.UnauthorizedError](err); ok {
+		WriteAPIError(w, http.StatusUnauthorized, "Token de API inválido o vencido")
+	} else if e, ok := errorAs[entry.UserSafeError](err); ok {
+		WriteAPIError(w, http.StatusBadRequest, e.Error())
+	} else {
+		logger.LogAttrs(
+			r.Context(),
+			slog.LevelError,
+			"internal server error",
+			slog.String("error", err.Error()),
+		)
+		WriteAPIError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// WriteAPIError writes an error response with the code of the status.
+func WriteAPIError(w http.ResponseWriter, status int, msg string) {
+	WriteJSON(w, status, APIError{Error: APIErrorBody{
+		Code:    apiErrorCode(status),
+		Message: msg,
+	}})
+}
+
+func apiErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	default:
+		if status >= 400 && status < 500 {
+			return CodeInvalidRequest
+		}
+		return CodeInternal
+	}
+}
+
+// WriteJSON writes v as the JSON body of the response.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// DecodeJSON decodes the body of the request into v. Unknown fields are
+// rejected, so that typos don't go unnoticed.
+func DecodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxJSONBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var syntax *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			return entry.NewUserSafeError("Tipo inválido en el campo " + typeErr.Field)
+		case errors.As(err, &syntax), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+			return entry.NewUserSafeError("El cuerpo de la solicitud no es JSON válido")
+		default:
+			return entry.NewUserSafeError(err.Error())
+		}
+	}
+	return nil
+}
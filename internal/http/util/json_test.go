@@ -0,0 +1,104 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func TestHandleAPIErrorCodes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"user safe", entry.NewUserSafeError("Nombre obligatorio"), http.StatusBadRequest, CodeInvalidRequest},
+		{"not found", entry.NewNotFoundError("Visita no encontrada"), http.StatusNotFound, CodeNotFound},
+		{"with code", NewErrorWithCode("Demasiados intentos", http.StatusTooManyRequests), http.StatusTooManyRequests, CodeTooManyRequests},
+		{"internal", errors.New("database is locked"), http.StatusInternalServerError, CodeInternal},
+		{"wrapped", errors.Join(errors.New("context"), entry.NewUserSafeError("x")), http.StatusBadRequest, CodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/api/v1/visits", nil)
+
+			HandleAPIError(w, r, logger, tt.err)
+
+			assertAPIError(t, w, tt.wantStatus, tt.wantCode)
+			if tt.wantCode == CodeInternal && strings.Contains(w.Body.String(), "database") {
+				t.Error("internal error details leaked to the client")
+			}
+		})
+	}
+}
+
+func TestHandleAPIErrorAuth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := APIHandler(logger, func(w http.ResponseWriter, r *http.Request) error {
+		_, err := entry.RequirePermission(r.Context(), entry.PermVisitsCreate)
+		return err
+	})
+
+	// Without a user the API answers 401, it never redirects to the login.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/visits", nil))
+	assertAPIError(t, w, http.StatusUnauthorized, CodeUnauthorized)
+
+	ctx := entry.WithUser(context.Background(), &entry.User{
+		ID: 1, CondominiumID: 1, Role: entry.RoleGuardian, Enabled: true,
+	})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/visits", nil).WithContext(ctx))
+	assertAPIError(t, w, http.StatusForbidden, CodeForbidden)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var v struct {
+		Code string `json:"code"`
+	}
+
+	for _, body := range []string{`{"code": 1}`, `{"code": "A", "extra": true}`, `{`, ``} {
+		r := httptest.NewRequest("POST", "/api/v1/entries", strings.NewReader(body))
+		err := DecodeJSON(r, &v)
+		if _, ok := errorAs[entry.UserSafeError](err); !ok {
+			t.Errorf("DecodeJSON(%q) = %v; want a UserSafeError", body, err)
+		}
+	}
+
+	r := httptest.NewRequest("POST", "/api/v1/entries", strings.NewReader(`{"code": "A"}`))
+	if err := DecodeJSON(r, &v); err != nil || v.Code != "A" {
+		t.Errorf("DecodeJSON() = %v, %q", err, v.Code)
+	}
+}
+
+func assertAPIError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Errorf("status = %d; want %d", w.Code, status)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	var body APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body isn't an APIError: %v", err)
+	}
+	if body.Error.Code != code {
+		t.Errorf("code = %q; want %q", body.Error.Code, code)
+	}
+	if body.Error.Message == "" {
+		t.Error("empty message")
+	}
+}
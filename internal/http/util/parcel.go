@@ -0,0 +1,28 @@
+package util
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// ServeParcelPhoto serves the photo of the parcel in the id path value, to
+// whoever app lets see it.
+func ServeParcelPhoto(w http.ResponseWriter, r *http.Request, app *entry.App) error {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return NewErrorWithCode("Foto no encontrada", http.StatusNotFound)
+	}
+
+	photo, err := app.ParcelPhoto(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = w.Write(photo.Data)
+	return err
+}
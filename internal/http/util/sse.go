@@ -0,0 +1,141 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+const (
+	// sseHeartbeat is how often an idle stream sends a comment, so that
+	// proxies keep it open and clients that went away are noticed.
+	sseHeartbeat = 30 * time.Second
+	// sseWriteTimeout gives up on clients that stopped reading.
+	sseWriteTimeout = 10 * time.Second
+	// sseMaxAge ends streams after a while. The browser reconnects at once,
+	// which authenticates the user again.
+	sseMaxAge = 15 * time.Minute
+	// sseRetry is how long the browser waits to reconnect, in milliseconds.
+	sseRetry = 3000
+)
+
+// Fragment is an HTML fragment sent as the SSE event Event, for the
+// elements of the page that swap on it with sse-swap.
+type Fragment struct {
+	Event     string
+	Component templ.Component
+}
+
+// FragmentRenderer returns the fragments that show a live event, none to
+// skip it.
+type FragmentRenderer func(ctx context.Context, event entry.LiveEvent) ([]Fragment, error)
+
+// EventStream streams the events of the subscription opened by subscribe as
+// Server-Sent Events, until the client goes away or the subscription ends.
+// The subscription is closed when the stream ends.
+func EventStream(
+	logger *slog.Logger,
+	subscribe func(ctx context.Context) (*entry.Subscription, error),
+	render FragmentRenderer,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := subscribe(r.Context())
+		if errors.Is(err, entry.ErrHubClosed) {
+			err = NewErrorWithCode("El servidor se está reiniciando", http.StatusServiceUnavailable)
+		}
+		if err != nil {
+			HandleError(w, r, logger, err)
+			return
+		}
+		defer sub.Close()
+
+		if err := streamEvents(w, r, sub, render); err != nil {
+			logger.Debug("Live update stream ended", "error", err)
+		}
+	})
+}
+
+func streamEvents(
+	w http.ResponseWriter,
+	r *http.Request,
+	sub *entry.Subscription,
+	render FragmentRenderer,
+) error {
+	rc := http.NewResponseController(w)
+	write := func(s string) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout)); err != nil &&
+			!errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := io.WriteString(w, s); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := write(fmt.Sprintf("retry: %d\n\n", sseRetry)); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	maxAge := time.NewTimer(sseMaxAge)
+	defer maxAge.Stop()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-maxAge.C:
+			return nil
+		case <-heartbeat.C:
+			if err := write(": ping\n\n"); err != nil {
+				return err
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind, or shutting down.
+				return nil
+			}
+			fragments, err := render(r.Context(), event)
+			if err != nil {
+				return err
+			}
+			for _, f := range fragments {
+				buf.Reset()
+				if err := f.Component.Render(r.Context(), &buf); err != nil {
+					return err
+				}
+				if err := write(formatEvent(f.Event, buf.String())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// formatEvent formats an SSE event, with a data line per line of data.
+func formatEvent(event string, data string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for line := range strings.SplitSeq(strings.TrimSpace(data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func TestEventStream(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	hub := entry.NewHub()
+	app := entry.NewApp(logger, nil, nil, nil, hub)
+
+	stream := EventStream(logger, app.GuardUpdates, func(
+		_ context.Context, event entry.LiveEvent,
+	) ([]Fragment, error) {
+		if event.Kind != entry.LiveAlert {
+			return nil, nil
+		}
+		return []Fragment{{
+			Event:     "alert",
+			Component: templ.Raw("<article>\n" + event.Message + "\n</article>\n"),
+		}}, nil
+	})
+	guard := &entry.User{ID: 3, Role: entry.RoleGuardian, CondominiumID: 1, Enabled: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream.ServeHTTP(w, r.WithContext(entry.WithUser(r.Context(), guard)))
+	}))
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// The stream subscribed once the retry hint arrives.
+	body := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		t.Helper()
+		var b strings.Builder
+		for {
+			line, err := body.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading the stream: %v", err)
+			}
+			if line == "\n" {
+				return b.String()
+			}
+			b.WriteString(line)
+		}
+	}
+	if got := readEvent(); got != "retry: 3000\n" {
+		t.Fatalf("first event = %q, want the retry hint", got)
+	}
+
+	hub.Publish(entry.LiveEvent{Kind: entry.LiveEntry, CondominiumID: 1})
+	hub.Publish(entry.LiveEvent{Kind: entry.LiveAlert, CondominiumID: 2, Message: "Otro"})
+	hub.Publish(entry.LiveEvent{Kind: entry.LiveAlert, CondominiumID: 1, Message: "Barrera"})
+	want := "event: alert\ndata: <article>\ndata: Barrera\ndata: </article>\n"
+	if got := readEvent(); got != want {
+		t.Errorf("event = %q, want %q", got, want)
+	}
+
+	// Closing the hub ends the stream, as the server does on shutdown.
+	hub.Close()
+	if rest, err := io.ReadAll(body); err != nil || len(rest) != 0 {
+		t.Errorf("after closing the hub read %q, %v; want the end of the stream", rest, err)
+	}
+}
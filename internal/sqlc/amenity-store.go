@@ -0,0 +1,318 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) AmenityList(ctx context.Context, condoID int64) ([]entry.Amenity, error) {
+	rows, err := s.ListAmenitiesByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	amenities := make([]entry.Amenity, 0, len(rows))
+	for _, row := range rows {
+		amenities = append(amenities, row.unmarshall())
+	}
+	return amenities, nil
+}
+
+func (s *Store) AmenityGetByID(ctx context.Context, id int64) (*entry.Amenity, error) {
+	row, err := s.GetAmenityByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Área común no encontrada")
+		}
+		return nil, err
+	}
+
+	amenity := row.unmarshall()
+	return &amenity, nil
+}
+
+func (s *Store) AmenityCreate(ctx context.Context, amenity *entry.Amenity) (*entry.Amenity, error) {
+	row, err := s.CreateAmenity(ctx, CreateAmenityParams{
+		CondominiumID:    amenity.CondominiumID,
+		Name:             amenity.Name,
+		Capacity:         amenity.Capacity,
+		OpensMinute:      amenity.OpensMinute,
+		ClosesMinute:     amenity.ClosesMinute,
+		SlotMinutes:      amenity.SlotMinutes,
+		MaxPerMonth:      amenity.MaxPerMonth,
+		LeadHours:        amenity.LeadHours,
+		RequiresApproval: amenity.RequiresApproval,
+		CreatedAt:        amenity.CreatedAt.Unix(),
+		CreatedBy:        nullInt64(amenity.CreatedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+func (s *Store) AmenityUpcomingReservations(
+	ctx context.Context, id int64, now time.Time,
+) (int64, error) {
+	return s.CountUpcomingReservations(ctx, CountUpcomingReservationsParams{
+		AmenityID: id,
+		Now:       now.Unix(),
+	})
+}
+
+// AmenityDelete removes the amenity, the foreign keys remove its
+// reservations.
+func (s *Store) AmenityDelete(ctx context.Context, id int64) error {
+	deleted, err := s.DeleteAmenity(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("Área común no encontrada")
+	}
+	return nil
+}
+
+// ReservationCreate checks the capacity and the monthly limit of the
+// amenity and saves the reservation, its guests and their passes together,
+// so two residents can't take the last places at the same time.
+func (s *Store) ReservationCreate(
+	ctx context.Context,
+	reservation *entry.Reservation,
+	passes []entry.Visit,
+	monthStart time.Time,
+	monthEnd time.Time,
+	check func(people int64, monthly int64) error,
+) (int64, error) {
+	var id int64
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		people, err := q.CountReservedPeople(ctx, CountReservedPeopleParams{
+			AmenityID: reservation.AmenityID,
+			StartsAt:  reservation.StartsAt.Unix(),
+			EndsAt:    reservation.EndsAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		monthly, err := q.CountResidentReservations(ctx, CountResidentReservationsParams{
+			AmenityID:  reservation.AmenityID,
+			ResidentID: reservation.ResidentID,
+			Since:      monthStart.Unix(),
+			Until:      monthEnd.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := check(people, monthly); err != nil {
+			return err
+		}
+
+		id, err = q.CreateReservation(ctx, CreateReservationParams{
+			CondominiumID: reservation.CondominiumID,
+			AmenityID:     reservation.AmenityID,
+			ResidentID:    reservation.ResidentID,
+			StartsAt:      reservation.StartsAt.Unix(),
+			EndsAt:        reservation.EndsAt.Unix(),
+			Status:        string(reservation.Status),
+			DecidedBy:     nullInt64(reservation.DecidedBy),
+			DecidedAt:     nullTime(reservation.DecidedAt),
+			CreatedAt:     reservation.CreatedAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range passes {
+			if _, err := q.CreateVisit(ctx, createVisitParams(&passes[i])); err != nil {
+				return err
+			}
+		}
+		for _, guest := range reservation.Guests {
+			err := q.AddReservationGuest(ctx, AddReservationGuestParams{
+				ReservationID: id,
+				Name:          guest.Name,
+				VisitID:       nullString(guest.VisitID),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (s *Store) ReservationGetByID(ctx context.Context, id int64) (*entry.Reservation, error) {
+	row, err := s.GetReservationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Reserva no encontrada")
+		}
+		return nil, err
+	}
+	guests, err := s.ListReservationGuests(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := row.unmarshall()
+	for _, guest := range guests {
+		reservation.Guests = append(reservation.Guests, guest.unmarshall())
+	}
+	return &reservation, nil
+}
+
+func (s *Store) ReservationList(
+	ctx context.Context, condoID int64, since time.Time, until time.Time,
+) ([]entry.Reservation, error) {
+	rows, err := s.ListReservationsByCondominium(ctx, ListReservationsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+		Until:         until.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	guests, err := s.ListReservationGuestsByCondominium(ctx, ListReservationGuestsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+		Until:         until.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]entry.Reservation, 0, len(rows))
+	for _, row := range rows {
+		reservations = append(reservations, GetReservationByIDRow(row).unmarshall())
+	}
+	addReservationGuests(reservations, guests)
+	return reservations, nil
+}
+
+func (s *Store) ReservationListByResident(
+	ctx context.Context, residentID int64, since time.Time,
+) ([]entry.Reservation, error) {
+	rows, err := s.ListReservationsByResident(ctx, ListReservationsByResidentParams{
+		ResidentID: residentID,
+		Since:      since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	guests, err := s.ListReservationGuestsByResident(ctx, ListReservationGuestsByResidentParams{
+		ResidentID: residentID,
+		Since:      since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]entry.Reservation, 0, len(rows))
+	for _, row := range rows {
+		reservations = append(reservations, GetReservationByIDRow(row).unmarshall())
+	}
+	addReservationGuests(reservations, guests)
+	return reservations, nil
+}
+
+// addReservationGuests adds the guests to the reservations they belong to.
+func addReservationGuests(reservations []entry.Reservation, guests []ReservationGuest) {
+	byID := make(map[int64]*entry.Reservation, len(reservations))
+	for i := range reservations {
+		byID[reservations[i].ID] = &reservations[i]
+	}
+	for _, guest := range guests {
+		if reservation, ok := byID[guest.ReservationID]; ok {
+			reservation.Guests = append(reservation.Guests, guest.unmarshall())
+		}
+	}
+}
+
+// ReservationConfirm confirms the reservation and creates the passes of its
+// guests together.
+func (s *Store) ReservationConfirm(
+	ctx context.Context,
+	reservation *entry.Reservation,
+	passes []entry.Visit,
+	by int64,
+	at time.Time,
+) error {
+	if len(passes) != len(reservation.Guests) {
+		return fmt.Errorf(
+			"reservation %d has %d guests, got %d passes",
+			reservation.ID, len(reservation.Guests), len(passes),
+		)
+	}
+
+	return withTx(ctx, s.db, func(q *Queries) error {
+		confirmed, err := q.ConfirmReservation(ctx, ConfirmReservationParams{
+			DecidedBy: nullInt64(by),
+			DecidedAt: nullTime(at),
+			ID:        reservation.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if confirmed == 0 {
+			return entry.NewNotFoundError("Reserva no encontrada")
+		}
+
+		for i, guest := range reservation.Guests {
+			if _, err := q.CreateVisit(ctx, createVisitParams(&passes[i])); err != nil {
+				return err
+			}
+			err := q.SetReservationGuestVisit(ctx, SetReservationGuestVisitParams{
+				VisitID: nullString(passes[i].ID),
+				ID:      guest.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReservationEnd ends the reservation and revokes the passes of its guests
+// together.
+func (s *Store) ReservationEnd(
+	ctx context.Context, id int64, status entry.ReservationStatus, by int64, at time.Time,
+) ([]entry.Visit, error) {
+	var revoked []entry.Visit
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		ended, err := q.EndReservation(ctx, EndReservationParams{
+			Status:    string(status),
+			DecidedBy: nullInt64(by),
+			DecidedAt: nullTime(at),
+			ID:        id,
+		})
+		if err != nil {
+			return err
+		}
+		if ended == 0 {
+			return entry.NewNotFoundError("Reserva no encontrada")
+		}
+
+		rows, err := q.RevokeReservationPasses(ctx, RevokeReservationPassesParams{
+			RevokedAt:     nullTime(at),
+			RevokedBy:     nullInt64(by),
+			ReservationID: id,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			revoked = append(revoked, row.unmarshall())
+		}
+		return nil
+	})
+	return revoked, err
+}
@@ -0,0 +1,198 @@
+package sqlc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// amenityTest is a condominium with an amenity that residents can reserve
+// any hour of the day.
+type amenityTest struct {
+	app       *entry.App
+	store     *Store
+	amenityID int64
+	residents []context.Context
+}
+
+func newAmenityTest(t *testing.T, amenity entry.Amenity) *amenityTest {
+	t.Helper()
+	ctx := context.Background()
+	store := newTestStore(t)
+	users := NewUserStore(store.db)
+	now := time.Now()
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	amenity.CondominiumID = condo.ID
+	amenity.Name = "Piscina"
+	amenity.OpensMinute = 0
+	amenity.ClosesMinute = 24 * 60
+	amenity.SlotMinutes = 60
+	amenity.CreatedAt = now
+	created, err := store.AmenityCreate(ctx, &amenity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	test := &amenityTest{
+		app: entry.NewApp(
+			logger,
+			store,
+			entry.NewAuditLogger(store, logger),
+			entry.NewNotifier(store, store, logger),
+			entry.NewHub(),
+		),
+		store:     store,
+		amenityID: created.ID,
+	}
+	for _, email := range []string{"vecina@example.com", "vecino@example.com"} {
+		user, err := users.CreateUser(ctx, &auth.User{
+			CondominiumID: condo.ID,
+			FirstName:     "Test",
+			LastName:      "Vecino",
+			Email:         email,
+			Role:          entry.RoleUser,
+			Enabled:       true,
+		}, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.residents = append(test.residents, entry.WithUser(ctx, &entry.User{
+			ID:            user.ID,
+			CondominiumID: condo.ID,
+			Role:          entry.RoleUser,
+			Enabled:       true,
+		}))
+	}
+	return test
+}
+
+// reserve reserves, for the i-th resident, the slot at hour of the day
+// days from today.
+func (a *amenityTest) reserve(
+	i int, days int, hour int64, guests ...string,
+) (*entry.Reservation, error) {
+	day := time.Now().AddDate(0, 0, days)
+	return a.app.ReserveAmenity(a.residents[i], a.amenityID, day, hour*60, guests)
+}
+
+func wantUserSafe(t *testing.T, err error) {
+	t.Helper()
+	var safe entry.UserSafeError
+	if !errors.As(err, &safe) {
+		t.Errorf("ReserveAmenity() = %v, want a UserSafeError", err)
+	}
+}
+
+func TestReserveAmenityLeadTime(t *testing.T) {
+	a := newAmenityTest(t, entry.Amenity{Capacity: 10, LeadHours: 48})
+
+	_, err := a.reserve(0, 1, 12)
+	wantUserSafe(t, err)
+	if _, err := a.reserve(0, 3, 12); err != nil {
+		t.Errorf("reserving after the lead time = %v", err)
+	}
+}
+
+func TestReserveAmenityMaxDays(t *testing.T) {
+	a := newAmenityTest(t, entry.Amenity{Capacity: 10})
+
+	_, err := a.reserve(0, entry.ReservationMaxDays+1, 12)
+	wantUserSafe(t, err)
+	if _, err := a.reserve(0, entry.ReservationMaxDays-1, 12); err != nil {
+		t.Errorf("reserving within %d days = %v", entry.ReservationMaxDays, err)
+	}
+}
+
+func TestReserveAmenityCapacity(t *testing.T) {
+	a := newAmenityTest(t, entry.Amenity{Capacity: 3})
+
+	// The resident counts against the capacity too.
+	_, err := a.reserve(0, 2, 12, "Ana", "Luis", "Marta")
+	wantUserSafe(t, err)
+
+	if _, err := a.reserve(0, 2, 12, "Ana"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.reserve(1, 2, 12, "Luis")
+	wantUserSafe(t, err)
+	if _, err := a.reserve(1, 2, 12); err != nil {
+		t.Errorf("reserving the last place = %v", err)
+	}
+	if _, err := a.reserve(1, 2, 13, "Luis"); err != nil {
+		t.Errorf("reserving another slot = %v", err)
+	}
+}
+
+func TestReserveAmenityMonthlyLimit(t *testing.T) {
+	a := newAmenityTest(t, entry.Amenity{Capacity: 10, MaxPerMonth: 2})
+
+	// Days of the next month, so that all of them are in the same one.
+	now := time.Now()
+	days := int(time.Date(now.Year(), now.Month()+1, 10, 0, 0, 0, 0, time.Local).Sub(now).Hours() / 24)
+	for hour := range int64(2) {
+		if _, err := a.reserve(0, days, 10+hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := a.reserve(0, days+1, 10)
+	wantUserSafe(t, err)
+	if _, err := a.reserve(1, days+1, 10); err != nil {
+		t.Errorf("another resident reserving = %v", err)
+	}
+}
+
+func TestReserveAmenityGuestPass(t *testing.T) {
+	a := newAmenityTest(t, entry.Amenity{Capacity: 10})
+
+	reservation, err := a.reserve(0, 2, 12, "Ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation.Status != entry.ReservationConfirmed {
+		t.Fatalf("status = %v, want confirmed", reservation.Status)
+	}
+	pass, err := a.store.VisitGetByID(context.Background(), reservation.Guests[0].VisitID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass.MaxUses != 1 {
+		t.Errorf("MaxUses = %d, want 1", pass.MaxUses)
+	}
+	if !pass.ValidFrom.Equal(reservation.StartsAt) || !pass.ValidTo.Equal(reservation.EndsAt) {
+		t.Errorf(
+			"pass valid from %v to %v, want %v to %v",
+			pass.ValidFrom, pass.ValidTo, reservation.StartsAt, reservation.EndsAt,
+		)
+	}
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		denied bool
+	}{
+		{"before", reservation.StartsAt.Add(-time.Minute), true},
+		{"start", reservation.StartsAt, false},
+		{"during", reservation.StartsAt.Add(30 * time.Minute), false},
+		{"end", reservation.EndsAt, false},
+		{"after", reservation.EndsAt.Add(time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pass.DenialReason(tt.at) != ""; got != tt.denied {
+				t.Errorf("denied = %t; want %t", got, tt.denied)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// AnnouncementCreate saves the announcement and the towers or units it is
+// for together.
+func (s *Store) AnnouncementCreate(
+	ctx context.Context, announcement *entry.Announcement,
+) (int64, error) {
+	var id int64
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		var err error
+		id, err = q.CreateAnnouncement(ctx, CreateAnnouncementParams{
+			CondominiumID: announcement.CondominiumID,
+			Title:         announcement.Title,
+			Body:          announcement.Body,
+			Audience:      string(announcement.Audience),
+			Pinned:        announcement.Pinned,
+			ExpiresAt:     nullTime(announcement.ExpiresAt),
+			CreatedAt:     announcement.CreatedAt.Unix(),
+			CreatedBy:     nullInt64(announcement.CreatedBy),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, tower := range announcement.Towers {
+			err := q.AddAnnouncementTarget(ctx, AddAnnouncementTargetParams{
+				AnnouncementID: id,
+				Tower:          tower,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, unit := range announcement.Units {
+			err := q.AddAnnouncementTarget(ctx, AddAnnouncementTargetParams{
+				AnnouncementID: id,
+				Tower:          unit.Tower,
+				Unit:           unit.Number,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (s *Store) AnnouncementGetByID(ctx context.Context, id int64) (*entry.Announcement, error) {
+	row, err := s.GetAnnouncementByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Comunicado no encontrado")
+		}
+		return nil, err
+	}
+	targets, err := s.ListAnnouncementTargets(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement := row.unmarshall()
+	for _, target := range targets {
+		target.addTo(&announcement)
+	}
+	return &announcement, nil
+}
+
+// AnnouncementList lists every announcement of a condominium.
+func (s *Store) AnnouncementList(ctx context.Context, condoID int64) ([]entry.Announcement, error) {
+	rows, err := s.ListAnnouncementsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.ListAnnouncementTargetsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]entry.Announcement, 0, len(rows))
+	for _, row := range rows {
+		announcements = append(announcements, row.unmarshall())
+	}
+	addAnnouncementTargets(announcements, targets)
+	return announcements, nil
+}
+
+// AnnouncementListActive lists the announcements of a condominium that
+// didn't expire.
+func (s *Store) AnnouncementListActive(
+	ctx context.Context, condoID int64, userID int64, now time.Time,
+) ([]entry.Announcement, error) {
+	rows, err := s.ListActiveAnnouncements(ctx, ListActiveAnnouncementsParams{
+		UserID:        userID,
+		CondominiumID: condoID,
+		Now:           nullTime(now),
+	})
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.ListAnnouncementTargetsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]entry.Announcement, 0, len(rows))
+	for _, row := range rows {
+		announcements = append(announcements, row.unmarshall())
+	}
+	addAnnouncementTargets(announcements, targets)
+	return announcements, nil
+}
+
+// addAnnouncementTargets adds the targets to the announcements they belong
+// to.
+func addAnnouncementTargets(announcements []entry.Announcement, targets []AnnouncementTarget) {
+	byID := make(map[int64]*entry.Announcement, len(announcements))
+	for i := range announcements {
+		byID[announcements[i].ID] = &announcements[i]
+	}
+	for _, target := range targets {
+		if announcement, ok := byID[target.AnnouncementID]; ok {
+			target.addTo(announcement)
+		}
+	}
+}
+
+func (s *Store) AnnouncementReads(ctx context.Context, id int64) (entry.AnnouncementReads, error) {
+	rows, err := s.ListAnnouncementReads(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reads := make(entry.AnnouncementReads, len(rows))
+	for _, row := range rows {
+		reads[row.UserID] = time.Unix(row.ReadAt, 0)
+	}
+	return reads, nil
+}
+
+// AnnouncementMarkRead records that the user read the announcement, once.
+func (s *Store) AnnouncementMarkRead(
+	ctx context.Context, id int64, userID int64, at time.Time,
+) error {
+	return s.MarkAnnouncementRead(ctx, MarkAnnouncementReadParams{
+		AnnouncementID: id,
+		UserID:         userID,
+		ReadAt:         at.Unix(),
+	})
+}
+
+func (s *Store) AnnouncementSetPinned(ctx context.Context, id int64, pinned bool) error {
+	updated, err := s.SetAnnouncementPinned(ctx, SetAnnouncementPinnedParams{
+		Pinned: pinned,
+		ID:     id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewNotFoundError("Comunicado no encontrado")
+	}
+	return nil
+}
+
+// AnnouncementDelete removes the announcement, the foreign keys remove its
+// targets and reads.
+func (s *Store) AnnouncementDelete(ctx context.Context, id int64) error {
+	deleted, err := s.DeleteAnnouncement(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("Comunicado no encontrado")
+	}
+	return nil
+}
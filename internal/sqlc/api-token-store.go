@@ -0,0 +1,113 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// APITokenStore persists the API tokens.
+// This implements auth.APITokenStore interface.
+type APITokenStore struct {
+	queries *Queries
+}
+
+var _ auth.APITokenStore = (*APITokenStore)(nil)
+
+// NewAPITokenStore creates a new APITokenStore that wraps the SQLC queries.
+func NewAPITokenStore(db *sql.DB) *APITokenStore {
+	return &APITokenStore{
+		queries: New(ctxDB{db}),
+	}
+}
+
+// CreateAPIToken stores a new token by the hash of its value.
+// Implements auth.APITokenStore.
+func (s *APITokenStore) CreateAPIToken(
+	ctx context.Context, token auth.APIToken, tokenHash string,
+) (auth.APIToken, error) {
+	scopes := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	created, err := s.queries.CreateAPIToken(ctx, CreateAPITokenParams{
+		TokenHash:     tokenHash,
+		UserID:        token.UserID,
+		CondominiumID: nullInt64(token.CondominiumID),
+		Name:          token.Name,
+		Scopes:        strings.Join(scopes, " "),
+		CreatedAt:     token.CreatedAt.Unix(),
+		ExpiresAt:     token.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return auth.APIToken{}, err
+	}
+	return created.unmarshall(), nil
+}
+
+// GetAPIToken retrieves an unexpired token by the hash of its value.
+// Implements auth.APITokenStore.
+func (s *APITokenStore) GetAPIToken(
+	ctx context.Context, tokenHash string, now time.Time,
+) (auth.APIToken, bool, error) {
+	token, err := s.queries.GetAPITokenByHash(ctx, GetAPITokenByHashParams{
+		TokenHash: tokenHash,
+		ExpiresAt: now.Unix(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.APIToken{}, false, nil
+		}
+		return auth.APIToken{}, false, err
+	}
+	return token.unmarshall(), true, nil
+}
+
+// TouchAPIToken records the last use of the token.
+// Implements auth.APITokenStore.
+func (s *APITokenStore) TouchAPIToken(ctx context.Context, id int64, now time.Time) error {
+	return s.queries.TouchAPIToken(ctx, TouchAPITokenParams{
+		LastUsedAt: nullTime(now),
+		ID:         id,
+	})
+}
+
+// ListUserAPITokens lists the unexpired tokens of the user.
+// Implements auth.APITokenStore.
+func (s *APITokenStore) ListUserAPITokens(
+	ctx context.Context, userID int64, now time.Time,
+) ([]auth.APIToken, error) {
+	rows, err := s.queries.ListAPITokensByUser(ctx, ListAPITokensByUserParams{
+		UserID:    userID,
+		ExpiresAt: now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]auth.APIToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, row.unmarshall())
+	}
+	return tokens, nil
+}
+
+// DeleteUserAPIToken deletes the token if it belongs to the user.
+// Implements auth.APITokenStore.
+func (s *APITokenStore) DeleteUserAPIToken(
+	ctx context.Context, userID int64, id int64,
+) (bool, error) {
+	deleted, err := s.queries.DeleteUserAPIToken(ctx, DeleteUserAPITokenParams{
+		UserID: userID,
+		ID:     id,
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
@@ -0,0 +1,139 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// AuditChainPage lists up to limit entries of the chain after afterID, in
+// chain order.
+func (s *Store) AuditChainPage(
+	ctx context.Context, chainID int64, afterID int64, limit int64,
+) ([]entry.AuditEntry, error) {
+	rows, err := s.ListAuditChain(ctx, ListAuditChainParams{
+		ChainID: chainID,
+		AfterID: afterID,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entry.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.unmarshall())
+	}
+	return entries, nil
+}
+
+// AuditChainHeadGet returns the head of the chain, and false if the chain
+// has no entries.
+func (s *Store) AuditChainHeadGet(
+	ctx context.Context, chainID int64,
+) (entry.AuditChainHead, bool, error) {
+	head, err := s.GetAuditChainHead(ctx, chainID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entry.AuditChainHead{}, false, nil
+		}
+		return entry.AuditChainHead{}, false, err
+	}
+	return head.unmarshall(), true, nil
+}
+
+// AuditChainHeadList lists the heads of every chain.
+func (s *Store) AuditChainHeadList(
+	ctx context.Context,
+) ([]entry.AuditChainHead, error) {
+	rows, err := s.ListAuditChainHeads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make([]entry.AuditChainHead, 0, len(rows))
+	for _, row := range rows {
+		heads = append(heads, row.unmarshall())
+	}
+	return heads, nil
+}
+
+// AuditChainIDs lists the chains that have entries, including the ones
+// whose head is missing.
+func (s *Store) AuditChainIDs(ctx context.Context) ([]int64, error) {
+	return s.ListAuditChainIDs(ctx)
+}
+
+// AuditCheckpointCreate stores a signed checkpoint.
+func (s *Store) AuditCheckpointCreate(
+	ctx context.Context, checkpoint *entry.AuditCheckpoint,
+) (*entry.AuditCheckpoint, error) {
+	row, err := s.CreateAuditCheckpoint(ctx, CreateAuditCheckpointParams{
+		ChainID:   checkpoint.ChainID,
+		LastID:    checkpoint.LastID,
+		Hash:      checkpoint.Hash,
+		Entries:   checkpoint.Entries,
+		PublicKey: checkpoint.PublicKey,
+		Signature: checkpoint.Signature,
+		CreatedAt: checkpoint.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row.unmarshall(), nil
+}
+
+// AuditCheckpointGet retrieves a checkpoint by its ID.
+func (s *Store) AuditCheckpointGet(
+	ctx context.Context, id int64,
+) (*entry.AuditCheckpoint, error) {
+	row, err := s.GetAuditCheckpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Punto de control no encontrado")
+		}
+		return nil, err
+	}
+	return row.unmarshall(), nil
+}
+
+// AuditCheckpointLatest returns the newest checkpoint of the chain, or nil
+// if it has none.
+func (s *Store) AuditCheckpointLatest(
+	ctx context.Context, chainID int64,
+) (*entry.AuditCheckpoint, error) {
+	row, err := s.GetLatestAuditCheckpoint(ctx, chainID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return row.unmarshall(), nil
+}
+
+// AuditCheckpointList lists the checkpoints of the chain, newest first. A
+// zero limit lists all of them.
+func (s *Store) AuditCheckpointList(
+	ctx context.Context, chainID int64, limit int64,
+) ([]entry.AuditCheckpoint, error) {
+	if limit == 0 {
+		limit = -1 // No limit
+	}
+
+	rows, err := s.ListAuditCheckpoints(ctx, ListAuditCheckpointsParams{
+		ChainID: chainID,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]entry.AuditCheckpoint, 0, len(rows))
+	for _, row := range rows {
+		checkpoints = append(checkpoints, *row.unmarshall())
+	}
+	return checkpoints, nil
+}
@@ -0,0 +1,167 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// AuditCreate stores an entry in the audit log and appends it to the chain
+// of its condominium. Appends are serialized by the write lock that
+// transactions take at their start, see Open.
+func (s *Store) AuditCreate(
+	ctx context.Context, record entry.AuditRecord, createdAt time.Time,
+) error {
+	var entryID int64
+	var entryHash string
+	if record.Entry != nil {
+		entryID = record.Entry.ID
+		entryHash = record.Entry.Hash()
+	}
+
+	return withTx(ctx, s.db, func(q *Queries) error {
+		row, err := q.CreateAuditLog(ctx, CreateAuditLogParams{
+			CondominiumID:  nullInt64(record.CondominiumID),
+			UserID:         nullInt64(record.UserID),
+			ImpersonatorID: nullInt64(record.ImpersonatorID),
+			Level:          int64(record.Level),
+			Action:         string(record.Action),
+			Message:        record.Message,
+			EntryID:        nullInt64(entryID),
+			EntryHash:      entryHash,
+			CreatedAt:      createdAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		head, err := q.GetAuditChainHead(ctx, record.CondominiumID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		_, err = chainAuditLog(ctx, q, row, head)
+		return err
+	})
+}
+
+// chainAuditLog links the row to the head of its chain and moves the head
+// to it, returning the new head.
+func chainAuditLog(
+	ctx context.Context, q *Queries, row AuditLog, head AuditChainHead,
+) (AuditChainHead, error) {
+	e := row.unmarshall()
+	next := UpsertAuditChainHeadParams{
+		ChainID:   e.CondominiumID,
+		LastID:    row.ID,
+		Hash:      e.ChainHash(head.Hash),
+		Entries:   head.Entries + 1,
+		UpdatedAt: time.Now().Unix(),
+	}
+
+	err := q.SetAuditLogHash(ctx, SetAuditLogHashParams{
+		PrevHash: head.Hash,
+		Hash:     next.Hash,
+		ID:       row.ID,
+	})
+	if err != nil {
+		return head, err
+	}
+	if err := q.UpsertAuditChainHead(ctx, next); err != nil {
+		return head, err
+	}
+	return AuditChainHead(next), nil
+}
+
+// AuditSealLegacy chains the entries written before the audit log was
+// chained. It only does something the first time it runs, once there are
+// chain heads every entry is chained when it is created.
+func (s *Store) AuditSealLegacy(ctx context.Context) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		heads, err := q.CountAuditChainHeads(ctx)
+		if err != nil || heads > 0 {
+			return err
+		}
+
+		chainIDs, err := q.ListAuditChainIDs(ctx)
+		if err != nil {
+			return err
+		}
+		for _, chainID := range chainIDs {
+			rows, err := q.ListAuditChain(ctx, ListAuditChainParams{
+				ChainID: chainID,
+				AfterID: 0,
+				Limit:   -1,
+			})
+			if err != nil {
+				return err
+			}
+
+			head := AuditChainHead{ChainID: chainID}
+			for _, row := range rows {
+				head, err = chainAuditLog(ctx, q, row, head)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// AuditList lists the entries of the audit log matching the filter, newest
+// first.
+func (s *Store) AuditList(
+	ctx context.Context, filter entry.AuditFilter,
+) ([]entry.AuditEntry, error) {
+	limit := filter.Limit
+	if limit == 0 {
+		limit = -1 // No limit
+	}
+
+	rows, err := s.ListAuditLogs(ctx, ListAuditLogsParams{
+		CondominiumID: filter.CondominiumID,
+		UserID:        filter.UserID,
+		MinLevel:      int64(filter.MinLevel),
+		Action:        string(filter.Action),
+		Search:        filter.Search,
+		CreatedFrom:   unixOrZero(filter.From),
+		CreatedTo:     unixOrZero(filter.To),
+		Limit:         limit,
+		Offset:        filter.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entry.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.unmarshall())
+	}
+	return entries, nil
+}
+
+// AuditCount counts the entries of the audit log matching the filter,
+// ignoring its limit and offset.
+func (s *Store) AuditCount(
+	ctx context.Context, filter entry.AuditFilter,
+) (int64, error) {
+	return s.CountAuditLogs(ctx, CountAuditLogsParams{
+		CondominiumID: filter.CondominiumID,
+		UserID:        filter.UserID,
+		MinLevel:      int64(filter.MinLevel),
+		Action:        string(filter.Action),
+		Search:        filter.Search,
+		CreatedFrom:   unixOrZero(filter.From),
+		CreatedTo:     unixOrZero(filter.To),
+	})
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
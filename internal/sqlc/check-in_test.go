@@ -0,0 +1,192 @@
+package sqlc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// slowOccupancyStore waits, for a while, until every check-in counted who
+// is inside before letting them go on, so that without a transaction they
+// all see the last place free.
+type slowOccupancyStore struct {
+	*Store
+	counted sync.WaitGroup
+}
+
+func (s *slowOccupancyStore) EntryListInside(
+	ctx context.Context, condoID int64, at time.Time,
+) ([]entry.Occupant, error) {
+	inside, err := s.Store.EntryListInside(ctx, condoID, at)
+	s.counted.Done()
+	waited := make(chan struct{})
+	go func() {
+		s.counted.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(500 * time.Millisecond):
+	}
+	return inside, err
+}
+
+// Two guards checking visitors in at the same time can't both take the
+// last place of the condominium.
+func TestCheckInOccupancyLimitIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	users := NewUserStore(store.db)
+	now := time.Now()
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CondoUpdate(ctx, condo.ID, func(c *entry.Condominium) (*entry.Condominium, error) {
+		c.MaxVisitors = 1
+		return c, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newUser := func(email string, role entry.UserRole) *auth.User {
+		t.Helper()
+		user, err := users.CreateUser(ctx, &auth.User{
+			CondominiumID: condo.ID,
+			FirstName:     "Test",
+			LastName:      string(role),
+			Email:         email,
+			Role:          role,
+			Enabled:       true,
+		}, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	resident := newUser("vecina@example.com", entry.RoleUser)
+	guards := []*auth.User{
+		newUser("norte@example.com", entry.RoleGuardian),
+		newUser("sur@example.com", entry.RoleGuardian),
+	}
+
+	codes := []string{"AAAA1111", "BBBB2222"}
+	for i, code := range codes {
+		_, err := store.VisitCreate(ctx, &entry.Visit{
+			ID:            code,
+			CondominiumID: condo.ID,
+			UserID:        resident.ID,
+			VisitorName:   fmt.Sprintf("Visita %d", i+1),
+			ValidFrom:     now.Add(-time.Hour),
+			ValidTo:       now.Add(time.Hour),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slow := &slowOccupancyStore{Store: store}
+	slow.counted.Add(len(codes))
+	logger := slog.New(slog.DiscardHandler)
+	app := entry.NewApp(
+		logger,
+		slow,
+		entry.NewAuditLogger(store, logger),
+		entry.NewNotifier(store, store, logger),
+		entry.NewHub(),
+	)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	entries := make([]*entry.Entry, len(codes))
+	errs := make([]error, len(codes))
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := entry.WithUser(ctx, &entry.User{
+				ID:            guards[i].ID,
+				CondominiumID: condo.ID,
+				Role:          entry.RoleGuardian,
+				Enabled:       true,
+			})
+			<-start
+			entries[i], errs[i] = app.CheckIn(ctx, codes[i], 0)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	accepted, overLimit := 0, 0
+	for i := range codes {
+		if errs[i] != nil {
+			t.Fatalf("CheckIn(%s) = %v", codes[i], errs[i])
+		}
+		if entries[i].Accepted {
+			accepted++
+		}
+		if entries[i].OverLimit {
+			overLimit++
+		}
+	}
+	if accepted != 1 || overLimit != 1 {
+		t.Errorf("accepted = %d, over the limit = %d, want 1 and 1", accepted, overLimit)
+	}
+}
+
+// An admin submitting the override of a denied check-in twice only lets
+// the visitor in once.
+func TestEntryCreateOverridesOnce(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now()
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	denied, err := store.EntryCreate(ctx, &entry.Entry{
+		CondominiumID: condo.ID,
+		VisitorName:   "Ana",
+		Reason:        "El condominio ya tiene 1 visitantes adentro, el máximo es 1",
+		OverLimit:     true,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	override := func() error {
+		_, err := store.EntryCreate(ctx, &entry.Entry{
+			CondominiumID:  condo.ID,
+			VisitorName:    "Ana",
+			Accepted:       true,
+			OverrideOf:     denied.ID,
+			OverrideReason: "Cumpleaños autorizado",
+			CreatedAt:      now,
+		})
+		return err
+	}
+	if err := override(); err != nil {
+		t.Fatal(err)
+	}
+	var safe entry.UserSafeError
+	if err := override(); !errors.As(err, &safe) {
+		t.Errorf("second override = %v, want a UserSafeError", err)
+	}
+}
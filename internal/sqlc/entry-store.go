@@ -0,0 +1,142 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// EntryCreate records a check-in attempt.
+func (s *Store) EntryCreate(ctx context.Context, e *entry.Entry) (*entry.Entry, error) {
+	row, err := s.CreateEntry(ctx, CreateEntryParams{
+		CondominiumID:  e.CondominiumID,
+		VisitID:        nullString(e.VisitID),
+		GuardID:        nullInt64(e.GuardID),
+		VisitorName:    e.VisitorName,
+		Accepted:       e.Accepted,
+		Reason:         e.Reason,
+		StationID:      nullInt64(e.StationID),
+		ShiftID:        nullInt64(e.ShiftID),
+		OverLimit:      e.OverLimit,
+		OverrideOf:     nullInt64(e.OverrideOf),
+		OverrideReason: e.OverrideReason,
+		CreatedAt:      e.CreatedAt.Unix(),
+	})
+	if err != nil {
+		if e.OverrideOf != 0 && isUniqueViolation(err) {
+			return nil, entry.NewUserSafeError("El ingreso ya fue autorizado")
+		}
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// EntryListByCondo lists the check-in attempts of a condominium since the
+// given time, newest first.
+func (s *Store) EntryListByCondo(
+	ctx context.Context, condoID int64, since time.Time,
+) ([]entry.Entry, error) {
+	rows, err := s.ListEntriesByCondominium(ctx, ListEntriesByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entry.Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.unmarshall())
+	}
+	return entries, nil
+}
+
+func (s *Store) EntryListByShift(ctx context.Context, shiftID int64) ([]entry.Entry, error) {
+	rows, err := s.ListEntriesByShift(ctx, nullInt64(shiftID))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entry.Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.unmarshall())
+	}
+	return entries, nil
+}
+
+func (s *Store) EntryGetByID(ctx context.Context, id int64) (*entry.Entry, error) {
+	row, err := s.GetEntryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Ingreso no encontrado")
+		}
+		return nil, err
+	}
+
+	e := row.unmarshall()
+	return &e, nil
+}
+
+func (s *Store) EntryListInside(
+	ctx context.Context, condoID int64, now time.Time,
+) ([]entry.Occupant, error) {
+	rows, err := s.ListEntriesInside(ctx, ListEntriesInsideParams{
+		CondominiumID: condoID,
+		Now:           now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	occupants := make([]entry.Occupant, 0, len(rows))
+	for _, row := range rows {
+		occupants = append(occupants, row.unmarshall())
+	}
+	return occupants, nil
+}
+
+func (s *Store) EntryExit(ctx context.Context, id int64, by int64, at time.Time) error {
+	exited, err := s.ExitEntry(ctx, ExitEntryParams{
+		ExitedAt: nullTime(at),
+		ExitedBy: nullInt64(by),
+		ID:       id,
+	})
+	if err != nil {
+		return err
+	}
+	if exited == 0 {
+		return entry.NewNotFoundError("Ingreso no encontrado")
+	}
+	return nil
+}
+
+func (s *Store) EntryListOverridable(
+	ctx context.Context, condoID int64, since time.Time,
+) ([]entry.Entry, error) {
+	rows, err := s.ListOverridableEntries(ctx, ListOverridableEntriesParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entry.Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.unmarshall())
+	}
+	return entries, nil
+}
+
+func (s *Store) EntryOverridden(ctx context.Context, id int64) (bool, error) {
+	overrides, err := s.CountEntryOverrides(ctx, nullInt64(id))
+	if err != nil {
+		return false, err
+	}
+	return overrides > 0, nil
+}
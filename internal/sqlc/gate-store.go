@@ -0,0 +1,163 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// GateList lists the gates of a condominium.
+func (s *Store) GateList(ctx context.Context, condoID int64) ([]entry.Gate, error) {
+	rows, err := s.ListGatesByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	gates := make([]entry.Gate, 0, len(rows))
+	for _, row := range rows {
+		gates = append(gates, row.unmarshall())
+	}
+	return gates, nil
+}
+
+func (s *Store) GateGetByID(ctx context.Context, id int64) (*entry.Gate, error) {
+	row, err := s.GetGateByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Barrera no encontrada")
+		}
+		return nil, err
+	}
+
+	gate := row.unmarshall()
+	return &gate, nil
+}
+
+func (s *Store) GateCreate(ctx context.Context, gate *entry.Gate) (*entry.Gate, error) {
+	row, err := s.CreateGate(ctx, CreateGateParams{
+		CondominiumID: gate.CondominiumID,
+		Name:          gate.Name,
+		Driver:        string(gate.Driver),
+		Target:        gate.Target,
+		HttpMethod:    gate.HTTPMethod,
+		Payload:       gate.Payload,
+		UnitID:        gate.UnitID,
+		Coil:          gate.Coil,
+		CreatedAt:     gate.CreatedAt.Unix(),
+		CreatedBy:     nullInt64(gate.CreatedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// GateDelete removes the gate, the foreign key leaves the stations that
+// used it without a gate.
+func (s *Store) GateDelete(ctx context.Context, id int64) error {
+	deleted, err := s.DeleteGate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("Barrera no encontrada")
+	}
+	return nil
+}
+
+// GuardStationList lists the guard stations of a condominium.
+func (s *Store) GuardStationList(
+	ctx context.Context, condoID int64,
+) ([]entry.GuardStation, error) {
+	rows, err := s.ListGuardStationsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	stations := make([]entry.GuardStation, 0, len(rows))
+	for _, row := range rows {
+		stations = append(stations, row.unmarshall())
+	}
+	return stations, nil
+}
+
+func (s *Store) GuardStationGetByID(
+	ctx context.Context, id int64,
+) (*entry.GuardStation, error) {
+	row, err := s.GetGuardStationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Garita no encontrada")
+		}
+		return nil, err
+	}
+
+	station := row.unmarshall()
+	return &station, nil
+}
+
+func (s *Store) GuardStationCreate(
+	ctx context.Context, station *entry.GuardStation,
+) (*entry.GuardStation, error) {
+	row, err := s.CreateGuardStation(ctx, CreateGuardStationParams{
+		CondominiumID: station.CondominiumID,
+		Name:          station.Name,
+		GateID:        nullInt64(station.GateID),
+		CreatedAt:     station.CreatedAt.Unix(),
+		CreatedBy:     nullInt64(station.CreatedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+func (s *Store) GuardStationSetGate(ctx context.Context, id int64, gateID int64) error {
+	updated, err := s.SetGuardStationGate(ctx, SetGuardStationGateParams{
+		GateID: nullInt64(gateID),
+		ID:     id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewNotFoundError("Garita no encontrada")
+	}
+	return nil
+}
+
+func (s *Store) GuardStationSetWelfare(
+	ctx context.Context, id int64, interval int64, grace int64,
+) error {
+	updated, err := s.SetGuardStationWelfare(ctx, SetGuardStationWelfareParams{
+		WelfareIntervalMinutes: interval,
+		WelfareGraceMinutes:    grace,
+		ID:                     id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewNotFoundError("Garita no encontrada")
+	}
+	return nil
+}
+
+// GuardStationDelete removes the station, the entries recorded at it keep
+// no station.
+func (s *Store) GuardStationDelete(ctx context.Context, id int64) error {
+	deleted, err := s.DeleteGuardStation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("Garita no encontrada")
+	}
+	return nil
+}
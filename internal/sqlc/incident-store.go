@@ -0,0 +1,206 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// IncidentCreate saves the incident with everything involved together.
+func (s *Store) IncidentCreate(
+	ctx context.Context, incident *entry.Incident, attachments []entry.IncidentAttachment,
+) (*entry.Incident, error) {
+	var id int64
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		var err error
+		id, err = q.CreateIncident(ctx, CreateIncidentParams{
+			CondominiumID: incident.CondominiumID,
+			Category:      string(incident.Category),
+			Severity:      string(incident.Severity),
+			Location:      incident.Location,
+			Description:   incident.Description,
+			Status:        string(incident.Status),
+			ReportedBy:    nullInt64(incident.ReportedBy),
+			ShiftID:       nullInt64(incident.ShiftID),
+			CreatedAt:     incident.CreatedAt.Unix(),
+			UpdatedAt:     incident.UpdatedAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, resident := range incident.Residents {
+			err := q.AddIncidentResident(ctx, AddIncidentResidentParams{
+				IncidentID: id,
+				UserID:     resident.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, visitID := range incident.VisitIDs {
+			err := q.AddIncidentVisit(ctx, AddIncidentVisitParams{
+				IncidentID: id,
+				VisitID:    visitID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, attachment := range attachments {
+			err := q.CreateIncidentAttachment(ctx, CreateIncidentAttachmentParams{
+				IncidentID:  id,
+				Filename:    attachment.Filename,
+				ContentType: attachment.ContentType,
+				Size:        attachment.Size,
+				Data:        attachment.Data,
+				CreatedAt:   attachment.CreatedAt.Unix(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.IncidentGetByID(ctx, id)
+}
+
+func (s *Store) IncidentGetByID(ctx context.Context, id int64) (*entry.Incident, error) {
+	row, err := s.GetIncidentByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Incidente no encontrado")
+		}
+		return nil, err
+	}
+	incident := row.unmarshall()
+
+	residents, err := s.ListIncidentResidents(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range residents {
+		incident.Residents = append(incident.Residents, entry.IncidentResident{
+			ID:   r.ID,
+			Name: r.Name,
+		})
+	}
+
+	incident.VisitIDs, err = s.ListIncidentVisits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.ListIncidentAttachments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		incident.Attachments = append(incident.Attachments, a.unmarshall())
+	}
+
+	comments, err := s.ListIncidentComments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		incident.Comments = append(incident.Comments, c.unmarshall())
+	}
+	return &incident, nil
+}
+
+func (s *Store) IncidentList(
+	ctx context.Context, condoID int64, status entry.IncidentStatus, limit int64,
+) ([]entry.Incident, error) {
+	rows, err := s.ListIncidentsByCondominium(ctx, ListIncidentsByCondominiumParams{
+		CondominiumID: condoID,
+		Status:        string(status),
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := make([]entry.Incident, 0, len(rows))
+	for _, row := range rows {
+		incidents = append(incidents, GetIncidentByIDRow(row).unmarshall())
+	}
+	return incidents, nil
+}
+
+func (s *Store) IncidentListByShift(
+	ctx context.Context, shiftID int64,
+) ([]entry.Incident, error) {
+	rows, err := s.ListIncidentsByShift(ctx, nullInt64(shiftID))
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := make([]entry.Incident, 0, len(rows))
+	for _, row := range rows {
+		incidents = append(incidents, GetIncidentByIDRow(row).unmarshall())
+	}
+	return incidents, nil
+}
+
+func (s *Store) IncidentComment(
+	ctx context.Context, comment *entry.IncidentComment, from entry.IncidentStatus,
+) error {
+	at := comment.CreatedAt.Unix()
+	return withTx(ctx, s.db, func(q *Queries) error {
+		if comment.Status == "" {
+			err := q.TouchIncident(ctx, TouchIncidentParams{UpdatedAt: at, ID: comment.IncidentID})
+			if err != nil {
+				return err
+			}
+		} else {
+			updated, err := q.UpdateIncidentStatus(ctx, UpdateIncidentStatusParams{
+				Status:     string(comment.Status),
+				UpdatedAt:  at,
+				ID:         comment.IncidentID,
+				FromStatus: string(from),
+			})
+			if err != nil {
+				return err
+			}
+			if updated == 0 {
+				return entry.NewUserSafeError("Alguien más cambió el estado del incidente")
+			}
+		}
+
+		return q.CreateIncidentComment(ctx, CreateIncidentCommentParams{
+			IncidentID: comment.IncidentID,
+			AuthorID:   nullInt64(comment.AuthorID),
+			Body:       comment.Body,
+			Status:     nullString(string(comment.Status)),
+			CreatedAt:  at,
+		})
+	})
+}
+
+func (s *Store) IncidentAttachmentGet(
+	ctx context.Context, id int64,
+) (*entry.IncidentAttachment, error) {
+	row, err := s.GetIncidentAttachment(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Adjunto no encontrado")
+		}
+		return nil, err
+	}
+	return &entry.IncidentAttachment{
+		ID:          row.ID,
+		IncidentID:  row.IncidentID,
+		Filename:    row.Filename,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		Data:        row.Data,
+		CreatedAt:   time.Unix(row.CreatedAt, 0),
+	}, nil
+}
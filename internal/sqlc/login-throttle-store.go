@@ -0,0 +1,98 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// GetLoginThrottle retrieves the recorded failures of a subject.
+// Implements auth.ThrottleStore.
+func (s *UserStore) GetLoginThrottle(
+	ctx context.Context, kind auth.ThrottleKind, subject string,
+) (auth.LoginThrottle, bool, error) {
+	throttle, err := s.queries.GetLoginThrottle(ctx, GetLoginThrottleParams{
+		Kind:    string(kind),
+		Subject: subject,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.LoginThrottle{}, false, nil
+		}
+		return auth.LoginThrottle{}, false, err
+	}
+
+	return throttle.unmarshall(), true, nil
+}
+
+// SaveLoginThrottle creates or replaces the recorded failures of a subject.
+// Implements auth.ThrottleStore.
+func (s *UserStore) SaveLoginThrottle(ctx context.Context, throttle auth.LoginThrottle) error {
+	var lockedUntil int64
+	if !throttle.LockedUntil.IsZero() {
+		lockedUntil = throttle.LockedUntil.Unix()
+	}
+
+	return s.queries.UpsertLoginThrottle(ctx, UpsertLoginThrottleParams{
+		Kind:          string(throttle.Kind),
+		Subject:       throttle.Subject,
+		Failures:      throttle.Failures,
+		LastFailureAt: throttle.LastFailureAt.Unix(),
+		LockedUntil:   lockedUntil,
+	})
+}
+
+// DeleteLoginThrottle forgets the failures of a subject.
+// Implements auth.ThrottleStore.
+func (s *UserStore) DeleteLoginThrottle(
+	ctx context.Context, kind auth.ThrottleKind, subject string,
+) error {
+	return s.queries.DeleteLoginThrottle(ctx, DeleteLoginThrottleParams{
+		Kind:    string(kind),
+		Subject: subject,
+	})
+}
+
+// DeleteStaleLoginThrottles forgets the failures of the subjects of the
+// kind that last failed before failedBefore and aren't locked at now.
+// Implements auth.ThrottleStore.
+func (s *UserStore) DeleteStaleLoginThrottles(
+	ctx context.Context, kind auth.ThrottleKind, failedBefore time.Time, now time.Time,
+) error {
+	return s.queries.DeleteStaleLoginThrottles(ctx, DeleteStaleLoginThrottlesParams{
+		Kind:         string(kind),
+		FailedBefore: failedBefore.Unix(),
+		Now:          now.Unix(),
+	})
+}
+
+// ListLockedAccounts lists the accounts locked at now.
+// Implements auth.ThrottleStore.
+func (s *UserStore) ListLockedAccounts(
+	ctx context.Context, condoID int64, now time.Time,
+) ([]auth.LockedAccount, error) {
+	rows, err := s.queries.ListLockedAccounts(ctx, ListLockedAccountsParams{
+		Now:           now.Unix(),
+		CondominiumID: condoID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]auth.LockedAccount, 0, len(rows))
+	for _, row := range rows {
+		accounts = append(accounts, auth.LockedAccount{
+			UserID:        row.ID,
+			CondominiumID: validNullInt64(row.CondominiumID),
+			FirstName:     row.FirstName,
+			LastName:      row.LastName,
+			Email:         row.Email,
+			Failures:      row.Failures,
+			LockedUntil:   time.Unix(row.LockedUntil, 0),
+		})
+	}
+	return accounts, nil
+}
@@ -0,0 +1,53 @@
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// MembershipCreate adds the user to the condominium inside a transaction.
+func (s *Store) MembershipCreate(
+	ctx context.Context,
+	userID int64,
+	membership entry.Membership,
+	createdAt time.Time,
+	createdBy int64,
+) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		existing, err := q.ListMembershipsByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, m := range existing {
+			if m.CondominiumID == membership.CondominiumID {
+				return entry.NewUserSafeError("El usuario ya pertenece a este condominio")
+			}
+		}
+
+		return q.CreateMembership(ctx, CreateMembershipParams{
+			UserID:        userID,
+			CondominiumID: membership.CondominiumID,
+			Role:          string(membership.Role),
+			CreatedAt:     createdAt.Unix(),
+			CreatedBy:     nullInt64(createdBy),
+		})
+	})
+}
+
+// MembershipDelete removes the user from the condominium. Home memberships
+// are stored with the user, so they are never found here.
+func (s *Store) MembershipDelete(ctx context.Context, userID int64, condoID int64) error {
+	deleted, err := s.DeleteMembership(ctx, DeleteMembershipParams{
+		UserID:        userID,
+		CondominiumID: condoID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("El usuario no pertenece a este condominio")
+	}
+	return nil
+}
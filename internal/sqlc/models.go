@@ -8,22 +8,400 @@ import (
 	"database/sql"
 )
 
-type AuditLog struct {
+type Amenity struct {
+	ID               int64
+	CondominiumID    int64
+	Name             string
+	Capacity         int64
+	OpensMinute      int64
+	ClosesMinute     int64
+	SlotMinutes      int64
+	MaxPerMonth      int64
+	LeadHours        int64
+	RequiresApproval bool
+	CreatedAt        int64
+	CreatedBy        sql.NullInt64
+}
+
+type Announcement struct {
+	ID            int64
+	CondominiumID int64
+	Title         string
+	Body          string
+	Audience      string
+	Pinned        bool
+	ExpiresAt     sql.NullInt64
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type AnnouncementRead struct {
+	AnnouncementID int64
+	UserID         int64
+	ReadAt         int64
+}
+
+type AnnouncementTarget struct {
+	AnnouncementID int64
+	Tower          string
+	Unit           string
+}
+
+type ApiToken struct {
+	ID            int64
+	TokenHash     string
+	UserID        int64
+	CondominiumID sql.NullInt64
+	Name          string
+	Scopes        string
+	CreatedAt     int64
+	LastUsedAt    sql.NullInt64
+	ExpiresAt     int64
+}
+
+type AuditChainHead struct {
+	ChainID   int64
+	LastID    int64
+	Hash      string
+	Entries   int64
+	UpdatedAt int64
+}
+
+type AuditCheckpoint struct {
 	ID        int64
-	UserID    sql.NullInt64
-	Level     int64
-	Message   string
+	ChainID   int64
+	LastID    int64
+	Hash      string
+	Entries   int64
+	PublicKey string
+	Signature string
 	CreatedAt int64
 }
 
+type AuditLog struct {
+	ID             int64
+	CondominiumID  sql.NullInt64
+	UserID         sql.NullInt64
+	Level          int64
+	Action         string
+	Message        string
+	CreatedAt      int64
+	PrevHash       string
+	Hash           string
+	ImpersonatorID sql.NullInt64
+	EntryID        sql.NullInt64
+	EntryHash      string
+}
+
 type Condominium struct {
+	ID                 int64
+	Name               string
+	Address            string
+	CreatedAt          int64
+	UpdatedAt          int64
+	CreatedBy          sql.NullInt64
+	UpdatedBy          sql.NullInt64
+	MaxVisitors        int64
+	MaxVisitorsPerUnit int64
+}
+
+type CondominiumMembership struct {
+	UserID        int64
+	CondominiumID int64
+	Role          string
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type Entry struct {
+	ID             int64
+	CondominiumID  int64
+	VisitID        sql.NullString
+	GuardID        sql.NullInt64
+	VisitorName    string
+	Accepted       bool
+	Reason         string
+	CreatedAt      int64
+	StationID      sql.NullInt64
+	ShiftID        sql.NullInt64
+	ExitedAt       sql.NullInt64
+	ExitedBy       sql.NullInt64
+	OverLimit      bool
+	OverrideOf     sql.NullInt64
+	OverrideReason string
+}
+
+type Gate struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	Driver        string
+	Target        string
+	HttpMethod    string
+	Payload       string
+	UnitID        int64
+	Coil          int64
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type GuardStation struct {
+	ID                     int64
+	CondominiumID          int64
+	Name                   string
+	GateID                 sql.NullInt64
+	CreatedAt              int64
+	CreatedBy              sql.NullInt64
+	WelfareIntervalMinutes int64
+	WelfareGraceMinutes    int64
+}
+
+type Incident struct {
+	ID            int64
+	CondominiumID int64
+	Category      string
+	Severity      string
+	Location      string
+	Description   string
+	Status        string
+	ReportedBy    sql.NullInt64
+	CreatedAt     int64
+	UpdatedAt     int64
+	ShiftID       sql.NullInt64
+}
+
+type IncidentAttachment struct {
+	ID          int64
+	IncidentID  int64
+	Filename    string
+	ContentType string
+	Size        int64
+	Data        []byte
+	CreatedAt   int64
+}
+
+type IncidentComment struct {
+	ID         int64
+	IncidentID int64
+	AuthorID   sql.NullInt64
+	Body       string
+	Status     sql.NullString
+	CreatedAt  int64
+}
+
+type IncidentResident struct {
+	IncidentID int64
+	UserID     int64
+}
+
+type IncidentVisit struct {
+	IncidentID int64
+	VisitID    string
+}
+
+type LoginThrottle struct {
+	Kind          string
+	Subject       string
+	Failures      int64
+	LastFailureAt int64
+	LockedUntil   int64
+}
+
+type Notification struct {
+	ID            int64
+	UserID        int64
+	CondominiumID int64
+	Event         string
+	Title         string
+	Body          string
+	CreatedAt     int64
+	ReadAt        sql.NullInt64
+}
+
+type NotificationDelivery struct {
+	ID            int64
+	UserID        int64
+	Channel       string
+	Recipient     string
+	Subject       string
+	Body          string
+	Status        string
+	Attempts      int64
+	NextAttemptAt int64
+	LastAttemptAt sql.NullInt64
+	LastError     sql.NullString
+	CreatedAt     int64
+	Url           string
+	Actions       string
+}
+
+type NotificationPreference struct {
+	UserID   int64
+	Event    string
+	Channels string
+}
+
+type NotificationQuietHour struct {
+	UserID      int64
+	StartMinute int64
+	EndMinute   int64
+}
+
+type Parcel struct {
+	ID               int64
+	CondominiumID    int64
+	ResidentID       sql.NullInt64
+	Tower            string
+	Unit             string
+	Carrier          string
+	TrackingNumber   string
+	Size             string
+	PickupCode       string
+	ReceivedBy       sql.NullInt64
+	AuthorizedAt     sql.NullInt64
+	CollectedAt      sql.NullInt64
+	CollectedBy      sql.NullString
+	HandedOverBy     sql.NullInt64
+	ShiftID          sql.NullInt64
+	CollectedShiftID sql.NullInt64
+	CreatedAt        int64
+}
+
+type ParcelPhoto struct {
+	ParcelID    int64
+	ContentType string
+	Data        []byte
+}
+
+type PatrolCheckpoint struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	Code          string
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type PatrolRound struct {
+	ID            int64
+	CondominiumID int64
+	RouteID       sql.NullInt64
+	RouteName     string
+	ShiftID       int64
+	DueAt         int64
+	Status        string
+	Checkpoints   int64
+	Scanned       int64
+	Missing       string
+	EvaluatedAt   int64
+}
+
+type PatrolRoute struct {
+	ID            int64
+	CondominiumID int64
+	Name          string
+	StationID     sql.NullInt64
+	StartMinute   int64
+	EndMinute     int64
+	EveryMinutes  int64
+	WindowMinutes int64
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type PatrolRouteCheckpoint struct {
+	RouteID      int64
+	CheckpointID int64
+	Position     int64
+}
+
+type PatrolScan struct {
+	ID            int64
+	CondominiumID int64
+	CheckpointID  int64
+	GuardID       sql.NullInt64
+	ShiftID       int64
+	ScannedAt     int64
+}
+
+type PermissionOverride struct {
+	CondominiumID int64
+	Role          string
+	Permission    string
+	Granted       bool
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type PushSubscription struct {
 	ID        int64
-	Name      string
-	Address   string
+	UserID    int64
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
 	CreatedAt int64
-	UpdatedAt int64
-	CreatedBy sql.NullInt64
-	UpdatedBy sql.NullInt64
+}
+
+type RecoveryCode struct {
+	ID        int64
+	UserID    int64
+	CodeHash  string
+	UsedAt    sql.NullInt64
+	CreatedAt int64
+}
+
+type Reservation struct {
+	ID            int64
+	CondominiumID int64
+	AmenityID     int64
+	ResidentID    int64
+	StartsAt      int64
+	EndsAt        int64
+	Status        string
+	DecidedBy     sql.NullInt64
+	DecidedAt     sql.NullInt64
+	CreatedAt     int64
+}
+
+type ReservationGuest struct {
+	ID            int64
+	ReservationID int64
+	Name          string
+	VisitID       sql.NullString
+}
+
+type Session struct {
+	ID         int64
+	TokenHash  string
+	UserID     sql.NullInt64
+	Data       []byte
+	UserAgent  string
+	Ip         string
+	CreatedAt  int64
+	LastSeenAt int64
+	ExpiresAt  int64
+}
+
+type Shift struct {
+	ID                 int64
+	CondominiumID      int64
+	GuardID            sql.NullInt64
+	StationID          sql.NullInt64
+	StartedAt          int64
+	EndedAt            sql.NullInt64
+	HandoverNotes      string
+	Logbook            sql.NullString
+	WelfareCheckedAt   sql.NullInt64
+	WelfareEscalatedAt sql.NullInt64
+}
+
+type TwoFactorRequirement struct {
+	ID            int64
+	CondominiumID sql.NullInt64
+	Role          string
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
 }
 
 type User struct {
@@ -41,6 +419,17 @@ type User struct {
 	UpdatedAt     int64
 	CreatedBy     sql.NullInt64
 	UpdatedBy     sql.NullInt64
+	TotpSecret    sql.NullString
+	TotpEnabled   bool
+	TotpLastStep  int64
+	Tower         string
+	Unit          string
+}
+
+type VapidKey struct {
+	ID         int64
+	PrivateKey []byte
+	CreatedAt  int64
 }
 
 type Visit struct {
@@ -54,4 +443,42 @@ type Visit struct {
 	ValidTo       int64
 	CreatedAt     int64
 	UpdatedAt     int64
+	RevokedAt     sql.NullInt64
+	RevokedBy     sql.NullInt64
+}
+
+type WalkIn struct {
+	ID            int64
+	CondominiumID int64
+	ResidentID    int64
+	VisitorName   string
+	AnnouncedBy   sql.NullInt64
+	Decision      sql.NullString
+	DecidedAt     sql.NullInt64
+	CreatedAt     int64
+}
+
+type Webhook struct {
+	ID            int64
+	CondominiumID int64
+	Url           string
+	Secret        string
+	Events        string
+	CreatedAt     int64
+	CreatedBy     sql.NullInt64
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        string
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  int64
+	LastAttemptAt  sql.NullInt64
+	ResponseStatus sql.NullInt64
+	LastError      sql.NullString
+	CreatedAt      int64
 }
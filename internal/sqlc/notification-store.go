@@ -0,0 +1,202 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) NotificationCreate(
+	ctx context.Context, n *entry.Notification,
+) (*entry.Notification, error) {
+	row, err := s.CreateNotification(ctx, CreateNotificationParams{
+		UserID:        n.UserID,
+		CondominiumID: n.CondominiumID,
+		Event:         string(n.Event),
+		Title:         n.Title,
+		Body:          n.Body,
+		CreatedAt:     n.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// NotificationList returns the newest notifications of the user.
+func (s *Store) NotificationList(
+	ctx context.Context, userID int64, limit int64,
+) ([]entry.Notification, error) {
+	rows, err := s.ListNotifications(ctx, ListNotificationsParams{
+		UserID: userID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]entry.Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, row.unmarshall())
+	}
+	return notifications, nil
+}
+
+func (s *Store) NotificationUnreadCount(ctx context.Context, userID int64) (int64, error) {
+	return s.CountUnreadNotifications(ctx, userID)
+}
+
+// NotificationMarkRead marks a notification of the user as read, or all of
+// them if id is zero. Notifications of other users are left as they are.
+func (s *Store) NotificationMarkRead(
+	ctx context.Context, userID int64, id int64, at time.Time,
+) error {
+	if id == 0 {
+		return s.MarkAllNotificationsRead(ctx, MarkAllNotificationsReadParams{
+			ReadAt: nullTime(at),
+			UserID: userID,
+		})
+	}
+	return s.MarkNotificationRead(ctx, MarkNotificationReadParams{
+		ReadAt: nullTime(at),
+		UserID: userID,
+		ID:     id,
+	})
+}
+
+// NotificationPreferencesGet returns the saved preferences of the user, over
+// the default ones.
+func (s *Store) NotificationPreferencesGet(
+	ctx context.Context, userID int64,
+) (*entry.NotificationPreferences, error) {
+	prefs := entry.DefaultNotificationPreferences(userID)
+
+	rows, err := s.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		var channels []entry.NotificationChannel
+		for _, channel := range strings.Fields(row.Channels) {
+			channels = append(channels, entry.NotificationChannel(channel))
+		}
+		prefs.Channels[entry.NotificationEvent(row.Event)] = channels
+	}
+
+	quiet, err := s.GetNotificationQuietHours(ctx, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		prefs.QuietHours = true
+		prefs.QuietStart = quiet.StartMinute
+		prefs.QuietEnd = quiet.EndMinute
+	}
+
+	return prefs, nil
+}
+
+// NotificationPreferencesSave replaces the preferences of the user.
+func (s *Store) NotificationPreferencesSave(
+	ctx context.Context, prefs *entry.NotificationPreferences,
+) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		if err := q.DeleteNotificationPreferences(ctx, prefs.UserID); err != nil {
+			return err
+		}
+		for event, channels := range prefs.Channels {
+			names := make([]string, 0, len(channels))
+			for _, channel := range channels {
+				names = append(names, string(channel))
+			}
+			err := q.CreateNotificationPreference(ctx, CreateNotificationPreferenceParams{
+				UserID:   prefs.UserID,
+				Event:    string(event),
+				Channels: strings.Join(names, " "),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := q.DeleteNotificationQuietHours(ctx, prefs.UserID); err != nil {
+			return err
+		}
+		if !prefs.QuietHours {
+			return nil
+		}
+		return q.CreateNotificationQuietHours(ctx, CreateNotificationQuietHoursParams{
+			UserID:      prefs.UserID,
+			StartMinute: prefs.QuietStart,
+			EndMinute:   prefs.QuietEnd,
+		})
+	})
+}
+
+func (s *Store) NotificationDeliveryCreate(
+	ctx context.Context, d *entry.NotificationDelivery,
+) (*entry.NotificationDelivery, error) {
+	actions, err := json.Marshal(d.Actions)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.CreateNotificationDelivery(ctx, CreateNotificationDeliveryParams{
+		UserID:        d.UserID,
+		Channel:       string(d.Channel),
+		Recipient:     d.Recipient,
+		Subject:       d.Subject,
+		Body:          d.Body,
+		Url:           d.URL,
+		Actions:       string(actions),
+		Status:        string(d.Status),
+		NextAttemptAt: d.NextAttemptAt.Unix(),
+		CreatedAt:     d.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// NotificationDeliveryDue returns pending deliveries whose next attempt is
+// not after now, oldest first.
+func (s *Store) NotificationDeliveryDue(
+	ctx context.Context, now time.Time, limit int64,
+) ([]entry.NotificationDelivery, error) {
+	rows, err := s.ListDueNotificationDeliveries(ctx, ListDueNotificationDeliveriesParams{
+		NextAttemptAt: now.Unix(),
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]entry.NotificationDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.unmarshall())
+	}
+	return deliveries, nil
+}
+
+func (s *Store) NotificationDeliveryUpdate(
+	ctx context.Context, d *entry.NotificationDelivery,
+) error {
+	return s.UpdateNotificationDelivery(ctx, UpdateNotificationDeliveryParams{
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt.Unix(),
+		LastAttemptAt: nullTime(d.LastAttemptAt),
+		LastError:     nullString(d.LastError),
+		ID:            d.ID,
+	})
+}
@@ -0,0 +1,40 @@
+package sqlc
+
+import (
+	"database/sql"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database of dataSourceName. Unless it says
+// otherwise, transactions take the write lock when they begin
+// (_txlock=immediate) and connections wait up to 5 seconds for it
+// (busy_timeout). Transactions of the Store read before they write, and
+// SQLite fails the second of two such transactions that run at the same
+// time instead of waiting, if they begin deferred.
+func Open(dataSourceName string) (*sql.DB, error) {
+	_, query, _ := strings.Cut(dataSourceName, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaults []string
+	if !params.Has("_txlock") {
+		defaults = append(defaults, "_txlock=immediate")
+	}
+	if !strings.Contains(query, "busy_timeout") {
+		defaults = append(defaults, "_pragma=busy_timeout(5000)")
+	}
+	if len(defaults) > 0 {
+		separator := "?"
+		if strings.Contains(dataSourceName, "?") {
+			separator = "&"
+		}
+		dataSourceName += separator + strings.Join(defaults, "&")
+	}
+
+	return sql.Open("sqlite", dataSourceName)
+}
@@ -0,0 +1,192 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// ParcelCreate saves the parcel and its photo, if there is one, together.
+func (s *Store) ParcelCreate(
+	ctx context.Context, parcel *entry.Parcel, photo *entry.ParcelPhoto,
+) (*entry.Parcel, error) {
+	var id int64
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		var err error
+		id, err = q.CreateParcel(ctx, CreateParcelParams{
+			CondominiumID:  parcel.CondominiumID,
+			ResidentID:     nullInt64(parcel.ResidentID),
+			Tower:          parcel.Unit.Tower,
+			Unit:           parcel.Unit.Number,
+			Carrier:        parcel.Carrier,
+			TrackingNumber: parcel.TrackingNumber,
+			Size:           string(parcel.Size),
+			PickupCode:     parcel.PickupCode,
+			ReceivedBy:     nullInt64(parcel.ReceivedBy),
+			ShiftID:        nullInt64(parcel.ShiftID),
+			CreatedAt:      parcel.CreatedAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		if photo == nil {
+			return nil
+		}
+		return q.CreateParcelPhoto(ctx, CreateParcelPhotoParams{
+			ParcelID:    id,
+			ContentType: photo.ContentType,
+			Data:        photo.Data,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ParcelGetByID(ctx, id)
+}
+
+func (s *Store) ParcelGetByID(ctx context.Context, id int64) (*entry.Parcel, error) {
+	row, err := s.GetParcelByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Paquete no encontrado")
+		}
+		return nil, err
+	}
+
+	parcel := row.unmarshall()
+	return &parcel, nil
+}
+
+// ParcelListPending lists the parcels of a condominium that haven't been
+// collected, oldest first.
+func (s *Store) ParcelListPending(ctx context.Context, condoID int64) ([]entry.Parcel, error) {
+	rows, err := s.ListPendingParcelsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]entry.Parcel, 0, len(rows))
+	for _, row := range rows {
+		parcels = append(parcels, GetParcelByIDRow(row).unmarshall())
+	}
+	return parcels, nil
+}
+
+// ParcelListCollected lists the parcels of a condominium collected since a
+// time, latest first.
+func (s *Store) ParcelListCollected(
+	ctx context.Context, condoID int64, since time.Time,
+) ([]entry.Parcel, error) {
+	rows, err := s.ListCollectedParcelsByCondominium(ctx, ListCollectedParcelsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         nullTime(since),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]entry.Parcel, 0, len(rows))
+	for _, row := range rows {
+		parcels = append(parcels, GetParcelByIDRow(row).unmarshall())
+	}
+	return parcels, nil
+}
+
+// ParcelListForResident lists the parcels of a resident, and of the unit it
+// lives in, that haven't been collected, oldest first.
+func (s *Store) ParcelListForResident(
+	ctx context.Context, condoID int64, residentID int64, unit entry.Unit,
+) ([]entry.Parcel, error) {
+	rows, err := s.ListPendingParcelsForResident(ctx, ListPendingParcelsForResidentParams{
+		CondominiumID: condoID,
+		ResidentID:    nullInt64(residentID),
+		Tower:         unit.Tower,
+		Unit:          unit.Number,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]entry.Parcel, 0, len(rows))
+	for _, row := range rows {
+		parcels = append(parcels, GetParcelByIDRow(row).unmarshall())
+	}
+	return parcels, nil
+}
+
+func (s *Store) ParcelListReceivedByShift(
+	ctx context.Context, shiftID int64,
+) ([]entry.Parcel, error) {
+	rows, err := s.ListParcelsReceivedByShift(ctx, nullInt64(shiftID))
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]entry.Parcel, 0, len(rows))
+	for _, row := range rows {
+		parcels = append(parcels, GetParcelByIDRow(row).unmarshall())
+	}
+	return parcels, nil
+}
+
+func (s *Store) ParcelListCollectedByShift(
+	ctx context.Context, shiftID int64,
+) ([]entry.Parcel, error) {
+	rows, err := s.ListParcelsCollectedByShift(ctx, nullInt64(shiftID))
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]entry.Parcel, 0, len(rows))
+	for _, row := range rows {
+		parcels = append(parcels, GetParcelByIDRow(row).unmarshall())
+	}
+	return parcels, nil
+}
+
+func (s *Store) ParcelAuthorize(ctx context.Context, id int64, at time.Time) error {
+	updated, err := s.AuthorizeParcel(ctx, AuthorizeParcelParams{
+		AuthorizedAt: nullTime(at),
+		ID:           id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewUserSafeError("Este paquete ya fue entregado")
+	}
+	return nil
+}
+
+func (s *Store) ParcelCollect(
+	ctx context.Context, id int64, collectedBy string, guardID, shiftID int64, at time.Time,
+) error {
+	updated, err := s.CollectParcel(ctx, CollectParcelParams{
+		CollectedAt:      nullTime(at),
+		CollectedBy:      nullString(collectedBy),
+		HandedOverBy:     nullInt64(guardID),
+		CollectedShiftID: nullInt64(shiftID),
+		ID:               id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewUserSafeError("Este paquete ya fue entregado")
+	}
+	return nil
+}
+
+func (s *Store) ParcelPhotoGet(ctx context.Context, parcelID int64) (*entry.ParcelPhoto, error) {
+	row, err := s.GetParcelPhoto(ctx, parcelID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Foto no encontrada")
+		}
+		return nil, err
+	}
+	return &entry.ParcelPhoto{ContentType: row.ContentType, Data: row.Data}, nil
+}
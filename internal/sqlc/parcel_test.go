@@ -0,0 +1,214 @@
+package sqlc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// parcelTest is a condominium with a guard, two residents of unit A - 302
+// and one of unit B - 101.
+type parcelTest struct {
+	app   *entry.App
+	store *Store
+	guard context.Context
+	// residents are, in order, the two residents of A - 302 and the one of
+	// B - 101.
+	residents   []context.Context
+	residentIDs []int64
+}
+
+var (
+	unitA302 = entry.Unit{Tower: "A", Number: "302"}
+	unitB101 = entry.Unit{Tower: "B", Number: "101"}
+)
+
+func newParcelTest(t *testing.T) *parcelTest {
+	t.Helper()
+	ctx := context.Background()
+	store := newTestStore(t)
+	users := NewUserStore(store.db)
+	now := time.Now()
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	test := &parcelTest{
+		app: entry.NewApp(
+			logger,
+			store,
+			entry.NewAuditLogger(store, logger),
+			entry.NewNotifier(store, store, logger),
+			entry.NewHub(),
+		),
+		store: store,
+	}
+	create := func(email string, role entry.UserRole, unit entry.Unit) context.Context {
+		user, err := users.CreateUser(ctx, &auth.User{
+			CondominiumID: condo.ID,
+			FirstName:     "Test",
+			LastName:      email,
+			Email:         email,
+			Role:          role,
+			Enabled:       true,
+		}, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = store.UserUpdate(ctx, user.ID, func(u *entry.UserProfile) (*entry.UserProfile, error) {
+			u.Unit = unit
+			return u, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if role == entry.RoleUser {
+			test.residentIDs = append(test.residentIDs, user.ID)
+		}
+		return entry.WithUser(ctx, &entry.User{
+			ID:            user.ID,
+			CondominiumID: condo.ID,
+			Role:          role,
+			Enabled:       true,
+		})
+	}
+	test.guard = create("guardia@example.com", entry.RoleGuardian, entry.Unit{})
+	for _, r := range []struct {
+		email string
+		unit  entry.Unit
+	}{
+		{"vecina@example.com", unitA302},
+		{"vecino@example.com", unitA302},
+		{"beto@example.com", unitB101},
+	} {
+		test.residents = append(test.residents, create(r.email, entry.RoleUser, r.unit))
+	}
+	return test
+}
+
+func (p *parcelTest) receive(t *testing.T, parcel entry.Parcel) *entry.Parcel {
+	t.Helper()
+	parcel.Carrier = "DHL"
+	parcel.Size = entry.ParcelSmall
+	created, err := p.app.ReceiveParcel(p.guard, parcel, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// pending counts the parcels each resident sees.
+func (p *parcelTest) pending(t *testing.T) []int {
+	t.Helper()
+	counts := make([]int, 0, len(p.residents))
+	for _, ctx := range p.residents {
+		parcels, err := p.app.PendingParcels(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, len(parcels))
+	}
+	return counts
+}
+
+// notified counts the notifications each resident got.
+func (p *parcelTest) notified(t *testing.T) []int {
+	t.Helper()
+	counts := make([]int, 0, len(p.residentIDs))
+	for _, id := range p.residentIDs {
+		notifications, err := p.store.NotificationList(context.Background(), id, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, len(notifications))
+	}
+	return counts
+}
+
+func TestParcelForUnit(t *testing.T) {
+	p := newParcelTest(t)
+
+	parcel := p.receive(t, entry.Parcel{Unit: unitA302})
+	if parcel.ResidentID != 0 || parcel.Unit != unitA302 {
+		t.Fatalf("parcel for %d, %v; want unit %v only", parcel.ResidentID, parcel.Unit, unitA302)
+	}
+	if got := p.pending(t); got[0] != 1 || got[1] != 1 || got[2] != 0 {
+		t.Errorf("pending parcels = %v, want [1 1 0]", got)
+	}
+	if got := p.notified(t); got[0] != 1 || got[1] != 1 || got[2] != 0 {
+		t.Errorf("notifications = %v, want [1 1 0]", got)
+	}
+
+	var notFound *entry.NotFoundError
+	if err := p.app.AuthorizeParcelPickup(p.residents[2], parcel.ID); !errors.As(err, &notFound) {
+		t.Errorf("another unit authorizing = %v, want a NotFoundError", err)
+	}
+	if err := p.app.AuthorizeParcelPickup(p.residents[1], parcel.ID); err != nil {
+		t.Fatalf("a resident of the unit authorizing = %v", err)
+	}
+	if err := p.app.CollectParcel(p.guard, parcel.ID, "", "Juan"); err != nil {
+		t.Fatalf("collecting an authorized parcel = %v", err)
+	}
+	if got := p.notified(t); got[0] != 2 || got[1] != 2 || got[2] != 0 {
+		t.Errorf("notifications after the pickup = %v, want [2 2 0]", got)
+	}
+}
+
+func TestParcelForResident(t *testing.T) {
+	p := newParcelTest(t)
+
+	// The parcel of a resident is for its unit too, so its neighbor can
+	// collect it with the code.
+	parcel := p.receive(t, entry.Parcel{ResidentID: p.residentIDs[0]})
+	if parcel.Unit != unitA302 {
+		t.Errorf("unit = %v, want %v", parcel.Unit, unitA302)
+	}
+	if got := p.pending(t); got[0] != 1 || got[1] != 1 || got[2] != 0 {
+		t.Errorf("pending parcels = %v, want [1 1 0]", got)
+	}
+
+	var safe entry.UserSafeError
+	if err := p.app.CollectParcel(p.guard, parcel.ID, "", "Juan"); !errors.As(err, &safe) {
+		t.Errorf("collecting without the code = %v, want a UserSafeError", err)
+	}
+	if err := p.app.CollectParcel(p.guard, parcel.ID, parcel.PickupCode, "Juan"); err != nil {
+		t.Errorf("collecting with the code = %v", err)
+	}
+	if got := p.pending(t); got[0] != 0 || got[1] != 0 {
+		t.Errorf("pending parcels after the pickup = %v, want none", got)
+	}
+}
+
+func TestReceiveParcelRecipient(t *testing.T) {
+	p := newParcelTest(t)
+
+	tests := []struct {
+		name   string
+		parcel entry.Parcel
+	}{
+		{"nobody", entry.Parcel{}},
+		{"resident of another unit", entry.Parcel{ResidentID: p.residentIDs[0], Unit: unitB101}},
+		{"unit without residents", entry.Parcel{Unit: entry.Unit{Tower: "C", Number: "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parcel.Carrier = "DHL"
+			tt.parcel.Size = entry.ParcelSmall
+			_, err := p.app.ReceiveParcel(p.guard, tt.parcel, nil)
+			var safe entry.UserSafeError
+			if !errors.As(err, &safe) {
+				t.Errorf("ReceiveParcel() = %v, want a UserSafeError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) PatrolCheckpointCreate(
+	ctx context.Context, checkpoint *entry.PatrolCheckpoint,
+) (*entry.PatrolCheckpoint, error) {
+	row, err := s.CreatePatrolCheckpoint(ctx, CreatePatrolCheckpointParams{
+		CondominiumID: checkpoint.CondominiumID,
+		Name:          checkpoint.Name,
+		Code:          checkpoint.Code,
+		CreatedAt:     checkpoint.CreatedAt.Unix(),
+		CreatedBy:     nullInt64(checkpoint.CreatedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+func (s *Store) PatrolCheckpointGetByID(
+	ctx context.Context, id int64,
+) (*entry.PatrolCheckpoint, error) {
+	row, err := s.GetPatrolCheckpointByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Punto de control no encontrado")
+		}
+		return nil, err
+	}
+
+	checkpoint := row.unmarshall()
+	return &checkpoint, nil
+}
+
+func (s *Store) PatrolCheckpointGetByCode(
+	ctx context.Context, code string,
+) (*entry.PatrolCheckpoint, error) {
+	row, err := s.GetPatrolCheckpointByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Punto de control no encontrado")
+		}
+		return nil, err
+	}
+
+	checkpoint := row.unmarshall()
+	return &checkpoint, nil
+}
+
+func (s *Store) PatrolCheckpointList(
+	ctx context.Context, condoID int64,
+) ([]entry.PatrolCheckpoint, error) {
+	rows, err := s.ListPatrolCheckpointsByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]entry.PatrolCheckpoint, 0, len(rows))
+	for _, row := range rows {
+		checkpoints = append(checkpoints, row.unmarshall())
+	}
+	return checkpoints, nil
+}
+
+func (s *Store) PatrolCheckpointDelete(ctx context.Context, id int64) error {
+	return s.DeletePatrolCheckpoint(ctx, id)
+}
+
+func (s *Store) PatrolRouteCreate(
+	ctx context.Context, route *entry.PatrolRoute,
+) (*entry.PatrolRoute, error) {
+	var id int64
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		var err error
+		id, err = q.CreatePatrolRoute(ctx, CreatePatrolRouteParams{
+			CondominiumID: route.CondominiumID,
+			Name:          route.Name,
+			StationID:     nullInt64(route.StationID),
+			StartMinute:   route.StartMinute,
+			EndMinute:     route.EndMinute,
+			EveryMinutes:  route.EveryMinutes,
+			WindowMinutes: route.WindowMinutes,
+			CreatedAt:     route.CreatedAt.Unix(),
+			CreatedBy:     nullInt64(route.CreatedBy),
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, checkpoint := range route.Checkpoints {
+			err := q.AddPatrolRouteCheckpoint(ctx, AddPatrolRouteCheckpointParams{
+				RouteID:      id,
+				CheckpointID: checkpoint.ID,
+				Position:     int64(i),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.PatrolRouteGetByID(ctx, id)
+}
+
+func (s *Store) PatrolRouteGetByID(ctx context.Context, id int64) (*entry.PatrolRoute, error) {
+	row, err := s.GetPatrolRouteByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Ronda no encontrada")
+		}
+		return nil, err
+	}
+
+	route := row.unmarshall()
+	if err := s.loadPatrolRouteCheckpoints(ctx, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+func (s *Store) PatrolRouteList(ctx context.Context, condoID int64) ([]entry.PatrolRoute, error) {
+	rows, err := s.ListPatrolRoutesByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]entry.PatrolRoute, 0, len(rows))
+	for _, row := range rows {
+		route := GetPatrolRouteByIDRow(row).unmarshall()
+		if err := s.loadPatrolRouteCheckpoints(ctx, &route); err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (s *Store) PatrolRouteListAll(ctx context.Context) ([]entry.PatrolRoute, error) {
+	rows, err := s.ListPatrolRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]entry.PatrolRoute, 0, len(rows))
+	for _, row := range rows {
+		route := GetPatrolRouteByIDRow(row).unmarshall()
+		if err := s.loadPatrolRouteCheckpoints(ctx, &route); err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (s *Store) loadPatrolRouteCheckpoints(ctx context.Context, route *entry.PatrolRoute) error {
+	rows, err := s.ListPatrolRouteCheckpoints(ctx, route.ID)
+	if err != nil {
+		return err
+	}
+
+	route.Checkpoints = make([]entry.PatrolCheckpoint, 0, len(rows))
+	for _, row := range rows {
+		route.Checkpoints = append(route.Checkpoints, row.unmarshall())
+	}
+	return nil
+}
+
+func (s *Store) PatrolRouteDelete(ctx context.Context, id int64) error {
+	return s.DeletePatrolRoute(ctx, id)
+}
+
+func (s *Store) PatrolScanCreate(
+	ctx context.Context, scan *entry.PatrolScan,
+) (*entry.PatrolScan, error) {
+	id, err := s.CreatePatrolScan(ctx, CreatePatrolScanParams{
+		CondominiumID: scan.CondominiumID,
+		CheckpointID:  scan.CheckpointID,
+		GuardID:       nullInt64(scan.GuardID),
+		ShiftID:       scan.ShiftID,
+		ScannedAt:     scan.ScannedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := *scan
+	created.ID = id
+	return &created, nil
+}
+
+func (s *Store) PatrolScanListByShift(
+	ctx context.Context, shiftID int64,
+) ([]entry.PatrolScan, error) {
+	rows, err := s.ListPatrolScansByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	scans := make([]entry.PatrolScan, 0, len(rows))
+	for _, row := range rows {
+		scans = append(scans, row.unmarshall())
+	}
+	return scans, nil
+}
+
+func (s *Store) PatrolRoundCreate(ctx context.Context, round *entry.PatrolRound) (bool, error) {
+	created, err := s.CreatePatrolRound(ctx, CreatePatrolRoundParams{
+		CondominiumID: round.CondominiumID,
+		RouteID:       nullInt64(round.RouteID),
+		RouteName:     round.RouteName,
+		ShiftID:       round.ShiftID,
+		DueAt:         round.DueAt.Unix(),
+		Status:        string(round.Status),
+		Checkpoints:   int64(round.Checkpoints),
+		Scanned:       int64(round.Scanned),
+		Missing:       strings.Join(round.Missing, "\n"),
+		EvaluatedAt:   round.EvaluatedAt.Unix(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return created > 0, nil
+}
+
+func (s *Store) PatrolRoundList(
+	ctx context.Context, condoID int64, since time.Time, until time.Time,
+) ([]entry.PatrolRound, error) {
+	rows, err := s.ListPatrolRoundsByCondominium(ctx, ListPatrolRoundsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+		Until:         until.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := make([]entry.PatrolRound, 0, len(rows))
+	for _, row := range rows {
+		rounds = append(rounds, row.unmarshall())
+	}
+	return rounds, nil
+}
@@ -0,0 +1,60 @@
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// PermissionOverrideList lists the permission overrides of a condominium.
+func (s *Store) PermissionOverrideList(
+	ctx context.Context, condoID int64,
+) ([]entry.PermissionOverride, error) {
+	return queryPermissionOverrides(ctx, s.Queries, condoID)
+}
+
+// PermissionOverrideReplace replaces the overrides of a condominium inside a
+// transaction.
+func (s *Store) PermissionOverrideReplace(
+	ctx context.Context,
+	condoID int64,
+	overrides []entry.PermissionOverride,
+	createdAt time.Time,
+	createdBy int64,
+) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		if err := q.DeletePermissionOverrides(ctx, condoID); err != nil {
+			return err
+		}
+		for _, o := range overrides {
+			err := q.CreatePermissionOverride(ctx, CreatePermissionOverrideParams{
+				CondominiumID: condoID,
+				Role:          string(o.Role),
+				Permission:    string(o.Permission),
+				Granted:       o.Granted,
+				CreatedAt:     createdAt.Unix(),
+				CreatedBy:     nullInt64(createdBy),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func queryPermissionOverrides(
+	ctx context.Context, q *Queries, condoID int64,
+) ([]entry.PermissionOverride, error) {
+	rows, err := q.ListPermissionOverrides(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make([]entry.PermissionOverride, 0, len(rows))
+	for _, row := range rows {
+		overrides = append(overrides, row.unmarshall())
+	}
+	return overrides, nil
+}
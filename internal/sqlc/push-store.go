@@ -0,0 +1,85 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) PushSubscriptionList(
+	ctx context.Context, userID int64,
+) ([]entry.PushSubscription, error) {
+	rows, err := s.ListPushSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]entry.PushSubscription, 0, len(rows))
+	for _, row := range rows {
+		subs = append(subs, row.unmarshall())
+	}
+	return subs, nil
+}
+
+func (s *Store) PushSubscriptionGetByID(
+	ctx context.Context, id int64,
+) (*entry.PushSubscription, error) {
+	row, err := s.GetPushSubscriptionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Dispositivo no encontrado")
+		}
+		return nil, err
+	}
+
+	sub := row.unmarshall()
+	return &sub, nil
+}
+
+// PushSubscriptionSave creates the subscription, or replaces the one with
+// the same endpoint.
+func (s *Store) PushSubscriptionSave(
+	ctx context.Context, sub *entry.PushSubscription,
+) (*entry.PushSubscription, error) {
+	row, err := s.SavePushSubscription(ctx, SavePushSubscriptionParams{
+		UserID:    sub.UserID,
+		Endpoint:  sub.Endpoint,
+		P256dh:    sub.P256DH,
+		Auth:      sub.Auth,
+		UserAgent: sub.UserAgent,
+		CreatedAt: sub.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	saved := row.unmarshall()
+	return &saved, nil
+}
+
+func (s *Store) PushSubscriptionDelete(ctx context.Context, id int64) error {
+	_, err := s.DeletePushSubscription(ctx, id)
+	return err
+}
+
+func (s *Store) VAPIDKeyGet(ctx context.Context) ([]byte, error) {
+	key, err := s.GetVAPIDKey(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("No hay clave VAPID")
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// VAPIDKeySave saves the key unless there already is one.
+func (s *Store) VAPIDKeySave(ctx context.Context, key []byte, createdAt time.Time) error {
+	return s.SaveVAPIDKey(ctx, SaveVAPIDKeyParams{
+		PrivateKey: key,
+		CreatedAt:  createdAt.Unix(),
+	})
+}
@@ -0,0 +1,147 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// SessionStore persists the server side sessions.
+// This implements auth.SessionBackend interface.
+type SessionStore struct {
+	queries *Queries
+}
+
+// NewSessionStore creates a new SessionStore that wraps the SQLC queries.
+func NewSessionStore(db *sql.DB) *SessionStore {
+	return &SessionStore{
+		queries: New(db),
+	}
+}
+
+// GetSession retrieves an unexpired session by the hash of its token.
+// Implements auth.SessionBackend.
+func (s *SessionStore) GetSession(
+	ctx context.Context, tokenHash string, now time.Time,
+) (auth.SessionRecord, bool, error) {
+	session, err := s.queries.GetSessionByTokenHash(ctx, GetSessionByTokenHashParams{
+		TokenHash: tokenHash,
+		ExpiresAt: now.Unix(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.SessionRecord{}, false, nil
+		}
+		return auth.SessionRecord{}, false, err
+	}
+
+	return auth.SessionRecord{
+		Session:   session.unmarshall(),
+		TokenHash: session.TokenHash,
+		Data:      session.Data,
+	}, true, nil
+}
+
+// CreateSession stores a new session and returns its ID.
+// Implements auth.SessionBackend.
+func (s *SessionStore) CreateSession(ctx context.Context, record auth.SessionRecord) (int64, error) {
+	return s.queries.CreateSession(ctx, CreateSessionParams{
+		TokenHash:  record.TokenHash,
+		UserID:     nullInt64(record.UserID),
+		Data:       record.Data,
+		UserAgent:  record.UserAgent,
+		Ip:         record.IP,
+		CreatedAt:  record.CreatedAt.Unix(),
+		LastSeenAt: record.LastSeenAt.Unix(),
+		ExpiresAt:  record.ExpiresAt.Unix(),
+	})
+}
+
+// UpdateSession updates an unexpired session.
+// Implements auth.SessionBackend.
+func (s *SessionStore) UpdateSession(
+	ctx context.Context, record auth.SessionRecord, now time.Time,
+) (bool, error) {
+	affected, err := s.queries.UpdateSession(ctx, UpdateSessionParams{
+		UserID:     nullInt64(record.UserID),
+		Data:       record.Data,
+		UserAgent:  record.UserAgent,
+		Ip:         record.IP,
+		LastSeenAt: record.LastSeenAt.Unix(),
+		ExpiresAt:  record.ExpiresAt.Unix(),
+		TokenHash:  record.TokenHash,
+		Now:        now.Unix(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// TouchSession records activity on a session.
+// Implements auth.SessionBackend.
+func (s *SessionStore) TouchSession(
+	ctx context.Context, tokenHash string, ip string, now time.Time,
+) error {
+	return s.queries.TouchSession(ctx, TouchSessionParams{
+		LastSeenAt: now.Unix(),
+		Ip:         ip,
+		TokenHash:  tokenHash,
+	})
+}
+
+// DeleteSession deletes a session by the hash of its token.
+// Implements auth.SessionBackend.
+func (s *SessionStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	return s.queries.DeleteSessionByTokenHash(ctx, tokenHash)
+}
+
+// DeleteExpiredSessions deletes the sessions expired at now.
+// Implements auth.SessionBackend.
+func (s *SessionStore) DeleteExpiredSessions(ctx context.Context, now time.Time) error {
+	return s.queries.DeleteExpiredSessions(ctx, now.Unix())
+}
+
+// ListUserSessions lists the unexpired sessions of a user.
+// Implements auth.SessionBackend.
+func (s *SessionStore) ListUserSessions(
+	ctx context.Context, userID int64, now time.Time,
+) ([]auth.Session, error) {
+	rows, err := s.queries.ListUserSessions(ctx, ListUserSessionsParams{
+		UserID:    nullInt64(userID),
+		ExpiresAt: now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]auth.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, row.unmarshall())
+	}
+	return sessions, nil
+}
+
+// DeleteUserSession deletes a session if it belongs to the user.
+// Implements auth.SessionBackend.
+func (s *SessionStore) DeleteUserSession(
+	ctx context.Context, userID int64, sessionID int64,
+) (bool, error) {
+	affected, err := s.queries.DeleteUserSession(ctx, DeleteUserSessionParams{
+		ID:     sessionID,
+		UserID: nullInt64(userID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// DeleteUserSessions deletes every session of a user.
+// Implements auth.SessionBackend.
+func (s *SessionStore) DeleteUserSessions(ctx context.Context, userID int64) error {
+	return s.queries.DeleteUserSessions(ctx, nullInt64(userID))
+}
@@ -0,0 +1,87 @@
+package sqlc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// A request that loaded a session before it was revoked can't bring it
+// back when it saves it.
+func TestSessionSaveDoesNotReviveRevoked(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	backend := NewSessionStore(store.db)
+	sessionStore := auth.NewSessionStore(
+		backend, slog.New(slog.DiscardHandler), &sessions.Options{Path: "/", MaxAge: 60 * 60},
+		[]byte("0123456789abcdef0123456789abcdef"),
+	)
+
+	user, err := NewUserStore(store.db).CreateUser(ctx, &auth.User{
+		FirstName: "Ana",
+		LastName:  "Admin",
+		Email:     "ana@example.com",
+		Role:      entry.RoleSuperAdmin,
+		Enabled:   true,
+	}, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	s, err := sessionStore.Get(r, auth.SessionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Values["user_id"] = user.ID
+	if err := s.Save(r, rec); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rec.Result().Cookies()
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	s, err = sessionStore.Get(r, auth.SessionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.IsNew {
+		t.Fatal("the session wasn't loaded")
+	}
+	id := sessionStore.CurrentSessionID(r)
+	if id == 0 {
+		t.Fatal("the session has no ID")
+	}
+
+	if ok, err := sessionStore.RevokeSession(ctx, user.ID, id); err != nil || !ok {
+		t.Fatalf("RevokeSession() = %t, %v", ok, err)
+	}
+	rec = httptest.NewRecorder()
+	if err := s.Save(r, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := backend.ListUserSessions(ctx, user.ID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 0 {
+		t.Errorf("got %d sessions after revoking, want 0", len(active))
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == auth.SessionName && c.MaxAge >= 0 {
+			t.Errorf("the cookie of the revoked session was kept: %v", c)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) ShiftCreate(ctx context.Context, shift *entry.Shift) (*entry.Shift, error) {
+	id, err := s.CreateShift(ctx, CreateShiftParams{
+		CondominiumID: shift.CondominiumID,
+		GuardID:       nullInt64(shift.GuardID),
+		StationID:     nullInt64(shift.StationID),
+		StartedAt:     shift.StartedAt.Unix(),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, entry.NewUserSafeError("Ya tienes un turno abierto")
+		}
+		return nil, err
+	}
+	return s.ShiftGetByID(ctx, id)
+}
+
+func (s *Store) ShiftGetByID(ctx context.Context, id int64) (*entry.Shift, error) {
+	row, err := s.GetShiftByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Turno no encontrado")
+		}
+		return nil, err
+	}
+
+	shift := row.unmarshall()
+	return &shift, nil
+}
+
+func (s *Store) ShiftGetOpen(ctx context.Context, guardID int64) (*entry.Shift, error) {
+	row, err := s.GetOpenShiftByGuard(ctx, nullInt64(guardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("No tienes un turno abierto")
+		}
+		return nil, err
+	}
+
+	shift := GetShiftByIDRow(row).unmarshall()
+	return &shift, nil
+}
+
+func (s *Store) ShiftListClosed(
+	ctx context.Context, condoID int64, limit int64,
+) ([]entry.Shift, error) {
+	rows, err := s.ListClosedShiftsByCondominium(ctx, ListClosedShiftsByCondominiumParams{
+		CondominiumID: condoID,
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := make([]entry.Shift, 0, len(rows))
+	for _, row := range rows {
+		shifts = append(shifts, GetShiftByIDRow(row).unmarshall())
+	}
+	return shifts, nil
+}
+
+func (s *Store) ShiftClose(
+	ctx context.Context, id int64, at time.Time, notes string, logbook *entry.Logbook,
+) error {
+	encoded, err := json.Marshal(logbook)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.CloseShift(ctx, CloseShiftParams{
+		EndedAt:       nullTime(at),
+		HandoverNotes: notes,
+		Logbook:       nullString(string(encoded)),
+		ID:            id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewUserSafeError("Este turno ya fue cerrado")
+	}
+	return nil
+}
+
+func (s *Store) ShiftListOverlapping(
+	ctx context.Context, condoID int64, since time.Time, until time.Time,
+) ([]entry.Shift, error) {
+	rows, err := s.ListShiftsOverlapping(ctx, ListShiftsOverlappingParams{
+		CondominiumID: condoID,
+		Until:         until.Unix(),
+		Since:         nullTime(since),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := make([]entry.Shift, 0, len(rows))
+	for _, row := range rows {
+		shifts = append(shifts, GetShiftByIDRow(row).unmarshall())
+	}
+	return shifts, nil
+}
+
+func (s *Store) ShiftListWelfareOverdue(ctx context.Context, at time.Time) ([]entry.Shift, error) {
+	rows, err := s.ListWelfareOverdueShifts(ctx, nullTime(at))
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := make([]entry.Shift, 0, len(rows))
+	for _, row := range rows {
+		shifts = append(shifts, GetShiftByIDRow(row).unmarshall())
+	}
+	return shifts, nil
+}
+
+func (s *Store) ShiftEscalateWelfare(ctx context.Context, id int64, at time.Time) (bool, error) {
+	updated, err := s.EscalateShiftWelfare(ctx, EscalateShiftWelfareParams{
+		WelfareEscalatedAt: nullTime(at),
+		ID:                 id,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+func (s *Store) ShiftConfirmWelfare(ctx context.Context, id int64, at time.Time) error {
+	updated, err := s.ConfirmShiftWelfare(ctx, ConfirmShiftWelfareParams{
+		WelfareCheckedAt: nullTime(at),
+		ID:               id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewUserSafeError("Este turno ya fue cerrado")
+	}
+	return nil
+}
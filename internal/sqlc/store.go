@@ -3,6 +3,8 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
 )
@@ -17,44 +19,244 @@ type Store struct {
 func NewStore(db *sql.DB) *Store {
 	return &Store{
 		db:      db,
-		Queries: New(db),
+		Queries: New(ctxDB{db}),
 	}
 }
 
 // VisitGetByID retrieves a visit by its ID.
 func (s *Store) VisitGetByID(ctx context.Context, id string) (*entry.Visit, error) {
-	panic("Not implemented")
+	row, err := s.GetVisitByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Visita no encontrada")
+		}
+		return nil, err
+	}
+
+	visit := row.unmarshall()
+	return &visit, nil
 }
 
 // VisitCreate creates a new visit.
 func (s *Store) VisitCreate(ctx context.Context, visit *entry.Visit) (*entry.Visit, error) {
-	panic("Not implemented")
+	row, err := s.CreateVisit(ctx, createVisitParams(visit))
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+func createVisitParams(visit *entry.Visit) CreateVisitParams {
+	return CreateVisitParams{
+		ID:            visit.ID,
+		CondominiumID: visit.CondominiumID,
+		UserID:        visit.UserID,
+		VisitorName:   visit.VisitorName,
+		MaxUses:       visit.MaxUses,
+		Uses:          visit.Uses,
+		ValidFrom:     visit.ValidFrom.Unix(),
+		ValidTo:       visit.ValidTo.Unix(),
+		CreatedAt:     visit.CreatedAt.Unix(),
+		UpdatedAt:     visit.UpdatedAt.Unix(),
+	}
 }
 
-// VisitUpdate updates an existing visit.
+// VisitUpdate updates an existing visit inside a transaction.
 func (s *Store) VisitUpdate(
 	ctx context.Context,
 	id string,
 	updateFn func(visit *entry.Visit) (*entry.Visit, error),
 ) error {
-	panic("Not implemented")
+	return withTx(ctx, s.db, func(q *Queries) error {
+		row, err := q.GetVisitByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entry.NewNotFoundError("Visita no encontrada")
+			}
+			return err
+		}
+
+		visit := row.unmarshall()
+		updated, err := updateFn(&visit)
+		if err != nil {
+			return err
+		}
+
+		return q.UpdateVisit(ctx, UpdateVisitParams{
+			VisitorName: updated.VisitorName,
+			MaxUses:     updated.MaxUses,
+			Uses:        updated.Uses,
+			ValidFrom:   updated.ValidFrom.Unix(),
+			ValidTo:     updated.ValidTo.Unix(),
+			RevokedAt:   nullTime(updated.RevokedAt),
+			RevokedBy:   nullInt64(updated.RevokedBy),
+			UpdatedAt:   updated.UpdatedAt.Unix(),
+			ID:          id,
+		})
+	})
+}
+
+// VisitListByUser lists the visits created by a user, latest first.
+func (s *Store) VisitListByUser(ctx context.Context, userID int64) ([]entry.Visit, error) {
+	rows, err := s.ListVisitsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visits := make([]entry.Visit, 0, len(rows))
+	for _, row := range rows {
+		visits = append(visits, row.unmarshall())
+	}
+	return visits, nil
+}
+
+// VisitListExpected lists the visits of a condominium usable between since
+// and until, by visitor name.
+func (s *Store) VisitListExpected(
+	ctx context.Context, condoID int64, since time.Time, until time.Time,
+) ([]entry.Visit, error) {
+	rows, err := s.ListExpectedVisitsByCondominium(ctx, ListExpectedVisitsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+		Until:         until.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	visits := make([]entry.Visit, 0, len(rows))
+	for _, row := range rows {
+		visits = append(visits, row.unmarshall())
+	}
+	return visits, nil
 }
 
 // CondoGetByID retrieves a condominium by its ID.
 func (s *Store) CondoGetByID(ctx context.Context, id int64) (*entry.Condominium, error) {
-	panic("Not implemented")
+	condo, err := s.GetCondominiumByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Condominio no encontrado")
+		}
+		return nil, err
+	}
+	return condo.unmarshall(), nil
+}
+
+// CondoList lists every condominium with its user and visit counts.
+func (s *Store) CondoList(ctx context.Context) ([]entry.CondominiumSummary, error) {
+	rows, err := s.ListCondominiumsWithCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	condos := make([]entry.CondominiumSummary, 0, len(rows))
+	for _, row := range rows {
+		condos = append(condos, row.unmarshall())
+	}
+	return condos, nil
 }
 
 // CondoCreate creates a new condominium.
 func (s *Store) CondoCreate(ctx context.Context, condo *entry.Condominium) (*entry.Condominium, error) {
-	panic("Not implemented")
+	created, err := insertCondominium(ctx, s.Queries, condo)
+	if err != nil {
+		return nil, err
+	}
+	return created.unmarshall(), nil
+}
+
+// CondoCreateWithAdmin creates a new condominium and its first admin in a
+// single transaction.
+func (s *Store) CondoCreateWithAdmin(
+	ctx context.Context,
+	condo *entry.Condominium,
+	admin *entry.NewCondominiumAdmin,
+) (*entry.Condominium, *entry.UserProfile, error) {
+	var createdCondo Condominium
+	var createdAdmin User
+	err := withTx(ctx, s.db, func(q *Queries) error {
+		_, err := q.GetUserByEmail(ctx, admin.Email)
+		if err == nil {
+			return entry.NewUserSafeError("Ya existe un usuario con ese correo")
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		createdCondo, err = insertCondominium(ctx, q, condo)
+		if err != nil {
+			return err
+		}
+
+		createdAdmin, err = q.CreateUser(ctx, CreateUserParams{
+			CondominiumID: nullInt64(createdCondo.ID),
+			FirstName:     admin.FirstName,
+			LastName:      admin.LastName,
+			Email:         admin.Email,
+			Phone:         nullString(admin.Phone),
+			Role:          string(entry.RoleAdmin),
+			Password:      admin.PasswordHash,
+			Enabled:       true,
+			Hidden:        false,
+			CreatedAt:     createdCondo.CreatedAt,
+			UpdatedAt:     createdCondo.CreatedAt,
+			CreatedBy:     createdCondo.CreatedBy,
+			UpdatedBy:     createdCondo.CreatedBy,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profile := createdAdmin.unmarshallProfile()
+	return createdCondo.unmarshall(), &profile, nil
 }
 
-// CondoUpdate updates an existing condominium.
+func insertCondominium(
+	ctx context.Context, q *Queries, condo *entry.Condominium,
+) (Condominium, error) {
+	return q.CreateCondominium(ctx, CreateCondominiumParams{
+		Name:      condo.Name,
+		Address:   condo.Address,
+		CreatedAt: condo.CreatedAt.Unix(),
+		UpdatedAt: condo.UpdatedAt.Unix(),
+		CreatedBy: nullInt64(condo.CreatedBy),
+		UpdatedBy: nullInt64(condo.UpdatedBy),
+	})
+}
+
+// CondoUpdate updates an existing condominium inside a transaction.
 func (s *Store) CondoUpdate(
 	ctx context.Context,
 	id int64,
 	updateFn func(condo *entry.Condominium) (*entry.Condominium, error),
 ) error {
-	panic("Not implemented")
+	return withTx(ctx, s.db, func(q *Queries) error {
+		condo, err := q.GetCondominiumByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entry.NewNotFoundError("Condominio no encontrado")
+			}
+			return err
+		}
+
+		updated, err := updateFn(condo.unmarshall())
+		if err != nil {
+			return err
+		}
+
+		return q.UpdateCondominium(ctx, UpdateCondominiumParams{
+			Name:               updated.Name,
+			Address:            updated.Address,
+			MaxVisitors:        updated.MaxVisitors,
+			MaxVisitorsPerUnit: updated.MaxVisitorsPerUnit,
+			UpdatedAt:          updated.UpdatedAt.Unix(),
+			UpdatedBy:          nullInt64(updated.UpdatedBy),
+			ID:                 id,
+		})
+	})
 }
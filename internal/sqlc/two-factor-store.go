@@ -0,0 +1,192 @@
+package sqlc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+	"github.com/Polo123456789/entry-watch/internal/http/auth"
+)
+
+// GetTwoFactor retrieves the TOTP state of a user.
+// Implements auth.UserStore.
+func (s *UserStore) GetTwoFactor(ctx context.Context, userID int64) (auth.TwoFactor, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return auth.TwoFactor{}, err
+	}
+
+	return auth.TwoFactor{
+		Secret:   validNullString(user.TotpSecret),
+		Enabled:  user.TotpEnabled,
+		LastStep: user.TotpLastStep,
+	}, nil
+}
+
+// StartTwoFactorEnrollment stores a new, not yet enabled, TOTP secret.
+// Implements auth.UserStore.
+func (s *UserStore) StartTwoFactorEnrollment(ctx context.Context, userID int64, secret string) error {
+	return s.queries.SetUserTOTPSecret(ctx, SetUserTOTPSecretParams{
+		TotpSecret: nullString(secret),
+		UpdatedAt:  time.Now().Unix(),
+		ID:         userID,
+	})
+}
+
+// EnableTwoFactor completes the enrollment and replaces the recovery codes.
+// Implements auth.UserStore.
+func (s *UserStore) EnableTwoFactor(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		err := q.EnableUserTOTP(ctx, EnableUserTOTPParams{
+			UpdatedAt: time.Now().Unix(),
+			ID:        userID,
+		})
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, q, userID, recoveryCodeHashes)
+	})
+}
+
+// DisableTwoFactor removes the TOTP secret and the recovery codes.
+// Implements auth.UserStore.
+func (s *UserStore) DisableTwoFactor(ctx context.Context, userID int64) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		err := q.DisableUserTOTP(ctx, DisableUserTOTPParams{
+			UpdatedAt: time.Now().Unix(),
+			ID:        userID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(ctx, userID)
+	})
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of the user.
+// Implements auth.UserStore.
+func (s *UserStore) ReplaceRecoveryCodes(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		return replaceRecoveryCodes(ctx, q, userID, recoveryCodeHashes)
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *Queries, userID int64, hashes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for _, hash := range hashes {
+		err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+			UserID:    userID,
+			CodeHash:  hash,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdvanceTwoFactorStep records step as the last accepted time step.
+// Implements auth.UserStore.
+func (s *UserStore) AdvanceTwoFactorStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	affected, err := s.queries.AdvanceUserTOTPStep(ctx, AdvanceUserTOTPStepParams{
+		TotpLastStep:   step,
+		ID:             userID,
+		TotpLastStep_2: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// UseRecoveryCode marks the recovery code as used.
+// Implements auth.UserStore.
+func (s *UserStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	affected, err := s.queries.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+		UsedAt:   nullInt64(time.Now().Unix()),
+		UserID:   userID,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes.
+// Implements auth.UserStore.
+func (s *UserStore) CountRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	return s.queries.CountUnusedRecoveryCodes(ctx, userID)
+}
+
+// IsTwoFactorRequired reports whether two-factor authentication is mandatory
+// for the role in the condominium.
+// Implements auth.UserStore.
+func (s *UserStore) IsTwoFactorRequired(ctx context.Context, role entry.UserRole, condoID int64) (bool, error) {
+	count, err := s.queries.CountTwoFactorRequirements(ctx, CountTwoFactorRequirementsParams{
+		Role:          string(role),
+		CondominiumID: nullInt64(condoID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// TwoFactorRequirementList lists every two-factor requirement.
+func (s *Store) TwoFactorRequirementList(ctx context.Context) ([]entry.TwoFactorRequirement, error) {
+	rows, err := s.ListTwoFactorRequirements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements := make([]entry.TwoFactorRequirement, 0, len(rows))
+	for _, row := range rows {
+		requirements = append(requirements, row.unmarshall())
+	}
+	return requirements, nil
+}
+
+// TwoFactorRequirementCreate creates a new two-factor requirement.
+func (s *Store) TwoFactorRequirementCreate(
+	ctx context.Context,
+	req *entry.TwoFactorRequirement,
+) (*entry.TwoFactorRequirement, error) {
+	row, err := s.CreateTwoFactorRequirement(ctx, CreateTwoFactorRequirementParams{
+		CondominiumID: nullInt64(req.CondominiumID),
+		Role:          string(req.Role),
+		CreatedAt:     req.CreatedAt.Unix(),
+		CreatedBy:     nullInt64(req.CreatedBy),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, entry.NewUserSafeError("La regla ya existe")
+		}
+		if isForeignKeyViolation(err) {
+			return nil, entry.NewUserSafeError("El condominio no existe")
+		}
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// TwoFactorRequirementDelete deletes a two-factor requirement.
+func (s *Store) TwoFactorRequirementDelete(ctx context.Context, id int64) error {
+	return s.DeleteTwoFactorRequirement(ctx, id)
+}
+
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func isForeignKeyViolation(err error) bool {
+	return strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
@@ -0,0 +1,103 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type txCtxKey struct{}
+
+// ctxDB runs the queries of the Store in the transaction of their context,
+// if InTx started one, and in db otherwise.
+type ctxDB struct {
+	db *sql.DB
+}
+
+func (c ctxDB) conn(ctx context.Context) DBTX {
+	if tx, ok := ctx.Value(txCtxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return c.db
+}
+
+func (c ctxDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.conn(ctx).ExecContext(ctx, query, args...)
+}
+
+func (c ctxDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.conn(ctx).PrepareContext(ctx, query)
+}
+
+func (c ctxDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn(ctx).QueryContext(ctx, query, args...)
+}
+
+func (c ctxDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.conn(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// InTx runs fn in a transaction. The store methods called with the context
+// fn gets join it, so their changes are committed together if fn returns
+// nil, and rolled back together otherwise.
+func (s *Store) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, s.db, func(ctx context.Context, _ *Queries) error {
+		return fn(ctx)
+	})
+}
+
+// InTx runs fn in a transaction, see Store.InTx.
+func (s *UserStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, s.db, func(ctx context.Context, _ *Queries) error {
+		return fn(ctx)
+	})
+}
+
+// withTx runs fn inside a transaction, committing if it returns nil and
+// rolling back otherwise.
+func withTx(ctx context.Context, db *sql.DB, fn func(q *Queries) error) error {
+	return inTx(ctx, db, func(_ context.Context, q *Queries) error {
+		return fn(q)
+	})
+}
+
+// inTx starts a transaction, or a savepoint if ctx is already in one, so
+// that a failed nested call only undoes its own changes.
+func inTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, q *Queries) error) error {
+	if tx, ok := ctx.Value(txCtxKey{}).(*sql.Tx); ok {
+		return withSavepoint(ctx, tx, func() error {
+			return fn(ctx, New(tx))
+		})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // No-op after commit
+
+	if err := fn(context.WithValue(ctx, txCtxKey{}, tx), New(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO nested"); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		if _, releaseErr := tx.ExecContext(ctx, "RELEASE nested"); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE nested")
+	return err
+}
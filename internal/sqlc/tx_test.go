@@ -0,0 +1,113 @@
+package sqlc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/Polo123456789/entry-watch/db"
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// newTestStore returns a store backed by a new database file, opened like
+// the server opens its database.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	conn, err := Open(filepath.Join(t.TempDir(), "entry-watch.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	goose.SetLogger(goose.NopLogger())
+	if err := db.AutoMigrate(conn, slog.New(slog.DiscardHandler)); err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(conn)
+}
+
+func TestInTxRollsBackWithTheAuditLog(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now()
+
+	condo, err := store.CondoCreate(ctx, &entry.Condominium{
+		Name: "Las Flores", Address: "Calle 1", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rename := func(ctx context.Context, name string) error {
+		return store.CondoUpdate(ctx, condo.ID, func(c *entry.Condominium) (*entry.Condominium, error) {
+			c.Name = name
+			return c, nil
+		})
+	}
+	record := func(ctx context.Context) error {
+		return store.AuditCreate(ctx, entry.AuditRecord{
+			CondominiumID: condo.ID,
+			Level:         entry.AuditImportant,
+			Action:        entry.ActionCondominiumChanged,
+			Message:       "Condominio actualizado",
+		}, now)
+	}
+	check := func(wantName string, wantAudits int64) {
+		t.Helper()
+		got, err := store.CondoGetByID(ctx, condo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != wantName {
+			t.Errorf("name = %q, want %q", got.Name, wantName)
+		}
+		audits, err := store.AuditCount(ctx, entry.AuditFilter{CondominiumID: condo.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if audits != wantAudits {
+			t.Errorf("audit entries = %d, want %d", audits, wantAudits)
+		}
+	}
+
+	errFailed := errors.New("failed")
+	err = store.InTx(ctx, func(ctx context.Context) error {
+		if err := rename(ctx, "Los Pinos"); err != nil {
+			return err
+		}
+		if err := record(ctx); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("err = %v, want %v", err, errFailed)
+	}
+	check("Las Flores", 0)
+
+	// A failed nested transaction only undoes its own changes.
+	err = store.InTx(ctx, func(ctx context.Context) error {
+		if err := rename(ctx, "Los Pinos"); err != nil {
+			return err
+		}
+		err := store.InTx(ctx, func(ctx context.Context) error {
+			if err := rename(ctx, "Los Robles"); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("nested err = %v, want %v", err, errFailed)
+		}
+		return record(ctx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Los Pinos", 1)
+}
@@ -2,6 +2,9 @@ package sqlc
 
 import (
 	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/auth"
@@ -23,6 +26,33 @@ func validNullString(n sql.NullString) string {
 	return ""
 }
 
+// nullInt64 converts zero values to NULL, the inverse of validNullInt64.
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+// nullString converts empty strings to NULL, the inverse of validNullString.
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// validNullTime converts a nullable Unix timestamp, NULL becomes the zero
+// time.
+func validNullTime(n sql.NullInt64) time.Time {
+	if n.Valid {
+		return time.Unix(n.Int64, 0)
+	}
+	return time.Time{}
+}
+
+// nullTime converts the zero time to NULL, the inverse of validNullTime.
+func nullTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
 func (u User) unmarshall() *auth.User {
 	return &auth.User{
 		ID:            u.ID,
@@ -34,5 +64,636 @@ func (u User) unmarshall() *auth.User {
 		Role:          entry.UserRole(u.Role),
 		Enabled:       u.Enabled,
 		Hidden:        u.Hidden,
+
+		TwoFactorEnabled: u.TotpEnabled,
+	}
+}
+
+func (u User) unmarshallProfile() entry.UserProfile {
+	return entry.UserProfile{
+		ID:            u.ID,
+		CondominiumID: validNullInt64(u.CondominiumID),
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Email:         u.Email,
+		Phone:         validNullString(u.Phone),
+		Role:          entry.UserRole(u.Role),
+		Enabled:       u.Enabled,
+		Hidden:        u.Hidden,
+		Unit:          entry.Unit{Tower: u.Tower, Number: u.Unit},
+		CreatedAt:     time.Unix(u.CreatedAt, 0),
+		UpdatedAt:     time.Unix(u.UpdatedAt, 0),
+		CreatedBy:     validNullInt64(u.CreatedBy),
+		UpdatedBy:     validNullInt64(u.UpdatedBy),
+	}
+}
+
+func (m ListMembershipsByUserRow) unmarshall() entry.Membership {
+	return entry.Membership{
+		CondominiumID:   m.CondominiumID,
+		CondominiumName: m.CondominiumName,
+		Role:            entry.UserRole(m.Role),
+		Home:            m.Home == 1,
+	}
+}
+
+func (o PermissionOverride) unmarshall() entry.PermissionOverride {
+	return entry.PermissionOverride{
+		CondominiumID: o.CondominiumID,
+		Role:          entry.UserRole(o.Role),
+		Permission:    entry.Permission(o.Permission),
+		Granted:       o.Granted,
+	}
+}
+
+func (r TwoFactorRequirement) unmarshall() entry.TwoFactorRequirement {
+	return entry.TwoFactorRequirement{
+		ID:            r.ID,
+		CondominiumID: validNullInt64(r.CondominiumID),
+		Role:          entry.UserRole(r.Role),
+		CreatedAt:     time.Unix(r.CreatedAt, 0),
+		CreatedBy:     validNullInt64(r.CreatedBy),
+	}
+}
+
+func (t LoginThrottle) unmarshall() auth.LoginThrottle {
+	var lockedUntil time.Time
+	if t.LockedUntil != 0 {
+		lockedUntil = time.Unix(t.LockedUntil, 0)
+	}
+
+	return auth.LoginThrottle{
+		Kind:          auth.ThrottleKind(t.Kind),
+		Subject:       t.Subject,
+		Failures:      t.Failures,
+		LastFailureAt: time.Unix(t.LastFailureAt, 0),
+		LockedUntil:   lockedUntil,
+	}
+}
+
+func (s Session) unmarshall() auth.Session {
+	return auth.Session{
+		ID:         s.ID,
+		UserID:     validNullInt64(s.UserID),
+		UserAgent:  s.UserAgent,
+		IP:         s.Ip,
+		CreatedAt:  time.Unix(s.CreatedAt, 0),
+		LastSeenAt: time.Unix(s.LastSeenAt, 0),
+		ExpiresAt:  time.Unix(s.ExpiresAt, 0),
+	}
+}
+
+func (v Visit) unmarshall() entry.Visit {
+	return entry.Visit{
+		ID:            v.ID,
+		CondominiumID: v.CondominiumID,
+		UserID:        v.UserID,
+		VisitorName:   v.VisitorName,
+		MaxUses:       v.MaxUses,
+		Uses:          v.Uses,
+		ValidFrom:     time.Unix(v.ValidFrom, 0),
+		ValidTo:       time.Unix(v.ValidTo, 0),
+		RevokedAt:     validNullTime(v.RevokedAt),
+		RevokedBy:     validNullInt64(v.RevokedBy),
+		CreatedAt:     time.Unix(v.CreatedAt, 0),
+		UpdatedAt:     time.Unix(v.UpdatedAt, 0),
+	}
+}
+
+func (e Entry) unmarshall() entry.Entry {
+	return entry.Entry{
+		ID:             e.ID,
+		CondominiumID:  e.CondominiumID,
+		VisitID:        validNullString(e.VisitID),
+		GuardID:        validNullInt64(e.GuardID),
+		VisitorName:    e.VisitorName,
+		Accepted:       e.Accepted,
+		Reason:         e.Reason,
+		StationID:      validNullInt64(e.StationID),
+		ShiftID:        validNullInt64(e.ShiftID),
+		OverLimit:      e.OverLimit,
+		OverrideOf:     validNullInt64(e.OverrideOf),
+		OverrideReason: e.OverrideReason,
+		ExitedAt:       validNullTime(e.ExitedAt),
+		ExitedBy:       validNullInt64(e.ExitedBy),
+		CreatedAt:      time.Unix(e.CreatedAt, 0),
+	}
+}
+
+func (a ListAuditLogsRow) unmarshall() entry.AuditEntry {
+	return entry.AuditEntry{
+		ID:               a.ID,
+		CondominiumID:    validNullInt64(a.CondominiumID),
+		CondominiumName:  a.CondominiumName,
+		UserID:           validNullInt64(a.UserID),
+		UserName:         a.UserName,
+		UserEmail:        a.UserEmail,
+		ImpersonatorID:   validNullInt64(a.ImpersonatorID),
+		ImpersonatorName: a.ImpersonatorName,
+		Level:            entry.AuditLevel(a.Level),
+		Action:           entry.AuditAction(a.Action),
+		Message:          a.Message,
+		CreatedAt:        time.Unix(a.CreatedAt, 0),
+	}
+}
+
+func (a AuditLog) unmarshall() entry.AuditEntry {
+	return entry.AuditEntry{
+		ID:             a.ID,
+		CondominiumID:  validNullInt64(a.CondominiumID),
+		UserID:         validNullInt64(a.UserID),
+		ImpersonatorID: validNullInt64(a.ImpersonatorID),
+		Level:          entry.AuditLevel(a.Level),
+		Action:         entry.AuditAction(a.Action),
+		Message:        a.Message,
+		CreatedAt:      time.Unix(a.CreatedAt, 0),
+		EntryID:        validNullInt64(a.EntryID),
+		EntryHash:      a.EntryHash,
+		PrevHash:       a.PrevHash,
+		Hash:           a.Hash,
+	}
+}
+
+func (h AuditChainHead) unmarshall() entry.AuditChainHead {
+	return entry.AuditChainHead{
+		ChainID:   h.ChainID,
+		LastID:    h.LastID,
+		Hash:      h.Hash,
+		Entries:   h.Entries,
+		UpdatedAt: time.Unix(h.UpdatedAt, 0),
+	}
+}
+
+func (r ListEntriesInsideRow) unmarshall() entry.Occupant {
+	return entry.Occupant{
+		Entry: Entry{
+			ID:             r.ID,
+			CondominiumID:  r.CondominiumID,
+			VisitID:        r.VisitID,
+			GuardID:        r.GuardID,
+			VisitorName:    r.VisitorName,
+			Accepted:       r.Accepted,
+			Reason:         r.Reason,
+			CreatedAt:      r.CreatedAt,
+			StationID:      r.StationID,
+			ShiftID:        r.ShiftID,
+			ExitedAt:       r.ExitedAt,
+			ExitedBy:       r.ExitedBy,
+			OverLimit:      r.OverLimit,
+			OverrideOf:     r.OverrideOf,
+			OverrideReason: r.OverrideReason,
+		}.unmarshall(),
+		ResidentID:   r.ResidentID,
+		ResidentName: r.ResidentName,
+		Unit:         entry.Unit{Tower: r.ResidentTower, Number: r.ResidentUnit},
+	}
+}
+
+func (c AuditCheckpoint) unmarshall() *entry.AuditCheckpoint {
+	return &entry.AuditCheckpoint{
+		ID:        c.ID,
+		ChainID:   c.ChainID,
+		LastID:    c.LastID,
+		Hash:      c.Hash,
+		Entries:   c.Entries,
+		CreatedAt: time.Unix(c.CreatedAt, 0),
+		PublicKey: c.PublicKey,
+		Signature: c.Signature,
+	}
+}
+
+func (c Condominium) unmarshall() *entry.Condominium {
+	return &entry.Condominium{
+		ID:                 c.ID,
+		Name:               c.Name,
+		Address:            c.Address,
+		MaxVisitors:        c.MaxVisitors,
+		MaxVisitorsPerUnit: c.MaxVisitorsPerUnit,
+		CreatedAt:          time.Unix(c.CreatedAt, 0),
+		UpdatedAt:          time.Unix(c.UpdatedAt, 0),
+		CreatedBy:          validNullInt64(c.CreatedBy),
+		UpdatedBy:          validNullInt64(c.UpdatedBy),
+	}
+}
+
+func (c ListCondominiumsWithCountsRow) unmarshall() entry.CondominiumSummary {
+	return entry.CondominiumSummary{
+		Condominium: entry.Condominium{
+			ID:        c.ID,
+			Name:      c.Name,
+			Address:   c.Address,
+			CreatedAt: time.Unix(c.CreatedAt, 0),
+			UpdatedAt: time.Unix(c.UpdatedAt, 0),
+			CreatedBy: validNullInt64(c.CreatedBy),
+			UpdatedBy: validNullInt64(c.UpdatedBy),
+		},
+		Users:  c.UserCount,
+		Visits: c.VisitCount,
+	}
+}
+
+func (t ApiToken) unmarshall() auth.APIToken {
+	var scopes []entry.Permission
+	for _, scope := range strings.Fields(t.Scopes) {
+		scopes = append(scopes, entry.Permission(scope))
+	}
+
+	return auth.APIToken{
+		ID:            t.ID,
+		UserID:        t.UserID,
+		CondominiumID: validNullInt64(t.CondominiumID),
+		Name:          t.Name,
+		Scopes:        scopes,
+		CreatedAt:     time.Unix(t.CreatedAt, 0),
+		LastUsedAt:    validNullTime(t.LastUsedAt),
+		ExpiresAt:     time.Unix(t.ExpiresAt, 0),
+	}
+}
+
+func (w Webhook) unmarshall() entry.Webhook {
+	var events []entry.WebhookEvent
+	for _, event := range strings.Fields(w.Events) {
+		events = append(events, entry.WebhookEvent(event))
+	}
+
+	return entry.Webhook{
+		ID:            w.ID,
+		CondominiumID: w.CondominiumID,
+		URL:           w.Url,
+		Secret:        w.Secret,
+		Events:        events,
+		CreatedAt:     time.Unix(w.CreatedAt, 0),
+		CreatedBy:     validNullInt64(w.CreatedBy),
+	}
+}
+
+func (d WebhookDelivery) unmarshall() entry.WebhookDelivery {
+	return entry.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		Event:          entry.WebhookEvent(d.Event),
+		Payload:        d.Payload,
+		Status:         entry.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  time.Unix(d.NextAttemptAt, 0),
+		LastAttemptAt:  validNullTime(d.LastAttemptAt),
+		ResponseStatus: validNullInt64(d.ResponseStatus),
+		LastError:      validNullString(d.LastError),
+		CreatedAt:      time.Unix(d.CreatedAt, 0),
+	}
+}
+
+func (g Gate) unmarshall() entry.Gate {
+	return entry.Gate{
+		ID:            g.ID,
+		CondominiumID: g.CondominiumID,
+		Name:          g.Name,
+		Driver:        entry.GateDriver(g.Driver),
+		Target:        g.Target,
+		HTTPMethod:    g.HttpMethod,
+		Payload:       g.Payload,
+		UnitID:        g.UnitID,
+		Coil:          g.Coil,
+		CreatedAt:     time.Unix(g.CreatedAt, 0),
+		CreatedBy:     validNullInt64(g.CreatedBy),
+	}
+}
+
+func (s GuardStation) unmarshall() entry.GuardStation {
+	return entry.GuardStation{
+		ID:            s.ID,
+		CondominiumID: s.CondominiumID,
+		Name:          s.Name,
+		GateID:        validNullInt64(s.GateID),
+		CreatedAt:     time.Unix(s.CreatedAt, 0),
+		CreatedBy:     validNullInt64(s.CreatedBy),
+
+		WelfareIntervalMinutes: s.WelfareIntervalMinutes,
+		WelfareGraceMinutes:    s.WelfareGraceMinutes,
+	}
+}
+
+func (n Notification) unmarshall() entry.Notification {
+	return entry.Notification{
+		ID:            n.ID,
+		UserID:        n.UserID,
+		CondominiumID: n.CondominiumID,
+		Event:         entry.NotificationEvent(n.Event),
+		Title:         n.Title,
+		Body:          n.Body,
+		CreatedAt:     time.Unix(n.CreatedAt, 0),
+		ReadAt:        validNullTime(n.ReadAt),
+	}
+}
+
+func (d NotificationDelivery) unmarshall() entry.NotificationDelivery {
+	return entry.NotificationDelivery{
+		ID:            d.ID,
+		UserID:        d.UserID,
+		Channel:       entry.NotificationChannel(d.Channel),
+		Recipient:     d.Recipient,
+		Subject:       d.Subject,
+		Body:          d.Body,
+		URL:           d.Url,
+		Actions:       unmarshallActions(d.Actions),
+		Status:        entry.WebhookDeliveryStatus(d.Status),
+		Attempts:      d.Attempts,
+		NextAttemptAt: time.Unix(d.NextAttemptAt, 0),
+		LastAttemptAt: validNullTime(d.LastAttemptAt),
+		LastError:     validNullString(d.LastError),
+		CreatedAt:     time.Unix(d.CreatedAt, 0),
+	}
+}
+
+// unmarshallActions decodes the actions of a notification delivery. They
+// are only written by NotificationDeliveryCreate, so invalid JSON is
+// dropped rather than failing the delivery.
+func unmarshallActions(s string) []entry.NotificationAction {
+	var actions []entry.NotificationAction
+	if err := json.Unmarshal([]byte(s), &actions); err != nil {
+		return nil
+	}
+	return actions
+}
+
+func (p PushSubscription) unmarshall() entry.PushSubscription {
+	return entry.PushSubscription{
+		ID:        p.ID,
+		UserID:    p.UserID,
+		Endpoint:  p.Endpoint,
+		P256DH:    p.P256dh,
+		Auth:      p.Auth,
+		UserAgent: p.UserAgent,
+		CreatedAt: time.Unix(p.CreatedAt, 0),
+	}
+}
+
+func (w GetWalkInByIDRow) unmarshall() entry.WalkIn {
+	return entry.WalkIn{
+		ID:            w.ID,
+		CondominiumID: w.CondominiumID,
+		ResidentID:    w.ResidentID,
+		ResidentName:  w.ResidentName,
+		VisitorName:   w.VisitorName,
+		AnnouncedBy:   validNullInt64(w.AnnouncedBy),
+		Decision:      entry.WalkInDecision(validNullString(w.Decision)),
+		DecidedAt:     validNullTime(w.DecidedAt),
+		CreatedAt:     time.Unix(w.CreatedAt, 0),
+	}
+}
+
+func (p GetParcelByIDRow) unmarshall() entry.Parcel {
+	return entry.Parcel{
+		ID:               p.ID,
+		CondominiumID:    p.CondominiumID,
+		ResidentID:       validNullInt64(p.ResidentID),
+		ResidentName:     p.ResidentName,
+		Unit:             entry.Unit{Tower: p.Tower, Number: p.Unit},
+		Carrier:          p.Carrier,
+		TrackingNumber:   p.TrackingNumber,
+		Size:             entry.ParcelSize(p.Size),
+		HasPhoto:         p.HasPhoto,
+		PickupCode:       p.PickupCode,
+		ReceivedBy:       validNullInt64(p.ReceivedBy),
+		AuthorizedAt:     validNullTime(p.AuthorizedAt),
+		CollectedAt:      validNullTime(p.CollectedAt),
+		CollectedBy:      validNullString(p.CollectedBy),
+		HandedOverBy:     validNullInt64(p.HandedOverBy),
+		ShiftID:          validNullInt64(p.ShiftID),
+		CollectedShiftID: validNullInt64(p.CollectedShiftID),
+		CreatedAt:        time.Unix(p.CreatedAt, 0),
+	}
+}
+
+func (i GetIncidentByIDRow) unmarshall() entry.Incident {
+	return entry.Incident{
+		ID:            i.ID,
+		CondominiumID: i.CondominiumID,
+		Category:      entry.IncidentCategory(i.Category),
+		Severity:      entry.IncidentSeverity(i.Severity),
+		Location:      i.Location,
+		Description:   i.Description,
+		Status:        entry.IncidentStatus(i.Status),
+		ReportedBy:    validNullInt64(i.ReportedBy),
+		ReporterName:  i.ReporterName,
+		ShiftID:       validNullInt64(i.ShiftID),
+		CreatedAt:     time.Unix(i.CreatedAt, 0),
+		UpdatedAt:     time.Unix(i.UpdatedAt, 0),
+	}
+}
+
+func (a ListIncidentAttachmentsRow) unmarshall() entry.IncidentAttachment {
+	return entry.IncidentAttachment{
+		ID:          a.ID,
+		IncidentID:  a.IncidentID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   time.Unix(a.CreatedAt, 0),
+	}
+}
+
+func (c ListIncidentCommentsRow) unmarshall() entry.IncidentComment {
+	return entry.IncidentComment{
+		ID:         c.ID,
+		IncidentID: c.IncidentID,
+		AuthorID:   validNullInt64(c.AuthorID),
+		AuthorName: c.AuthorName,
+		Body:       c.Body,
+		Status:     entry.IncidentStatus(validNullString(c.Status)),
+		CreatedAt:  time.Unix(c.CreatedAt, 0),
+	}
+}
+
+func (s GetShiftByIDRow) unmarshall() entry.Shift {
+	return entry.Shift{
+		ID:            s.ID,
+		CondominiumID: s.CondominiumID,
+		GuardID:       validNullInt64(s.GuardID),
+		GuardName:     s.GuardName,
+		StationID:     validNullInt64(s.StationID),
+		StationName:   s.StationName,
+		StartedAt:     time.Unix(s.StartedAt, 0),
+		EndedAt:       validNullTime(s.EndedAt),
+		HandoverNotes: s.HandoverNotes,
+		Logbook:       unmarshallLogbook(s.Logbook),
+
+		WelfareCheckedAt:   validNullTime(s.WelfareCheckedAt),
+		WelfareEscalatedAt: validNullTime(s.WelfareEscalatedAt),
+	}
+}
+
+// unmarshallLogbook decodes the logbook of a shift, nil while it is open.
+// Logbooks are only written by ShiftClose, so invalid JSON is treated as
+// missing rather than failing to show the shift.
+func unmarshallLogbook(s sql.NullString) *entry.Logbook {
+	if !s.Valid {
+		return nil
+	}
+	var logbook entry.Logbook
+	if err := json.Unmarshal([]byte(s.String), &logbook); err != nil {
+		return nil
+	}
+	return &logbook
+}
+
+func (s PatrolCheckpoint) unmarshall() entry.PatrolCheckpoint {
+	return entry.PatrolCheckpoint{
+		ID:            s.ID,
+		CondominiumID: s.CondominiumID,
+		Name:          s.Name,
+		Code:          s.Code,
+		CreatedAt:     time.Unix(s.CreatedAt, 0),
+		CreatedBy:     validNullInt64(s.CreatedBy),
+	}
+}
+
+func (s GetPatrolRouteByIDRow) unmarshall() entry.PatrolRoute {
+	return entry.PatrolRoute{
+		ID:            s.ID,
+		CondominiumID: s.CondominiumID,
+		Name:          s.Name,
+		StationID:     validNullInt64(s.StationID),
+		StationName:   s.StationName,
+		StartMinute:   s.StartMinute,
+		EndMinute:     s.EndMinute,
+		EveryMinutes:  s.EveryMinutes,
+		WindowMinutes: s.WindowMinutes,
+		CreatedAt:     time.Unix(s.CreatedAt, 0),
+		CreatedBy:     validNullInt64(s.CreatedBy),
+	}
+}
+
+func (s ListPatrolScansByShiftRow) unmarshall() entry.PatrolScan {
+	return entry.PatrolScan{
+		ID:             s.ID,
+		CondominiumID:  s.CondominiumID,
+		CheckpointID:   s.CheckpointID,
+		CheckpointName: s.CheckpointName,
+		GuardID:        validNullInt64(s.GuardID),
+		ShiftID:        s.ShiftID,
+		ScannedAt:      time.Unix(s.ScannedAt, 0),
+	}
+}
+
+func (s ListPatrolRoundsByCondominiumRow) unmarshall() entry.PatrolRound {
+	var missing []string
+	if s.Missing != "" {
+		missing = strings.Split(s.Missing, "\n")
+	}
+	return entry.PatrolRound{
+		ID:             s.ID,
+		CondominiumID:  s.CondominiumID,
+		RouteID:        validNullInt64(s.RouteID),
+		RouteName:      s.RouteName,
+		ShiftID:        s.ShiftID,
+		ShiftStartedAt: time.Unix(s.ShiftStartedAt, 0),
+		GuardID:        validNullInt64(s.GuardID),
+		GuardName:      s.GuardName,
+		DueAt:          time.Unix(s.DueAt, 0),
+		Status:         entry.RoundStatus(s.Status),
+		Checkpoints:    int(s.Checkpoints),
+		Scanned:        int(s.Scanned),
+		Missing:        missing,
+		EvaluatedAt:    time.Unix(s.EvaluatedAt, 0),
+	}
+}
+
+func (a GetAnnouncementByIDRow) unmarshall() entry.Announcement {
+	return entry.Announcement{
+		ID:            a.ID,
+		CondominiumID: a.CondominiumID,
+		Title:         a.Title,
+		Body:          a.Body,
+		Audience:      entry.AnnouncementAudience(a.Audience),
+		Pinned:        a.Pinned,
+		ExpiresAt:     validNullTime(a.ExpiresAt),
+		CreatedBy:     validNullInt64(a.CreatedBy),
+		AuthorName:    a.AuthorName,
+		CreatedAt:     time.Unix(a.CreatedAt, 0),
+	}
+}
+
+func (a ListAnnouncementsByCondominiumRow) unmarshall() entry.Announcement {
+	return entry.Announcement{
+		ID:            a.ID,
+		CondominiumID: a.CondominiumID,
+		Title:         a.Title,
+		Body:          a.Body,
+		Audience:      entry.AnnouncementAudience(a.Audience),
+		Pinned:        a.Pinned,
+		ExpiresAt:     validNullTime(a.ExpiresAt),
+		CreatedBy:     validNullInt64(a.CreatedBy),
+		AuthorName:    a.AuthorName,
+		CreatedAt:     time.Unix(a.CreatedAt, 0),
+		ReadCount:     a.ReadCount,
+	}
+}
+
+func (a ListActiveAnnouncementsRow) unmarshall() entry.Announcement {
+	return entry.Announcement{
+		ID:            a.ID,
+		CondominiumID: a.CondominiumID,
+		Title:         a.Title,
+		Body:          a.Body,
+		Audience:      entry.AnnouncementAudience(a.Audience),
+		Pinned:        a.Pinned,
+		ExpiresAt:     validNullTime(a.ExpiresAt),
+		CreatedBy:     validNullInt64(a.CreatedBy),
+		CreatedAt:     time.Unix(a.CreatedAt, 0),
+		ReadAt:        validNullTime(a.ReadAt),
+	}
+}
+
+// addTo adds the tower, or the unit, the target is to the announcement.
+func (t AnnouncementTarget) addTo(announcement *entry.Announcement) {
+	if t.Unit == "" {
+		announcement.Towers = append(announcement.Towers, t.Tower)
+		return
+	}
+	announcement.Units = append(announcement.Units, entry.Unit{Tower: t.Tower, Number: t.Unit})
+}
+
+func (a Amenity) unmarshall() entry.Amenity {
+	return entry.Amenity{
+		ID:               a.ID,
+		CondominiumID:    a.CondominiumID,
+		Name:             a.Name,
+		Capacity:         a.Capacity,
+		OpensMinute:      a.OpensMinute,
+		ClosesMinute:     a.ClosesMinute,
+		SlotMinutes:      a.SlotMinutes,
+		MaxPerMonth:      a.MaxPerMonth,
+		LeadHours:        a.LeadHours,
+		RequiresApproval: a.RequiresApproval,
+		CreatedAt:        time.Unix(a.CreatedAt, 0),
+		CreatedBy:        validNullInt64(a.CreatedBy),
+	}
+}
+
+func (r GetReservationByIDRow) unmarshall() entry.Reservation {
+	return entry.Reservation{
+		ID:            r.ID,
+		CondominiumID: r.CondominiumID,
+		AmenityID:     r.AmenityID,
+		AmenityName:   r.AmenityName,
+		ResidentID:    r.ResidentID,
+		ResidentName:  r.ResidentName,
+		ResidentUnit:  entry.Unit{Tower: r.ResidentTower, Number: r.ResidentUnit},
+		StartsAt:      time.Unix(r.StartsAt, 0),
+		EndsAt:        time.Unix(r.EndsAt, 0),
+		Status:        entry.ReservationStatus(r.Status),
+		DecidedBy:     validNullInt64(r.DecidedBy),
+		DecidedAt:     validNullTime(r.DecidedAt),
+		CreatedAt:     time.Unix(r.CreatedAt, 0),
+	}
+}
+
+func (g ReservationGuest) unmarshall() entry.ReservationGuest {
+	return entry.ReservationGuest{
+		ID:      g.ID,
+		Name:    g.Name,
+		VisitID: validNullString(g.VisitID),
 	}
 }
@@ -0,0 +1,91 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// UserGetByID retrieves a user by its ID.
+func (s *Store) UserGetByID(ctx context.Context, id int64) (*entry.UserProfile, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Usuario no encontrado")
+		}
+		return nil, err
+	}
+
+	profile := user.unmarshallProfile()
+	return &profile, nil
+}
+
+// UserGetByEmail retrieves a user by its email.
+func (s *Store) UserGetByEmail(ctx context.Context, email string) (*entry.UserProfile, error) {
+	user, err := s.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("No existe un usuario con ese correo")
+		}
+		return nil, err
+	}
+
+	profile := user.unmarshallProfile()
+	return &profile, nil
+}
+
+// UserListByCondo lists the users of a condominium.
+func (s *Store) UserListByCondo(ctx context.Context, condoID int64) ([]entry.UserProfile, error) {
+	rows, err := s.ListUsersByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]entry.UserProfile, 0, len(rows))
+	for _, row := range rows {
+		profile := row.User.unmarshallProfile()
+		profile.Role = entry.UserRole(row.MembershipRole)
+		users = append(users, profile)
+	}
+	return users, nil
+}
+
+// UserUpdate updates an existing user inside a transaction.
+func (s *Store) UserUpdate(
+	ctx context.Context,
+	id int64,
+	updateFn func(user *entry.UserProfile) (*entry.UserProfile, error),
+) error {
+	return withTx(ctx, s.db, func(q *Queries) error {
+		user, err := q.GetUserByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entry.NewNotFoundError("Usuario no encontrado")
+			}
+			return err
+		}
+
+		profile := user.unmarshallProfile()
+		updated, err := updateFn(&profile)
+		if err != nil {
+			return err
+		}
+
+		return q.UpdateUser(ctx, UpdateUserParams{
+			CondominiumID: nullInt64(updated.CondominiumID),
+			FirstName:     updated.FirstName,
+			LastName:      updated.LastName,
+			Phone:         nullString(updated.Phone),
+			Role:          string(updated.Role),
+			Enabled:       updated.Enabled,
+			Hidden:        updated.Hidden,
+			Tower:         updated.Unit.Tower,
+			Unit:          updated.Unit.Number,
+			UpdatedAt:     updated.UpdatedAt.Unix(),
+			UpdatedBy:     nullInt64(updated.UpdatedBy),
+			ID:            id,
+		})
+	})
+}
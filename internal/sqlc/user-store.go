@@ -6,19 +6,22 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Polo123456789/entry-watch/internal/entry"
 	"github.com/Polo123456789/entry-watch/internal/http/auth"
 )
 
 // UserStore wraps SQLC queries to provide user-related operations.
 // This implements auth.UserStore interface.
 type UserStore struct {
+	db      *sql.DB
 	queries *Queries
 }
 
 // NewUserStore creates a new UserStore that wraps the SQLC queries.
 func NewUserStore(db *sql.DB) *UserStore {
 	return &UserStore{
-		queries: New(db),
+		db:      db,
+		queries: New(ctxDB{db}),
 	}
 }
 
@@ -51,7 +54,38 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*auth.User, bool, er
 		return nil, false, err
 	}
 
-	return user.unmarshall(), true, nil
+	u := user.unmarshall()
+	u.Memberships, err = s.memberships(ctx, u.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	return u, true, nil
+}
+
+// memberships lists the condominiums the user holds a role in, with the
+// permissions of the role in each of them and whether it requires two-factor
+// authentication.
+func (s *UserStore) memberships(ctx context.Context, userID int64) ([]entry.Membership, error) {
+	rows, err := s.queries.ListMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make([]entry.Membership, 0, len(rows))
+	for _, row := range rows {
+		m := row.unmarshall()
+		overrides, err := queryPermissionOverrides(ctx, s.queries, m.CondominiumID)
+		if err != nil {
+			return nil, err
+		}
+		m.Permissions = entry.RolePermissions(m.Role, overrides)
+		m.TwoFactorRequired, err = s.IsTwoFactorRequired(ctx, m.Role, m.CondominiumID)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, nil
 }
 
 // CreateUser creates a new user with the given password hash.
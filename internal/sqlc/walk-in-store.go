@@ -0,0 +1,96 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+func (s *Store) WalkInCreate(ctx context.Context, walkIn *entry.WalkIn) (*entry.WalkIn, error) {
+	id, err := s.CreateWalkIn(ctx, CreateWalkInParams{
+		CondominiumID: walkIn.CondominiumID,
+		ResidentID:    walkIn.ResidentID,
+		VisitorName:   walkIn.VisitorName,
+		AnnouncedBy:   nullInt64(walkIn.AnnouncedBy),
+		CreatedAt:     walkIn.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.WalkInGetByID(ctx, id)
+}
+
+func (s *Store) WalkInGetByID(ctx context.Context, id int64) (*entry.WalkIn, error) {
+	row, err := s.GetWalkInByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Visita no encontrada")
+		}
+		return nil, err
+	}
+
+	walkIn := row.unmarshall()
+	return &walkIn, nil
+}
+
+// WalkInListByCondo lists the walk-ins of a condominium since a time,
+// latest first.
+func (s *Store) WalkInListByCondo(
+	ctx context.Context, condoID int64, since time.Time,
+) ([]entry.WalkIn, error) {
+	rows, err := s.ListWalkInsByCondominium(ctx, ListWalkInsByCondominiumParams{
+		CondominiumID: condoID,
+		Since:         since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	walkIns := make([]entry.WalkIn, 0, len(rows))
+	for _, row := range rows {
+		walkIns = append(walkIns, GetWalkInByIDRow(row).unmarshall())
+	}
+	return walkIns, nil
+}
+
+// WalkInListPending lists the walk-ins a resident hasn't answered since a
+// time, latest first.
+func (s *Store) WalkInListPending(
+	ctx context.Context, residentID int64, since time.Time,
+) ([]entry.WalkIn, error) {
+	rows, err := s.ListPendingWalkInsByResident(ctx, ListPendingWalkInsByResidentParams{
+		ResidentID: residentID,
+		Since:      since.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	walkIns := make([]entry.WalkIn, 0, len(rows))
+	for _, row := range rows {
+		walkIns = append(walkIns, GetWalkInByIDRow(row).unmarshall())
+	}
+	return walkIns, nil
+}
+
+// WalkInDecide records the decision of a pending walk-in. Only the first
+// decision counts.
+func (s *Store) WalkInDecide(
+	ctx context.Context, id int64, decision entry.WalkInDecision, at time.Time,
+) error {
+	updated, err := s.DecideWalkIn(ctx, DecideWalkInParams{
+		Decision:  nullString(string(decision)),
+		DecidedAt: nullTime(at),
+		ID:        id,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return entry.NewUserSafeError("Ya respondiste a esta visita")
+	}
+	return nil
+}
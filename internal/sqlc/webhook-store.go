@@ -0,0 +1,158 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+// WebhookList lists the webhooks of a condominium.
+func (s *Store) WebhookList(ctx context.Context, condoID int64) ([]entry.Webhook, error) {
+	rows, err := s.ListWebhooksByCondominium(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]entry.Webhook, 0, len(rows))
+	for _, row := range rows {
+		webhooks = append(webhooks, row.unmarshall())
+	}
+	return webhooks, nil
+}
+
+func (s *Store) WebhookGetByID(ctx context.Context, id int64) (*entry.Webhook, error) {
+	row, err := s.GetWebhookByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Webhook no encontrado")
+		}
+		return nil, err
+	}
+
+	webhook := row.unmarshall()
+	return &webhook, nil
+}
+
+func (s *Store) WebhookCreate(
+	ctx context.Context, webhook *entry.Webhook,
+) (*entry.Webhook, error) {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+
+	row, err := s.CreateWebhook(ctx, CreateWebhookParams{
+		CondominiumID: webhook.CondominiumID,
+		Url:           webhook.URL,
+		Secret:        webhook.Secret,
+		Events:        strings.Join(events, " "),
+		CreatedAt:     webhook.CreatedAt.Unix(),
+		CreatedBy:     nullInt64(webhook.CreatedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+// WebhookDelete removes the webhook, its deliveries are removed by the
+// foreign key.
+func (s *Store) WebhookDelete(ctx context.Context, id int64) error {
+	deleted, err := s.DeleteWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return entry.NewNotFoundError("Webhook no encontrado")
+	}
+	return nil
+}
+
+func (s *Store) WebhookDeliveryCreate(
+	ctx context.Context, delivery *entry.WebhookDelivery,
+) (*entry.WebhookDelivery, error) {
+	row, err := s.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         string(delivery.Event),
+		Payload:       delivery.Payload,
+		Status:        string(delivery.Status),
+		NextAttemptAt: delivery.NextAttemptAt.Unix(),
+		CreatedAt:     delivery.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := row.unmarshall()
+	return &created, nil
+}
+
+func (s *Store) WebhookDeliveryGetByID(
+	ctx context.Context, id int64,
+) (*entry.WebhookDelivery, error) {
+	row, err := s.GetWebhookDeliveryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entry.NewNotFoundError("Entrega no encontrada")
+		}
+		return nil, err
+	}
+
+	delivery := row.unmarshall()
+	return &delivery, nil
+}
+
+func (s *Store) WebhookDeliveryList(
+	ctx context.Context, webhookID int64, limit int64,
+) ([]entry.WebhookDelivery, error) {
+	rows, err := s.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unmarshallDeliveries(rows), nil
+}
+
+func (s *Store) WebhookDeliveryDue(
+	ctx context.Context, now time.Time, limit int64,
+) ([]entry.WebhookDelivery, error) {
+	rows, err := s.ListDueWebhookDeliveries(ctx, ListDueWebhookDeliveriesParams{
+		NextAttemptAt: now.Unix(),
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unmarshallDeliveries(rows), nil
+}
+
+func (s *Store) WebhookDeliveryUpdate(
+	ctx context.Context, delivery *entry.WebhookDelivery,
+) error {
+	return s.UpdateWebhookDelivery(ctx, UpdateWebhookDeliveryParams{
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt.Unix(),
+		LastAttemptAt:  nullTime(delivery.LastAttemptAt),
+		ResponseStatus: nullInt64(delivery.ResponseStatus),
+		LastError:      nullString(delivery.LastError),
+		ID:             delivery.ID,
+	})
+}
+
+func unmarshallDeliveries(rows []WebhookDelivery) []entry.WebhookDelivery {
+	deliveries := make([]entry.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.unmarshall())
+	}
+	return deliveries
+}
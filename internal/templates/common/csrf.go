@@ -0,0 +1,18 @@
+package common
+
+import "context"
+
+type csrfTokenCtxKey struct{}
+
+// WithCSRFToken stores the CSRF token of the request, so that Layout can add
+// it to the forms of the page.
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenCtxKey{}, token)
+}
+
+// CSRFToken returns the CSRF token of the request, or an empty string if
+// there is none.
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenCtxKey{}).(string)
+	return token
+}
@@ -0,0 +1,24 @@
+package common
+
+import "context"
+
+// Impersonation describes the user a superadmin is viewing the system as.
+type Impersonation struct {
+	UserName  string
+	UserEmail string
+}
+
+type impersonationCtxKey struct{}
+
+// WithImpersonation marks the request as impersonated, so that Layout shows
+// the banner to stop impersonating.
+func WithImpersonation(ctx context.Context, impersonation Impersonation) context.Context {
+	return context.WithValue(ctx, impersonationCtxKey{}, impersonation)
+}
+
+// ImpersonationFromCtx returns the impersonation of the request, and false
+// if there is none.
+func ImpersonationFromCtx(ctx context.Context) (Impersonation, bool) {
+	impersonation, ok := ctx.Value(impersonationCtxKey{}).(Impersonation)
+	return impersonation, ok
+}
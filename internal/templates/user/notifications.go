@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"context"
+
+	"github.com/Polo123456789/entry-watch/internal/entry"
+)
+
+type unreadCtxKey struct{}
+
+type walkInsCtxKey struct{}
+
+// WithUnreadNotifications stores the number of unread notifications of the
+// user, so that Navbar can show it.
+func WithUnreadNotifications(ctx context.Context, unread int64) context.Context {
+	return context.WithValue(ctx, unreadCtxKey{}, unread)
+}
+
+// UnreadNotifications returns the number of unread notifications of the
+// user, zero if it is unknown.
+func UnreadNotifications(ctx context.Context) int64 {
+	unread, _ := ctx.Value(unreadCtxKey{}).(int64)
+	return unread
+}
+
+// WithPendingWalkIns stores the walk-ins the user hasn't answered, so that
+// Navbar can ask about them on every page.
+func WithPendingWalkIns(ctx context.Context, walkIns []entry.WalkIn) context.Context {
+	return context.WithValue(ctx, walkInsCtxKey{}, walkIns)
+}
+
+// PendingWalkInsFromCtx returns the walk-ins stored by WithPendingWalkIns.
+func PendingWalkInsFromCtx(ctx context.Context) []entry.WalkIn {
+	walkIns, _ := ctx.Value(walkInsCtxKey{}).([]entry.WalkIn)
+	return walkIns
+}
@@ -0,0 +1,288 @@
+// Interactive documentation of the JSON API. It renders the OpenAPI
+// document of the server and lets the user try each operation with an API
+// token. It has no dependencies, so that it works without a CDN.
+(function () {
+	"use strict";
+
+	const specURL = document.getElementById("spec-link").getAttribute("href");
+	const methods = ["get", "put", "post", "delete", "patch"];
+	const tokenKey = "entry-watch-api-token";
+
+	const tokenInput = document.getElementById("token");
+	tokenInput.value = sessionStorage.getItem(tokenKey) || "";
+	tokenInput.addEventListener("input", function () {
+		sessionStorage.setItem(tokenKey, tokenInput.value.trim());
+	});
+
+	function el(tag, attrs, ...children) {
+		const node = document.createElement(tag);
+		for (const [key, value] of Object.entries(attrs || {})) {
+			if (key === "class") {
+				node.className = value;
+			} else {
+				node.setAttribute(key, value);
+			}
+		}
+		for (const child of children) {
+			if (child === null || child === undefined) {
+				continue;
+			}
+			node.append(child);
+		}
+		return node;
+	}
+
+	// text renders the `code` spans of the descriptions. Everything else is
+	// shown as plain text.
+	function text(value) {
+		const span = el("span");
+		(value || "").split("`").forEach(function (part, i) {
+			span.append(i % 2 === 1 ? el("code", null, part) : part);
+		});
+		return span;
+	}
+
+	function resolve(spec, node) {
+		while (node && node.$ref) {
+			node = node.$ref
+				.replace(/^#\//, "")
+				.split("/")
+				.reduce(function (target, part) {
+					return target[part];
+				}, spec);
+		}
+		return node;
+	}
+
+	function refName(node) {
+		return node && node.$ref ? node.$ref.split("/").pop() : "";
+	}
+
+	function typeName(spec, schema) {
+		const name = refName(schema);
+		schema = resolve(spec, schema);
+		if (name && !schema.enum) {
+			return name;
+		}
+		if (schema.type === "array") {
+			return typeName(spec, schema.items) + "[]";
+		}
+		let type = [].concat(schema.type || "any").join(" | ");
+		if (schema.format) {
+			type += " (" + schema.format + ")";
+		}
+		if (schema.enum) {
+			type += ": " + schema.enum.join(", ");
+		}
+		return type;
+	}
+
+	// renderSchema lists the properties of an object schema, nested objects
+	// and lists of objects are expanded.
+	function renderSchema(spec, schema) {
+		schema = resolve(spec, schema);
+		if (schema.type === "array") {
+			return el("div", null, "Lista de:", renderSchema(spec, schema.items));
+		}
+		if (schema.type !== "object" || !schema.properties) {
+			return el("code", null, typeName(spec, schema));
+		}
+
+		const required = schema.required || [];
+		const rows = el("tbody");
+		for (const [name, property] of Object.entries(schema.properties)) {
+			const resolved = resolve(spec, property);
+			const nested =
+				resolved.type === "object" ||
+				(resolved.type === "array" && resolve(spec, resolved.items).type === "object");
+			rows.append(
+				el(
+					"tr",
+					null,
+					el("td", null, el("code", null, name), required.includes(name) ? " *" : ""),
+					el("td", null, el("code", null, typeName(spec, property))),
+					el(
+						"td",
+						null,
+						text(resolved.description),
+						nested ? renderSchema(spec, resolved) : null,
+					),
+				),
+			);
+		}
+		return el(
+			"table",
+			null,
+			el("thead", null, el("tr", null, el("th", null, "Campo"), el("th", null, "Tipo"), el("th", null, ""))),
+			rows,
+		);
+	}
+
+	// example builds a request body from the schema, to be edited by the
+	// user.
+	function example(spec, schema) {
+		schema = resolve(spec, schema);
+		if (schema.enum) {
+			return schema.enum[0];
+		}
+		const type = [].concat(schema.type)[0];
+		switch (type) {
+			case "object": {
+				const value = {};
+				for (const [name, property] of Object.entries(schema.properties || {})) {
+					value[name] = example(spec, property);
+				}
+				return value;
+			}
+			case "array":
+				return [example(spec, schema.items)];
+			case "integer":
+			case "number":
+				return 0;
+			case "boolean":
+				return false;
+			case "string":
+				return schema.format === "date-time" ? new Date().toISOString() : "";
+			default:
+				return null;
+		}
+	}
+
+	function renderTry(spec, method, path, op) {
+		const params = (op.parameters || []).map(function (param) {
+			return resolve(spec, param);
+		});
+		const inputs = {};
+		const form = el("form", { class: "try" });
+
+		for (const param of params) {
+			inputs[param.name] = el("input", { name: param.name, required: "" });
+			form.append(el("label", null, param.name, " ", text(param.description), inputs[param.name]));
+		}
+
+		let body = null;
+		const media = op.requestBody && op.requestBody.content["application/json"];
+		if (media) {
+			body = el("textarea", { rows: "8", spellcheck: "false" });
+			body.value = JSON.stringify(example(spec, media.schema), null, 2);
+			form.append(el("label", null, "Cuerpo", body));
+		}
+
+		const output = el("pre", { class: "output", hidden: "" });
+		form.append(el("button", { type: "submit" }, "Enviar"), output);
+
+		form.addEventListener("submit", async function (event) {
+			event.preventDefault();
+			let url = path;
+			for (const param of params) {
+				url = url.replace("{" + param.name + "}", encodeURIComponent(inputs[param.name].value));
+			}
+
+			const headers = {};
+			const token = tokenInput.value.trim();
+			if (token) {
+				headers["Authorization"] = "Bearer " + token;
+			}
+			if (body) {
+				headers["Content-Type"] = "application/json";
+			}
+
+			output.hidden = false;
+			output.textContent = "Enviando…";
+			try {
+				const response = await fetch(url, {
+					method: method.toUpperCase(),
+					headers: headers,
+					body: body ? body.value : undefined,
+					credentials: "omit",
+				});
+				let content = await response.text();
+				try {
+					content = JSON.stringify(JSON.parse(content), null, 2);
+				} catch (_) {
+					// Not JSON, shown as is.
+				}
+				output.textContent = response.status + " " + response.statusText + "\n\n" + content;
+			} catch (err) {
+				output.textContent = String(err);
+			}
+		});
+
+		return form;
+	}
+
+	function renderOperation(spec, method, path, op) {
+		const details = el(
+			"details",
+			{ class: "operation " + method, id: op.operationId || method + path },
+			el(
+				"summary",
+				null,
+				el("span", { class: "method" }, method.toUpperCase()),
+				el("code", null, path),
+				el("span", { class: "summary" }, op.summary || ""),
+			),
+		);
+
+		details.append(el("p", null, text(op.description)));
+
+		const media = op.requestBody && op.requestBody.content["application/json"];
+		if (media) {
+			details.append(el("h4", null, "Cuerpo de la solicitud"), renderSchema(spec, media.schema));
+		}
+
+		const responses = el("dl", { class: "responses" });
+		for (const [status, ref] of Object.entries(op.responses || {})) {
+			const response = resolve(spec, ref);
+			const content = response.content && response.content["application/json"];
+			responses.append(
+				el("dt", null, status + " " + (response.description || "")),
+				el("dd", null, content ? renderSchema(spec, content.schema) : "Sin contenido"),
+			);
+		}
+		details.append(el("h4", null, "Respuestas"), responses);
+
+		details.append(el("h4", null, "Probar"), renderTry(spec, method, path, op));
+		return details;
+	}
+
+	function render(spec) {
+		document.title = spec.info.title;
+		document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+		document.getElementById("description").replaceChildren(text(spec.info.description));
+
+		const byTag = new Map((spec.tags || []).map((tag) => [tag.name, []]));
+		for (const [path, item] of Object.entries(spec.paths)) {
+			for (const method of methods) {
+				if (!item[method]) {
+					continue;
+				}
+				const tag = (item[method].tags || ["Otros"])[0];
+				if (!byTag.has(tag)) {
+					byTag.set(tag, []);
+				}
+				byTag.get(tag).push(renderOperation(spec, method, path, item[method]));
+			}
+		}
+
+		const main = document.getElementById("operations");
+		main.replaceChildren();
+		for (const [tag, operations] of byTag) {
+			if (operations.length > 0) {
+				main.append(el("section", null, el("h2", null, tag), ...operations));
+			}
+		}
+	}
+
+	fetch(specURL)
+		.then(function (response) {
+			if (!response.ok) {
+				throw new Error("No se pudo cargar el documento OpenAPI: " + response.status);
+			}
+			return response.json();
+		})
+		.then(render)
+		.catch(function (err) {
+			document.getElementById("operations").replaceChildren(el("p", { class: "error" }, String(err)));
+		});
+})();
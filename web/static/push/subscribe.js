@@ -0,0 +1,116 @@
+// Turns browser notifications on or off in this browser, for the
+// pushDevice Alpine component of the notification preferences. It must load
+// before Alpine starts.
+(function () {
+	"use strict";
+
+	const workerURL = "/static/push/sw.js";
+
+	function csrfToken() {
+		return document.querySelector('meta[name="csrf-token"]').content;
+	}
+
+	// keyBytes decodes the base64url server key, as pushManager expects it.
+	function keyBytes(key) {
+		const base64 = key.replace(/-/g, "+").replace(/_/g, "/");
+		const raw = atob(base64 + "=".repeat((4 - (base64.length % 4)) % 4));
+		return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+	}
+
+	async function post(url, body) {
+		const res = await fetch(url, {
+			method: "POST",
+			headers: {
+				"Content-Type": "application/json",
+				"X-CSRF-Token": csrfToken(),
+			},
+			body: JSON.stringify(body),
+		});
+		if (!res.ok) {
+			throw new Error("the server answered " + res.status);
+		}
+	}
+
+	// activated waits for the worker, push subscriptions need an active one.
+	function activated(registration) {
+		if (registration.active) {
+			return Promise.resolve(registration);
+		}
+		const worker = registration.installing || registration.waiting;
+		return new Promise(function (resolve) {
+			worker.addEventListener("statechange", function () {
+				if (worker.state === "activated") {
+					resolve(registration);
+				}
+			});
+		});
+	}
+
+	window.pushDevice = function (publicKey) {
+		return {
+			supported: "serviceWorker" in navigator && "PushManager" in window &&
+				"Notification" in window,
+			subscribed: false,
+			busy: false,
+			error: "",
+
+			async init() {
+				if (!this.supported) {
+					return;
+				}
+				const registration = await navigator.serviceWorker.getRegistration(workerURL);
+				const subscription = registration &&
+					await registration.pushManager.getSubscription();
+				this.subscribed = !!subscription;
+			},
+
+			async subscribe() {
+				this.busy = true;
+				this.error = "";
+				try {
+					if (await Notification.requestPermission() !== "granted") {
+						this.error = "Permite las notificaciones de este sitio en tu navegador.";
+						return;
+					}
+					const registration = await activated(
+						await navigator.serviceWorker.register(workerURL),
+					);
+					const subscription = await registration.pushManager.getSubscription() ||
+						await registration.pushManager.subscribe({
+							userVisibleOnly: true,
+							applicationServerKey: keyBytes(publicKey),
+						});
+					await post("/neighbor/push/subscriptions", subscription.toJSON());
+					location.reload();
+				} catch (err) {
+					console.error(err);
+					this.error = "No se pudieron activar los avisos. Intenta de nuevo.";
+				} finally {
+					this.busy = false;
+				}
+			},
+
+			async unsubscribe() {
+				this.busy = true;
+				this.error = "";
+				try {
+					const registration = await navigator.serviceWorker.getRegistration(workerURL);
+					const subscription = registration &&
+						await registration.pushManager.getSubscription();
+					if (subscription) {
+						await post("/neighbor/push/subscriptions/delete", {
+							endpoint: subscription.endpoint,
+						});
+						await subscription.unsubscribe();
+					}
+					location.reload();
+				} catch (err) {
+					console.error(err);
+					this.error = "No se pudieron desactivar los avisos. Intenta de nuevo.";
+				} finally {
+					this.busy = false;
+				}
+			},
+		};
+	};
+})();
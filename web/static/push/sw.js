@@ -0,0 +1,85 @@
+// Service worker that shows the push notifications of Entry Watch. The
+// payload is JSON: title, body, the url to open, a tag, and actions, whose
+// buttons post a form to a URL with the session of the user.
+"use strict";
+
+const defaultURL = "/neighbor/notifications";
+
+self.addEventListener("push", function (event) {
+	let data;
+	try {
+		data = event.data ? event.data.json() : {};
+	} catch (err) {
+		data = { body: event.data.text() };
+	}
+	const actions = data.actions || [];
+
+	event.waitUntil(self.registration.showNotification(data.title || "Entry Watch", {
+		body: data.body || "",
+		tag: data.tag,
+		data: { url: data.url || defaultURL, actions: actions },
+		actions: actions.map(function (a) {
+			return { action: a.action, title: a.title };
+		}),
+		// Questions wait for an answer instead of going away.
+		requireInteraction: actions.length > 0,
+	}));
+});
+
+self.addEventListener("notificationclick", function (event) {
+	event.notification.close();
+	const data = event.notification.data || {};
+	const action = (data.actions || []).find(function (a) {
+		return a.action === event.action;
+	});
+
+	if (!action) {
+		event.waitUntil(openPage(data.url));
+		return;
+	}
+	// If the answer can't be sent, like when the session expired, the page
+	// lets the user answer there.
+	event.waitUntil(runAction(action).catch(function (err) {
+		console.error(err);
+		return openPage(data.url);
+	}));
+});
+
+// runAction posts the form of the action. Forms need the CSRF token of the
+// session, which every page of the user carries.
+async function runAction(action) {
+	const page = await fetch(defaultURL, { credentials: "same-origin" });
+	if (!page.ok || page.redirected) {
+		throw new Error("not signed in");
+	}
+	const match = (await page.text()).match(/<meta name="csrf-token" content="([^"]+)"/);
+	if (!match) {
+		throw new Error("no CSRF token");
+	}
+
+	const res = await fetch(action.url, {
+		method: "POST",
+		credentials: "same-origin",
+		headers: {
+			"Content-Type": "application/x-www-form-urlencoded",
+			"X-CSRF-Token": match[1],
+		},
+		body: action.form,
+		// Saved forms redirect back to a page, there is no need to load it.
+		redirect: "manual",
+	});
+	if (res.type !== "opaqueredirect" && !res.ok) {
+		throw new Error("the server answered " + res.status);
+	}
+}
+
+async function openPage(url) {
+	const target = new URL(url || defaultURL, self.location.origin).href;
+	const windows = await self.clients.matchAll({ type: "window", includeUncontrolled: true });
+	for (const client of windows) {
+		if (client.url === target && "focus" in client) {
+			return client.focus();
+		}
+	}
+	return self.clients.openWindow(target);
+}